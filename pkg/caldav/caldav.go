@@ -38,26 +38,48 @@ type Todo struct {
 	UID       string
 
 	// Optional
-	Summary      string
-	Description  string
-	Completed    time.Time
-	Organizer    *user.User
-	Priority     int64 // 0-9, 1 is highest
-	RelatedToUID string
-	Color        string
-	Categories   []string
-	Start        time.Time
-	End          time.Time
-	DueDate      time.Time
-	Duration     time.Duration
-	RepeatAfter  int64
-	RepeatMode   models.TaskRepeatMode
-	Alarms       []Alarm
+	Summary     string
+	Description string
+	Completed   time.Time
+	Organizer   *user.User
+	Priority    int64 // 0-9, 1 is highest
+	Relations   []Relation
+	Color       string
+	Categories  []string
+	Start       time.Time
+	End         time.Time
+	DueDate     time.Time
+	Duration    time.Duration
+	RepeatAfter int64
+	RepeatMode  models.TaskRepeatMode
+	RepeatRule  string
+	Alarms      []Alarm
+	Attachments []Attachment
+	Comments    []string
+
+	// Custom X- properties, used for custom fields
+	CustomProperties []CustomProperty
 
 	Created time.Time
 	Updated time.Time // last-mod
 }
 
+// RelationType is the RELTYPE parameter of a RELATED-TO property
+type RelationType string
+
+// All relation types defined in RFC 5545
+const (
+	RelationTypeParent  RelationType = `PARENT`
+	RelationTypeChild   RelationType = `CHILD`
+	RelationTypeSibling RelationType = `SIBLING`
+)
+
+// Relation holds a RELATED-TO property of a todo
+type Relation struct {
+	UID  string
+	Type RelationType
+}
+
 // Alarm holds infos about an alarm from a caldav event
 type Alarm struct {
 	Time        time.Time
@@ -66,6 +88,21 @@ type Alarm struct {
 	Description string
 }
 
+// Attachment holds an ATTACH property of a todo. Exported attachments link to their file, attachments sent by
+// clients contain the file itself.
+type Attachment struct {
+	URL      string
+	Filename string
+	Mime     string
+	Content  []byte
+}
+
+// CustomProperty holds a non-standard X- property of a VTODO
+type CustomProperty struct {
+	Name  string
+	Value string
+}
+
 // Config is the caldav calendar config
 type Config struct {
 	Name   string
@@ -99,14 +136,33 @@ func formatDuration(duration time.Duration) string {
 		strconv.FormatFloat(seconds, 'f', 0, 64) + `S`
 }
 
-// ParseTodos returns a caldav vcalendar string with todos
-func ParseTodos(config *Config, todos []*Todo) (caldavtodos string) {
-	caldavtodos = `BEGIN:VCALENDAR
+func getCalendarHeader(config *Config) string {
+	return `BEGIN:VCALENDAR
 VERSION:2.0
 METHOD:PUBLISH
 X-PUBLISHED-TTL:PT4H
 X-WR-CALNAME:` + config.Name + `
 PRODID:-//` + config.ProdID + `//EN` + getCaldavColor(config.Color)
+}
+
+// quoteParameterValue quotes a property parameter value if it contains characters which would otherwise end it.
+// Parameter values can't contain double quotes at all.
+func quoteParameterValue(value string) string {
+	value = strings.ReplaceAll(value, `"`, ``)
+	if strings.ContainsAny(value, `;:,`) {
+		return `"` + value + `"`
+	}
+	return value
+}
+
+func formatCaldavDescription(description string) string {
+	re := regexp.MustCompile(`\r?\n`)
+	return re.ReplaceAllString(description, "\\n")
+}
+
+// ParseTodos returns a caldav vcalendar string with todos
+func ParseTodos(config *Config, todos []*Todo) (caldavtodos string) {
+	caldavtodos = getCalendarHeader(config)
 
 	for _, t := range todos {
 		if t.UID == "" {
@@ -132,10 +188,8 @@ DURATION:PT` + formatDuration(t.Duration)
 DTEND:` + makeCalDavTimeFromTimeStamp(t.End)
 		}
 		if t.Description != "" {
-			re := regexp.MustCompile(`\r?\n`)
-			formattedDescription := re.ReplaceAllString(t.Description, "\\n")
 			caldavtodos += `
-DESCRIPTION:` + formattedDescription
+DESCRIPTION:` + formatCaldavDescription(t.Description)
 		}
 		if t.Completed.Unix() > 0 {
 			caldavtodos += `
@@ -147,9 +201,9 @@ STATUS:COMPLETED`
 ORGANIZER;CN=:` + t.Organizer.Username
 		}
 
-		if t.RelatedToUID != "" {
+		for _, r := range t.Relations {
 			caldavtodos += `
-RELATED-TO:` + t.RelatedToUID
+RELATED-TO;RELTYPE=` + string(r.Type) + `:` + r.UID
 		}
 
 		if t.DueDate.Unix() > 0 {
@@ -167,7 +221,10 @@ CREATED:` + makeCalDavTimeFromTimeStamp(t.Created)
 PRIORITY:` + strconv.Itoa(mapPriorityToCaldav(t.Priority))
 		}
 
-		if t.RepeatAfter > 0 || t.RepeatMode == models.TaskRepeatModeMonth {
+		if t.RepeatRule != "" {
+			caldavtodos += `
+RRULE:` + t.RepeatRule
+		} else if t.RepeatAfter > 0 || t.RepeatMode == models.TaskRepeatModeMonth {
 			if t.RepeatMode == models.TaskRepeatModeMonth {
 				caldavtodos += `
 RRULE:FREQ=MONTHLY;BYMONTHDAY=` + t.DueDate.Format("02") // Day of the month
@@ -182,6 +239,28 @@ RRULE:FREQ=SECONDLY;INTERVAL=` + strconv.FormatInt(t.RepeatAfter, 10)
 CATEGORIES:` + strings.Join(t.Categories, ",")
 		}
 
+		for _, a := range t.Attachments {
+			caldavtodos += `
+ATTACH`
+			if a.Mime != "" {
+				caldavtodos += `;FMTTYPE=` + quoteParameterValue(a.Mime)
+			}
+			if a.Filename != "" {
+				caldavtodos += `;FILENAME=` + quoteParameterValue(a.Filename)
+			}
+			caldavtodos += `:` + a.URL
+		}
+
+		for _, c := range t.Comments {
+			caldavtodos += `
+COMMENT:` + escapeCaldavText(c)
+		}
+
+		for _, p := range t.CustomProperties {
+			caldavtodos += `
+` + p.Name + `:` + p.Value
+		}
+
 		caldavtodos += `
 LAST-MODIFIED:` + makeCalDavTimeFromTimeStamp(t.Updated)
 		caldavtodos += ParseAlarms(t.Alarms, t.Summary)
@@ -195,6 +274,64 @@ END:VCALENDAR` // Need a line break
 	return
 }
 
+// ParseEvents returns a vcalendar string with one event at the due date of each todo. Todos without a due date
+// are skipped. Calendar apps like Google Calendar or Outlook only show events when subscribing to a calendar.
+func ParseEvents(config *Config, todos []*Todo) (caldavevents string) {
+	caldavevents = getCalendarHeader(config)
+
+	for _, t := range todos {
+		if t.DueDate.Unix() <= 0 {
+			continue
+		}
+
+		if t.UID == "" {
+			t.UID = makeCalDavTimeFromTimeStamp(t.Timestamp) + utils.Sha256(t.Summary)
+		}
+
+		caldavevents += `
+BEGIN:VEVENT
+UID:` + t.UID + `
+DTSTAMP:` + makeCalDavTimeFromTimeStamp(t.Timestamp) + `
+SUMMARY:` + t.Summary + getCaldavColor(t.Color) + `
+DTSTART:` + makeCalDavTimeFromTimeStamp(t.DueDate)
+
+		if t.Description != "" {
+			caldavevents += `
+DESCRIPTION:` + formatCaldavDescription(t.Description)
+		}
+
+		if len(t.Categories) > 0 {
+			caldavevents += `
+CATEGORIES:` + strings.Join(t.Categories, ",")
+		}
+
+		if t.Created.Unix() > 0 {
+			caldavevents += `
+CREATED:` + makeCalDavTimeFromTimeStamp(t.Created)
+		}
+
+		// Events don't have an end here, which is why all reminders use their absolute time
+		alarms := make([]Alarm, 0, len(t.Alarms))
+		for _, a := range t.Alarms {
+			if a.Time.Unix() <= 0 {
+				continue
+			}
+			alarms = append(alarms, Alarm{Time: a.Time, Description: a.Description})
+		}
+
+		caldavevents += `
+LAST-MODIFIED:` + makeCalDavTimeFromTimeStamp(t.Updated)
+		caldavevents += ParseAlarms(alarms, t.Summary)
+		caldavevents += `
+END:VEVENT`
+	}
+
+	caldavevents += `
+END:VCALENDAR`
+
+	return
+}
+
 func ParseAlarms(alarms []Alarm, taskDescription string) (caldavalarms string) {
 	for _, a := range alarms {
 		if a.Description == "" {
@@ -212,6 +212,42 @@ DUE:20181201T011204Z
 RRULE:FREQ=SECONDLY;INTERVAL=435
 LAST-MODIFIED:00010101T000000Z
 END:VTODO
+END:VCALENDAR`,
+		},
+		{
+			name: "with repeat rule",
+			args: args{
+				config: &Config{
+					Name:   "test",
+					ProdID: "RandomProdID which is not random",
+				},
+				todos: []*Todo{
+					{
+						Summary:     "Todo #1",
+						Description: "Lorem Ipsum",
+						UID:         "randommduid",
+						Timestamp:   time.Unix(1543626724, 0).In(config.GetTimeZone()),
+						DueDate:     time.Unix(1543626724, 0).In(config.GetTimeZone()),
+						RepeatAfter: 435,
+						RepeatRule:  "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+					},
+				},
+			},
+			wantCaldavtasks: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randommduid
+DTSTAMP:20181201T011204Z
+SUMMARY:Todo #1
+DESCRIPTION:Lorem Ipsum
+DUE:20181201T011204Z
+RRULE:FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1
+LAST-MODIFIED:00010101T000000Z
+END:VTODO
 END:VCALENDAR`,
 		},
 		{
@@ -336,3 +372,64 @@ END:VCALENDAR`,
 		})
 	}
 }
+
+func TestParseEvents(t *testing.T) {
+	t.Run("only todos with due date", func(t *testing.T) {
+		config := &Config{
+			Name:   "test",
+			ProdID: "RandomProdID which is not random",
+		}
+		todos := []*Todo{
+			{
+				Summary:     "Todo #1",
+				Description: "Lorem Ipsum\nDolor sit amet",
+				UID:         "randommduid",
+				Timestamp:   time.Unix(1543626724, 0).In(time.UTC),
+				DueDate:     time.Unix(1543626724, 0).In(time.UTC),
+				Categories:  []string{"label1", "label2"},
+				Alarms: []Alarm{
+					{
+						Time: time.Unix(1543626000, 0).In(time.UTC),
+					},
+					{
+						Time:       time.Unix(1543619524, 0).In(time.UTC),
+						Duration:   -2 * time.Hour,
+						RelativeTo: models.ReminderRelationDueDate,
+					},
+				},
+			},
+			{
+				Summary:   "Todo #2",
+				UID:       "randommduid2",
+				Timestamp: time.Unix(1543626724, 0).In(time.UTC),
+			},
+		}
+
+		assert.Equal(t, `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VEVENT
+UID:randommduid
+DTSTAMP:20181201T011204Z
+SUMMARY:Todo #1
+DTSTART:20181201T011204Z
+DESCRIPTION:Lorem Ipsum\nDolor sit amet
+CATEGORIES:label1,label2
+LAST-MODIFIED:00010101T000000Z
+BEGIN:VALARM
+TRIGGER;VALUE=DATE-TIME:20181201T010000Z
+ACTION:DISPLAY
+DESCRIPTION:Todo #1
+END:VALARM
+BEGIN:VALARM
+TRIGGER;VALUE=DATE-TIME:20181130T231204Z
+ACTION:DISPLAY
+DESCRIPTION:Todo #1
+END:VALARM
+END:VEVENT
+END:VCALENDAR`, ParseEvents(config, todos))
+	})
+}
@@ -17,13 +17,16 @@
 package caldav
 
 import (
+	"encoding/base64"
 	"errors"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"code.vikunja.io/api/pkg/config"
-	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/models"
 	"code.vikunja.io/api/pkg/utils"
@@ -32,9 +35,36 @@ import (
 )
 
 func GetCaldavTodosForTasks(project *models.ProjectWithTasksAndBuckets, projectTasks []*models.TaskWithComments) string {
+	caldavConfig := &Config{
+		Name:   project.Title,
+		ProdID: "Vikunja Todo App",
+	}
 
-	// Make caldav todos from Vikunja todos
-	var caldavtodos []*Todo
+	return ParseTodos(caldavConfig, getTodosForTasks(projectTasks))
+}
+
+// GetICSFeedForTasks returns an iCalendar feed for all tasks. By default, every task with a due date is an event at
+// that date. If asTodos is true, the tasks are returned as todos instead.
+func GetICSFeedForTasks(name string, tasks []*models.Task, asTodos bool) string {
+	tasksWithComments := make([]*models.TaskWithComments, 0, len(tasks))
+	for _, t := range tasks {
+		tasksWithComments = append(tasksWithComments, &models.TaskWithComments{Task: *t})
+	}
+
+	caldavConfig := &Config{
+		Name:   name,
+		ProdID: "Vikunja Todo App",
+	}
+
+	if asTodos {
+		return ParseTodos(caldavConfig, getTodosForTasks(tasksWithComments))
+	}
+
+	return ParseEvents(caldavConfig, getTodosForTasks(tasksWithComments))
+}
+
+// getTodosForTasks makes caldav todos from Vikunja tasks
+func getTodosForTasks(projectTasks []*models.TaskWithComments) (caldavtodos []*Todo) {
 	for _, t := range projectTasks {
 
 		duration := t.EndDate.Sub(t.StartDate)
@@ -68,16 +98,193 @@ func GetCaldavTodosForTasks(project *models.ProjectWithTasksAndBuckets, projectT
 			Duration:    duration,
 			RepeatAfter: t.RepeatAfter,
 			RepeatMode:  t.RepeatMode,
+			RepeatRule:  t.RepeatRule,
+			Relations:   getRelationsFromRelatedTasks(t.RelatedTasks),
 			Alarms:      alarms,
+			Attachments: getAttachmentsFromTaskAttachments(t.Attachments),
+			Comments:    getCommentsFromTaskComments(t.Comments),
+
+			CustomProperties: getCustomPropertiesFromCustomFields(t.CustomFields),
 		})
 	}
 
-	caldavConfig := &Config{
-		Name:   project.Title,
-		ProdID: "Vikunja Todo App",
+	return caldavtodos
+}
+
+// getRelationsFromRelatedTasks maps the related tasks of a task to RELATED-TO properties. Parent and subtasks have
+// their own relation type, all other kinds of relations have no equivalent in caldav and become siblings.
+func getRelationsFromRelatedTasks(relatedTasks models.RelatedTaskMap) (relations []Relation) {
+	kinds := make([]models.RelationKind, 0, len(relatedTasks))
+	for kind := range relatedTasks {
+		kinds = append(kinds, kind)
 	}
+	sort.Slice(kinds, func(i, j int) bool {
+		return kinds[i] < kinds[j]
+	})
+
+	for _, kind := range kinds {
+		relationType := RelationTypeSibling
+		switch kind {
+		case models.RelationKindParenttask:
+			relationType = RelationTypeParent
+		case models.RelationKindSubtask:
+			relationType = RelationTypeChild
+		}
 
-	return ParseTodos(caldavConfig, caldavtodos)
+		for _, t := range relatedTasks[kind] {
+			if t.UID == "" {
+				continue
+			}
+			relations = append(relations, Relation{
+				UID:  t.UID,
+				Type: relationType,
+			})
+		}
+	}
+
+	return
+}
+
+// getRelatedTaskFromRelation maps a RELATED-TO property to a relation kind and a task with only the uid set.
+// A missing RELTYPE means the related task is the parent, as defined in RFC 5545.
+func getRelatedTaskFromRelation(relation ics.IANAProperty) (kind models.RelationKind, task *models.Task) {
+	kind = models.RelationKindParenttask
+	if relType, has := relation.ICalParameters["RELTYPE"]; has && len(relType) > 0 {
+		switch RelationType(strings.ToUpper(relType[0])) {
+		case RelationTypeChild:
+			kind = models.RelationKindSubtask
+		case RelationTypeSibling:
+			kind = models.RelationKindRelated
+		}
+	}
+
+	return kind, &models.Task{UID: relation.Value}
+}
+
+// GetAttachmentURL returns the url caldav clients can download an attachment from with their caldav credentials.
+func GetAttachmentURL(attachment *models.TaskAttachment) string {
+	return config.ServiceFrontendurl.GetString() + "dav/attachments/" +
+		strconv.FormatInt(attachment.TaskID, 10) + "/" + strconv.FormatInt(attachment.ID, 10)
+}
+
+func getAttachmentsFromTaskAttachments(taskAttachments []*models.TaskAttachment) (attachments []Attachment) {
+	if !config.ServiceEnableTaskAttachments.GetBool() {
+		return nil
+	}
+
+	for _, a := range taskAttachments {
+		attachment := Attachment{URL: GetAttachmentURL(a)}
+		if a.File != nil {
+			attachment.Filename = a.File.Name
+			attachment.Mime = a.File.Mime
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return
+}
+
+// getCommentsFromTaskComments returns the comments of a task prefixed with the name of their author.
+func getCommentsFromTaskComments(taskComments []*models.TaskComment) (comments []string) {
+	if !config.ServiceEnableTaskComments.GetBool() {
+		return nil
+	}
+
+	for _, c := range taskComments {
+		if c.Author == nil {
+			comments = append(comments, c.Comment)
+			continue
+		}
+		comments = append(comments, c.Author.GetName()+": "+c.Comment)
+	}
+
+	return
+}
+
+// getAttachmentFromProperty returns the file of an ATTACH property if the client sent it inline. Attachments which
+// only link to a file can't be imported.
+func getAttachmentFromProperty(property ics.IANAProperty) (attachment *models.TaskAttachment, err error) {
+	if !contains(property.ICalParameters["VALUE"], "BINARY") {
+		return nil, nil
+	}
+
+	content, err := base64.StdEncoding.DecodeString(property.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	var filename, mime string
+	for _, param := range []string{"FILENAME", "X-FILENAME", "X-APPLE-FILENAME", "X-ORACLE-FILENAME"} {
+		if values := property.ICalParameters[param]; len(values) > 0 && values[0] != "" {
+			filename = values[0]
+			break
+		}
+	}
+	if values := property.ICalParameters["FMTTYPE"]; len(values) > 0 {
+		mime = values[0]
+	}
+	if filename == "" {
+		filename = "attachment"
+	}
+
+	return &models.TaskAttachment{
+		File: &files.File{
+			Name:        filename,
+			Mime:        mime,
+			Size:        uint64(len(content)),
+			FileContent: content,
+		},
+	}, nil
+}
+
+var customPropertyNameRegex = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// getCustomPropertiesFromCustomFields converts the custom field values of a task to X-VIKUNJA-FIELD-<TITLE> properties.
+func getCustomPropertiesFromCustomFields(values []*models.TaskCustomFieldValue) (properties []CustomProperty) {
+	for _, v := range values {
+		if v.Field == nil || v.Value == nil {
+			continue
+		}
+
+		name := strings.Trim(customPropertyNameRegex.ReplaceAllString(strings.ToUpper(v.Field.Title), "-"), "-")
+		if name == "" {
+			name = strconv.FormatInt(v.Field.ID, 10)
+		}
+
+		var value string
+		switch val := v.Value.(type) {
+		case string:
+			value = escapeCaldavText(val)
+		case float64:
+			value = strconv.FormatFloat(val, 'f', -1, 64)
+		case int64:
+			value = strconv.FormatInt(val, 10)
+		case time.Time:
+			if val.IsZero() {
+				continue
+			}
+			value = makeCalDavTimeFromTimeStamp(val)
+		default:
+			continue
+		}
+
+		properties = append(properties, CustomProperty{
+			Name:  "X-VIKUNJA-FIELD-" + name,
+			Value: value,
+		})
+	}
+
+	return
+}
+
+func escapeCaldavText(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`;`, `\;`,
+		`,`, `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(text)
 }
 
 func ParseTaskFromVTODO(content string) (vTask *models.Task, err error) {
@@ -91,11 +298,22 @@ func ParseTaskFromVTODO(content string) (vTask *models.Task, err error) {
 	}
 	// We put the vTodo details in a map to be able to handle them more easily
 	task := make(map[string]ics.IANAProperty)
-	var relation ics.IANAProperty
+	var relations []ics.IANAProperty
+	var attachments []*models.TaskAttachment
 	for _, c := range vTodo.UnknownPropertiesIANAProperties() {
 		task[c.IANAToken] = c
-		if strings.HasPrefix(c.IANAToken, "RELATED-TO") {
-			relation = c
+		if strings.HasPrefix(c.IANAToken, "RELATED-TO") && c.Value != "" {
+			relations = append(relations, c)
+		}
+		if c.IANAToken == "ATTACH" {
+			attachment, err := getAttachmentFromProperty(c)
+			if err != nil {
+				log.Warningf("Ignoring invalid attachment of task %s: %s", task["UID"].Value, err)
+				continue
+			}
+			if attachment != nil {
+				attachments = append(attachments, attachment)
+			}
 		}
 	}
 
@@ -137,19 +355,16 @@ func ParseTaskFromVTODO(content string) (vTask *models.Task, err error) {
 		Updated:     caldavTimeToTimestamp(task["DTSTAMP"]),
 		StartDate:   caldavTimeToTimestamp(task["DTSTART"]),
 		DoneAt:      caldavTimeToTimestamp(task["COMPLETED"]),
+		Attachments: attachments,
 	}
 
-	if relation.Value != "" {
-		s := db.NewSession()
-		defer s.Close()
-
-		subtask, err := models.GetTaskSimpleByUUID(s, relation.Value)
-		if err != nil {
-			return nil, err
+	// The related tasks only have their uid set, resolving them is up to the caller
+	if len(relations) > 0 {
+		vTask.RelatedTasks = make(models.RelatedTaskMap)
+		for _, relation := range relations {
+			kind, relatedTask := getRelatedTaskFromRelation(relation)
+			vTask.RelatedTasks[kind] = append(vTask.RelatedTasks[kind], relatedTask)
 		}
-
-		vTask.RelatedTasks = make(map[models.RelationKind][]*models.Task)
-		vTask.RelatedTasks[models.RelationKindSubtask] = []*models.Task{subtask}
 	}
 
 	if task["STATUS"].Value == "COMPLETED" {
@@ -160,6 +375,15 @@ func ParseTaskFromVTODO(content string) (vTask *models.Task, err error) {
 		vTask.EndDate = vTask.StartDate.Add(duration)
 	}
 
+	if val, ok := task["RRULE"]; ok {
+		rule, err := models.ParseRepeatRule(val.Value)
+		if err != nil {
+			log.Warningf("Ignoring unsupported RRULE %s of task %s: %s", val.Value, vTask.UID, err)
+		} else {
+			vTask.RepeatRule = rule.String()
+		}
+	}
+
 	for _, vAlarm := range vTodo.SubComponents() {
 		if vAlarm, ok := vAlarm.(*ics.VAlarm); ok {
 			vTask = parseVAlarm(vAlarm, vTask)
@@ -21,7 +21,9 @@ import (
 	"time"
 
 	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/user"
 	"gopkg.in/d4l3k/messagediff.v1"
 )
 
@@ -85,6 +87,57 @@ END:VCALENDAR`,
 				Updated:     time.Unix(1543626724, 0).In(config.GetTimeZone()),
 			},
 		},
+		{
+			name: "With repeat rule",
+			args: args{content: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011204
+SUMMARY:Todo #1
+DESCRIPTION:Lorem Ipsum
+RRULE:FREQ=MONTHLY;BYDAY=2TU;COUNT=5
+LAST-MODIFIED:00010101T000000
+END:VTODO
+END:VCALENDAR`,
+			},
+			wantVTask: &models.Task{
+				Title:       "Todo #1",
+				UID:         "randomuid",
+				Description: "Lorem Ipsum",
+				RepeatRule:  "FREQ=MONTHLY;COUNT=5;BYDAY=2TU",
+				Updated:     time.Unix(1543626724, 0).In(config.GetTimeZone()),
+			},
+		},
+		{
+			name: "With unsupported repeat rule",
+			args: args{content: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011204
+SUMMARY:Todo #1
+DESCRIPTION:Lorem Ipsum
+RRULE:FREQ=DAILY;BYHOUR=9,17
+LAST-MODIFIED:00010101T000000
+END:VTODO
+END:VCALENDAR`,
+			},
+			wantVTask: &models.Task{
+				Title:       "Todo #1",
+				UID:         "randomuid",
+				Description: "Lorem Ipsum",
+				Updated:     time.Unix(1543626724, 0).In(config.GetTimeZone()),
+			},
+		},
 		{
 			name: "With categories",
 			args: args{content: `BEGIN:VCALENDAR
@@ -289,6 +342,79 @@ END:VCALENDAR`,
 				},
 			},
 		},
+		{
+			name: "With binary attachment",
+			args: args{content: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011204
+SUMMARY:Todo #1
+ATTACH;VALUE=BINARY;ENCODING=BASE64;FMTTYPE=text/plain;FILENAME="hello world.txt":SGVsbG8g
+ V29ybGQ=
+ATTACH:https://example.com/not-imported.txt
+LAST-MODIFIED:00010101T000000
+END:VTODO
+END:VCALENDAR`,
+			},
+			wantVTask: &models.Task{
+				Title:   "Todo #1",
+				UID:     "randomuid",
+				Updated: time.Unix(1543626724, 0).In(config.GetTimeZone()),
+				Attachments: []*models.TaskAttachment{
+					{
+						File: &files.File{
+							Name:        "hello world.txt",
+							Mime:        "text/plain",
+							Size:        11,
+							FileContent: []byte("Hello World"),
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "With relations",
+			args: args{content: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:test
+PRODID:-//RandomProdID which is not random//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011204
+SUMMARY:Todo #1
+RELATED-TO:parentuid
+RELATED-TO;RELTYPE=CHILD:childuid1
+RELATED-TO;RELTYPE=child:childuid2
+RELATED-TO;RELTYPE=SIBLING:siblinguid
+LAST-MODIFIED:00010101T000000
+END:VTODO
+END:VCALENDAR`,
+			},
+			wantVTask: &models.Task{
+				Title:   "Todo #1",
+				UID:     "randomuid",
+				Updated: time.Unix(1543626724, 0).In(config.GetTimeZone()),
+				RelatedTasks: models.RelatedTaskMap{
+					models.RelationKindParenttask: {
+						{UID: "parentuid"},
+					},
+					models.RelationKindSubtask: {
+						{UID: "childuid1"},
+						{UID: "childuid2"},
+					},
+					models.RelationKindRelated: {
+						{UID: "siblinguid"},
+					},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -305,6 +431,10 @@ END:VCALENDAR`,
 }
 
 func TestGetCaldavTodosForTasks(t *testing.T) {
+	config.ServiceFrontendurl.Set("https://vikunja.example/")
+	config.ServiceEnableTaskAttachments.Set(true)
+	config.ServiceEnableTaskComments.Set(true)
+
 	type args struct {
 		list  *models.ProjectWithTasksAndBuckets
 		tasks []*models.TaskWithComments
@@ -392,6 +522,161 @@ ACTION:DISPLAY
 DESCRIPTION:Task 1
 END:VALARM
 END:VTODO
+END:VCALENDAR`,
+		},
+		{
+			name: "Format Task with custom fields as CalDAV",
+			args: args{
+				list: &models.ProjectWithTasksAndBuckets{
+					Project: models.Project{
+						Title: "List title",
+					},
+				},
+				tasks: []*models.TaskWithComments{
+					{
+						Task: models.Task{
+							Title:   "Task 1",
+							UID:     "randomuid",
+							Updated: time.Unix(1543626725, 0).In(config.GetTimeZone()),
+							CustomFields: []*models.TaskCustomFieldValue{
+								{
+									FieldID: 1,
+									Value:   "ACME, Inc.",
+									Field:   &models.CustomField{ID: 1, Title: "Customer", Type: models.CustomFieldTypeText},
+								},
+								{
+									FieldID: 2,
+									Value:   float64(5),
+									Field:   &models.CustomField{ID: 2, Title: "Story points", Type: models.CustomFieldTypeNumber},
+								},
+								{
+									FieldID: 3,
+									Value:   time.Unix(1543626722, 0).In(config.GetTimeZone()),
+									Field:   &models.CustomField{ID: 3, Title: "Go live", Type: models.CustomFieldTypeDate},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantCaldav: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:List title
+PRODID:-//Vikunja Todo App//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011205Z
+SUMMARY:Task 1
+X-VIKUNJA-FIELD-CUSTOMER:ACME\, Inc.
+X-VIKUNJA-FIELD-STORY-POINTS:5
+X-VIKUNJA-FIELD-GO-LIVE:20181201T011202Z
+LAST-MODIFIED:20181201T011205Z
+END:VTODO
+END:VCALENDAR`,
+		},
+		{
+			name: "Format Task with relations as CalDAV",
+			args: args{
+				list: &models.ProjectWithTasksAndBuckets{
+					Project: models.Project{
+						Title: "List title",
+					},
+				},
+				tasks: []*models.TaskWithComments{
+					{
+						Task: models.Task{
+							Title:   "Task 1",
+							UID:     "randomuid",
+							Updated: time.Unix(1543626725, 0).In(config.GetTimeZone()),
+							RelatedTasks: models.RelatedTaskMap{
+								models.RelationKindSubtask: {
+									{ID: 2, UID: "subtaskuid"},
+								},
+								models.RelationKindParenttask: {
+									{ID: 3, UID: "parentuid"},
+								},
+								models.RelationKindBlocking: {
+									{ID: 4, UID: "blockeduid"},
+									{ID: 5},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantCaldav: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:List title
+PRODID:-//Vikunja Todo App//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011205Z
+SUMMARY:Task 1
+RELATED-TO;RELTYPE=SIBLING:blockeduid
+RELATED-TO;RELTYPE=PARENT:parentuid
+RELATED-TO;RELTYPE=CHILD:subtaskuid
+LAST-MODIFIED:20181201T011205Z
+END:VTODO
+END:VCALENDAR`,
+		},
+		{
+			name: "Format Task with attachments and comments as CalDAV",
+			args: args{
+				list: &models.ProjectWithTasksAndBuckets{
+					Project: models.Project{
+						Title: "List title",
+					},
+				},
+				tasks: []*models.TaskWithComments{
+					{
+						Task: models.Task{
+							ID:      1,
+							Title:   "Task 1",
+							UID:     "randomuid",
+							Updated: time.Unix(1543626725, 0).In(config.GetTimeZone()),
+							Attachments: []*models.TaskAttachment{
+								{
+									ID:     3,
+									TaskID: 1,
+									File: &files.File{
+										Name: "test; file.png",
+										Mime: "image/png",
+									},
+								},
+							},
+						},
+						Comments: []*models.TaskComment{
+							{
+								Comment: "First comment, with a comma",
+								Author:  &user.User{Username: "user1"},
+							},
+							{
+								Comment: "Second comment",
+								Author:  &user.User{Username: "user2", Name: "User Two"},
+							},
+						},
+					},
+				},
+			},
+			wantCaldav: `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+X-PUBLISHED-TTL:PT4H
+X-WR-CALNAME:List title
+PRODID:-//Vikunja Todo App//EN
+BEGIN:VTODO
+UID:randomuid
+DTSTAMP:20181201T011205Z
+SUMMARY:Task 1
+ATTACH;FMTTYPE=image/png;FILENAME="test; file.png":https://vikunja.example/dav/attachments/1/3
+COMMENT:user1: First comment\, with a comma
+COMMENT:User Two: Second comment
+LAST-MODIFIED:20181201T011205Z
+END:VTODO
 END:VCALENDAR`,
 		},
 	}
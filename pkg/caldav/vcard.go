@@ -0,0 +1,60 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package caldav
+
+import (
+	"strconv"
+
+	"code.vikunja.io/api/pkg/user"
+)
+
+// GetVCardUID returns the uid of the vcard of a user
+func GetVCardUID(u *user.User) string {
+	return "vikunja-user-" + strconv.FormatInt(u.ID, 10)
+}
+
+// GetVCardForUser returns a vcard for a user. The name and email address are only included if the user made
+// them discoverable, otherwise only the username is.
+func GetVCardForUser(u *user.User) string {
+	name := u.Username
+	if u.DiscoverableByName && u.Name != "" {
+		name = u.Name
+	}
+
+	vcard := `BEGIN:VCARD
+VERSION:3.0
+PRODID:-//Vikunja Todo App//EN
+UID:` + GetVCardUID(u) + `
+FN:` + escapeCaldavText(name) + `
+N:;` + escapeCaldavText(name) + `;;;
+NICKNAME:` + escapeCaldavText(u.Username)
+
+	if u.DiscoverableByEmail && u.Email != "" {
+		vcard += `
+EMAIL;TYPE=INTERNET:` + u.Email
+	}
+
+	if u.Updated.Unix() > 0 {
+		vcard += `
+REV:` + makeCalDavTimeFromTimeStamp(u.Updated)
+	}
+
+	vcard += `
+END:VCARD`
+
+	return vcard
+}
@@ -0,0 +1,63 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package caldav
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetVCardForUser(t *testing.T) {
+	u := &user.User{
+		ID:       3,
+		Username: "user3",
+		Name:     "Lorem, Ipsum",
+		Email:    "user3@example.com",
+		Updated:  time.Unix(1543626724, 0),
+	}
+
+	t.Run("not discoverable", func(t *testing.T) {
+		assert.Equal(t, `BEGIN:VCARD
+VERSION:3.0
+PRODID:-//Vikunja Todo App//EN
+UID:vikunja-user-3
+FN:user3
+N:;user3;;;
+NICKNAME:user3
+REV:20181201T011204Z
+END:VCARD`, GetVCardForUser(u))
+	})
+	t.Run("discoverable by name and email", func(t *testing.T) {
+		discoverable := *u
+		discoverable.DiscoverableByName = true
+		discoverable.DiscoverableByEmail = true
+		assert.Equal(t, `BEGIN:VCARD
+VERSION:3.0
+PRODID:-//Vikunja Todo App//EN
+UID:vikunja-user-3
+FN:Lorem\, Ipsum
+N:;Lorem\, Ipsum;;;
+NICKNAME:user3
+EMAIL;TYPE=INTERNET:user3@example.com
+REV:20181201T011204Z
+END:VCARD`, GetVCardForUser(&discoverable))
+	})
+}
@@ -0,0 +1,259 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/initialize"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/c2h5oh/datasize"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"xorm.io/xorm"
+)
+
+var (
+	filesFlagFrom         string
+	filesFlagTo           string
+	filesFlagDeleteSource bool
+	filesFlagDryRun       bool
+	filesFlagQuotaUser    int64
+	filesFlagQuotaProject int64
+)
+
+func init() {
+	filesMigrateCmd.Flags().StringVarP(&filesFlagFrom, "from", "f", files.StorageLocal, "The storage to move the files from. Either local or s3.")
+	filesMigrateCmd.Flags().StringVarP(&filesFlagTo, "to", "t", files.StorageS3, "The storage to move the files to. Either local or s3.")
+	filesMigrateCmd.Flags().BoolVarP(&filesFlagDeleteSource, "delete", "d", false, "If provided, deletes every file from the source storage after it was copied.")
+
+	filesGCCmd.Flags().BoolVarP(&filesFlagDryRun, "dry-run", "n", false, "If provided, only lists the files which would be removed.")
+
+	for _, c := range []*cobra.Command{filesQuotaShowCmd, filesQuotaSetCmd, filesQuotaResetCmd} {
+		c.Flags().Int64VarP(&filesFlagQuotaUser, "user", "u", 0, "The id of the user.")
+		c.Flags().Int64VarP(&filesFlagQuotaProject, "project", "p", 0, "The id of the project.")
+	}
+
+	filesQuotaCmd.AddCommand(filesQuotaShowCmd, filesQuotaSetCmd, filesQuotaResetCmd)
+	filesCmd.AddCommand(filesMigrateCmd, filesGCCmd, filesQuotaCmd)
+	rootCmd.AddCommand(filesCmd)
+}
+
+var filesCmd = &cobra.Command{
+	Use:   "files",
+	Short: "Manage the storage of files like attachments or backgrounds.",
+}
+
+var filesMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Copy all files from one storage to another. Change files.storage in the config afterwards to use the new one.",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initialize.FullInitWithoutAsync()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		log.Infof("Migrating files from %s to %s… This may take a while.", filesFlagFrom, filesFlagTo)
+
+		if err := files.MigrateStorage(filesFlagFrom, filesFlagTo, filesFlagDeleteSource); err != nil {
+			log.Fatalf("Could not migrate files: %s", err)
+		}
+
+		log.Infof("Done! Set files.storage to %s in the config and restart Vikunja.", filesFlagTo)
+	},
+}
+
+var filesGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove all files which are not used as task attachment, avatar, project background or user export anymore.",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initialize.FullInitWithoutAsync()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		s := db.NewSession()
+		defer s.Close()
+
+		createdBefore := time.Now().Add(-models.OrphanedFilesMinAge)
+
+		if filesFlagDryRun {
+			orphans, err := models.GetOrphanedFiles(s, createdBefore)
+			if err != nil {
+				log.Fatalf("Could not get orphaned files: %s", err)
+			}
+
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"ID", "Name", "Size", "Created"})
+			for _, f := range orphans {
+				table.Append([]string{
+					strconv.FormatInt(f.ID, 10),
+					f.Name,
+					strconv.FormatUint(f.Size, 10),
+					f.Created.Format(time.RFC3339),
+				})
+			}
+			table.Render()
+			return
+		}
+
+		deleted, err := models.DeleteOrphanedFiles(s, createdBefore)
+		if err != nil {
+			log.Fatalf("Could not remove orphaned files: %s", err)
+		}
+
+		log.Infof("Removed %d orphaned files.", deleted)
+	},
+}
+
+var filesQuotaCmd = &cobra.Command{
+	Use:   "quota",
+	Short: "Show and override the storage quotas of users and projects.",
+}
+
+// Makes sure exactly one of --user and --project was passed and that the user or project exists
+func getQuotaTarget(s *xorm.Session) (kind string, id int64) {
+	if (filesFlagQuotaUser == 0) == (filesFlagQuotaProject == 0) {
+		log.Fatalf("Please provide either a user with --user or a project with --project.")
+	}
+
+	if filesFlagQuotaUser != 0 {
+		if _, err := user.GetUserByID(s, filesFlagQuotaUser); err != nil {
+			log.Fatalf("Could not get user: %s", err)
+		}
+		return "user", filesFlagQuotaUser
+	}
+
+	if _, err := models.GetProjectSimpleByID(s, filesFlagQuotaProject); err != nil {
+		log.Fatalf("Could not get project: %s", err)
+	}
+	return "project", filesFlagQuotaProject
+}
+
+func formatQuota(quota uint64) string {
+	if quota == 0 {
+		return "unlimited"
+	}
+	return datasize.ByteSize(quota).HumanReadable()
+}
+
+var filesQuotaShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show how much storage a user or project uses and their quota.",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initialize.FullInitWithoutAsync()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		s := db.NewSession()
+		defer s.Close()
+
+		kind, id := getQuotaTarget(s)
+
+		var usage *files.StorageUsage
+		var err error
+		if kind == "user" {
+			usage, err = files.GetUserStorageUsage(s, id)
+		} else {
+			usage, err = files.GetProjectStorageUsage(s, id)
+		}
+		if err != nil {
+			log.Fatalf("Could not get storage usage: %s", err)
+		}
+
+		source := "config"
+		if usage.Overridden {
+			source = "override"
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Used", "Quota", "Quota Source"})
+		table.Append([]string{
+			datasize.ByteSize(usage.Used).HumanReadable(),
+			formatQuota(usage.Quota),
+			source,
+		})
+		table.Render()
+	},
+}
+
+var filesQuotaSetCmd = &cobra.Command{
+	Use:   "set [quota]",
+	Short: "Override the quota of a user or project with a human-readable size like 5GB. Use unlimited or 0 to remove the limit.",
+	Args:  cobra.ExactArgs(1),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initialize.FullInitWithoutAsync()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		s := db.NewSession()
+		defer s.Close()
+
+		quota, err := files.ParseQuota(args[0])
+		if err != nil {
+			log.Fatalf("Invalid quota %s: %s", args[0], err)
+		}
+
+		kind, id := getQuotaTarget(s)
+		if kind == "user" {
+			err = files.SetUserQuota(s, id, quota)
+		} else {
+			err = files.SetProjectQuota(s, id, quota)
+		}
+		if err != nil {
+			_ = s.Rollback()
+			log.Fatalf("Could not set quota: %s", err)
+		}
+
+		if err := s.Commit(); err != nil {
+			log.Fatalf("Could not set quota: %s", err)
+		}
+
+		log.Infof("Set the quota of %s %d to %s.", kind, id, formatQuota(quota))
+	},
+}
+
+var filesQuotaResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Remove the quota override of a user or project so the configured quota applies again.",
+	PreRun: func(cmd *cobra.Command, args []string) {
+		initialize.FullInitWithoutAsync()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		s := db.NewSession()
+		defer s.Close()
+
+		var err error
+		kind, id := getQuotaTarget(s)
+		if kind == "user" {
+			err = files.ResetUserQuota(s, id)
+		} else {
+			err = files.ResetProjectQuota(s, id)
+		}
+		if err != nil {
+			_ = s.Rollback()
+			log.Fatalf("Could not reset quota: %s", err)
+		}
+
+		if err := s.Commit(); err != nil {
+			log.Fatalf("Could not reset quota: %s", err)
+		}
+
+		log.Infof("Reset the quota of %s %d.", kind, id)
+	},
+}
@@ -58,6 +58,7 @@ const (
 	ServiceEnableTaskAttachments Key = `service.enabletaskattachments`
 	ServiceTimeZone              Key = `service.timezone`
 	ServiceEnableTaskComments    Key = `service.enabletaskcomments`
+	ServiceEnableTimeTracking    Key = `service.enabletimetracking`
 	ServiceEnableTotp            Key = `service.enabletotp`
 	ServiceSentryDsn             Key = `service.sentrydsn`
 	ServiceTestingtoken          Key = `service.testingtoken`
@@ -128,8 +129,21 @@ const (
 	RateLimitLimit   Key = `ratelimit.limit`
 	RateLimitStore   Key = `ratelimit.store`
 
-	FilesBasePath Key = `files.basepath`
-	FilesMaxSize  Key = `files.maxsize`
+	FilesBasePath       Key = `files.basepath`
+	FilesMaxSize        Key = `files.maxsize`
+	FilesStorage        Key = `files.storage`
+	FilesS3Endpoint     Key = `files.s3.endpoint`
+	FilesS3Bucket       Key = `files.s3.bucket`
+	FilesS3Region       Key = `files.s3.region`
+	FilesS3AccessKey    Key = `files.s3.accesskey`
+	FilesS3SecretKey    Key = `files.s3.secretkey`
+	FilesS3UsePathStyle Key = `files.s3.usepathstyle`
+	FilesS3Timeout      Key = `files.s3.timeout`
+	FilesUserQuota      Key = `files.quota.user`
+	FilesProjectQuota   Key = `files.quota.project`
+	FilesScanType       Key = `files.scan.type`
+	FilesScanAddress    Key = `files.scan.address`
+	FilesScanTimeout    Key = `files.scan.timeout`
 
 	MigrationTodoistEnable             Key = `migration.todoist.enable`
 	MigrationTodoistClientID           Key = `migration.todoist.clientid`
@@ -172,6 +186,15 @@ const (
 	DefaultSettingsLanguage                    Key = `defaultsettings.language`
 	DefaultSettingsTimezone                    Key = `defaultsettings.timezone`
 	DefaultSettingsOverdueTaskRemindersTime    Key = `defaultsettings.overdue_tasks_reminders_time`
+
+	WebhooksEnabled               Key = `webhooks.enabled`
+	WebhooksTimeoutSeconds        Key = `webhooks.timeoutseconds`
+	WebhooksMaxRetries            Key = `webhooks.maxretries`
+	WebhooksAllowNonRoutableIPs   Key = `webhooks.allownonroutableips`
+	WebhooksMaxConcurrentRequests Key = `webhooks.maxconcurrentrequests`
+
+	AutomationsEnabled   Key = `automations.enabled`
+	AutomationsLoopLimit Key = `automations.looplimit`
 )
 
 // GetString returns a string config value
@@ -297,6 +320,7 @@ func InitDefaultConfig() {
 	ServiceEnableTaskAttachments.setDefault(true)
 	ServiceTimeZone.setDefault("GMT")
 	ServiceEnableTaskComments.setDefault(true)
+	ServiceEnableTimeTracking.setDefault(true)
 	ServiceEnableTotp.setDefault(true)
 	ServiceEnableEmailReminders.setDefault(true)
 	ServiceEnableUserDeletion.setDefault(true)
@@ -365,6 +389,13 @@ func InitDefaultConfig() {
 	// Files
 	FilesBasePath.setDefault("files")
 	FilesMaxSize.setDefault("20MB")
+	FilesStorage.setDefault("local")
+	FilesS3Region.setDefault("us-east-1")
+	FilesS3UsePathStyle.setDefault(false)
+	FilesS3Timeout.setDefault(30)
+	FilesUserQuota.setDefault("0")
+	FilesProjectQuota.setDefault("0")
+	FilesScanTimeout.setDefault(60)
 	// Cors
 	CorsEnable.setDefault(true)
 	CorsOrigins.setDefault([]string{"*"})
@@ -387,6 +418,15 @@ func InitDefaultConfig() {
 	DefaultSettingsAvatarProvider.setDefault("initials")
 	DefaultSettingsOverdueTaskRemindersEnabled.setDefault(true)
 	DefaultSettingsOverdueTaskRemindersTime.setDefault("9:00")
+	// Webhook
+	WebhooksEnabled.setDefault(true)
+	WebhooksTimeoutSeconds.setDefault(30)
+	WebhooksMaxRetries.setDefault(3)
+	WebhooksAllowNonRoutableIPs.setDefault(false)
+	WebhooksMaxConcurrentRequests.setDefault(10)
+	// Automations
+	AutomationsEnabled.setDefault(true)
+	AutomationsLoopLimit.setDefault(5)
 }
 
 // InitConfig initializes the config, sets defaults etc.
@@ -17,6 +17,8 @@
 package cron
 
 import (
+	"time"
+
 	"github.com/robfig/cron/v3"
 )
 
@@ -34,6 +36,15 @@ func Schedule(schedule string, f func()) (err error) {
 	return
 }
 
+// NextRun parses a standard cron schedule expression and returns the next time it is due after the given time.
+func NextRun(schedule string, after time.Time) (next time.Time, err error) {
+	sched, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return
+	}
+	return sched.Next(after), nil
+}
+
 // Stop stops the cron scheduler
 func Stop() {
 	c.Stop()
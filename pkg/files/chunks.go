@@ -0,0 +1,104 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Chunks are parts of a file which is uploaded in multiple requests. They are kept in the storage
+// until the upload is finished and the file is created from them. Every chunk is stored under a random key, so
+// concurrent requests uploading a chunk for the same part of a file never overwrite each other's content.
+
+func getChunkName(uploadID int64, key uint64) string {
+	return "upload_" + strconv.FormatInt(uploadID, 10) + "_" + strconv.FormatUint(key, 10)
+}
+
+// SaveChunk stores a chunk of an upload and returns the key it was stored under
+func SaveChunk(uploadID int64, content io.Reader) (key uint64, err error) {
+	b := make([]byte, 8)
+	_, err = rand.Read(b)
+	if err != nil {
+		return 0, err
+	}
+	key = binary.BigEndian.Uint64(b)
+
+	return key, store.save(getChunkName(uploadID, key), content)
+}
+
+// RemoveChunks removes the chunks of an upload with the given keys from the storage
+func RemoveChunks(uploadID int64, keys []uint64) error {
+	for _, key := range keys {
+		err := store.remove(getChunkName(uploadID, key))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// OpenChunks returns the content of the chunks with the given keys in order.
+// Only one chunk is opened at a time.
+func OpenChunks(uploadID int64, keys []uint64) io.ReadCloser {
+	return &chunkReader{uploadID: uploadID, keys: keys}
+}
+
+type chunkReader struct {
+	uploadID int64
+	keys     []uint64
+	current  Reader
+}
+
+func (r *chunkReader) Read(p []byte) (n int, err error) {
+	for {
+		if r.current == nil {
+			if len(r.keys) == 0 {
+				return 0, io.EOF
+			}
+			r.current, err = store.open(getChunkName(r.uploadID, r.keys[0]))
+			if err != nil {
+				return 0, err
+			}
+			r.keys = r.keys[1:]
+		}
+
+		n, err = r.current.Read(p)
+		if !errors.Is(err, io.EOF) {
+			return n, err
+		}
+
+		_ = r.current.Close()
+		r.current = nil
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+func (r *chunkReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	err := r.current.Close()
+	r.current = nil
+	return err
+}
@@ -0,0 +1,54 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunks(t *testing.T) {
+	first, err := SaveChunk(1, strings.NewReader("first "))
+	assert.NoError(t, err)
+	second, err := SaveChunk(1, strings.NewReader("second "))
+	assert.NoError(t, err)
+	third, err := SaveChunk(1, strings.NewReader("third"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	keys := []uint64{first, second, third}
+
+	content := OpenChunks(1, keys)
+	all, err := io.ReadAll(content)
+	assert.NoError(t, err)
+	assert.Equal(t, "first second third", string(all))
+	assert.NoError(t, content.Close())
+
+	err = RemoveChunks(1, keys)
+	assert.NoError(t, err)
+	_, err = store.open(getChunkName(1, second))
+	assert.True(t, os.IsNotExist(err))
+
+	t.Run("Missing chunk", func(t *testing.T) {
+		_, err := io.ReadAll(OpenChunks(2, []uint64{0}))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
@@ -39,5 +39,6 @@ func SetEngine() (err error) {
 func GetTables() []interface{} {
 	return []interface{}{
 		&File{},
+		&StorageQuota{},
 	}
 }
@@ -16,7 +16,12 @@
 
 package files
 
-import "fmt"
+import (
+	"fmt"
+	"net/http"
+
+	"code.vikunja.io/web"
+)
 
 // ErrFileDoesNotExist defines an error where a file does not exist in the db
 type ErrFileDoesNotExist struct {
@@ -66,3 +71,71 @@ func IsErrFileIsNotUnsplashFile(err error) bool {
 	_, ok := err.(ErrFileIsNotUnsplashFile)
 	return ok
 }
+
+// ErrStorageQuotaExceeded defines an error where a file does not fit into the storage quota of a user or project
+type ErrStorageQuotaExceeded struct {
+	UserID    int64
+	ProjectID int64
+	Quota     uint64
+	Used      uint64
+	Size      uint64
+}
+
+// Error is the error implementation of ErrStorageQuotaExceeded
+func (err ErrStorageQuotaExceeded) Error() string {
+	return fmt.Sprintf("storage quota exceeded [UserID: %d, ProjectID: %d, Quota: %d, Used: %d, Size: %d]", err.UserID, err.ProjectID, err.Quota, err.Used, err.Size)
+}
+
+// IsErrStorageQuotaExceeded checks if an error is ErrStorageQuotaExceeded
+func IsErrStorageQuotaExceeded(err error) bool {
+	_, ok := err.(ErrStorageQuotaExceeded)
+	return ok
+}
+
+// ErrCodeStorageQuotaExceeded holds the unique world-error code of this error
+const ErrCodeStorageQuotaExceeded = 20001
+
+// HTTPError holds the http error description
+func (err ErrStorageQuotaExceeded) HTTPError() web.HTTPError {
+	if err.ProjectID != 0 {
+		return web.HTTPError{
+			HTTPCode: http.StatusBadRequest,
+			Code:     ErrCodeStorageQuotaExceeded,
+			Message:  "The file does not fit into the storage quota of this project.",
+		}
+	}
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeStorageQuotaExceeded,
+		Message:  "The file does not fit into your storage quota.",
+	}
+}
+
+// ErrFileIsInfected defines an error where the virus scanner found a virus in an uploaded file
+type ErrFileIsInfected struct {
+	Name  string
+	Virus string
+}
+
+// Error is the error implementation of ErrFileIsInfected
+func (err ErrFileIsInfected) Error() string {
+	return fmt.Sprintf("file is infected [Name: %s, Virus: %s]", err.Name, err.Virus)
+}
+
+// IsErrFileIsInfected checks if an error is ErrFileIsInfected
+func IsErrFileIsInfected(err error) bool {
+	_, ok := err.(ErrFileIsInfected)
+	return ok
+}
+
+// ErrCodeFileIsInfected holds the unique world-error code of this error
+const ErrCodeFileIsInfected = 20002
+
+// HTTPError holds the http error description
+func (err ErrFileIsInfected) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeFileIsInfected,
+		Message:  "The file contains a virus and was rejected.",
+	}
+}
@@ -35,12 +35,25 @@ var afs *afero.Afero
 func InitFileHandler() {
 	fs = afero.NewOsFs()
 	afs = &afero.Afero{Fs: fs}
+
+	var err error
+	store, err = newStorage(config.FilesStorage.GetString())
+	if err != nil {
+		log.Fatalf("Could not initialize file storage: %s", err)
+	}
+
+	virusScanner, err = newScanner(config.FilesScanType.GetString())
+	if err != nil {
+		log.Fatalf("Could not initialize virus scanner: %s", err)
+	}
 }
 
 // InitTestFileHandler initializes a new memory file system for testing
 func InitTestFileHandler() {
 	fs = afero.NewMemMapFs()
 	afs = &afero.Afero{Fs: fs}
+	store = &localStorage{fs: afs}
+	virusScanner = nil
 }
 
 func initFixtures(t *testing.T) {
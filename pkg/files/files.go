@@ -17,6 +17,8 @@
 package files
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"io"
 	"os"
@@ -30,7 +32,6 @@ import (
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/web"
 	"github.com/c2h5oh/datasize"
-	"github.com/spf13/afero"
 )
 
 // File holds all information about a file
@@ -39,11 +40,16 @@ type File struct {
 	Name string `xorm:"text not null" json:"name"`
 	Mime string `xorm:"text null" json:"mime"`
 	Size uint64 `xorm:"bigint not null" json:"size"`
+	// The hash of the content. Files with the same content share it in the storage, files without a hash
+	// were created before deduplication and are stored under their id.
+	Sha256 string `xorm:"varchar(64) null index" json:"-"`
+	// The project this file belongs to, if any. Used to calculate how much storage a project uses.
+	ProjectID int64 `xorm:"bigint null index" json:"-"`
 
 	Created     time.Time `xorm:"created" json:"created"`
 	CreatedByID int64     `xorm:"bigint not null" json:"-"`
 
-	File afero.File `xorm:"-" json:"-"`
+	File Reader `xorm:"-" json:"-"`
 	// This ReadCloser is only used for migration purposes. Use with care!
 	// There is currentlc no better way of doing this.
 	FileContent []byte `xorm:"-" json:"-"`
@@ -55,12 +61,27 @@ func (File) TableName() string {
 }
 
 func (f *File) getFileName() string {
-	return config.FilesBasePath.GetString() + "/" + strconv.FormatInt(f.ID, 10)
+	if f.Sha256 != "" {
+		return f.Sha256
+	}
+	return strconv.FormatInt(f.ID, 10)
 }
 
-// LoadFileByID returns a file by its ID
+// LoadFileByID returns a file by its ID. The content of a file is stored under its hash, so the hash is loaded first
+// if the metadata of the file was not loaded yet.
 func (f *File) LoadFileByID() (err error) {
-	f.File, err = afs.Open(f.getFileName())
+	if f.Sha256 == "" {
+		_, err = x.
+			Table("files").
+			Where("id = ?", f.ID).
+			Cols("sha256").
+			Get(&f.Sha256)
+		if err != nil {
+			return err
+		}
+	}
+
+	f.File, err = store.open(f.getFileName())
 	return
 }
 
@@ -73,17 +94,37 @@ func (f *File) LoadFileMetaByID() (err error) {
 	return
 }
 
+// GetMaxFileSize returns the configured maximum size of a file in bytes
+func GetMaxFileSize() (uint64, error) {
+	var maxSize datasize.ByteSize
+	err := maxSize.UnmarshalText([]byte(config.FilesMaxSize.GetString()))
+	return maxSize.Bytes(), err
+}
+
 // Create creates a new file from an FileHeader
 func Create(f io.Reader, realname string, realsize uint64, a web.Auth) (file *File, err error) {
 	return CreateWithMime(f, realname, realsize, a, "")
 }
 
+// CreateForProject creates a new file which counts towards the storage quota of a project
+func CreateForProject(f io.Reader, realname string, realsize uint64, a web.Auth, projectID int64) (file *File, err error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	file, err = CreateWithMimeAndSession(s, f, realname, realsize, a, "", projectID, true)
+	if err != nil {
+		_ = s.Rollback()
+		return
+	}
+	return
+}
+
 // CreateWithMime creates a new file from an FileHeader and sets its mime type
 func CreateWithMime(f io.Reader, realname string, realsize uint64, a web.Auth, mime string) (file *File, err error) {
 	s := db.NewSession()
 	defer s.Close()
 
-	file, err = CreateWithMimeAndSession(s, f, realname, realsize, a, mime, true)
+	file, err = CreateWithMimeAndSession(s, f, realname, realsize, a, mime, 0, true)
 	if err != nil {
 		_ = s.Rollback()
 		return
@@ -91,23 +132,49 @@ func CreateWithMime(f io.Reader, realname string, realsize uint64, a web.Auth, m
 	return
 }
 
-func CreateWithMimeAndSession(s *xorm.Session, f io.Reader, realname string, realsize uint64, a web.Auth, mime string, checkFileSizeLimit bool) (file *File, err error) {
-	// Get and parse the configured file size
-	var maxSize datasize.ByteSize
-	err = maxSize.UnmarshalText([]byte(config.FilesMaxSize.GetString()))
+// CreateWithMimeAndSession creates a new file in the given session. If projectID is not 0, the file counts towards
+// the storage quota of that project. checkUpload controls if the file is treated as an upload: The maximum file size
+// and the quotas are enforced and it is scanned for viruses. Files Vikunja creates itself, like exports, skip this.
+func CreateWithMimeAndSession(s *xorm.Session, f io.Reader, realname string, realsize uint64, a web.Auth, mime string, projectID int64, checkUpload bool) (file *File, err error) {
+	if checkUpload {
+		maxSize, err := GetMaxFileSize()
+		if err != nil {
+			return nil, err
+		}
+		if realsize > maxSize {
+			return nil, ErrFileIsTooLarge{Size: realsize}
+		}
+
+		err = CheckQuotas(s, a, projectID, realsize, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Hash the content before saving it to find out if it is already stored
+	content, hash, err := spoolContent(f)
 	if err != nil {
 		return nil, err
 	}
-	if realsize > maxSize.Bytes() && checkFileSizeLimit {
-		return nil, ErrFileIsTooLarge{Size: realsize}
+	defer func() {
+		_ = content.Close()
+		_ = os.Remove(content.Name())
+	}()
+
+	if checkUpload {
+		err = scanContent(content, realname, a)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// We first insert the file into the db to get it's ID
 	file = &File{
 		Name:        realname,
 		Size:        realsize,
 		CreatedByID: a.GetID(),
 		Mime:        mime,
+		Sha256:      hash,
+		ProjectID:   projectID,
 	}
 
 	_, err = s.Insert(file)
@@ -115,43 +182,120 @@ func CreateWithMimeAndSession(s *xorm.Session, f io.Reader, realname string, rea
 		return
 	}
 
-	// Save the file to storage with its new ID as path
-	err = file.Save(f)
+	// Locking the other files with the same content makes sure it is not removed by a concurrent delete
+	// while this file starts referencing it.
+	others, err := lockFilesWithHash(s, hash, file.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(others) > 0 {
+		// Another file already has the same content
+		return file, nil
+	}
+
+	err = file.Save(content)
 	return
 }
 
-// Delete removes a file from the DB and the file system
+// spoolContent copies the content to a temporary file and calculates its hash on the way.
+// This avoids keeping the whole file in memory.
+func spoolContent(f io.Reader) (content *os.File, hash string, err error) {
+	content, err = os.CreateTemp("", "vikunja-file-*")
+	if err != nil {
+		return nil, "", err
+	}
+
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(content, h), f)
+	if err == nil {
+		_, err = content.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = content.Close()
+		_ = os.Remove(content.Name())
+		return nil, "", err
+	}
+
+	return content, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// lockFilesWithHash returns the ids of all files with the given content hash except the one with exceptID and locks
+// them until the transaction of the session ends. This serializes creating and deleting files with the same content.
+func lockFilesWithHash(s *xorm.Session, hash string, exceptID int64) (ids []int64, err error) {
+	ids = []int64{}
+	err = s.
+		Table("files").
+		Where("sha256 = ? AND id != ?", hash, exceptID).
+		Cols("id").
+		ForUpdate().
+		Find(&ids)
+	return
+}
+
+// Delete removes a file from the DB and the file system.
+// The content is only removed once no other file with the same content references it.
 func (f *File) Delete() (err error) {
 	s := db.NewSession()
 	defer s.Close()
 
-	deleted, err := s.Where("id = ?", f.ID).Delete(f)
+	err = s.Begin()
+	if err != nil {
+		return err
+	}
+
+	stored := &File{}
+	exists, err := s.Where("id = ?", f.ID).Get(stored)
 	if err != nil {
 		_ = s.Rollback()
 		return err
 	}
-	if deleted == 0 {
+	if !exists {
 		_ = s.Rollback()
 		return ErrFileDoesNotExist{FileID: f.ID}
 	}
 
-	err = afs.Remove(f.getFileName())
-	if err != nil {
-		var perr *os.PathError
-		if errors.As(err, &perr) {
-			// Don't fail when removing the file failed
-			log.Errorf("Error deleting file %d: %w", f.ID, err)
-			return s.Commit()
+	if stored.Sha256 != "" {
+		// Wait for concurrent creates of files with the same content to finish, see CreateWithMimeAndSession
+		_, err = lockFilesWithHash(s, stored.Sha256, 0)
+		if err != nil {
+			_ = s.Rollback()
+			return err
 		}
+	}
 
+	_, err = s.Where("id = ?", f.ID).Delete(&File{})
+	if err != nil {
 		_ = s.Rollback()
 		return err
 	}
 
-	return
+	if stored.Sha256 != "" {
+		references, err := s.Where("sha256 = ?", stored.Sha256).Count(&File{})
+		if err != nil {
+			_ = s.Rollback()
+			return err
+		}
+		if references > 0 {
+			return s.Commit()
+		}
+	}
+
+	err = store.remove(stored.getFileName())
+	if err != nil {
+		var perr *os.PathError
+		if !errors.As(err, &perr) {
+			_ = s.Rollback()
+			return err
+		}
+
+		// Don't fail when removing the file failed
+		log.Errorf("Error deleting file %d: %s", f.ID, err)
+	}
+
+	return s.Commit()
 }
 
 // Save saves a file to storage
 func (f *File) Save(fcontent io.Reader) error {
-	return afs.WriteReader(f.getFileName(), fcontent)
+	return store.save(f.getFileName(), fcontent)
 }
@@ -17,10 +17,14 @@
 package files
 
 import (
+	"errors"
 	"io"
 	"os"
+	"strconv"
 	"testing"
 
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -69,6 +73,36 @@ func TestCreate(t *testing.T) {
 		assert.Equal(t, uint64(100), file.Size)
 
 	})
+	t.Run("Deduplicates content", func(t *testing.T) {
+		initFixtures(t)
+		ta := &testauth{id: 1}
+		first, err := Create(&testfile{content: []byte("testfile")}, "first", 100, ta)
+		assert.NoError(t, err)
+		second, err := Create(&testfile{content: []byte("testfile")}, "second", 100, ta)
+		assert.NoError(t, err)
+
+		assert.NotEqual(t, first.ID, second.ID)
+		assert.NotEmpty(t, first.Sha256)
+		assert.Equal(t, first.Sha256, second.Sha256)
+		_, err = FileStat(config.FilesBasePath.GetString() + "/" + first.Sha256)
+		assert.NoError(t, err)
+		_, err = FileStat(config.FilesBasePath.GetString() + "/" + strconv.FormatInt(second.ID, 10))
+		assert.True(t, os.IsNotExist(err))
+
+		// The content is only removed with the last file using it
+		err = first.Delete()
+		assert.NoError(t, err)
+		_, err = FileStat(config.FilesBasePath.GetString() + "/" + first.Sha256)
+		assert.NoError(t, err)
+		err = second.LoadFileByID()
+		assert.NoError(t, err)
+		_ = second.File.Close()
+
+		err = second.Delete()
+		assert.NoError(t, err)
+		_, err = FileStat(config.FilesBasePath.GetString() + "/" + first.Sha256)
+		assert.True(t, os.IsNotExist(err))
+	})
 	t.Run("Too Large", func(t *testing.T) {
 		initFixtures(t)
 		tf := &testfile{
@@ -81,6 +115,15 @@ func TestCreate(t *testing.T) {
 	})
 }
 
+// failingRemoveStorage is a storage which can't remove anything
+type failingRemoveStorage struct {
+	storage
+}
+
+func (failingRemoveStorage) remove(string) error {
+	return errors.New("storage unavailable")
+}
+
 func TestFile_Delete(t *testing.T) {
 	t.Run("Normal", func(t *testing.T) {
 		initFixtures(t)
@@ -95,6 +138,20 @@ func TestFile_Delete(t *testing.T) {
 		assert.Error(t, err)
 		assert.True(t, IsErrFileDoesNotExist(err))
 	})
+	t.Run("Removing the content fails", func(t *testing.T) {
+		initFixtures(t)
+		original := store
+		store = failingRemoveStorage{storage: original}
+		defer func() { store = original }()
+
+		f := &File{ID: 1}
+		err := f.Delete()
+		assert.Error(t, err)
+		// The file is kept so its content can still be removed later
+		db.AssertExists(t, "files", map[string]interface{}{
+			"id": 1,
+		}, false)
+	})
 }
 
 func TestFile_LoadFileByID(t *testing.T) {
@@ -111,6 +168,20 @@ func TestFile_LoadFileByID(t *testing.T) {
 		assert.Error(t, err)
 		assert.True(t, os.IsNotExist(err))
 	})
+	t.Run("Stored under its hash", func(t *testing.T) {
+		initFixtures(t)
+		created, err := Create(&testfile{content: []byte("testfile")}, "testfile", 100, &testauth{id: 1})
+		assert.NoError(t, err)
+
+		// Only the id is known, the hash is loaded from the metadata
+		f := &File{ID: created.ID}
+		err = f.LoadFileByID()
+		assert.NoError(t, err)
+		content, err := io.ReadAll(f.File)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("testfile"), content)
+		_ = f.File.Close()
+	})
 }
 
 func TestFile_LoadFileMetaByID(t *testing.T) {
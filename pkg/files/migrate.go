@@ -0,0 +1,112 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"fmt"
+	"os"
+
+	"code.vikunja.io/api/pkg/log"
+)
+
+// MigrateStorage copies the content of all files from one storage backend to another.
+// Files which already exist in the target storage with the same size are skipped, so an interrupted
+// migration can be continued by running it again.
+func MigrateStorage(from, to string, deleteSource bool) error {
+	if from == to {
+		return fmt.Errorf("source and target storage are both %s", from)
+	}
+
+	source, err := newStorage(from)
+	if err != nil {
+		return err
+	}
+	target, err := newStorage(to)
+	if err != nil {
+		return err
+	}
+
+	return migrateFiles(source, target, deleteSource)
+}
+
+func migrateFiles(source, target storage, deleteSource bool) error {
+	allFiles := []*File{}
+	err := x.OrderBy("id asc").Find(&allFiles)
+	if err != nil {
+		return err
+	}
+
+	var migrated, missing int
+	// Files with the same content share it in the storage
+	seen := make(map[string]bool, len(allFiles))
+	for _, f := range allFiles {
+		if seen[f.getFileName()] {
+			continue
+		}
+		seen[f.getFileName()] = true
+
+		copied, err := migrateFile(source, target, f.getFileName())
+		if os.IsNotExist(err) {
+			log.Warningf("File %d does not exist in the source storage, skipping it", f.ID)
+			missing++
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("could not migrate file %d: %w", f.ID, err)
+		}
+
+		if deleteSource {
+			if err := source.remove(f.getFileName()); err != nil {
+				return fmt.Errorf("could not remove file %d from the source storage: %w", f.ID, err)
+			}
+		}
+
+		if copied {
+			log.Infof("Migrated file %d", f.ID)
+		} else {
+			log.Infof("File %d already exists in the target storage, skipping it", f.ID)
+		}
+		migrated++
+	}
+
+	log.Infof("Migrated %d files, %d files were missing in the source storage.", migrated, missing)
+	return nil
+}
+
+func migrateFile(source, target storage, name string) (copied bool, err error) {
+	content, err := source.open(name)
+	if err != nil {
+		return false, err
+	}
+	defer content.Close()
+
+	info, err := content.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	existing, err := target.open(name)
+	if err == nil {
+		existingInfo, err := existing.Stat()
+		_ = existing.Close()
+		if err == nil && existingInfo.Size() == info.Size() {
+			return false, nil
+		}
+	}
+
+	return true, target.save(name, content)
+}
@@ -0,0 +1,181 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"github.com/c2h5oh/datasize"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// StorageQuota overrides the configured storage quota for a single user or project
+type StorageQuota struct {
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id"`
+	// Only one of UserID and ProjectID is set
+	UserID    int64 `xorm:"bigint null index" json:"user_id"`
+	ProjectID int64 `xorm:"bigint null index" json:"project_id"`
+	// The quota in bytes, 0 means unlimited.
+	Quota uint64 `xorm:"bigint not null" json:"quota"`
+
+	Created time.Time `xorm:"created not null" json:"created"`
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+}
+
+// TableName is the table name for the storage quotas table
+func (StorageQuota) TableName() string {
+	return "storage_quotas"
+}
+
+// StorageUsage holds how much storage a user or project uses and how much it may use
+type StorageUsage struct {
+	// How many bytes the files of the user or project use.
+	Used uint64 `json:"used"`
+	// How many bytes the files of the user or project may use. 0 means unlimited.
+	Quota uint64 `json:"quota"`
+	// Whether the quota was set for this user or project by an admin instead of coming from the config.
+	Overridden bool `json:"overridden"`
+}
+
+// ParseQuota parses a human-readable quota like 1GB. "unlimited" and 0 both mean no quota.
+func ParseQuota(quota string) (uint64, error) {
+	if strings.EqualFold(quota, "unlimited") {
+		return 0, nil
+	}
+	var size datasize.ByteSize
+	err := size.UnmarshalText([]byte(quota))
+	return size.Bytes(), err
+}
+
+// getStorageUsage sums the size of all files whose filesColumn is id and looks up the quota override whose
+// quotaColumn is id.
+func getStorageUsage(s *xorm.Session, filesColumn, quotaColumn string, id int64, defaultQuota config.Key) (usage *StorageUsage, err error) {
+	usage = &StorageUsage{}
+
+	used, err := s.Where(builder.Eq{filesColumn: id}).SumInt(&File{}, "size")
+	if err != nil {
+		return nil, err
+	}
+	usage.Used = uint64(used)
+
+	override := &StorageQuota{}
+	usage.Overridden, err = s.Where(builder.Eq{quotaColumn: id}).Get(override)
+	if err != nil {
+		return nil, err
+	}
+	if usage.Overridden {
+		usage.Quota = override.Quota
+		return usage, nil
+	}
+
+	usage.Quota, err = ParseQuota(defaultQuota.GetString())
+	return usage, err
+}
+
+// GetUserStorageUsage returns how much storage the files uploaded by a user use
+func GetUserStorageUsage(s *xorm.Session, userID int64) (*StorageUsage, error) {
+	return getStorageUsage(s, "created_by_id", "user_id", userID, config.FilesUserQuota)
+}
+
+// GetProjectStorageUsage returns how much storage the attachments and background of a project use
+func GetProjectStorageUsage(s *xorm.Session, projectID int64) (*StorageUsage, error) {
+	return getStorageUsage(s, "project_id", "project_id", projectID, config.FilesProjectQuota)
+}
+
+func setQuota(s *xorm.Session, column string, id int64, quota uint64) error {
+	override := &StorageQuota{}
+	exists, err := s.Where(builder.Eq{column: id}).Get(override)
+	if err != nil {
+		return err
+	}
+
+	override.Quota = quota
+	if exists {
+		_, err = s.Where("id = ?", override.ID).Cols("quota").Update(override)
+		return err
+	}
+
+	if column == "user_id" {
+		override.UserID = id
+	} else {
+		override.ProjectID = id
+	}
+	_, err = s.Insert(override)
+	return err
+}
+
+// SetUserQuota overrides the configured quota for a user. A quota of 0 means unlimited.
+func SetUserQuota(s *xorm.Session, userID int64, quota uint64) error {
+	return setQuota(s, "user_id", userID, quota)
+}
+
+// SetProjectQuota overrides the configured quota for a project. A quota of 0 means unlimited.
+func SetProjectQuota(s *xorm.Session, projectID int64, quota uint64) error {
+	return setQuota(s, "project_id", projectID, quota)
+}
+
+// ResetUserQuota removes the override of a user's quota so the configured one is used again
+func ResetUserQuota(s *xorm.Session, userID int64) error {
+	_, err := s.Where("user_id = ?", userID).Delete(&StorageQuota{})
+	return err
+}
+
+// ResetProjectQuota removes the override of a project's quota so the configured one is used again
+func ResetProjectQuota(s *xorm.Session, projectID int64) error {
+	_, err := s.Where("project_id = ?", projectID).Delete(&StorageQuota{})
+	return err
+}
+
+func (usage *StorageUsage) fits(size uint64) bool {
+	return usage.Quota == 0 || usage.Used+size <= usage.Quota
+}
+
+// CheckQuotas makes sure a new file of the given size does not exceed the quota of its creator or project.
+// Files created by link shares only count towards the quota of the project. pendingUser and pendingProject are bytes
+// which are not stored as files yet but will be, like unfinished uploads. They count as used.
+func CheckQuotas(s *xorm.Session, a web.Auth, projectID int64, size, pendingUser, pendingProject uint64) error {
+	if u, is := a.(*user.User); is {
+		usage, err := GetUserStorageUsage(s, u.ID)
+		if err != nil {
+			return err
+		}
+		usage.Used += pendingUser
+		if !usage.fits(size) {
+			return ErrStorageQuotaExceeded{UserID: u.ID, Quota: usage.Quota, Used: usage.Used, Size: size}
+		}
+	}
+
+	if projectID == 0 {
+		return nil
+	}
+
+	usage, err := GetProjectStorageUsage(s, projectID)
+	if err != nil {
+		return err
+	}
+	usage.Used += pendingProject
+	if !usage.fits(size) {
+		return ErrStorageQuotaExceeded{ProjectID: projectID, Quota: usage.Quota, Used: usage.Used, Size: size}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuota(t *testing.T) {
+	quota, err := ParseQuota("unlimited")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), quota)
+
+	quota, err = ParseQuota("2KB")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2048), quota)
+
+	_, err = ParseQuota("lots")
+	assert.Error(t, err)
+}
+
+func TestStorageQuota(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("Usage", func(t *testing.T) {
+		initFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		usage, err := GetUserStorageUsage(s, 1)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(100), usage.Used)
+		assert.Equal(t, uint64(0), usage.Quota)
+		assert.False(t, usage.Overridden)
+
+		_, err = CreateForProject(&testfile{content: []byte("testfile")}, "testfile", 50, u, 3)
+		assert.NoError(t, err)
+
+		usage, err = GetProjectStorageUsage(s, 3)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(50), usage.Used)
+	})
+	t.Run("Configured user quota", func(t *testing.T) {
+		initFixtures(t)
+		config.FilesUserQuota.Set("150B")
+		defer config.FilesUserQuota.Set("0")
+
+		_, err := Create(&testfile{content: []byte("testfile")}, "testfile", 50, u)
+		assert.NoError(t, err)
+
+		_, err = Create(&testfile{content: []byte("testfile")}, "testfile", 1, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrStorageQuotaExceeded(err))
+		assert.Equal(t, int64(1), err.(ErrStorageQuotaExceeded).UserID)
+	})
+	t.Run("Configured project quota", func(t *testing.T) {
+		initFixtures(t)
+		config.FilesProjectQuota.Set("100B")
+		defer config.FilesProjectQuota.Set("0")
+
+		_, err := CreateForProject(&testfile{content: []byte("testfile")}, "testfile", 100, u, 3)
+		assert.NoError(t, err)
+
+		_, err = CreateForProject(&testfile{content: []byte("testfile")}, "testfile", 1, u, 3)
+		assert.Error(t, err)
+		assert.True(t, IsErrStorageQuotaExceeded(err))
+		assert.Equal(t, int64(3), err.(ErrStorageQuotaExceeded).ProjectID)
+
+		// Other projects have their own quota
+		_, err = CreateForProject(&testfile{content: []byte("testfile")}, "testfile", 1, u, 4)
+		assert.NoError(t, err)
+	})
+	t.Run("Override", func(t *testing.T) {
+		initFixtures(t)
+		config.FilesUserQuota.Set("1GB")
+		defer config.FilesUserQuota.Set("0")
+		s := db.NewSession()
+		defer s.Close()
+
+		err := SetUserQuota(s, 1, 120)
+		assert.NoError(t, err)
+		// Setting it again updates the override
+		err = SetUserQuota(s, 1, 110)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+		defer func() {
+			s := db.NewSession()
+			defer s.Close()
+			_ = ResetUserQuota(s, 1)
+			_ = s.Commit()
+		}()
+
+		usage, err := GetUserStorageUsage(s, 1)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(110), usage.Quota)
+		assert.True(t, usage.Overridden)
+
+		_, err = Create(&testfile{content: []byte("testfile")}, "testfile", 20, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrStorageQuotaExceeded(err))
+
+		// Files not created by users, like the ones of link shares, only count towards the project
+		_, err = Create(&testfile{content: []byte("testfile")}, "testfile", 20, &testauth{id: 1})
+		assert.NoError(t, err)
+
+		err = ResetUserQuota(s, 1)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		usage, err = GetUserStorageUsage(s, 1)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1024*1024*1024), usage.Quota)
+		assert.False(t, usage.Overridden)
+	})
+	t.Run("Limits not checked", func(t *testing.T) {
+		initFixtures(t)
+		config.FilesUserQuota.Set("1B")
+		defer config.FilesUserQuota.Set("0")
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := CreateWithMimeAndSession(s, &testfile{content: []byte("testfile")}, "export.zip", 50, u, "application/zip", 0, false)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,492 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+)
+
+// S3 needs at least 5 MiB for every part of a multipart upload except the last one.
+// Files are uploaded in parts of this size, so this is also the most we keep in memory per upload.
+const s3PartSize = 5 << 20
+
+const s3TimeFormat = "20060102T150405Z"
+
+// s3Storage talks to an S3-compatible object storage. Requests are signed with AWS Signature Version 4.
+type s3Storage struct {
+	client       *http.Client
+	endpoint     *url.URL
+	bucket       string
+	region       string
+	accessKey    string
+	secretKey    string
+	usePathStyle bool
+	partSize     int
+	now          func() time.Time
+}
+
+func newS3Storage() (*s3Storage, error) {
+	endpoint, err := url.Parse(strings.TrimSuffix(config.FilesS3Endpoint.GetString(), "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+	if endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q, it needs to include the scheme and host", config.FilesS3Endpoint.GetString())
+	}
+	if config.FilesS3Bucket.GetString() == "" {
+		return nil, errors.New("no s3 bucket configured")
+	}
+
+	// The timeout only covers connecting and waiting for the response. An overall timeout would also cut off
+	// the transfer of large files.
+	timeout := time.Duration(config.FilesS3Timeout.GetInt()) * time.Second
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout: timeout,
+			}).DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			ExpectContinueTimeout: time.Second,
+			IdleConnTimeout:       90 * time.Second,
+		},
+	}
+
+	return &s3Storage{
+		client:       client,
+		endpoint:     endpoint,
+		bucket:       config.FilesS3Bucket.GetString(),
+		region:       config.FilesS3Region.GetString(),
+		accessKey:    config.FilesS3AccessKey.GetString(),
+		secretKey:    config.FilesS3SecretKey.GetString(),
+		usePathStyle: config.FilesS3UsePathStyle.GetBool(),
+		partSize:     s3PartSize,
+		now:          time.Now,
+	}, nil
+}
+
+// s3Error is an error response from the object storage
+type s3Error struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (err *s3Error) Error() string {
+	return fmt.Sprintf("s3 request failed with status %d [Code: %s, Message: %s]", err.StatusCode, err.Code, err.Message)
+}
+
+func newS3Error(resp *http.Response) error {
+	err := &s3Error{StatusCode: resp.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	_ = xml.Unmarshal(body, err)
+	return err
+}
+
+func (s *s3Storage) objectURL(name string, query url.Values) *url.URL {
+	u := *s.endpoint
+	if s.usePathStyle {
+		u.Path = s.endpoint.Path + "/" + s.bucket + "/" + name
+	} else {
+		u.Host = s.bucket + "." + s.endpoint.Host
+		u.Path = s.endpoint.Path + "/" + name
+	}
+	u.RawPath = ""
+	u.RawQuery = query.Encode()
+	return &u
+}
+
+// request sends a signed request for an object. The response body needs to be closed by the caller.
+func (s *s3Storage) request(method, name string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.objectURL(name, query).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) == 0 {
+		// Otherwise the request would be sent with Transfer-Encoding: chunked
+		req.Body = http.NoBody
+		req.ContentLength = 0
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	payloadHash := sha256.Sum256(body)
+	s.sign(req, hex.EncodeToString(payloadHash[:]))
+
+	return s.client.Do(req)
+}
+
+// sign adds the headers for AWS Signature Version 4 to the request.
+// See https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func (s *s3Storage) sign(req *http.Request, payloadHash string) {
+	now := s.now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for key, values := range req.Header {
+		headers[strings.ToLower(key)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	headerNames := make([]string, 0, len(headers))
+	for name := range headers {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range headerNames {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(headerNames, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3URIEncode(req.URL.Path, false),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := now.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + now.Format(s3TimeFormat) + "\n" + scope + "\n" + hex.EncodeToString(canonicalRequestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.accessKey+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3URIEncode encodes everything except the unreserved characters like S3 expects it when signing a request.
+func s3URIEncode(s string, encodeSlash bool) string {
+	var encoded strings.Builder
+	for _, b := range []byte(s) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9', b == '-', b == '_', b == '.', b == '~':
+			encoded.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			encoded.WriteByte(b)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+	return encoded.String()
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	params := make([]string, 0, len(query))
+	for key, values := range query {
+		for _, value := range values {
+			params = append(params, s3URIEncode(key, true)+"="+s3URIEncode(value, true))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+func (s *s3Storage) open(name string) (Reader, error) {
+	resp, err := s.request(http.MethodHead, name, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newS3Error(resp)
+	}
+
+	info := &s3FileInfo{name: name, size: resp.ContentLength}
+	if modTime, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.modTime = modTime
+	}
+
+	return &s3Object{storage: s, info: info}, nil
+}
+
+// save uploads the content in one request if it fits into one part and as a multipart upload otherwise.
+// This way, the content is never completely loaded into memory.
+func (s *s3Storage) save(name string, content io.Reader) error {
+	buf := make([]byte, s.partSize)
+	n, err := io.ReadFull(content, buf)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return s.putObject(name, buf[:n])
+	}
+	if err != nil {
+		return err
+	}
+
+	uploadID, err := s.createMultipartUpload(name)
+	if err != nil {
+		return err
+	}
+
+	err = s.uploadParts(name, uploadID, buf, content)
+	if err != nil {
+		// Abort the upload so that the storage does not keep the parts already uploaded
+		_ = s.abortMultipartUpload(name, uploadID)
+		return err
+	}
+
+	return nil
+}
+
+func (s *s3Storage) putObject(name string, content []byte) error {
+	resp, err := s.request(http.MethodPut, name, nil, nil, content)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newS3Error(resp)
+	}
+	return nil
+}
+
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+func (s *s3Storage) createMultipartUpload(name string) (uploadID string, err error) {
+	resp, err := s.request(http.MethodPost, name, url.Values{"uploads": {""}}, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", newS3Error(resp)
+	}
+
+	result := struct {
+		UploadID string `xml:"UploadId"`
+	}{}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("could not parse multipart upload: %w", err)
+	}
+	return result.UploadID, nil
+}
+
+// uploadParts uploads the first part which was already read into buf and then the rest of the content.
+func (s *s3Storage) uploadParts(name, uploadID string, buf []byte, content io.Reader) error {
+	parts := []s3CompletedPart{}
+	part := buf
+	for {
+		query := url.Values{
+			"partNumber": {strconv.Itoa(len(parts) + 1)},
+			"uploadId":   {uploadID},
+		}
+		resp, err := s.request(http.MethodPut, name, query, nil, part)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return newS3Error(resp)
+		}
+		parts = append(parts, s3CompletedPart{PartNumber: len(parts) + 1, ETag: resp.Header.Get("ETag")})
+
+		n, err := io.ReadFull(content, buf)
+		if n == 0 && errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return err
+		}
+		part = buf[:n]
+	}
+
+	return s.completeMultipartUpload(name, uploadID, parts)
+}
+
+func (s *s3Storage) completeMultipartUpload(name, uploadID string, parts []s3CompletedPart) error {
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+		Parts   []s3CompletedPart `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.request(http.MethodPost, name, url.Values{"uploadId": {uploadID}}, nil, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newS3Error(resp)
+	}
+
+	// S3 may report an error after it already sent the 200 status
+	result, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if bytes.Contains(result, []byte("<Error>")) {
+		s3err := &s3Error{StatusCode: resp.StatusCode}
+		_ = xml.Unmarshal(result, s3err)
+		return s3err
+	}
+	return nil
+}
+
+func (s *s3Storage) abortMultipartUpload(name, uploadID string) error {
+	resp, err := s.request(http.MethodDelete, name, url.Values{"uploadId": {uploadID}}, nil, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (s *s3Storage) remove(name string) error {
+	resp, err := s.request(http.MethodDelete, name, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	default:
+		return newS3Error(resp)
+	}
+}
+
+type s3FileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (i *s3FileInfo) Name() string       { return i.name }
+func (i *s3FileInfo) Size() int64        { return i.size }
+func (i *s3FileInfo) Mode() os.FileMode  { return 0644 }
+func (i *s3FileInfo) ModTime() time.Time { return i.modTime }
+func (i *s3FileInfo) IsDir() bool        { return false }
+func (i *s3FileInfo) Sys() interface{}   { return nil }
+
+// s3Object streams an object from the storage. It only starts downloading when reading from it and
+// uses range requests to continue at the current offset after seeking.
+type s3Object struct {
+	storage *s3Storage
+	info    *s3FileInfo
+	offset  int64
+	body    io.ReadCloser
+}
+
+func (o *s3Object) Read(p []byte) (n int, err error) {
+	if o.offset >= o.info.size {
+		return 0, io.EOF
+	}
+
+	if o.body == nil {
+		header := http.Header{"Range": {"bytes=" + strconv.FormatInt(o.offset, 10) + "-"}}
+		resp, err := o.storage.request(http.MethodGet, o.info.name, nil, header, nil)
+		if err != nil {
+			return 0, err
+		}
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+			defer resp.Body.Close()
+			return 0, newS3Error(resp)
+		}
+		o.body = resp.Body
+		if resp.StatusCode == http.StatusOK && o.offset > 0 {
+			// The storage ignored the range and sent the whole object
+			if _, err := io.CopyN(io.Discard, o.body, o.offset); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	n, err = o.body.Read(p)
+	o.offset += int64(n)
+	return
+}
+
+func (o *s3Object) Seek(offset int64, whence int) (int64, error) {
+	var newOffset int64
+	switch whence {
+	case io.SeekStart:
+		newOffset = offset
+	case io.SeekCurrent:
+		newOffset = o.offset + offset
+	case io.SeekEnd:
+		newOffset = o.info.size + offset
+	default:
+		return o.offset, errors.New("invalid whence")
+	}
+	if newOffset < 0 {
+		return o.offset, errors.New("negative position")
+	}
+
+	if newOffset != o.offset && o.body != nil {
+		_ = o.body.Close()
+		o.body = nil
+	}
+	o.offset = newOffset
+	return o.offset, nil
+}
+
+func (o *s3Object) Stat() (os.FileInfo, error) {
+	return o.info, nil
+}
+
+func (o *s3Object) Close() error {
+	if o.body == nil {
+		return nil
+	}
+	err := o.body.Close()
+	o.body = nil
+	return err
+}
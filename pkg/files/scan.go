@@ -0,0 +1,307 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/web"
+)
+
+const (
+	// ScannerClamd scans files with clamd
+	ScannerClamd = "clamd"
+	// ScannerICAP scans files with an ICAP server
+	ScannerICAP = "icap"
+)
+
+// The size of the chunks the content is sent to the scanner in
+const scanChunkSize = 32 * 1024
+
+type scanner interface {
+	// scan returns the name of the virus if the content is infected or an empty string if it is clean
+	scan(content io.Reader) (virus string, err error)
+}
+
+// The configured virus scanner, nil if scanning is disabled
+var virusScanner scanner
+
+func newScanner(kind string) (scanner, error) {
+	address := config.FilesScanAddress.GetString()
+	timeout := time.Duration(config.FilesScanTimeout.GetInt()) * time.Second
+
+	switch kind {
+	case "":
+		return nil, nil
+	case ScannerClamd:
+		return newClamdScanner(address, timeout)
+	case ScannerICAP:
+		return newICAPScanner(address, timeout)
+	default:
+		return nil, fmt.Errorf("unknown virus scanner %s, must be either %s or %s", kind, ScannerClamd, ScannerICAP)
+	}
+}
+
+// scanContent checks the content with the configured virus scanner and rewinds it afterwards
+func scanContent(content io.ReadSeeker, name string, a web.Auth) error {
+	if virusScanner == nil {
+		return nil
+	}
+
+	virus, err := virusScanner.scan(content)
+	if err != nil {
+		log.Errorf("Could not scan file %s for viruses: %s", name, err)
+		return err
+	}
+	if virus != "" {
+		log.Warningf("Rejected file %s uploaded by %d, it contains %s", name, a.GetID(), virus)
+		return ErrFileIsInfected{Name: name, Virus: virus}
+	}
+	log.Infof("Scanned file %s uploaded by %d, no virus found", name, a.GetID())
+
+	_, err = content.Seek(0, io.SeekStart)
+	return err
+}
+
+func dialScanner(network, address string, timeout time.Duration) (net.Conn, error) {
+	conn, err := net.DialTimeout(network, address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	// The timeout applies to the whole scan
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+type clamdScanner struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// newClamdScanner creates a scanner for clamd listening on tcp://host:port or unix:///path/to/socket
+func newClamdScanner(address string, timeout time.Duration) (*clamdScanner, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "tcp":
+		return &clamdScanner{network: "tcp", address: u.Host, timeout: timeout}, nil
+	case "unix":
+		return &clamdScanner{network: "unix", address: u.Path, timeout: timeout}, nil
+	default:
+		return nil, fmt.Errorf("invalid clamd address %s, it must start with tcp:// or unix://", address)
+	}
+}
+
+// scan uses the INSTREAM command of clamd which expects the content in chunks,
+// each prefixed with its length, and a chunk with length zero at the end.
+func (c *clamdScanner) scan(content io.Reader) (virus string, err error) {
+	conn, err := dialScanner(c.network, c.address, c.timeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, scanChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, err := content.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(append(size, buf[:n]...)); err != nil {
+				return "", err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := conn.Write(size); err != nil {
+		return "", err
+	}
+
+	response, err := bufio.NewReader(conn).ReadString('\x00')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return parseClamdResponse(response)
+}
+
+// parseClamdResponse parses responses like "stream: OK" or "stream: Eicar-Signature FOUND"
+func parseClamdResponse(response string) (virus string, err error) {
+	response = strings.TrimSpace(strings.TrimRight(response, "\x00"))
+	result := strings.TrimPrefix(response, "stream: ")
+
+	if result == "OK" {
+		return "", nil
+	}
+	if strings.HasSuffix(result, " FOUND") {
+		return strings.TrimSuffix(result, " FOUND"), nil
+	}
+	return "", fmt.Errorf("clamd returned an error: %s", response)
+}
+
+type icapScanner struct {
+	host    string
+	url     string
+	timeout time.Duration
+}
+
+// newICAPScanner creates a scanner for an ICAP service like icap://host:1344/avscan
+func newICAPScanner(address string, timeout time.Duration) (*icapScanner, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "icap" || u.Host == "" {
+		return nil, fmt.Errorf("invalid icap address %s, it must look like icap://host:1344/service", address)
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "1344")
+	}
+	return &icapScanner{host: host, url: address, timeout: timeout}, nil
+}
+
+// scan sends the content as the body of an http response in a RESPMOD request.
+// The server answers with 204 if it does not need to modify the response, which means the content is clean.
+func (c *icapScanner) scan(content io.Reader) (virus string, err error) {
+	conn, err := dialScanner("tcp", c.host, c.timeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	w := bufio.NewWriter(conn)
+	httpHeader := "HTTP/1.1 200 OK\r\nContent-Type: application/octet-stream\r\n\r\n"
+	_, err = fmt.Fprintf(w, "RESPMOD %s ICAP/1.0\r\nHost: %s\r\nAllow: 204\r\nEncapsulated: res-hdr=0, res-body=%d\r\n\r\n%s",
+		c.url, c.host, len(httpHeader), httpHeader)
+	if err != nil {
+		return "", err
+	}
+
+	buf := make([]byte, scanChunkSize)
+	for {
+		n, err := content.Read(buf)
+		if n > 0 {
+			if _, err := fmt.Fprintf(w, "%x\r\n%s\r\n", n, buf[:n]); err != nil {
+				return "", err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	if _, err := w.WriteString("0\r\n\r\n"); err != nil {
+		return "", err
+	}
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+
+	r := textproto.NewReader(bufio.NewReader(conn))
+	statusLine, err := r.ReadLine()
+	if err != nil {
+		return "", err
+	}
+	status := strings.SplitN(statusLine, " ", 3)
+	if len(status) < 2 || !strings.HasPrefix(status[0], "ICAP/") {
+		return "", fmt.Errorf("invalid icap response: %s", statusLine)
+	}
+	code, err := strconv.Atoi(status[1])
+	if err != nil {
+		return "", fmt.Errorf("invalid icap response: %s", statusLine)
+	}
+
+	headers, err := r.ReadMIMEHeader()
+	if err != nil {
+		return "", err
+	}
+
+	switch code {
+	case 204:
+		return "", nil
+	case 200:
+		return parseICAPInfection(headers, r)
+	default:
+		return "", fmt.Errorf("icap server returned an error: %s", statusLine)
+	}
+}
+
+// parseICAPInfection finds out if a modified response from an ICAP server means the content is infected.
+// Most servers report the virus in a header, others only replace the response with an error page.
+func parseICAPInfection(headers textproto.MIMEHeader, r *textproto.Reader) (virus string, err error) {
+	if virus := headers.Get("X-Virus-ID"); virus != "" {
+		return virus, nil
+	}
+	// Looks like "Type=0; Resolution=2; Threat=Eicar-Signature;"
+	if infection := headers.Get("X-Infection-Found"); infection != "" {
+		for _, part := range strings.Split(infection, ";") {
+			if threat, has := strings.CutPrefix(strings.TrimSpace(part), "Threat="); has {
+				return threat, nil
+			}
+		}
+		return infection, nil
+	}
+	if violations := headers.Values("X-Violations-Found"); len(violations) > 0 {
+		return strings.Join(violations, " "), nil
+	}
+
+	if !strings.Contains(headers.Get("Encapsulated"), "res-hdr=0") {
+		return "", nil
+	}
+	httpStatus, err := r.ReadLine()
+	if err != nil {
+		return "", err
+	}
+	parts := strings.Fields(httpStatus)
+	if len(parts) >= 2 && strings.HasPrefix(parts[0], "HTTP/") && parts[1] == "200" {
+		return "", nil
+	}
+	return "unknown", nil
+}
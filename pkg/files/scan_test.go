@@ -0,0 +1,222 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Content containing this is reported as infected by the fake scanners
+const testVirus = "vikunja-test-virus"
+
+func serveFake(t *testing.T, l net.Listener, handle func(conn net.Conn)) {
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				handle(conn)
+			}()
+		}
+	}()
+}
+
+// fakeClamd implements the INSTREAM command of clamd
+func fakeClamd(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	command, err := r.ReadString('\x00')
+	if err != nil || command != "zINSTREAM\x00" {
+		_, _ = conn.Write([]byte("UNKNOWN COMMAND\x00"))
+		return
+	}
+
+	content := &bytes.Buffer{}
+	size := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(r, size); err != nil {
+			return
+		}
+		n := binary.BigEndian.Uint32(size)
+		if n == 0 {
+			break
+		}
+		if _, err := io.CopyN(content, r, int64(n)); err != nil {
+			return
+		}
+	}
+
+	if strings.Contains(content.String(), testVirus) {
+		_, _ = conn.Write([]byte("stream: Vikunja-Test-Signature FOUND\x00"))
+		return
+	}
+	_, _ = conn.Write([]byte("stream: OK\x00"))
+}
+
+// fakeICAP implements RESPMOD requests of an ICAP server
+func fakeICAP(conn net.Conn) {
+	r := textproto.NewReader(bufio.NewReader(conn))
+	if _, err := r.ReadLine(); err != nil {
+		return
+	}
+	if _, err := r.ReadMIMEHeader(); err != nil {
+		return
+	}
+	// The encapsulated http response header
+	if _, err := r.ReadLine(); err != nil {
+		return
+	}
+	if _, err := r.ReadMIMEHeader(); err != nil {
+		return
+	}
+
+	content := &bytes.Buffer{}
+	for {
+		line, err := r.ReadLine()
+		if err != nil {
+			return
+		}
+		n, err := strconv.ParseInt(line, 16, 64)
+		if err != nil {
+			return
+		}
+		if n == 0 {
+			_, _ = r.ReadLine()
+			break
+		}
+		if _, err := io.CopyN(content, r.R, n); err != nil {
+			return
+		}
+		_, _ = r.ReadLine()
+	}
+
+	if strings.Contains(content.String(), testVirus) {
+		_, _ = conn.Write([]byte("ICAP/1.0 200 OK\r\nX-Infection-Found: Type=0; Resolution=2; Threat=Vikunja-Test-Signature;\r\nEncapsulated: res-hdr=0, null-body=25\r\n\r\nHTTP/1.1 403 Forbidden\r\n\r\n"))
+		return
+	}
+	_, _ = conn.Write([]byte("ICAP/1.0 204 No Content\r\nEncapsulated: null-body=0\r\n\r\n"))
+}
+
+func assertScans(t *testing.T, sc scanner) {
+	// Larger than one chunk to make sure all of them arrive
+	clean := strings.Repeat("clean content ", scanChunkSize/10)
+	virus, err := sc.scan(strings.NewReader(clean))
+	assert.NoError(t, err)
+	assert.Empty(t, virus)
+
+	virus, err = sc.scan(strings.NewReader(clean + testVirus))
+	assert.NoError(t, err)
+	assert.Equal(t, "Vikunja-Test-Signature", virus)
+}
+
+func TestClamdScanner(t *testing.T) {
+	t.Run("tcp", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		serveFake(t, l, fakeClamd)
+
+		sc, err := newClamdScanner("tcp://"+l.Addr().String(), time.Second)
+		assert.NoError(t, err)
+		assertScans(t, sc)
+	})
+	t.Run("unix socket", func(t *testing.T) {
+		socket := filepath.Join(t.TempDir(), "clamd.sock")
+		l, err := net.Listen("unix", socket)
+		assert.NoError(t, err)
+		serveFake(t, l, fakeClamd)
+
+		sc, err := newClamdScanner("unix://"+socket, time.Second)
+		assert.NoError(t, err)
+		assertScans(t, sc)
+	})
+	t.Run("invalid address", func(t *testing.T) {
+		_, err := newClamdScanner("localhost:3310", time.Second)
+		assert.Error(t, err)
+	})
+	t.Run("error response", func(t *testing.T) {
+		_, err := parseClamdResponse("INSTREAM size limit exceeded. ERROR\x00")
+		assert.Error(t, err)
+	})
+}
+
+func TestICAPScanner(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	serveFake(t, l, fakeICAP)
+
+	sc, err := newICAPScanner("icap://"+l.Addr().String()+"/avscan", time.Second)
+	assert.NoError(t, err)
+	assertScans(t, sc)
+
+	sc, err = newICAPScanner("icap://localhost/avscan", time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost:1344", sc.host)
+
+	_, err = newICAPScanner("http://localhost/avscan", time.Second)
+	assert.Error(t, err)
+}
+
+func TestCreateScansUploads(t *testing.T) {
+	initFixtures(t)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	serveFake(t, l, fakeClamd)
+
+	virusScanner, err = newClamdScanner("tcp://"+l.Addr().String(), time.Second)
+	assert.NoError(t, err)
+	defer func() { virusScanner = nil }()
+
+	ta := &testauth{id: 1}
+	file, err := Create(strings.NewReader("clean"), "clean.txt", 5, ta)
+	assert.NoError(t, err)
+	err = file.LoadFileByID()
+	assert.NoError(t, err)
+	content, err := io.ReadAll(file.File)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("clean"), content)
+
+	_, err = Create(strings.NewReader(testVirus), "infected.txt", uint64(len(testVirus)), ta)
+	assert.Error(t, err)
+	assert.True(t, IsErrFileIsInfected(err))
+	assert.Equal(t, "Vikunja-Test-Signature", err.(ErrFileIsInfected).Virus)
+	exists, err := x.Where("name = ?", "infected.txt").Exist(&File{})
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// Files created by Vikunja itself are not scanned
+	s := db.NewSession()
+	defer s.Close()
+	_, err = CreateWithMimeAndSession(s, strings.NewReader(testVirus), "export.zip", uint64(len(testVirus)), ta, "application/zip", 0, false)
+	assert.NoError(t, err)
+}
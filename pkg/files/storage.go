@@ -0,0 +1,82 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package files
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"code.vikunja.io/api/pkg/config"
+	"github.com/spf13/afero"
+)
+
+const (
+	// StorageLocal stores files in files.basepath on the local file system
+	StorageLocal = "local"
+	// StorageS3 stores files in an S3-compatible object storage
+	StorageS3 = "s3"
+)
+
+// Reader is the content of a file opened from the storage backend
+type Reader interface {
+	io.ReadSeekCloser
+	Stat() (os.FileInfo, error)
+}
+
+// storage is a backend the content of files is stored in. Files are stored under their id as name.
+type storage interface {
+	// open returns an error matching os.IsNotExist if there is no file with that name.
+	open(name string) (Reader, error)
+	// save stores the content under that name and replaces any existing file.
+	save(name string, content io.Reader) error
+	// remove returns an error matching os.IsNotExist if there is no file with that name.
+	remove(name string) error
+}
+
+var store storage
+
+func newStorage(kind string) (storage, error) {
+	switch kind {
+	case StorageLocal:
+		return &localStorage{fs: afs}, nil
+	case StorageS3:
+		return newS3Storage()
+	default:
+		return nil, fmt.Errorf("unknown file storage %q, must be one of %s, %s", kind, StorageLocal, StorageS3)
+	}
+}
+
+type localStorage struct {
+	fs *afero.Afero
+}
+
+func (l *localStorage) path(name string) string {
+	return config.FilesBasePath.GetString() + "/" + name
+}
+
+func (l *localStorage) open(name string) (Reader, error) {
+	return l.fs.Open(l.path(name))
+}
+
+func (l *localStorage) save(name string, content io.Reader) error {
+	return l.fs.WriteReader(l.path(name), content)
+}
+
+func (l *localStorage) remove(name string) error {
+	return l.fs.Remove(l.path(name))
+}
@@ -93,8 +93,11 @@ func FullInit() {
 	user.RegisterDeletionNotificationCron()
 	models.RegisterUserDeletionCron()
 	models.RegisterOldExportCleanupCron()
+	models.RegisterOrphanedFilesCleanupCron()
+	models.RegisterExpiredTaskAttachmentUploadsCleanupCron()
 	openid.CleanupSavedOpenIDProviders()
 	models.RegisterPeriodicTypesenseResyncCron()
+	models.RegisterAutomationCron()
 
 	// Start processing events
 	go func() {
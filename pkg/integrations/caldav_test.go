@@ -20,7 +20,10 @@ import (
 	"net/http"
 	"testing"
 
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/routes/caldav"
+	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -74,4 +77,192 @@ func TestCaldav(t *testing.T) {
 		assert.Contains(t, rec.Body.String(), "ACTION:DISPLAY")
 		assert.Contains(t, rec.Body.String(), "END:VALARM")
 	})
+	t.Run("Import VTODO with parent", func(t *testing.T) {
+		const vtodoWithParent = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Vikunja Todo App//EN
+BEGIN:VTODO
+UID:uid-child
+DTSTAMP:20230301T073337Z
+SUMMARY:Caldav Subtask
+RELATED-TO;RELTYPE=PARENT:uid-caldav-test
+RELATED-TO;RELTYPE=SIBLING:uid-which-does-not-exist
+END:VTODO
+END:VCALENDAR`
+		rec, err := newCaldavTestRequestWithUser(t, http.MethodPut, caldav.TaskHandler, &testuser15, vtodoWithParent, nil, map[string]string{"project": "36", "task": "uid-child"})
+		assert.NoError(t, err)
+		assert.Equal(t, 201, rec.Result().StatusCode)
+		db.AssertExists(t, "task_relations", map[string]interface{}{
+			"other_task_id": 40,
+			"relation_kind": "parenttask",
+		}, false)
+		db.AssertExists(t, "task_relations", map[string]interface{}{
+			"task_id":       40,
+			"relation_kind": "subtask",
+		}, false)
+	})
+	t.Run("Update VTODO without relations", func(t *testing.T) {
+		const vtodoWithoutParent = `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Vikunja Todo App//EN
+BEGIN:VTODO
+UID:uid-child
+DTSTAMP:20230301T073337Z
+SUMMARY:Caldav Subtask
+END:VTODO
+END:VCALENDAR`
+		rec, err := newCaldavTestRequestWithUser(t, http.MethodPut, caldav.TaskHandler, &testuser15, vtodoWithoutParent, nil, map[string]string{"project": "36", "task": "uid-child"})
+		assert.NoError(t, err)
+		assert.Equal(t, 201, rec.Result().StatusCode)
+		db.AssertMissing(t, "task_relations", map[string]interface{}{
+			"other_task_id": 40,
+			"relation_kind": "parenttask",
+		})
+		db.AssertMissing(t, "task_relations", map[string]interface{}{
+			"task_id":       40,
+			"relation_kind": "subtask",
+		})
+	})
+	t.Run("Delivers VTODO for saved filter", func(t *testing.T) {
+		rec, err := newCaldavTestRequestWithUser(t, http.MethodGet, caldav.ProjectHandler, &testuser1, ``, nil, map[string]string{"project": "-2"})
+		assert.NoError(t, err)
+		assert.Contains(t, rec.Body.String(), "BEGIN:VCALENDAR")
+		assert.Contains(t, rec.Body.String(), "X-WR-CALNAME:testfilter1")
+		assert.Contains(t, rec.Body.String(), "BEGIN:VTODO")
+		assert.Contains(t, rec.Body.String(), "SUMMARY:task #5 higher due date")
+	})
+	t.Run("Saved filter is read-only", func(t *testing.T) {
+		rec, err := newCaldavTestRequestWithUser(t, http.MethodPut, caldav.TaskHandler, &testuser1, vtodo, nil, map[string]string{"project": "-2", "task": "uid"})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+	})
+	t.Run("Import VTODO with binary attachment", func(t *testing.T) {
+		const vtodoWithAttachment = `BEGIN:VCALENDAR
+VERSION:2.0
+METHOD:PUBLISH
+PRODID:-//Vikunja Todo App//EN
+BEGIN:VTODO
+UID:uid-attachment
+DTSTAMP:20230301T073337Z
+SUMMARY:Caldav Task with attachment
+ATTACH;VALUE=BINARY;ENCODING=BASE64;FMTTYPE=text/plain;FILENAME=hello.txt:SGVsbG8gV29ybGQ=
+LAST-MODIFIED:20230301T073337Z
+END:VTODO
+END:VCALENDAR`
+
+		rec, err := newCaldavTestRequestWithUser(t, http.MethodPut, caldav.TaskHandler, &testuser15, vtodoWithAttachment, nil, map[string]string{"project": "36", "task": "uid-attachment"})
+		assert.NoError(t, err)
+		assert.Equal(t, 201, rec.Result().StatusCode)
+
+		db.AssertExists(t, "files", map[string]interface{}{
+			"name": "hello.txt",
+			"mime": "text/plain",
+			"size": 11,
+		}, false)
+	})
+	t.Run("Download attachment", func(t *testing.T) {
+		rec, c := testRequestSetup(t, http.MethodGet, "", nil, map[string]string{"task": "1", "attachment": "1"})
+		files.InitTestFileFixtures(t)
+		result, _ := caldav.BasicAuth(testuser1.Username, "1234", c)
+		assert.True(t, result)
+
+		err := caldav.AttachmentHandler(c)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+		assert.Equal(t, "testfile1", rec.Body.String())
+	})
+	t.Run("Download attachment without access", func(t *testing.T) {
+		_, err := newCaldavTestRequestWithUser(t, http.MethodGet, caldav.AttachmentHandler, &testuser15, "", nil, map[string]string{"task": "1", "attachment": "1"})
+		var httperr *echo.HTTPError
+		assert.ErrorAs(t, err, &httperr)
+		assert.Equal(t, http.StatusForbidden, httperr.Code)
+	})
+	t.Run("Sync collection", func(t *testing.T) {
+		t.Run("Initial sync", func(t *testing.T) {
+			rec, err := newCaldavTestRequestWithUser(t, "REPORT", caldav.ProjectHandler, &testuser15, syncCollectionRequest(""), nil, map[string]string{"project": "36"})
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusMultiStatus, rec.Result().StatusCode)
+			assert.Contains(t, rec.Body.String(), "<D:href>/dav/projects/36/uid-caldav-test.ics</D:href>")
+			assert.Contains(t, rec.Body.String(), "<D:getetag>")
+			assert.Contains(t, rec.Body.String(), "<D:sync-token>http://vikunja.io/ns/sync/0</D:sync-token>")
+		})
+		t.Run("Changes since token", func(t *testing.T) {
+			rec, err := newCaldavTestRequestWithUser(t, "REPORT", caldav.ProjectHandler, &testuser1, syncCollectionRequest("http://vikunja.io/ns/sync/2"), nil, map[string]string{"project": "1"})
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusMultiStatus, rec.Result().StatusCode)
+			assert.Contains(t, rec.Body.String(), "SUMMARY:task #3 high prio")
+			assert.NotContains(t, rec.Body.String(), "SUMMARY:task #2 done")
+			assert.Contains(t, rec.Body.String(), "<D:href>/dav/projects/1/uid-deleted-task.ics</D:href><D:status>HTTP/1.1 404 Not Found</D:status>")
+			assert.Contains(t, rec.Body.String(), "<D:sync-token>http://vikunja.io/ns/sync/4</D:sync-token>")
+		})
+		t.Run("Invalid token", func(t *testing.T) {
+			rec, err := newCaldavTestRequestWithUser(t, "REPORT", caldav.ProjectHandler, &testuser15, syncCollectionRequest("http://vikunja.io/ns/sync/9999"), nil, map[string]string{"project": "36"})
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusForbidden, rec.Result().StatusCode)
+			assert.Contains(t, rec.Body.String(), "<D:valid-sync-token/>")
+		})
+	})
+}
+
+func syncCollectionRequest(token string) string {
+	return `<?xml version="1.0" encoding="utf-8" ?>
+<d:sync-collection xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:sync-token>` + token + `</d:sync-token>
+  <d:sync-level>1</d:sync-level>
+  <d:prop>
+    <d:getetag/>
+    <c:calendar-data/>
+  </d:prop>
+</d:sync-collection>`
+}
+
+func TestCarddav(t *testing.T) {
+	t.Run("Lists contacts of users sharing projects", func(t *testing.T) {
+		rec, c := testRequestSetup(t, "PROPFIND", `<?xml version="1.0" encoding="utf-8" ?>
+<d:propfind xmlns:d="DAV:"><d:prop><d:getetag/><d:resourcetype/></d:prop></d:propfind>`, nil, map[string]string{"addressbook": "contacts"})
+		c.Request().Header.Set("Depth", "1")
+		result, _ := caldav.BasicAuth(testuser15.Username, "1234", c)
+		assert.True(t, result)
+
+		err := caldav.AddressbookHandler(c)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusMultiStatus, rec.Result().StatusCode)
+		assert.Contains(t, rec.Body.String(), "<D:href>/dav/addressbooks/contacts/</D:href>")
+		assert.Contains(t, rec.Body.String(), "<CR:addressbook/>")
+		assert.Contains(t, rec.Body.String(), "<D:href>/dav/addressbooks/contacts/vikunja-user-1.vcf</D:href>")
+		assert.Contains(t, rec.Body.String(), "<D:href>/dav/addressbooks/contacts/vikunja-user-6.vcf</D:href>")
+		assert.NotContains(t, rec.Body.String(), "vikunja-user-2.vcf")
+	})
+	t.Run("Multiget", func(t *testing.T) {
+		rec, err := newCaldavTestRequestWithUser(t, "REPORT", caldav.AddressbookHandler, &testuser15, `<?xml version="1.0" encoding="utf-8" ?>
+<card:addressbook-multiget xmlns:d="DAV:" xmlns:card="urn:ietf:params:xml:ns:carddav">
+  <d:prop><d:getetag/><card:address-data/></d:prop>
+  <d:href>/dav/addressbooks/contacts/vikunja-user-1.vcf</d:href>
+  <d:href>/dav/addressbooks/contacts/vikunja-user-2.vcf</d:href>
+</card:addressbook-multiget>`, nil, map[string]string{"addressbook": "contacts"})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusMultiStatus, rec.Result().StatusCode)
+		assert.Contains(t, rec.Body.String(), "NICKNAME:user1")
+		assert.Contains(t, rec.Body.String(), "<D:href>/dav/addressbooks/contacts/vikunja-user-2.vcf</D:href><D:status>HTTP/1.1 404 Not Found</D:status>")
+	})
+	t.Run("Get contact", func(t *testing.T) {
+		rec, err := newCaldavTestRequestWithUser(t, http.MethodGet, caldav.ContactHandler, &testuser15, ``, nil, map[string]string{"addressbook": "contacts", "contact": "vikunja-user-1.vcf"})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+		assert.Contains(t, rec.Body.String(), "BEGIN:VCARD")
+		assert.Contains(t, rec.Body.String(), "UID:vikunja-user-1")
+		assert.NotContains(t, rec.Body.String(), "EMAIL")
+	})
+	t.Run("Get contact of user not sharing anything", func(t *testing.T) {
+		_, err := newCaldavTestRequestWithUser(t, http.MethodGet, caldav.ContactHandler, &testuser15, ``, nil, map[string]string{"addressbook": "contacts", "contact": "vikunja-user-2.vcf"})
+		var httperr *echo.HTTPError
+		assert.ErrorAs(t, err, &httperr)
+		assert.Equal(t, http.StatusNotFound, httperr.Code)
+	})
+	t.Run("Address book is read-only", func(t *testing.T) {
+		_, err := newCaldavTestRequestWithUser(t, http.MethodPut, caldav.ContactHandler, &testuser15, "BEGIN:VCARD\nEND:VCARD", nil, map[string]string{"addressbook": "contacts", "contact": "new.vcf"})
+		var httperr *echo.HTTPError
+		assert.ErrorAs(t, err, &httperr)
+		assert.Equal(t, http.StatusForbidden, httperr.Code)
+	})
 }
@@ -0,0 +1,370 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type projectViews20230921093512 struct {
+	ID              int64     `xorm:"bigint autoincr not null unique pk" json:"id" param:"view"`
+	Title           string    `xorm:"varchar(255) not null" json:"title"`
+	ProjectID       int64     `xorm:"bigint not null index" json:"project_id" param:"project"`
+	ViewKind        string    `xorm:"varchar(20) not null" json:"view_kind"`
+	Filter          string    `xorm:"text null" json:"filter"`
+	Position        float64   `xorm:"double null" json:"position"`
+	DefaultBucketID int64     `xorm:"bigint INDEX null" json:"default_bucket_id"`
+	DoneBucketID    int64     `xorm:"bigint INDEX null" json:"done_bucket_id"`
+	Created         time.Time `xorm:"created not null" json:"created"`
+	Updated         time.Time `xorm:"updated not null" json:"updated"`
+}
+
+func (projectViews20230921093512) TableName() string {
+	return "project_views"
+}
+
+type taskBuckets20230921093512 struct {
+	TaskID        int64 `xorm:"bigint not null unique(task_view)" json:"task_id"`
+	BucketID      int64 `xorm:"bigint not null index" json:"bucket_id"`
+	ProjectViewID int64 `xorm:"bigint not null unique(task_view) index" json:"project_view_id"`
+}
+
+func (taskBuckets20230921093512) TableName() string {
+	return "task_buckets"
+}
+
+type taskPositions20230921093512 struct {
+	TaskID        int64   `xorm:"bigint not null unique(task_view)" json:"task_id"`
+	ProjectViewID int64   `xorm:"bigint not null unique(task_view) index" json:"project_view_id"`
+	Position      float64 `xorm:"double not null" json:"position"`
+}
+
+func (taskPositions20230921093512) TableName() string {
+	return "task_positions"
+}
+
+type buckets20230921093512 struct {
+	ID            int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"bucket"`
+	ProjectID     int64 `xorm:"bigint not null" json:"project_id" param:"project"`
+	ProjectViewID int64 `xorm:"bigint not null default 0 index" json:"project_view_id" param:"view"`
+}
+
+func (buckets20230921093512) TableName() string {
+	return "buckets"
+}
+
+type projects20230921093512 struct {
+	ID              int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"project"`
+	DefaultBucketID int64 `xorm:"bigint INDEX null" json:"default_bucket_id"`
+	DoneBucketID    int64 `xorm:"bigint INDEX null" json:"done_bucket_id"`
+}
+
+func (projects20230921093512) TableName() string {
+	return "projects"
+}
+
+type savedFilters20230921093512 struct {
+	ID int64 `xorm:"autoincr not null unique pk" json:"id"`
+}
+
+func (savedFilters20230921093512) TableName() string {
+	return "saved_filters"
+}
+
+type tasks20230921093512 struct {
+	ID             int64   `xorm:"bigint autoincr not null unique pk" json:"id" param:"projecttask"`
+	ProjectID      int64   `xorm:"bigint INDEX not null" json:"project_id" param:"project"`
+	BucketID       int64   `xorm:"bigint null" json:"bucket_id"`
+	Position       float64 `xorm:"double null" json:"position"`
+	KanbanPosition float64 `xorm:"double null" json:"kanban_position"`
+}
+
+func (tasks20230921093512) TableName() string {
+	return "tasks"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20230921093512",
+		Description: "Add project views and move buckets and task positions to them",
+		Migrate: func(tx *xorm.Engine) (err error) {
+			err = tx.Sync2(
+				projectViews20230921093512{},
+				taskBuckets20230921093512{},
+				taskPositions20230921093512{},
+				buckets20230921093512{},
+			)
+			if err != nil {
+				return
+			}
+
+			projects := []*projects20230921093512{}
+			err = tx.Find(&projects)
+			if err != nil {
+				return
+			}
+
+			// Project id as key
+			listViews := make(map[int64]int64, len(projects))
+			kanbanViews := make(map[int64]int64, len(projects))
+
+			for _, project := range projects {
+				views := []*projectViews20230921093512{
+					{Title: "List", ViewKind: "list", Position: 100},
+					{Title: "Gantt", ViewKind: "gantt", Position: 200},
+					{Title: "Table", ViewKind: "table", Position: 300},
+					{
+						Title:           "Kanban",
+						ViewKind:        "kanban",
+						Position:        400,
+						DefaultBucketID: project.DefaultBucketID,
+						DoneBucketID:    project.DoneBucketID,
+					},
+				}
+
+				for _, view := range views {
+					view.ProjectID = project.ID
+					_, err = tx.Insert(view)
+					if err != nil {
+						return
+					}
+				}
+
+				listViews[project.ID] = views[0].ID
+				kanbanViews[project.ID] = views[3].ID
+
+				_, err = tx.
+					Where("project_id = ?", project.ID).
+					Cols("project_view_id").
+					Update(&buckets20230921093512{ProjectViewID: views[3].ID})
+				if err != nil {
+					return
+				}
+			}
+
+			// Saved filters use a pseudo project id and can have their own buckets, they get the same views.
+			filters := []*savedFilters20230921093512{}
+			err = tx.Find(&filters)
+			if err != nil {
+				return
+			}
+
+			for _, filter := range filters {
+				projectID := filter.ID*-1 - 1
+				views := []*projectViews20230921093512{
+					{Title: "List", ViewKind: "list", Position: 100},
+					{Title: "Gantt", ViewKind: "gantt", Position: 200},
+					{Title: "Table", ViewKind: "table", Position: 300},
+					{Title: "Kanban", ViewKind: "kanban", Position: 400},
+				}
+
+				for _, view := range views {
+					view.ProjectID = projectID
+					_, err = tx.Insert(view)
+					if err != nil {
+						return
+					}
+				}
+
+				listViews[projectID] = views[0].ID
+				kanbanViews[projectID] = views[3].ID
+
+				_, err = tx.
+					Where("project_id = ?", projectID).
+					Cols("project_view_id").
+					Update(&buckets20230921093512{ProjectViewID: views[3].ID})
+				if err != nil {
+					return
+				}
+			}
+
+			buckets := []*buckets20230921093512{}
+			err = tx.Find(&buckets)
+			if err != nil {
+				return
+			}
+			bucketProjects := make(map[int64]int64, len(buckets))
+			for _, bucket := range buckets {
+				bucketProjects[bucket.ID] = bucket.ProjectID
+			}
+
+			tasks := []*tasks20230921093512{}
+			err = tx.Find(&tasks)
+			if err != nil {
+				return
+			}
+
+			for _, task := range tasks {
+				bucketProject := bucketProjects[task.BucketID]
+				if task.BucketID != 0 && (bucketProject == task.ProjectID || bucketProject < 0) {
+					_, err = tx.Insert(&taskBuckets20230921093512{
+						TaskID:        task.ID,
+						BucketID:      task.BucketID,
+						ProjectViewID: kanbanViews[bucketProject],
+					})
+					if err != nil {
+						return
+					}
+				}
+
+				if task.Position != 0 {
+					_, err = tx.Insert(&taskPositions20230921093512{
+						TaskID:        task.ID,
+						ProjectViewID: listViews[task.ProjectID],
+						Position:      task.Position,
+					})
+					if err != nil {
+						return
+					}
+				}
+
+				if task.KanbanPosition != 0 {
+					_, err = tx.Insert(&taskPositions20230921093512{
+						TaskID:        task.ID,
+						ProjectViewID: kanbanViews[task.ProjectID],
+						Position:      task.KanbanPosition,
+					})
+					if err != nil {
+						return
+					}
+				}
+			}
+
+			for _, col := range []string{"bucket_id", "position", "kanban_position"} {
+				err = dropTableColum(tx, "tasks", col)
+				if err != nil {
+					return
+				}
+			}
+
+			err = dropTableColum(tx, "projects", "done_bucket_id")
+			if err != nil {
+				return
+			}
+			return dropTableColum(tx, "projects", "default_bucket_id")
+		},
+		Rollback: func(tx *xorm.Engine) (err error) {
+			err = tx.Sync2(
+				tasks20230921093512{},
+				projects20230921093512{},
+			)
+			if err != nil {
+				return
+			}
+
+			views := []*projectViews20230921093512{}
+			err = tx.
+				Where("project_id > 0").
+				OrderBy("position asc, id asc").
+				Find(&views)
+			if err != nil {
+				return
+			}
+
+			// Only the first list and kanban view of each project can be moved back, all others are lost.
+			listViews := make(map[int64]bool)
+			kanbanViews := make(map[int64]bool)
+			seenList := make(map[int64]bool)
+			seenKanban := make(map[int64]bool)
+			for _, view := range views {
+				switch view.ViewKind {
+				case "list":
+					if !seenList[view.ProjectID] {
+						seenList[view.ProjectID] = true
+						listViews[view.ID] = true
+					}
+				case "kanban":
+					if seenKanban[view.ProjectID] {
+						continue
+					}
+					seenKanban[view.ProjectID] = true
+					kanbanViews[view.ID] = true
+
+					_, err = tx.
+						Where("id = ?", view.ProjectID).
+						Cols("default_bucket_id", "done_bucket_id").
+						Update(&projects20230921093512{
+							DefaultBucketID: view.DefaultBucketID,
+							DoneBucketID:    view.DoneBucketID,
+						})
+					if err != nil {
+						return
+					}
+				}
+			}
+
+			taskBuckets := []*taskBuckets20230921093512{}
+			err = tx.Find(&taskBuckets)
+			if err != nil {
+				return
+			}
+			for _, tb := range taskBuckets {
+				if !kanbanViews[tb.ProjectViewID] {
+					continue
+				}
+				_, err = tx.
+					Where("id = ?", tb.TaskID).
+					Cols("bucket_id").
+					Update(&tasks20230921093512{BucketID: tb.BucketID})
+				if err != nil {
+					return
+				}
+			}
+
+			positions := []*taskPositions20230921093512{}
+			err = tx.Find(&positions)
+			if err != nil {
+				return
+			}
+			for _, tp := range positions {
+				col := ""
+				task := &tasks20230921093512{}
+				switch {
+				case listViews[tp.ProjectViewID]:
+					col = "position"
+					task.Position = tp.Position
+				case kanbanViews[tp.ProjectViewID]:
+					col = "kanban_position"
+					task.KanbanPosition = tp.Position
+				default:
+					continue
+				}
+				_, err = tx.
+					Where("id = ?", tp.TaskID).
+					Cols(col).
+					Update(task)
+				if err != nil {
+					return
+				}
+			}
+
+			err = dropTableColum(tx, "buckets", "project_view_id")
+			if err != nil {
+				return
+			}
+
+			return tx.DropTables(
+				projectViews20230921093512{},
+				taskBuckets20230921093512{},
+				taskPositions20230921093512{},
+			)
+		},
+	})
+}
@@ -0,0 +1,55 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type projectViews20230925104210 struct {
+	BucketConfigurationMode string `xorm:"varchar(50) not null default 'manual'" json:"bucket_configuration_mode"`
+}
+
+func (projectViews20230925104210) TableName() string {
+	return "project_views"
+}
+
+type buckets20230925104210 struct {
+	Filter     string `xorm:"text null" json:"filter"`
+	MoveAction string `xorm:"json null" json:"move_action"`
+}
+
+func (buckets20230925104210) TableName() string {
+	return "buckets"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20230925104210",
+		Description: "Add bucket configuration mode to project views and filter and move action to buckets",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(
+				projectViews20230925104210{},
+				buckets20230925104210{},
+			)
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
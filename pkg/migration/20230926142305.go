@@ -0,0 +1,72 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type automationRules20230926142305 struct {
+	ID              int64     `xorm:"bigint autoincr not null unique pk" json:"id" param:"automation"`
+	Title           string    `xorm:"varchar(250) not null" json:"title"`
+	ProjectID       int64     `xorm:"bigint not null index" json:"project_id" param:"project"`
+	IsDisabled      bool      `xorm:"not null default false" json:"is_disabled"`
+	TriggerEvent    string    `xorm:"varchar(250) null" json:"trigger_event"`
+	TriggerSchedule string    `xorm:"varchar(250) null" json:"trigger_schedule"`
+	Filter          string    `xorm:"text null" json:"filter"`
+	Actions         string    `xorm:"JSON not null" json:"actions"`
+	CreatedByID     int64     `xorm:"bigint not null" json:"-"`
+	Created         time.Time `xorm:"created not null" json:"created"`
+	Updated         time.Time `xorm:"updated not null" json:"updated"`
+}
+
+func (automationRules20230926142305) TableName() string {
+	return "automation_rules"
+}
+
+type automationRuleExecutions20230926142305 struct {
+	ID               int64     `xorm:"bigint autoincr not null unique pk" json:"id"`
+	AutomationRuleID int64     `xorm:"bigint not null index" json:"automation_rule_id"`
+	TaskID           int64     `xorm:"bigint not null index" json:"task_id"`
+	TriggeredBy      string    `xorm:"varchar(250) not null" json:"triggered_by"`
+	Status           string    `xorm:"varchar(50) not null" json:"status"`
+	Message          string    `xorm:"text null" json:"message"`
+	Created          time.Time `xorm:"created not null index" json:"created"`
+}
+
+func (automationRuleExecutions20230926142305) TableName() string {
+	return "automation_rule_executions"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20230926142305",
+		Description: "Add automation rules and their execution log",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(
+				automationRules20230926142305{},
+				automationRuleExecutions20230926142305{},
+			)
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
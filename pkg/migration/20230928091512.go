@@ -0,0 +1,56 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type historyEntries20230928091512 struct {
+	ID         int64     `xorm:"bigint autoincr not null unique pk" json:"id"`
+	EntityKind string    `xorm:"varchar(50) not null index(entity)" json:"entity_kind"`
+	EntityID   int64     `xorm:"bigint not null index(entity)" json:"entity_id"`
+	Action     string    `xorm:"varchar(50) not null" json:"action"`
+	Field      string    `xorm:"varchar(250) null" json:"field"`
+	OldValue   string    `xorm:"text null" json:"old_value"`
+	NewValue   string    `xorm:"text null" json:"new_value"`
+	TaskID     int64     `xorm:"bigint not null default 0 index" json:"task_id"`
+	ProjectID  int64     `xorm:"bigint not null default 0 index" json:"project_id"`
+	TeamID     int64     `xorm:"bigint not null default 0 index" json:"team_id"`
+	DoerID     int64     `xorm:"bigint not null" json:"-"`
+	Created    time.Time `xorm:"created not null index" json:"created"`
+}
+
+func (historyEntries20230928091512) TableName() string {
+	return "history_entries"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20230928091512",
+		Description: "Add the change history of tasks, projects, buckets, shares and team members",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(historyEntries20230928091512{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
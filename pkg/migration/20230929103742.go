@@ -0,0 +1,59 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type taskTemplates20230929103742 struct {
+	ID                int64     `xorm:"bigint autoincr not null unique pk" json:"id"`
+	Title             string    `xorm:"varchar(250) not null" json:"title"`
+	Description       string    `xorm:"longtext null" json:"description"`
+	Priority          int64     `xorm:"bigint null" json:"priority"`
+	HexColor          string    `xorm:"varchar(6) null" json:"hex_color"`
+	RelativeDueDate   int64     `xorm:"bigint null" json:"relative_due_date"`
+	RelativeStartDate int64     `xorm:"bigint null" json:"relative_start_date"`
+	RelativeEndDate   int64     `xorm:"bigint null" json:"relative_end_date"`
+	LabelIDs          string    `xorm:"JSON null 'label_ids'" json:"label_ids"`
+	AssigneeIDs       string    `xorm:"JSON null 'assignee_ids'" json:"assignee_ids"`
+	Reminders         string    `xorm:"JSON null" json:"reminders"`
+	Subtasks          string    `xorm:"JSON null" json:"subtasks"`
+	OwnerID           int64     `xorm:"bigint not null INDEX" json:"-"`
+	Created           time.Time `xorm:"created not null" json:"created"`
+	Updated           time.Time `xorm:"updated not null" json:"updated"`
+}
+
+func (taskTemplates20230929103742) TableName() string {
+	return "task_templates"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20230929103742",
+		Description: "Add task templates",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(taskTemplates20230929103742{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return nil
+		},
+	})
+}
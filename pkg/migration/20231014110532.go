@@ -0,0 +1,54 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type taskAttachmentUploads20231014110532 struct {
+	ID          int64     `xorm:"bigint autoincr not null unique pk" json:"id"`
+	TaskID      int64     `xorm:"bigint not null index" json:"task_id"`
+	Filename    string    `xorm:"text not null" json:"filename"`
+	Size        uint64    `xorm:"bigint not null" json:"size"`
+	Offset      uint64    `xorm:"bigint not null default 0 'upload_offset'" json:"offset"`
+	Chunks      []uint64  `xorm:"json null" json:"-"`
+	CreatedByID int64     `xorm:"bigint not null" json:"-"`
+	Expires     time.Time `xorm:"DATETIME not null" json:"expires"`
+	Created     time.Time `xorm:"created not null" json:"created"`
+	Updated     time.Time `xorm:"updated not null" json:"updated"`
+}
+
+func (taskAttachmentUploads20231014110532) TableName() string {
+	return "task_attachment_uploads"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20231014110532",
+		Description: "Add task_attachment_uploads table",
+		Migrate: func(tx *xorm.Engine) error {
+			return tx.Sync2(taskAttachmentUploads20231014110532{})
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return tx.DropTables(taskAttachmentUploads20231014110532{})
+		},
+	})
+}
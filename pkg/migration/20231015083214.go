@@ -0,0 +1,77 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migration
+
+import (
+	"time"
+
+	"src.techknowlogick.com/xormigrate"
+	"xorm.io/xorm"
+)
+
+type files20231015083214 struct {
+	ProjectID int64 `xorm:"bigint null index" json:"-"`
+}
+
+func (files20231015083214) TableName() string {
+	return "files"
+}
+
+type storageQuotas20231015083214 struct {
+	ID        int64     `xorm:"bigint autoincr not null unique pk" json:"id"`
+	UserID    int64     `xorm:"bigint null index" json:"user_id"`
+	ProjectID int64     `xorm:"bigint null index" json:"project_id"`
+	Quota     uint64    `xorm:"bigint not null" json:"quota"`
+	Created   time.Time `xorm:"created not null" json:"created"`
+	Updated   time.Time `xorm:"updated not null" json:"updated"`
+}
+
+func (storageQuotas20231015083214) TableName() string {
+	return "storage_quotas"
+}
+
+func init() {
+	migrations = append(migrations, &xormigrate.Migration{
+		ID:          "20231015083214",
+		Description: "Add project_id to files and storage_quotas table",
+		Migrate: func(tx *xorm.Engine) error {
+			err := tx.Sync2(files20231015083214{}, storageQuotas20231015083214{})
+			if err != nil {
+				return err
+			}
+
+			// Existing attachments and backgrounds count towards the quota of their project
+			_, err = tx.Exec(`UPDATE files SET project_id = (
+				SELECT tasks.project_id FROM task_attachments
+				INNER JOIN tasks ON tasks.id = task_attachments.task_id
+				WHERE task_attachments.file_id = files.id
+				LIMIT 1
+			) WHERE id IN (SELECT file_id FROM task_attachments)`)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(`UPDATE files SET project_id = (
+				SELECT projects.id FROM projects WHERE projects.background_file_id = files.id LIMIT 1
+			) WHERE project_id IS NULL AND id IN (SELECT background_file_id FROM projects)`)
+			return err
+		},
+		Rollback: func(tx *xorm.Engine) error {
+			return tx.DropTables(storageQuotas20231015083214{})
+		},
+	})
+}
@@ -0,0 +1,720 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/cron"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+
+	"code.vikunja.io/web"
+	"github.com/labstack/echo/v4"
+	"xorm.io/xorm"
+)
+
+// AutomationActionType defines what an automation action does with a task
+type AutomationActionType string
+
+const (
+	// AutomationActionSetField changes one of the fields done, priority, percent_done or hex_color of the task
+	AutomationActionSetField AutomationActionType = "set_field"
+	// AutomationActionAddLabel adds the label with the id in value to the task
+	AutomationActionAddLabel AutomationActionType = "add_label"
+	// AutomationActionAssign assigns the user with the id in value to the task
+	AutomationActionAssign AutomationActionType = "assign"
+	// AutomationActionMoveBucket moves the task into the bucket with the id in value
+	AutomationActionMoveBucket AutomationActionType = "move_bucket"
+	// AutomationActionComment adds a comment with the text in value to the task
+	AutomationActionComment AutomationActionType = "comment"
+)
+
+// AutomationAction is one step an automation rule performs on a task
+type AutomationAction struct {
+	// What this action does. Can be one of `set_field`, `add_label`, `assign`, `move_bucket` or `comment`.
+	Type AutomationActionType `json:"type"`
+	// The task field to change. Only used with `set_field`, can be one of `done`, `priority`, `percent_done` or `hex_color`.
+	Field string `json:"field"`
+	// The new value of the field for `set_field`, the id of the label, user or bucket for `add_label`, `assign` and
+	// `move_bucket` or the text of the comment for `comment`.
+	Value string `json:"value"`
+}
+
+// AutomationRule runs a set of actions on all tasks of a project matching its filter whenever its trigger fires.
+type AutomationRule struct {
+	// The unique, numeric id of this automation rule.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"automation"`
+	// The title of this automation rule.
+	Title string `xorm:"varchar(250) not null" json:"title" valid:"required,runelength(1|250)" minLength:"1" maxLength:"250"`
+	// The project this automation rule belongs to.
+	ProjectID int64 `xorm:"bigint not null index" json:"project_id" param:"project"`
+	// Disabled automation rules are never triggered.
+	IsDisabled bool `xorm:"not null default false" json:"is_disabled"`
+
+	// The event which triggers this rule. Check /automations/events for a list of all available events.
+	// Either this or a trigger schedule must be set.
+	TriggerEvent string `xorm:"varchar(250) null" json:"trigger_event"`
+	// A cron schedule like `0 9 * * *` which triggers this rule for all matching tasks of the project.
+	// Either this or a trigger event must be set.
+	TriggerSchedule string `xorm:"varchar(250) null" json:"trigger_schedule"`
+
+	// The rule only runs for tasks matching this filter. Uses the same syntax as the filter of a project view.
+	// If empty, the rule runs for all tasks.
+	Filter string `xorm:"text null" json:"filter"`
+	// The actions this rule performs, in order.
+	Actions []*AutomationAction `xorm:"JSON not null" json:"actions" valid:"required"`
+
+	// The user who initially created the automation rule. All actions are performed as this user.
+	CreatedBy   *user.User `xorm:"-" json:"created_by" valid:"-"`
+	CreatedByID int64      `xorm:"bigint not null" json:"-"`
+
+	// A timestamp when this automation rule was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this automation rule was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for automation rules
+func (*AutomationRule) TableName() string {
+	return "automation_rules"
+}
+
+// AutomationRuleExecutionStatus is the outcome of running an automation rule for a task
+type AutomationRuleExecutionStatus string
+
+const (
+	// AutomationRuleExecutionStatusSuccess means all actions of the rule were performed
+	AutomationRuleExecutionStatusSuccess AutomationRuleExecutionStatus = "success"
+	// AutomationRuleExecutionStatusFailed means one of the actions failed, none of them were saved
+	AutomationRuleExecutionStatusFailed AutomationRuleExecutionStatus = "failed"
+	// AutomationRuleExecutionStatusSkipped means the rule was not run because of the loop protection
+	AutomationRuleExecutionStatusSkipped AutomationRuleExecutionStatus = "skipped"
+)
+
+// AutomationRuleExecution is one entry in the execution log of an automation rule
+type AutomationRuleExecution struct {
+	// The unique, numeric id of this execution.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id"`
+	// The automation rule which was executed.
+	AutomationRuleID int64 `xorm:"bigint not null index" json:"automation_rule_id" param:"automation"`
+	// The task the rule was executed for.
+	TaskID int64 `xorm:"bigint not null index" json:"task_id"`
+	// The event which triggered the execution or `schedule` if it was triggered by the schedule of the rule.
+	TriggeredBy string `xorm:"varchar(250) not null" json:"triggered_by"`
+	// The outcome of the execution. Can be one of `success`, `failed` or `skipped`.
+	Status AutomationRuleExecutionStatus `xorm:"varchar(50) not null" json:"status"`
+	// Why an execution failed or was skipped.
+	Message string `xorm:"text null" json:"message"`
+
+	// The project the automation rule belongs to.
+	ProjectID int64 `xorm:"-" json:"-" param:"project"`
+
+	// A timestamp when the rule was executed.
+	Created time.Time `xorm:"created not null index" json:"created"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for automation rule executions
+func (*AutomationRuleExecution) TableName() string {
+	return "automation_rule_executions"
+}
+
+const automationTriggerSchedule = "schedule"
+
+var availableAutomationEvents map[string]bool
+var availableAutomationEventsLock *sync.Mutex
+
+func init() {
+	availableAutomationEvents = make(map[string]bool)
+	availableAutomationEventsLock = &sync.Mutex{}
+}
+
+// RegisterEventForAutomations makes an event available as trigger for automation rules and registers the listener
+// which runs all rules triggered by it.
+func RegisterEventForAutomations(event events.Event) {
+	availableAutomationEventsLock.Lock()
+	defer availableAutomationEventsLock.Unlock()
+
+	availableAutomationEvents[event.Name()] = true
+	events.RegisterListener(event.Name(), &AutomationRuleListener{
+		EventName: event.Name(),
+	})
+}
+
+// GetAvailableAutomationEvents returns a list of all events automation rules can be triggered by
+// @Summary Get all possible automation trigger events
+// @Description Get all possible events to use as trigger when creating or updating an automation rule.
+// @tags automations
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {array} string "The list of all possible trigger events"
+// @Failure 500 {object} models.Message "Internal server error"
+// @Router /automations/events [get]
+func GetAvailableAutomationEvents(c echo.Context) error {
+	availableAutomationEventsLock.Lock()
+	defer availableAutomationEventsLock.Unlock()
+
+	evts := []string{}
+	for e := range availableAutomationEvents {
+		evts = append(evts, e)
+	}
+	sort.Strings(evts)
+
+	return c.JSON(http.StatusOK, evts)
+}
+
+func (r *AutomationRule) validate(s *xorm.Session, a web.Auth) (err error) {
+	invalidTrigger := &ErrInvalidAutomationTrigger{TriggerEvent: r.TriggerEvent, TriggerSchedule: r.TriggerSchedule}
+	if (r.TriggerEvent == "") == (r.TriggerSchedule == "") {
+		return invalidTrigger
+	}
+
+	if r.TriggerEvent != "" {
+		availableAutomationEventsLock.Lock()
+		_, has := availableAutomationEvents[r.TriggerEvent]
+		availableAutomationEventsLock.Unlock()
+		if !has {
+			return invalidTrigger
+		}
+	}
+
+	if r.TriggerSchedule != "" {
+		if _, err := cron.NextRun(r.TriggerSchedule, time.Now()); err != nil {
+			return invalidTrigger
+		}
+	}
+
+	// Make sure the filter can be used later on
+	_, err = parseTaskFilterExpression(r.Filter)
+	if err != nil {
+		return err
+	}
+
+	if len(r.Actions) == 0 {
+		return &ErrAutomationRuleHasNoActions{}
+	}
+
+	for _, action := range r.Actions {
+		if err := action.validate(s, a, r.ProjectID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (action *AutomationAction) getIDValue() (int64, error) {
+	id, err := strconv.ParseInt(action.Value, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, &ErrInvalidAutomationAction{Type: action.Type, Field: action.Field, Value: action.Value}
+	}
+	return id, nil
+}
+
+func (action *AutomationAction) validate(s *xorm.Session, a web.Auth, projectID int64) error {
+	invalid := &ErrInvalidAutomationAction{Type: action.Type, Field: action.Field, Value: action.Value}
+
+	switch action.Type {
+	case AutomationActionSetField:
+		if !(&Task{}).setFieldFromString(action.Field, action.Value) {
+			return invalid
+		}
+	case AutomationActionAddLabel:
+		labelID, err := action.getIDValue()
+		if err != nil {
+			return err
+		}
+		has, _, err := (&Label{ID: labelID}).hasAccessToLabel(s, a)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return invalid
+		}
+	case AutomationActionAssign:
+		userID, err := action.getIDValue()
+		if err != nil {
+			return err
+		}
+		if _, err := user.GetUserByID(s, userID); err != nil {
+			return err
+		}
+	case AutomationActionMoveBucket:
+		bucketID, err := action.getIDValue()
+		if err != nil {
+			return err
+		}
+		bucket, err := getBucketByID(s, bucketID)
+		if err != nil {
+			return err
+		}
+		if bucket.ProjectID != projectID {
+			return ErrBucketDoesNotBelongToProject{ProjectID: projectID, BucketID: bucketID}
+		}
+	case AutomationActionComment:
+		if strings.TrimSpace(action.Value) == "" {
+			return invalid
+		}
+	default:
+		return invalid
+	}
+
+	return nil
+}
+
+func getAutomationRuleByID(s *xorm.Session, id int64) (rule *AutomationRule, err error) {
+	rule = &AutomationRule{}
+	exists, err := s.Where("id = ?", id).Get(rule)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrAutomationRuleDoesNotExist{AutomationRuleID: id}
+	}
+	return rule, nil
+}
+
+// Create creates an automation rule
+// @Summary Create an automation rule
+// @Description Create an automation rule which runs its actions on all tasks of the project matching its filter whenever its trigger fires.
+// @tags automations
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Project ID"
+// @Param automation body models.AutomationRule true "The automation rule object with required fields"
+// @Success 200 {object} models.AutomationRule "The created automation rule."
+// @Failure 400 {object} web.HTTPError "Invalid automation rule object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the project."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{id}/automations [put]
+func (r *AutomationRule) Create(s *xorm.Session, a web.Auth) (err error) {
+	if err := r.validate(s, a); err != nil {
+		return err
+	}
+
+	r.ID = 0
+	r.CreatedByID = a.GetID()
+	_, err = s.Insert(r)
+	if err != nil {
+		return err
+	}
+
+	r.CreatedBy, err = user.GetUserByID(s, a.GetID())
+	return
+}
+
+// ReadAll returns all automation rules of a project
+// @Summary Get all automation rules of a project
+// @Description Get all automation rules of the specified project.
+// @tags automations
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. This parameter is limited by the configured maximum of items per page."
+// @Param id path int true "Project ID"
+// @Success 200 {array} models.AutomationRule "The list of all automation rules"
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the project."
+// @Failure 500 {object} models.Message "Internal server error"
+// @Router /projects/{id}/automations [get]
+func (r *AutomationRule) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	can, err := r.canDoAutomationRule(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	rules := []*AutomationRule{}
+	err = s.
+		Where("project_id = ?", r.ProjectID).
+		OrderBy("id asc").
+		Limit(getLimitFromPageIndex(page, perPage)).
+		Find(&rules)
+	if err != nil {
+		return
+	}
+
+	userIDs := []int64{}
+	for _, rule := range rules {
+		userIDs = append(userIDs, rule.CreatedByID)
+	}
+
+	users, err := user.GetUsersByIDs(s, userIDs)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	for _, rule := range rules {
+		rule.CreatedBy = users[rule.CreatedByID]
+	}
+
+	total, err := s.Where("project_id = ?", r.ProjectID).Count(&AutomationRule{})
+	return rules, len(rules), total, err
+}
+
+// Update updates an automation rule
+// @Summary Update an automation rule
+// @Description Change the title, trigger, filter or actions of an automation rule or disable it.
+// @tags automations
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Project ID"
+// @Param automationID path int true "Automation rule ID"
+// @Param automation body models.AutomationRule true "The automation rule object with required fields"
+// @Success 200 {object} models.AutomationRule "The updated automation rule."
+// @Failure 400 {object} web.HTTPError "Invalid automation rule object provided."
+// @Failure 404 {object} web.HTTPError "The automation rule does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{id}/automations/{automationID} [post]
+func (r *AutomationRule) Update(s *xorm.Session, a web.Auth) (err error) {
+	if err := r.validate(s, a); err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where("id = ?", r.ID).
+		Cols(
+			"title",
+			"is_disabled",
+			"trigger_event",
+			"trigger_schedule",
+			"filter",
+			"actions",
+		).
+		Update(r)
+	return
+}
+
+// Delete deletes an automation rule
+// @Summary Delete an automation rule
+// @Description Delete an automation rule of a project together with its execution log.
+// @tags automations
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Project ID"
+// @Param automationID path int true "Automation rule ID"
+// @Success 200 {object} models.Message "Successfully deleted."
+// @Failure 404 {object} web.HTTPError "The automation rule does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{id}/automations/{automationID} [delete]
+func (r *AutomationRule) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	_, err = s.Where("automation_rule_id = ?", r.ID).Delete(&AutomationRuleExecution{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Where("id = ?", r.ID).Delete(&AutomationRule{})
+	return
+}
+
+// ReadAll returns the execution log of an automation rule
+// @Summary Get the execution log of an automation rule
+// @Description Returns all executions of an automation rule, newest first.
+// @tags automations
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. This parameter is limited by the configured maximum of items per page."
+// @Param id path int true "Project ID"
+// @Param automationID path int true "Automation rule ID"
+// @Success 200 {array} models.AutomationRuleExecution "The execution log"
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the project."
+// @Failure 404 {object} web.HTTPError "The automation rule does not exist."
+// @Failure 500 {object} models.Message "Internal server error"
+// @Router /projects/{id}/automations/{automationID}/executions [get]
+func (e *AutomationRuleExecution) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	rule := &AutomationRule{ID: e.AutomationRuleID, ProjectID: e.ProjectID}
+	can, err := rule.canDoAutomationRule(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	executions := []*AutomationRuleExecution{}
+	err = s.
+		Where("automation_rule_id = ?", e.AutomationRuleID).
+		OrderBy("created desc, id desc").
+		Limit(getLimitFromPageIndex(page, perPage)).
+		Find(&executions)
+	if err != nil {
+		return
+	}
+
+	total, err := s.Where("automation_rule_id = ?", e.AutomationRuleID).Count(&AutomationRuleExecution{})
+	return executions, len(executions), total, err
+}
+
+func getAutomationRulesForProjectAndEvent(s *xorm.Session, projectID int64, eventName string) (rules []*AutomationRule, err error) {
+	rules = []*AutomationRule{}
+	err = s.
+		Where("project_id = ? AND trigger_event = ? AND is_disabled = ?", projectID, eventName, false).
+		OrderBy("id asc").
+		Find(&rules)
+	return
+}
+
+// isDueAt checks if the schedule of a rule is due in the minute of the given time.
+func (r *AutomationRule) isDueAt(t time.Time) (bool, error) {
+	minute := t.Truncate(time.Minute)
+	next, err := cron.NextRun(r.TriggerSchedule, minute.Add(-time.Second))
+	if err != nil {
+		return false, err
+	}
+	return next.Equal(minute), nil
+}
+
+// execute runs all actions of a rule for a task if the task matches the filter of the rule. It returns the
+// execution which should be added to the log or nil if the task does not match.
+func (r *AutomationRule) execute(s *xorm.Session, taskID int64, trigger string) (execution *AutomationRuleExecution, err error) {
+	execution = &AutomationRuleExecution{
+		AutomationRuleID: r.ID,
+		TaskID:           taskID,
+		TriggeredBy:      trigger,
+		Status:           AutomationRuleExecutionStatusSuccess,
+	}
+
+	// Actions of a rule can trigger the same or other rules again. To prevent running into an endless loop,
+	// a rule only runs a limited number of times per task and minute.
+	runs, err := s.
+		Where("automation_rule_id = ? AND task_id = ? AND status != ? AND created > ?",
+			r.ID, taskID, AutomationRuleExecutionStatusSkipped, time.Now().Add(-time.Minute)).
+		Count(&AutomationRuleExecution{})
+	if err != nil {
+		return nil, err
+	}
+	if runs >= config.AutomationsLoopLimit.GetInt64() {
+		execution.Status = AutomationRuleExecutionStatusSkipped
+		execution.Message = "The rule ran too often for this task in the last minute, it probably triggers itself."
+		return execution, nil
+	}
+
+	matches, err := taskMatchesFilter(s, r.ProjectID, taskID, r.Filter)
+	if err != nil || !matches {
+		return nil, err
+	}
+
+	doer, err := user.GetUserByID(s, r.CreatedByID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, action := range r.Actions {
+		err = action.execute(s, taskID, doer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return execution, nil
+}
+
+// execute performs an action on a task. Actions which would not change anything are skipped so that rules
+// reacting to the changes of other rules settle down.
+func (action *AutomationAction) execute(s *xorm.Session, taskID int64, doer *user.User) (err error) {
+	// Rules act on behalf of their creator who might have lost access to the project since creating the rule.
+	task := &Task{ID: taskID}
+	canUpdate, err := task.CanUpdate(s, doer)
+	if err != nil {
+		return err
+	}
+	if !canUpdate {
+		return ErrGenericForbidden{}
+	}
+
+	err = task.ReadOne(s, doer)
+	if err != nil {
+		return err
+	}
+
+	switch action.Type {
+	case AutomationActionSetField:
+		before := *task
+		if !task.setFieldFromString(action.Field, action.Value) {
+			return &ErrInvalidAutomationAction{Type: action.Type, Field: action.Field, Value: action.Value}
+		}
+		if before.Done == task.Done && before.Priority == task.Priority &&
+			before.PercentDone == task.PercentDone && before.HexColor == task.HexColor {
+			return nil
+		}
+		return task.Update(s, doer)
+	case AutomationActionAddLabel:
+		labelID, err := action.getIDValue()
+		if err != nil {
+			return err
+		}
+		labelTask := &LabelTask{TaskID: taskID, LabelID: labelID}
+		canCreate, err := labelTask.CanCreate(s, doer)
+		if err != nil {
+			return err
+		}
+		if !canCreate {
+			return ErrGenericForbidden{}
+		}
+		err = labelTask.Create(s, doer)
+		if IsErrLabelIsAlreadyOnTask(err) {
+			return nil
+		}
+		return err
+	case AutomationActionAssign:
+		userID, err := action.getIDValue()
+		if err != nil {
+			return err
+		}
+		assigned, err := s.Where("task_id = ? AND user_id = ?", taskID, userID).Exist(&TaskAssginee{})
+		if err != nil || assigned {
+			return err
+		}
+		project, err := GetProjectSimpleByID(s, task.ProjectID)
+		if err != nil {
+			return err
+		}
+		return task.addNewAssigneeByID(s, userID, project, doer)
+	case AutomationActionMoveBucket:
+		bucketID, err := action.getIDValue()
+		if err != nil {
+			return err
+		}
+		inBucket, err := s.Where("task_id = ? AND bucket_id = ?", taskID, bucketID).Exist(&TaskBucket{})
+		if err != nil || inBucket {
+			return err
+		}
+		task.BucketID = bucketID
+		return task.Update(s, doer)
+	case AutomationActionComment:
+		return (&TaskComment{TaskID: taskID, Comment: action.Value}).Create(s, doer)
+	}
+
+	return &ErrInvalidAutomationAction{Type: action.Type, Field: action.Field, Value: action.Value}
+}
+
+// runAutomationRuleForTask runs a rule for a task in its own transaction and adds the result to the execution log.
+func runAutomationRuleForTask(rule *AutomationRule, taskID int64, trigger string) {
+	s := db.NewSession()
+	defer s.Close()
+
+	err := s.Begin()
+	if err != nil {
+		log.Errorf("[Automations] Could not start transaction for automation rule %d: %s", rule.ID, err)
+		return
+	}
+
+	execution, err := rule.execute(s, taskID, trigger)
+	if err != nil {
+		_ = s.Rollback()
+		log.Errorf("[Automations] Could not run automation rule %d for task %d: %s", rule.ID, taskID, err)
+
+		execution = &AutomationRuleExecution{
+			AutomationRuleID: rule.ID,
+			TaskID:           taskID,
+			TriggeredBy:      trigger,
+			Status:           AutomationRuleExecutionStatusFailed,
+			Message:          err.Error(),
+		}
+		s = db.NewSession()
+		defer s.Close()
+		if err := s.Begin(); err != nil {
+			log.Errorf("[Automations] Could not start transaction for automation rule %d: %s", rule.ID, err)
+			return
+		}
+	}
+
+	if execution == nil {
+		return
+	}
+
+	_, err = s.Insert(execution)
+	if err != nil {
+		_ = s.Rollback()
+		log.Errorf("[Automations] Could not save execution of automation rule %d for task %d: %s", rule.ID, taskID, err)
+		return
+	}
+
+	if err := s.Commit(); err != nil {
+		log.Errorf("[Automations] Could not save execution of automation rule %d for task %d: %s", rule.ID, taskID, err)
+	}
+}
+
+// RegisterAutomationCron registers a function which runs all automation rules with a schedule when they are due.
+func RegisterAutomationCron() {
+	if !config.AutomationsEnabled.GetBool() {
+		return
+	}
+
+	err := cron.Schedule("* * * * *", func() {
+		s := db.NewSession()
+		defer s.Close()
+
+		rules := []*AutomationRule{}
+		err := s.
+			Where("trigger_schedule != '' AND trigger_schedule IS NOT NULL AND is_disabled = ?", false).
+			Find(&rules)
+		if err != nil {
+			log.Errorf("[Automations] Could not get scheduled automation rules: %s", err)
+			return
+		}
+
+		now := time.Now()
+		for _, rule := range rules {
+			due, err := rule.isDueAt(now)
+			if err != nil {
+				log.Errorf("[Automations] Could not check schedule of automation rule %d: %s", rule.ID, err)
+				continue
+			}
+			if !due {
+				continue
+			}
+
+			taskIDs, err := getTaskIDsMatchingFilter(s, rule.ProjectID, rule.Filter)
+			if err != nil {
+				log.Errorf("[Automations] Could not get tasks for automation rule %d: %s", rule.ID, err)
+				continue
+			}
+
+			log.Debugf("[Automations] Running scheduled automation rule %d for %d tasks", rule.ID, len(taskIDs))
+
+			for _, taskID := range taskIDs {
+				runAutomationRuleForTask(rule, taskID, automationTriggerSchedule)
+			}
+		}
+	})
+	if err != nil {
+		log.Fatalf("Could not register automation cron: %s", err)
+	}
+}
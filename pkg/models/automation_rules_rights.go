@@ -0,0 +1,58 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanCreate checks if a user can create an automation rule for a project
+func (r *AutomationRule) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	return r.canDoAutomationRule(s, a)
+}
+
+// CanUpdate checks if a user can update an automation rule
+func (r *AutomationRule) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return r.canDoAutomationRule(s, a)
+}
+
+// CanDelete checks if a user can delete an automation rule
+func (r *AutomationRule) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return r.canDoAutomationRule(s, a)
+}
+
+func (r *AutomationRule) canDoAutomationRule(s *xorm.Session, a web.Auth) (bool, error) {
+	// Link shares can't manage automation rules
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	// Make sure the automation rule actually belongs to the project from the url
+	if r.ID > 0 {
+		existing, err := getAutomationRuleByID(s, r.ID)
+		if err != nil {
+			return false, err
+		}
+		if existing.ProjectID != r.ProjectID {
+			return false, nil
+		}
+	}
+
+	p := &Project{ID: r.ProjectID}
+	return p.IsAdmin(s, a)
+}
@@ -0,0 +1,468 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutomationRule_Create(t *testing.T) {
+	RegisterEventForAutomations(&TaskCreatedEvent{})
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &AutomationRule{
+			ProjectID:    1,
+			Title:        "Label bugs",
+			TriggerEvent: "task.created",
+			Filter:       "priority >= 3",
+			Actions: []*AutomationAction{
+				{Type: AutomationActionAddLabel, Value: "1"},
+				{Type: AutomationActionAssign, Value: "1"},
+			},
+		}
+		err := r.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), r.CreatedByID)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "automation_rules", map[string]interface{}{
+			"id":            r.ID,
+			"project_id":    1,
+			"trigger_event": "task.created",
+			"filter":        "priority >= 3",
+		}, false)
+	})
+	t.Run("with schedule", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &AutomationRule{
+			ProjectID:       1,
+			Title:           "Overdue tasks",
+			TriggerSchedule: "0 * * * *",
+			Filter:          "due_date < now",
+			Actions: []*AutomationAction{
+				{Type: AutomationActionSetField, Field: "priority", Value: "4"},
+			},
+		}
+		err := r.Create(s, u)
+		assert.NoError(t, err)
+	})
+	t.Run("no trigger", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &AutomationRule{
+			ProjectID: 1,
+			Title:     "No trigger",
+			Actions:   []*AutomationAction{{Type: AutomationActionComment, Value: "Hello"}},
+		}
+		err := r.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidAutomationTrigger(err))
+	})
+	t.Run("event and schedule", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &AutomationRule{
+			ProjectID:       1,
+			Title:           "Both",
+			TriggerEvent:    "task.created",
+			TriggerSchedule: "0 * * * *",
+			Actions:         []*AutomationAction{{Type: AutomationActionComment, Value: "Hello"}},
+		}
+		err := r.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidAutomationTrigger(err))
+	})
+	t.Run("invalid event", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &AutomationRule{
+			ProjectID:    1,
+			Title:        "Invalid event",
+			TriggerEvent: "task.exploded",
+			Actions:      []*AutomationAction{{Type: AutomationActionComment, Value: "Hello"}},
+		}
+		err := r.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidAutomationTrigger(err))
+	})
+	t.Run("invalid schedule", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &AutomationRule{
+			ProjectID:       1,
+			Title:           "Invalid schedule",
+			TriggerSchedule: "every day",
+			Actions:         []*AutomationAction{{Type: AutomationActionComment, Value: "Hello"}},
+		}
+		err := r.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidAutomationTrigger(err))
+	})
+	t.Run("no actions", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &AutomationRule{
+			ProjectID:    1,
+			Title:        "No actions",
+			TriggerEvent: "task.created",
+		}
+		err := r.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrAutomationRuleHasNoActions(err))
+	})
+	t.Run("invalid field value", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &AutomationRule{
+			ProjectID:    1,
+			Title:        "Invalid value",
+			TriggerEvent: "task.created",
+			Actions:      []*AutomationAction{{Type: AutomationActionSetField, Field: "priority", Value: "high"}},
+		}
+		err := r.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidAutomationAction(err))
+	})
+	t.Run("nonexisting label", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &AutomationRule{
+			ProjectID:    1,
+			Title:        "Invalid label",
+			TriggerEvent: "task.created",
+			Actions:      []*AutomationAction{{Type: AutomationActionAddLabel, Value: "9999"}},
+		}
+		err := r.Create(s, u)
+		assert.Error(t, err)
+	})
+	t.Run("bucket of another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &AutomationRule{
+			ProjectID:    1,
+			Title:        "Invalid bucket",
+			TriggerEvent: "task.created",
+			Actions:      []*AutomationAction{{Type: AutomationActionMoveBucket, Value: "4"}},
+		}
+		err := r.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrBucketDoesNotBelongToProject(err))
+	})
+}
+
+func TestAutomationRule_ReadAll(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &AutomationRule{ProjectID: 1}
+		result, count, total, err := r.ReadAll(s, u, "", 1, 50)
+		assert.NoError(t, err)
+		rules, is := result.([]*AutomationRule)
+		assert.True(t, is)
+		assert.Len(t, rules, 2)
+		assert.Equal(t, 2, count)
+		assert.Equal(t, int64(2), total)
+		assert.Equal(t, int64(1), rules[0].ID)
+		assert.Equal(t, int64(1), rules[0].CreatedBy.ID)
+		assert.Len(t, rules[0].Actions, 1)
+		assert.Equal(t, AutomationActionSetField, rules[0].Actions[0].Type)
+	})
+	t.Run("no admin access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &AutomationRule{ProjectID: 2}
+		_, _, _, err := r.ReadAll(s, u, "", 1, 50)
+		assert.Error(t, err)
+		assert.IsType(t, ErrGenericForbidden{}, err)
+	})
+}
+
+func TestAutomationRule_Delete(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	r := &AutomationRule{ID: 1, ProjectID: 1}
+	err := r.Delete(s, &user.User{ID: 1})
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	db.AssertMissing(t, "automation_rules", map[string]interface{}{
+		"id": 1,
+	})
+	db.AssertMissing(t, "automation_rule_executions", map[string]interface{}{
+		"automation_rule_id": 1,
+	})
+}
+
+func TestAutomationRule_CanDelete(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("own project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &AutomationRule{ID: 1, ProjectID: 1}
+		can, err := r.CanDelete(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+	})
+	t.Run("rule of another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &AutomationRule{ID: 3, ProjectID: 1}
+		can, err := r.CanDelete(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r := &AutomationRule{ID: 9999, ProjectID: 1}
+		_, err := r.CanDelete(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrAutomationRuleDoesNotExist(err))
+	})
+}
+
+func TestAutomationRuleExecution_ReadAll(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		e := &AutomationRuleExecution{AutomationRuleID: 1, ProjectID: 1}
+		result, _, total, err := e.ReadAll(s, u, "", 1, 50)
+		assert.NoError(t, err)
+		executions, is := result.([]*AutomationRuleExecution)
+		assert.True(t, is)
+		assert.Len(t, executions, 1)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, AutomationRuleExecutionStatusSuccess, executions[0].Status)
+	})
+	t.Run("rule of another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		e := &AutomationRuleExecution{AutomationRuleID: 3, ProjectID: 1}
+		_, _, _, err := e.ReadAll(s, u, "", 1, 50)
+		assert.Error(t, err)
+		assert.IsType(t, ErrGenericForbidden{}, err)
+	})
+}
+
+func TestAutomationRule_execute(t *testing.T) {
+	runRule := func(t *testing.T, actions []*AutomationAction, filter string, taskID int64) *AutomationRuleExecution {
+		s := db.NewSession()
+		defer s.Close()
+
+		r, err := getAutomationRuleByID(s, 2)
+		assert.NoError(t, err)
+		r.Filter = filter
+		r.Actions = actions
+
+		execution, err := r.execute(s, taskID, "task.created")
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+		return execution
+	}
+
+	t.Run("set field", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		execution := runRule(t, []*AutomationAction{
+			{Type: AutomationActionSetField, Field: "priority", Value: "4"},
+		}, "done = false", 1)
+		assert.NotNil(t, execution)
+		assert.Equal(t, AutomationRuleExecutionStatusSuccess, execution.Status)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":       1,
+			"priority": 4,
+		}, false)
+	})
+	t.Run("task does not match the filter", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		execution := runRule(t, []*AutomationAction{
+			{Type: AutomationActionSetField, Field: "priority", Value: "4"},
+		}, "done = false", 2)
+		assert.Nil(t, execution)
+
+		db.AssertMissing(t, "tasks", map[string]interface{}{
+			"id":       2,
+			"priority": 4,
+		})
+	})
+	t.Run("add label", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		execution := runRule(t, []*AutomationAction{
+			{Type: AutomationActionAddLabel, Value: "1"},
+		}, "", 1)
+		assert.NotNil(t, execution)
+
+		db.AssertExists(t, "label_tasks", map[string]interface{}{
+			"task_id":  1,
+			"label_id": 1,
+		}, false)
+	})
+	t.Run("assign", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		execution := runRule(t, []*AutomationAction{
+			{Type: AutomationActionAssign, Value: "1"},
+			{Type: AutomationActionAssign, Value: "1"}, // Already assigned, should be ignored
+		}, "", 1)
+		assert.NotNil(t, execution)
+
+		db.AssertExists(t, "task_assignees", map[string]interface{}{
+			"task_id": 1,
+			"user_id": 1,
+		}, false)
+	})
+	t.Run("move bucket", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		execution := runRule(t, []*AutomationAction{
+			{Type: AutomationActionMoveBucket, Value: "3"},
+		}, "", 1)
+		assert.NotNil(t, execution)
+
+		db.AssertExists(t, "task_buckets", map[string]interface{}{
+			"task_id":   1,
+			"bucket_id": 3,
+		}, false)
+		// Bucket 3 is the done bucket
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":   1,
+			"done": true,
+		}, false)
+	})
+	t.Run("comment", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+
+		execution := runRule(t, []*AutomationAction{
+			{Type: AutomationActionComment, Value: "Thanks for creating this task"},
+		}, "", 1)
+		assert.NotNil(t, execution)
+
+		db.AssertExists(t, "task_comments", map[string]interface{}{
+			"task_id":   1,
+			"author_id": 1,
+			"comment":   "Thanks for creating this task",
+		}, false)
+	})
+	t.Run("creator lost access to the project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		r, err := getAutomationRuleByID(s, 2)
+		assert.NoError(t, err)
+		r.CreatedByID = 2
+
+		_, err = r.execute(s, 1, "task.created")
+		assert.Error(t, err)
+		assert.IsType(t, ErrGenericForbidden{}, err)
+
+		db.AssertMissing(t, "task_comments", map[string]interface{}{
+			"task_id": 1,
+			"comment": "Thanks for creating this task",
+		})
+	})
+	t.Run("loop protection", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		for i := 0; i < 5; i++ {
+			_, err := s.Insert(&AutomationRuleExecution{
+				AutomationRuleID: 2,
+				TaskID:           1,
+				TriggeredBy:      "task.created",
+				Status:           AutomationRuleExecutionStatusSuccess,
+			})
+			assert.NoError(t, err)
+		}
+
+		r, err := getAutomationRuleByID(s, 2)
+		assert.NoError(t, err)
+		execution, err := r.execute(s, 1, "task.created")
+		assert.NoError(t, err)
+		assert.Equal(t, AutomationRuleExecutionStatusSkipped, execution.Status)
+	})
+}
+
+func TestAutomationRule_isDueAt(t *testing.T) {
+	r := &AutomationRule{TriggerSchedule: "0 9 * * *"}
+
+	due, err := r.isDueAt(time.Date(2023, 9, 26, 9, 0, 30, 0, time.Local))
+	assert.NoError(t, err)
+	assert.True(t, due)
+
+	due, err = r.isDueAt(time.Date(2023, 9, 26, 9, 1, 0, 0, time.Local))
+	assert.NoError(t, err)
+	assert.False(t, due)
+}
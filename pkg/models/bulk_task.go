@@ -80,11 +80,32 @@ func (bt *BulkTask) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /tasks/bulk [post]
 func (bt *BulkTask) Update(s *xorm.Session, a web.Auth) (err error) {
+	cols := []string{
+		"title",
+		"description",
+		"done",
+		"due_date",
+		"reminders",
+		"repeat_after",
+		"priority",
+		"start_date",
+		"end_date",
+	}
+
 	for _, oldtask := range bt.Tasks {
 
+		originalTask := *oldtask
+		wasDone := oldtask.Done
+
 		// When a repeating task is marked as done, we update all deadlines and reminders and set it as undone
 		updateDone(oldtask, &bt.Task)
 
+		if !wasDone && bt.Task.Done {
+			if err := checkTaskBlockers(s, oldtask); err != nil {
+				return err
+			}
+		}
+
 		// Update the assignees
 		if err := oldtask.updateTaskAssignees(s, bt.Assignees, a); err != nil {
 			return err
@@ -103,19 +124,26 @@ func (bt *BulkTask) Update(s *xorm.Session, a web.Auth) (err error) {
 		}
 
 		_, err = s.ID(oldtask.ID).
-			Cols("title",
-				"description",
-				"done",
-				"due_date",
-				"reminders",
-				"repeat_after",
-				"priority",
-				"start_date",
-				"end_date").
+			Cols(cols...).
 			Update(oldtask)
 		if err != nil {
 			return err
 		}
+
+		err = addHistoryEntries(s, a, historyEntriesForChanges(HistoryEntry{
+			EntityKind: HistoryEntityKindTask,
+			EntityID:   oldtask.ID,
+			TaskID:     oldtask.ID,
+			ProjectID:  oldtask.ProjectID,
+		}, &originalTask, oldtask, cols)...)
+		if err != nil {
+			return err
+		}
+
+		err = recordTaskSyncChange(s, oldtask.ProjectID, oldtask, false)
+		if err != nil {
+			return err
+		}
 	}
 
 	return
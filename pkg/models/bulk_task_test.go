@@ -21,6 +21,9 @@ import (
 
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBulkTask_Update(t *testing.T) {
@@ -92,3 +95,27 @@ func TestBulkTask_Update(t *testing.T) {
 		})
 	}
 }
+
+func TestBulkTask_Update_UndoneBlockers(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	u := &user.User{ID: 1}
+	_, err := s.Where("id = ?", 1).Cols("enforce_dependencies").Update(&Project{EnforceDependencies: true})
+	require.NoError(t, err)
+	addTestTaskRelation(t, s, 3, 11, RelationKindBlocking)
+
+	bt := &BulkTask{
+		IDs:  []int64{10, 11},
+		Task: Task{Done: true},
+	}
+	allowed, err := bt.CanUpdate(s, u)
+	require.NoError(t, err)
+	require.True(t, allowed)
+
+	err = bt.Update(s, u)
+	require.Error(t, err)
+	assert.True(t, IsErrTaskHasUndoneBlockers(err))
+	assert.Equal(t, []int64{3}, err.(ErrTaskHasUndoneBlockers).BlockerIDs)
+}
@@ -0,0 +1,716 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// CustomFieldType defines which kind of values a custom field holds
+type CustomFieldType string
+
+const (
+	CustomFieldTypeText   CustomFieldType = "text"
+	CustomFieldTypeNumber CustomFieldType = "number"
+	CustomFieldTypeDate   CustomFieldType = "date"
+	CustomFieldTypeSelect CustomFieldType = "select"
+	CustomFieldTypeUser   CustomFieldType = "user"
+)
+
+// taskPropertyCustomFieldPrefix is used to filter or sort tasks by a custom field, for example "custom_fields.3".
+const taskPropertyCustomFieldPrefix = "custom_fields."
+
+// CustomField is the definition of a custom field all tasks of a project can have a value for.
+type CustomField struct {
+	// The unique, numeric id of this custom field.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"customfield"`
+	// The project this custom field belongs to.
+	ProjectID int64 `xorm:"bigint not null index" json:"project_id" param:"project"`
+	// The title of the custom field.
+	Title string `xorm:"varchar(250) not null" json:"title" valid:"required,runelength(1|250)" minLength:"1" maxLength:"250"`
+	// The type of the values of this field. Can be one of `text`, `number`, `date`, `select` or `user`.
+	// The type cannot be changed once the field was created.
+	Type CustomFieldType `xorm:"varchar(50) not null" json:"type"`
+	// The options a user can choose from if this is a select field.
+	Options []string `xorm:"JSON null" json:"options"`
+
+	// The user who initially created the custom field.
+	CreatedBy   *user.User `xorm:"-" json:"created_by" valid:"-"`
+	CreatedByID int64      `xorm:"bigint not null" json:"-"`
+
+	// A timestamp when this custom field was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this custom field was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for custom fields
+func (*CustomField) TableName() string {
+	return "custom_fields"
+}
+
+// TaskCustomFieldValue holds the value of a custom field for a task
+type TaskCustomFieldValue struct {
+	ID     int64 `xorm:"bigint autoincr not null unique pk" json:"-"`
+	TaskID int64 `xorm:"bigint not null unique(task_field)" json:"-"`
+	// The id of the custom field this value belongs to.
+	FieldID int64 `xorm:"bigint not null unique(task_field) index" json:"field_id"`
+	// The value of the field. Its type depends on the field: A string for text and select fields, a number for number fields,
+	// a date for date fields and the id of a user for user fields. Set this to null to remove the value from the task.
+	Value interface{} `xorm:"-" json:"value"`
+	// The custom field definition. You can only read this property.
+	Field *CustomField `xorm:"-" json:"field,omitempty"`
+
+	TextValue   string    `xorm:"text null" json:"-"`
+	NumberValue float64   `xorm:"double null" json:"-"`
+	DateValue   time.Time `xorm:"DATETIME null" json:"-"`
+	UserID      int64     `xorm:"bigint null" json:"-"`
+
+	Created time.Time `xorm:"created not null" json:"-"`
+	Updated time.Time `xorm:"updated not null" json:"-"`
+}
+
+// TableName returns the table name for custom field values
+func (*TaskCustomFieldValue) TableName() string {
+	return "task_custom_field_values"
+}
+
+func (t CustomFieldType) isValid() bool {
+	switch t {
+	case CustomFieldTypeText,
+		CustomFieldTypeNumber,
+		CustomFieldTypeDate,
+		CustomFieldTypeSelect,
+		CustomFieldTypeUser:
+		return true
+	}
+	return false
+}
+
+// valueColumn returns the name of the struct field and the db column which holds values of this type.
+func (t CustomFieldType) valueColumn() (fieldName string, column string) {
+	switch t {
+	case CustomFieldTypeNumber:
+		return "NumberValue", "number_value"
+	case CustomFieldTypeDate:
+		return "DateValue", "date_value"
+	case CustomFieldTypeUser:
+		return "UserID", "user_id"
+	default:
+		return "TextValue", "text_value"
+	}
+}
+
+func (cf *CustomField) validate() error {
+	if !cf.Type.isValid() {
+		return &ErrInvalidCustomFieldType{Type: cf.Type}
+	}
+
+	if cf.Type != CustomFieldTypeSelect {
+		cf.Options = nil
+		return nil
+	}
+
+	if len(cf.Options) == 0 {
+		return &ErrCustomFieldSelectNeedsOptions{}
+	}
+
+	return nil
+}
+
+func (cf *CustomField) hasOption(option string) bool {
+	for _, o := range cf.Options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// getCustomFieldIDFromTaskProperty returns the id of a custom field if the task property references one.
+func getCustomFieldIDFromTaskProperty(property string) (id int64, is bool) {
+	if !strings.HasPrefix(property, taskPropertyCustomFieldPrefix) {
+		return 0, false
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(property, taskPropertyCustomFieldPrefix), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+
+	return id, true
+}
+
+func getCustomFieldByID(s *xorm.Session, id int64) (cf *CustomField, err error) {
+	cf = &CustomField{}
+	exists, err := s.Where("id = ?", id).Get(cf)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrCustomFieldDoesNotExist{CustomFieldID: id}
+	}
+	return
+}
+
+func getCustomFieldsByIDs(s *xorm.Session, ids []int64) (fields map[int64]*CustomField, err error) {
+	fields = make(map[int64]*CustomField, len(ids))
+	if len(ids) == 0 {
+		return
+	}
+
+	err = s.In("id", ids).Find(&fields)
+	return
+}
+
+// GetCustomFieldsByProjectID returns all custom fields of a project
+func GetCustomFieldsByProjectID(s *xorm.Session, projectID int64) (fields []*CustomField, err error) {
+	fields = []*CustomField{}
+	err = s.
+		Where("project_id = ?", projectID).
+		OrderBy("id asc").
+		Find(&fields)
+	return
+}
+
+// setValue converts a value from user input to the native type of the field and stores it in the right column.
+func (v *TaskCustomFieldValue) setValue(s *xorm.Session, field *CustomField, value interface{}) (err error) {
+	invalid := &ErrInvalidCustomFieldValue{CustomFieldID: field.ID, Value: value}
+
+	switch field.Type {
+	case CustomFieldTypeText, CustomFieldTypeSelect:
+		str, is := value.(string)
+		if !is {
+			return invalid
+		}
+		if field.Type == CustomFieldTypeSelect && !field.hasOption(str) {
+			return invalid
+		}
+		v.TextValue = str
+	case CustomFieldTypeNumber:
+		v.NumberValue, err = getCustomFieldNumberFromInput(value)
+		if err != nil {
+			return invalid
+		}
+	case CustomFieldTypeDate:
+		switch d := value.(type) {
+		case time.Time:
+			v.DateValue = d
+		case string:
+			v.DateValue, err = parseTimeFromUserInput(d)
+			if err != nil {
+				return invalid
+			}
+		default:
+			return invalid
+		}
+	case CustomFieldTypeUser:
+		id, err := getCustomFieldNumberFromInput(value)
+		if err != nil {
+			return invalid
+		}
+		u, err := user.GetUserByID(s, int64(id))
+		if err != nil {
+			if user.IsErrUserDoesNotExist(err) {
+				return invalid
+			}
+			return err
+		}
+		v.UserID = u.ID
+	}
+
+	v.FieldID = field.ID
+	v.Field = field
+	v.setValueFromColumn()
+	return nil
+}
+
+func getCustomFieldNumberFromInput(value interface{}) (float64, error) {
+	switch n := value.(type) {
+	case float64:
+		return n, nil
+	case int:
+		return float64(n), nil
+	case int64:
+		return float64(n), nil
+	case json.Number:
+		return n.Float64()
+	case string:
+		return strconv.ParseFloat(n, 64)
+	}
+	return 0, &ErrInvalidCustomFieldValue{Value: value}
+}
+
+// setValueFromColumn puts the value from the column of the field's type into the Value property
+func (v *TaskCustomFieldValue) setValueFromColumn() {
+	if v.Field == nil {
+		return
+	}
+
+	switch v.Field.Type {
+	case CustomFieldTypeNumber:
+		v.Value = v.NumberValue
+	case CustomFieldTypeDate:
+		v.Value = v.DateValue
+	case CustomFieldTypeUser:
+		v.Value = v.UserID
+	default:
+		v.Value = v.TextValue
+	}
+}
+
+func getCustomFieldValuesForTasks(s *xorm.Session, taskIDs []int64) (values []*TaskCustomFieldValue, err error) {
+	values = []*TaskCustomFieldValue{}
+	if len(taskIDs) == 0 {
+		return
+	}
+
+	err = s.
+		In("task_id", taskIDs).
+		OrderBy("field_id asc").
+		Find(&values)
+	if err != nil {
+		return
+	}
+
+	fieldIDs := make([]int64, 0, len(values))
+	for _, v := range values {
+		fieldIDs = append(fieldIDs, v.FieldID)
+	}
+
+	fields, err := getCustomFieldsByIDs(s, fieldIDs)
+	if err != nil {
+		return
+	}
+
+	for _, v := range values {
+		v.Field = fields[v.FieldID]
+		v.setValueFromColumn()
+	}
+
+	return
+}
+
+func addCustomFieldValuesToTasks(s *xorm.Session, taskIDs []int64, taskMap map[int64]*Task) (err error) {
+	values, err := getCustomFieldValuesForTasks(s, taskIDs)
+	if err != nil {
+		return
+	}
+
+	for _, v := range values {
+		taskMap[v.TaskID].CustomFields = append(taskMap[v.TaskID].CustomFields, v)
+	}
+
+	return
+}
+
+// updateCustomFieldValues saves all custom field values passed with the task. Values of fields which are not
+// passed stay untouched, values which are set to null are removed.
+func (t *Task) updateCustomFieldValues(s *xorm.Session, values []*TaskCustomFieldValue) (err error) {
+	if len(values) == 0 {
+		return
+	}
+
+	fieldIDs := make([]int64, 0, len(values))
+	for _, v := range values {
+		fieldIDs = append(fieldIDs, v.FieldID)
+	}
+
+	fields, err := getCustomFieldsByIDs(s, fieldIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range values {
+		field, exists := fields[v.FieldID]
+		if !exists || field.ProjectID != t.ProjectID {
+			return &ErrCustomFieldDoesNotExist{CustomFieldID: v.FieldID}
+		}
+
+		_, err = s.
+			Where("task_id = ? AND field_id = ?", t.ID, v.FieldID).
+			Delete(&TaskCustomFieldValue{})
+		if err != nil {
+			return err
+		}
+
+		if v.Value == nil {
+			continue
+		}
+
+		newValue := &TaskCustomFieldValue{TaskID: t.ID}
+		err = newValue.setValue(s, field, v.Value)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.Insert(newValue)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteCustomFieldValuesFromOtherProjects removes all values of fields which don't belong to the task's project,
+// for example after the task was moved to another project.
+func (t *Task) deleteCustomFieldValuesFromOtherProjects(s *xorm.Session) (err error) {
+	_, err = s.
+		Where("task_id = ?", t.ID).
+		NotIn("field_id", builder.
+			Select("id").
+			From("custom_fields").
+			Where(builder.Eq{"project_id": t.ProjectID})).
+		Delete(&TaskCustomFieldValue{})
+	return
+}
+
+// resolveCustomFieldsInSearchOptions loads the definitions of all custom fields used to filter or sort tasks and
+// converts the filter values to the native type of their field.
+func resolveCustomFieldsInSearchOptions(s *xorm.Session, opts *taskSearchOptions) (err error) {
+	filters := append([]*taskFilter{}, opts.filters...)
+	filters = append(filters, opts.filterExpression.getFilters()...)
+
+	fieldIDs := []int64{}
+	for _, f := range filters {
+		if f.customFieldID > 0 {
+			fieldIDs = append(fieldIDs, f.customFieldID)
+		}
+	}
+	for _, param := range opts.sortby {
+		if id, is := getCustomFieldIDFromTaskProperty(param.sortBy); is {
+			fieldIDs = append(fieldIDs, id)
+		}
+	}
+
+	if len(fieldIDs) == 0 {
+		return nil
+	}
+
+	fields, err := getCustomFieldsByIDs(s, fieldIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range filters {
+		if f.customFieldID == 0 {
+			continue
+		}
+
+		field, exists := fields[f.customFieldID]
+		if !exists {
+			return &ErrCustomFieldDoesNotExist{CustomFieldID: f.customFieldID}
+		}
+
+		f.customField = field
+		err = f.convertCustomFieldValue()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, param := range opts.sortby {
+		id, is := getCustomFieldIDFromTaskProperty(param.sortBy)
+		if !is {
+			continue
+		}
+
+		field, exists := fields[id]
+		if !exists {
+			return &ErrCustomFieldDoesNotExist{CustomFieldID: id}
+		}
+		param.customField = field
+	}
+
+	return nil
+}
+
+// convertCustomFieldValue converts the raw filter value to the native type of the custom field.
+func (f *taskFilter) convertCustomFieldValue() (err error) {
+	rawValue, is := f.value.(string)
+	if !is {
+		// Already converted
+		return nil
+	}
+
+	structFieldName, _ := f.customField.Type.valueColumn()
+	structField, _ := reflect.TypeOf(TaskCustomFieldValue{}).FieldByName(structFieldName)
+
+	if f.comparator == taskFilterComparatorIn {
+		vals := []interface{}{}
+		for _, val := range strings.Split(rawValue, ",") {
+			v, err := getValueForField(structField, strings.TrimSpace(val))
+			if err != nil {
+				return ErrInvalidTaskFilterValue{Field: f.field, Value: rawValue}
+			}
+			vals = append(vals, v)
+		}
+		f.value = vals
+		return nil
+	}
+
+	f.value, err = getValueForField(structField, rawValue)
+	if err != nil {
+		return ErrInvalidTaskFilterValue{Field: f.field, Value: rawValue}
+	}
+
+	return nil
+}
+
+// getFilterCondForCustomField returns a condition matching all tasks which have a value for the filter's custom field
+// matching the filter.
+func getFilterCondForCustomField(f *taskFilter, includeNulls bool) (cond builder.Cond, err error) {
+	if f.customField == nil {
+		return nil, &ErrCustomFieldDoesNotExist{CustomFieldID: f.customFieldID}
+	}
+
+	filter := *f
+	_, filter.field = f.customField.Type.valueColumn()
+	filter.isNumeric = false
+
+	valueCond, err := getFilterCond(&filter, false)
+	if err != nil {
+		return nil, err
+	}
+
+	cond = builder.In(
+		"id",
+		builder.
+			Select("task_id").
+			From("task_custom_field_values").
+			Where(builder.And(builder.Eq{"field_id": f.customField.ID}, valueCond)),
+	)
+
+	if includeNulls {
+		cond = builder.Or(cond, builder.NotIn(
+			"id",
+			builder.
+				Select("task_id").
+				From("task_custom_field_values").
+				Where(builder.Eq{"field_id": f.customField.ID}),
+		))
+	}
+
+	return
+}
+
+// getOrderByColumnForCustomField returns a subquery which returns the value of a custom field for each task
+// so that it can be used to sort tasks.
+func getOrderByColumnForCustomField(field *CustomField) string {
+	_, column := field.Type.valueColumn()
+	return "(SELECT `" + column + "` FROM task_custom_field_values " +
+		"WHERE task_custom_field_values.task_id = tasks.id " +
+		"AND task_custom_field_values.field_id = " + strconv.FormatInt(field.ID, 10) + ")"
+}
+
+// usesCustomFields returns true if tasks are filtered or sorted by custom fields.
+func (opts *taskSearchOptions) usesCustomFields() bool {
+	for _, param := range opts.sortby {
+		if param.customField != nil {
+			return true
+		}
+	}
+
+	filters := append([]*taskFilter{}, opts.filters...)
+	filters = append(filters, opts.filterExpression.getFilters()...)
+	for _, f := range filters {
+		if f.customFieldID > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Create creates a new custom field
+// @Summary Create a custom field
+// @Description Creates a new custom field for all tasks of a project. The user needs write access to the project.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param projectID path int true "Project ID"
+// @Param field body models.CustomField true "The custom field object"
+// @Success 201 {object} models.CustomField "The created custom field."
+// @Failure 400 {object} web.HTTPError "Invalid custom field object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the project."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{projectID}/customfields [put]
+func (cf *CustomField) Create(s *xorm.Session, a web.Auth) (err error) {
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	cf.CreatedBy, err = GetUserOrLinkShareUser(s, a)
+	if err != nil {
+		return err
+	}
+
+	cf.ID = 0
+	cf.CreatedByID = cf.CreatedBy.ID
+	_, err = s.Insert(cf)
+	return
+}
+
+// ReadOne returns a single custom field
+// @Summary Get one custom field
+// @Description Returns a single custom field of a project.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param projectID path int true "Project ID"
+// @Param fieldID path int true "Custom field ID"
+// @Success 200 {object} models.CustomField "The custom field."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project."
+// @Failure 404 {object} web.HTTPError "The custom field does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{projectID}/customfields/{fieldID} [get]
+func (cf *CustomField) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	field, err := getCustomFieldByID(s, cf.ID)
+	if err != nil {
+		return err
+	}
+
+	*cf = *field
+	users, err := getUsersOrLinkSharesFromIDs(s, []int64{cf.CreatedByID})
+	if err != nil {
+		return err
+	}
+	cf.CreatedBy = users[cf.CreatedByID]
+	return
+}
+
+// ReadAll returns all custom fields of a project
+// @Summary Get all custom fields of a project
+// @Description Returns all custom fields of a project.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param projectID path int true "Project ID"
+// @Success 200 {array} models.CustomField "The custom fields."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{projectID}/customfields [get]
+func (cf *CustomField) ReadAll(s *xorm.Session, a web.Auth, _ string, _ int, _ int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	p := &Project{ID: cf.ProjectID}
+	canRead, _, err := p.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	fields, err := GetCustomFieldsByProjectID(s, cf.ProjectID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	userIDs := make([]int64, 0, len(fields))
+	for _, field := range fields {
+		userIDs = append(userIDs, field.CreatedByID)
+	}
+
+	users, err := getUsersOrLinkSharesFromIDs(s, userIDs)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	for _, field := range fields {
+		field.CreatedBy = users[field.CreatedByID]
+	}
+
+	return fields, len(fields), int64(len(fields)), nil
+}
+
+// Update updates a custom field
+// @Summary Update a custom field
+// @Description Updates the title and options of a custom field. The type of a field cannot be changed.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param projectID path int true "Project ID"
+// @Param fieldID path int true "Custom field ID"
+// @Param field body models.CustomField true "The custom field object"
+// @Success 200 {object} models.CustomField "The updated custom field."
+// @Failure 400 {object} web.HTTPError "Invalid custom field object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the project."
+// @Failure 404 {object} web.HTTPError "The custom field does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{projectID}/customfields/{fieldID} [post]
+func (cf *CustomField) Update(s *xorm.Session, a web.Auth) (err error) {
+	existing, err := getCustomFieldByID(s, cf.ID)
+	if err != nil {
+		return err
+	}
+
+	cf.Type = existing.Type
+	if err := cf.validate(); err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where("id = ?", cf.ID).
+		Cols("title", "options").
+		Update(cf)
+	if err != nil {
+		return err
+	}
+
+	return cf.ReadOne(s, a)
+}
+
+// Delete removes a custom field
+// @Summary Delete a custom field
+// @Description Deletes a custom field and all of its values.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param projectID path int true "Project ID"
+// @Param fieldID path int true "Custom field ID"
+// @Success 200 {object} models.Message "The custom field was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not have write access to the project."
+// @Failure 404 {object} web.HTTPError "The custom field does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{projectID}/customfields/{fieldID} [delete]
+func (cf *CustomField) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	_, err = s.Where("field_id = ?", cf.ID).Delete(&TaskCustomFieldValue{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Where("id = ?", cf.ID).Delete(&CustomField{})
+	return
+}
@@ -0,0 +1,69 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user can read a custom field
+func (cf *CustomField) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	belongs, err := cf.belongsToProject(s)
+	if err != nil || !belongs {
+		return false, 0, err
+	}
+
+	p := &Project{ID: cf.ProjectID}
+	return p.CanRead(s, a)
+}
+
+// CanCreate checks if a user can create a custom field for a project
+func (cf *CustomField) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	p := &Project{ID: cf.ProjectID}
+	return p.CanWrite(s, a)
+}
+
+// CanUpdate checks if a user can update a custom field
+func (cf *CustomField) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return cf.canDoCustomField(s, a)
+}
+
+// CanDelete checks if a user can delete a custom field
+func (cf *CustomField) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return cf.canDoCustomField(s, a)
+}
+
+func (cf *CustomField) canDoCustomField(s *xorm.Session, a web.Auth) (bool, error) {
+	belongs, err := cf.belongsToProject(s)
+	if err != nil || !belongs {
+		return false, err
+	}
+
+	p := &Project{ID: cf.ProjectID}
+	return p.CanWrite(s, a)
+}
+
+// belongsToProject makes sure the custom field actually belongs to the project from the url
+func (cf *CustomField) belongsToProject(s *xorm.Session) (bool, error) {
+	existing, err := getCustomFieldByID(s, cf.ID)
+	if err != nil {
+		return false, err
+	}
+
+	return existing.ProjectID == cf.ProjectID, nil
+}
@@ -0,0 +1,264 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomField_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &CustomField{
+			ProjectID: 1,
+			Title:     "Release",
+			Type:      CustomFieldTypeSelect,
+			Options:   []string{"1.0", "2.0"},
+		}
+		err := cf.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), cf.CreatedBy.ID)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "custom_fields", map[string]interface{}{
+			"id":         cf.ID,
+			"project_id": 1,
+			"title":      "Release",
+			"type":       "select",
+		}, false)
+	})
+	t.Run("invalid type", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &CustomField{
+			ProjectID: 1,
+			Title:     "Invalid",
+			Type:      "color",
+		}
+		err := cf.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldType(err))
+	})
+	t.Run("select without options", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &CustomField{
+			ProjectID: 1,
+			Title:     "Empty",
+			Type:      CustomFieldTypeSelect,
+		}
+		err := cf.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrCustomFieldSelectNeedsOptions(err))
+	})
+}
+
+func TestCustomField_CanRead(t *testing.T) {
+	t.Run("field from another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &CustomField{ID: 4, ProjectID: 1}
+		can, _, err := cf.CanRead(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("no access to project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		cf := &CustomField{ID: 1, ProjectID: 1}
+		can, _, err := cf.CanRead(s, &user.User{ID: 13})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestTask_UpdateCustomFieldValues(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("set values", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:        1,
+			Title:     "test",
+			ProjectID: 1,
+			CustomFields: []*TaskCustomFieldValue{
+				{FieldID: 1, Value: nil},
+				{FieldID: 2, Value: float64(13)},
+				{FieldID: 3, Value: "staging"},
+			},
+		}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "task_custom_field_values", map[string]interface{}{
+			"task_id":  1,
+			"field_id": 1,
+		})
+		db.AssertExists(t, "task_custom_field_values", map[string]interface{}{
+			"task_id":      1,
+			"field_id":     2,
+			"number_value": 13,
+		}, false)
+		db.AssertExists(t, "task_custom_field_values", map[string]interface{}{
+			"task_id":    1,
+			"field_id":   3,
+			"text_value": "staging",
+		}, false)
+	})
+	t.Run("invalid select option", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:        1,
+			Title:     "test",
+			ProjectID: 1,
+			CustomFields: []*TaskCustomFieldValue{
+				{FieldID: 3, Value: "testing"},
+			},
+		}
+		err := task.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidCustomFieldValue(err))
+	})
+	t.Run("field from another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:        1,
+			Title:     "test",
+			ProjectID: 1,
+			CustomFields: []*TaskCustomFieldValue{
+				{FieldID: 4, Value: "ACME"},
+			},
+		}
+		err := task.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrCustomFieldDoesNotExist(err))
+	})
+}
+
+func TestTaskCollection_ReadAllWithCustomFields(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	getTaskIDs := func(t *testing.T, tc *TaskCollection) []int64 {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		result, _, _, err := tc.ReadAll(s, u, "", 1, 50)
+		assert.NoError(t, err)
+
+		ids := []int64{}
+		for _, task := range result.([]*Task) {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+
+	t.Run("filter", func(t *testing.T) {
+		ids := getTaskIDs(t, &TaskCollection{
+			ProjectID: 1,
+			Filter:    "custom_fields.2 > 4",
+		})
+		assert.Equal(t, []int64{1, 3}, ids)
+	})
+	t.Run("filter by select option", func(t *testing.T) {
+		ids := getTaskIDs(t, &TaskCollection{
+			ProjectID: 1,
+			Filter:    "custom_fields.3 = production",
+		})
+		assert.Equal(t, []int64{3}, ids)
+	})
+	t.Run("sort", func(t *testing.T) {
+		ids := getTaskIDs(t, &TaskCollection{
+			ProjectID: 1,
+			SortBy:    []string{"custom_fields.2", "id"},
+			OrderBy:   []string{"desc", "asc"},
+			Filter:    "custom_fields.2 > 0",
+		})
+		assert.Equal(t, []int64{1, 3, 2}, ids)
+	})
+	t.Run("field from another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{
+			ProjectID: 1,
+			Filter:    "custom_fields.999 = 1",
+		}
+		_, _, _, err := tc.ReadAll(s, u, "", 0, 50)
+		assert.Error(t, err)
+	})
+}
+
+func TestProjectDuplicate_CustomFields(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	files.InitTestFileFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	u := &user.User{ID: 1}
+	pd := &ProjectDuplicate{ProjectID: 1}
+	can, err := pd.CanCreate(s, u)
+	assert.NoError(t, err)
+	assert.True(t, can)
+	err = pd.Create(s, u)
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	db.AssertExists(t, "custom_fields", map[string]interface{}{
+		"project_id": pd.Project.ID,
+		"title":      "Customer",
+		"type":       "text",
+	}, false)
+	db.AssertExists(t, "custom_fields", map[string]interface{}{
+		"project_id": pd.Project.ID,
+		"title":      "Environment",
+		"type":       "select",
+	}, false)
+}
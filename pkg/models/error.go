@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/web"
@@ -992,6 +993,343 @@ func (err ErrReminderRelativeToMissing) HTTPError() web.HTTPError {
 	}
 }
 
+// ErrInvalidTaskFilterExpression represents an error where a task filter expression could not be parsed
+type ErrInvalidTaskFilterExpression struct {
+	Expression string
+	Reason     string
+}
+
+// IsErrInvalidTaskFilterExpression checks if an error is ErrInvalidTaskFilterExpression.
+func IsErrInvalidTaskFilterExpression(err error) bool {
+	_, ok := err.(*ErrInvalidTaskFilterExpression)
+	return ok
+}
+
+func (err *ErrInvalidTaskFilterExpression) Error() string {
+	return fmt.Sprintf("Task filter expression is invalid [Expression: %s, Reason: %s]", err.Expression, err.Reason)
+}
+
+// ErrCodeInvalidTaskFilterExpression holds the unique world-error code of this error
+const ErrCodeInvalidTaskFilterExpression = 4023
+
+// HTTPError holds the http error description
+func (err *ErrInvalidTaskFilterExpression) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTaskFilterExpression,
+		Message:  fmt.Sprintf("The task filter expression is invalid: %s", err.Reason),
+	}
+}
+
+// ErrTaskTimeEntryDoesNotExist represents an error where a task time entry does not exist
+type ErrTaskTimeEntryDoesNotExist struct {
+	ID     int64
+	TaskID int64
+}
+
+// IsErrTaskTimeEntryDoesNotExist checks if an error is ErrTaskTimeEntryDoesNotExist.
+func IsErrTaskTimeEntryDoesNotExist(err error) bool {
+	_, ok := err.(ErrTaskTimeEntryDoesNotExist)
+	return ok
+}
+
+func (err ErrTaskTimeEntryDoesNotExist) Error() string {
+	return fmt.Sprintf("Task time entry does not exist [ID: %d, TaskID: %d]", err.ID, err.TaskID)
+}
+
+// ErrCodeTaskTimeEntryDoesNotExist holds the unique world-error code of this error
+const ErrCodeTaskTimeEntryDoesNotExist = 4024
+
+// HTTPError holds the http error description
+func (err ErrTaskTimeEntryDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeTaskTimeEntryDoesNotExist,
+		Message:  "This task time entry does not exist",
+	}
+}
+
+// ErrInvalidTaskTimeEntryRange represents an error where a time entry ends before it starts
+type ErrInvalidTaskTimeEntryRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// IsErrInvalidTaskTimeEntryRange checks if an error is ErrInvalidTaskTimeEntryRange.
+func IsErrInvalidTaskTimeEntryRange(err error) bool {
+	_, ok := err.(ErrInvalidTaskTimeEntryRange)
+	return ok
+}
+
+func (err ErrInvalidTaskTimeEntryRange) Error() string {
+	return fmt.Sprintf("Task time entry ends before it starts [Start: %s, End: %s]", err.Start, err.End)
+}
+
+// ErrCodeInvalidTaskTimeEntryRange holds the unique world-error code of this error
+const ErrCodeInvalidTaskTimeEntryRange = 4025
+
+// HTTPError holds the http error description
+func (err ErrInvalidTaskTimeEntryRange) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTaskTimeEntryRange,
+		Message:  "A time entry needs a start date and cannot end before it starts.",
+	}
+}
+
+// ErrNoRunningTaskTimer represents an error where a user tries to stop a timer which is not running
+type ErrNoRunningTaskTimer struct {
+	TaskID int64
+	UserID int64
+}
+
+// IsErrNoRunningTaskTimer checks if an error is ErrNoRunningTaskTimer.
+func IsErrNoRunningTaskTimer(err error) bool {
+	_, ok := err.(ErrNoRunningTaskTimer)
+	return ok
+}
+
+func (err ErrNoRunningTaskTimer) Error() string {
+	return fmt.Sprintf("No running timer for this task [TaskID: %d, UserID: %d]", err.TaskID, err.UserID)
+}
+
+// ErrCodeNoRunningTaskTimer holds the unique world-error code of this error
+const ErrCodeNoRunningTaskTimer = 4026
+
+// HTTPError holds the http error description
+func (err ErrNoRunningTaskTimer) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeNoRunningTaskTimer,
+		Message:  "There is no running timer for this task.",
+	}
+}
+
+// ErrInvalidRepeatRule represents an error where a task has a recurrence rule which can't be parsed
+type ErrInvalidRepeatRule struct {
+	Rule string
+}
+
+// IsErrInvalidRepeatRule checks if an error is ErrInvalidRepeatRule.
+func IsErrInvalidRepeatRule(err error) bool {
+	_, ok := err.(ErrInvalidRepeatRule)
+	return ok
+}
+
+func (err ErrInvalidRepeatRule) Error() string {
+	return fmt.Sprintf("Invalid repeat rule [Rule: %s]", err.Rule)
+}
+
+// ErrCodeInvalidRepeatRule holds the unique world-error code of this error
+const ErrCodeInvalidRepeatRule = 4027
+
+// HTTPError holds the http error description
+func (err ErrInvalidRepeatRule) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidRepeatRule,
+		Message:  "The repeat rule is invalid or uses unsupported parts.",
+	}
+}
+
+// ErrTaskHasUndoneBlockers represents an error where a task is marked as done while tasks blocking it are not done
+type ErrTaskHasUndoneBlockers struct {
+	TaskID     int64
+	BlockerIDs []int64
+}
+
+// IsErrTaskHasUndoneBlockers checks if an error is ErrTaskHasUndoneBlockers.
+func IsErrTaskHasUndoneBlockers(err error) bool {
+	_, ok := err.(ErrTaskHasUndoneBlockers)
+	return ok
+}
+
+func (err ErrTaskHasUndoneBlockers) Error() string {
+	return fmt.Sprintf("Task is blocked by tasks which are not done [TaskID: %d, BlockerIDs: %v]", err.TaskID, err.BlockerIDs)
+}
+
+// ErrCodeTaskHasUndoneBlockers holds the unique world-error code of this error
+const ErrCodeTaskHasUndoneBlockers = 4028
+
+// HTTPError holds the http error description
+func (err ErrTaskHasUndoneBlockers) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeTaskHasUndoneBlockers,
+		Message:  "This task cannot be marked as done while it is blocked by tasks which are not done yet.",
+	}
+}
+
+// ErrInvalidTaskReschedule represents an error where a task is rescheduled without dates or to end before it starts
+type ErrInvalidTaskReschedule struct {
+	TaskID    int64
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// IsErrInvalidTaskReschedule checks if an error is ErrInvalidTaskReschedule.
+func IsErrInvalidTaskReschedule(err error) bool {
+	_, ok := err.(ErrInvalidTaskReschedule)
+	return ok
+}
+
+func (err ErrInvalidTaskReschedule) Error() string {
+	return fmt.Sprintf("Invalid task reschedule [TaskID: %d, Start: %s, End: %s]", err.TaskID, err.StartDate, err.EndDate)
+}
+
+// ErrCodeInvalidTaskReschedule holds the unique world-error code of this error
+const ErrCodeInvalidTaskReschedule = 4029
+
+// HTTPError holds the http error description
+func (err ErrInvalidTaskReschedule) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTaskReschedule,
+		Message:  "A task needs a start or end date to be rescheduled and cannot end before it starts.",
+	}
+}
+
+// ErrInvalidPreviewSize represents an error where an attachment preview is requested in a size which does not exist
+type ErrInvalidPreviewSize struct {
+	Size string
+}
+
+// IsErrInvalidPreviewSize checks if an error is ErrInvalidPreviewSize.
+func IsErrInvalidPreviewSize(err error) bool {
+	_, ok := err.(ErrInvalidPreviewSize)
+	return ok
+}
+
+func (err ErrInvalidPreviewSize) Error() string {
+	return fmt.Sprintf("Invalid preview size [Size: %s]", err.Size)
+}
+
+// ErrCodeInvalidPreviewSize holds the unique world-error code of this error
+const ErrCodeInvalidPreviewSize = 4030
+
+// HTTPError holds the http error description
+func (err ErrInvalidPreviewSize) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidPreviewSize,
+		Message:  "The preview size is invalid. It must be one of sm, md or lg.",
+	}
+}
+
+// ErrTaskAttachmentUploadDoesNotExist represents an error where a resumable attachment upload does not exist
+type ErrTaskAttachmentUploadDoesNotExist struct {
+	UploadID int64
+	TaskID   int64
+}
+
+// IsErrTaskAttachmentUploadDoesNotExist checks if an error is ErrTaskAttachmentUploadDoesNotExist.
+func IsErrTaskAttachmentUploadDoesNotExist(err error) bool {
+	_, ok := err.(ErrTaskAttachmentUploadDoesNotExist)
+	return ok
+}
+
+func (err ErrTaskAttachmentUploadDoesNotExist) Error() string {
+	return fmt.Sprintf("Task attachment upload does not exist [UploadID: %d, TaskID: %d]", err.UploadID, err.TaskID)
+}
+
+// ErrCodeTaskAttachmentUploadDoesNotExist holds the unique world-error code of this error
+const ErrCodeTaskAttachmentUploadDoesNotExist = 4031
+
+// HTTPError holds the http error description
+func (err ErrTaskAttachmentUploadDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeTaskAttachmentUploadDoesNotExist,
+		Message:  "This upload does not exist or it expired.",
+	}
+}
+
+// ErrTaskAttachmentUploadOffsetMismatch represents an error where a chunk does not start where the upload currently ends
+type ErrTaskAttachmentUploadOffsetMismatch struct {
+	UploadID int64
+	Expected uint64
+	Offset   uint64
+}
+
+// IsErrTaskAttachmentUploadOffsetMismatch checks if an error is ErrTaskAttachmentUploadOffsetMismatch.
+func IsErrTaskAttachmentUploadOffsetMismatch(err error) bool {
+	_, ok := err.(ErrTaskAttachmentUploadOffsetMismatch)
+	return ok
+}
+
+func (err ErrTaskAttachmentUploadOffsetMismatch) Error() string {
+	return fmt.Sprintf("Task attachment upload chunk has the wrong offset [UploadID: %d, Expected: %d, Offset: %d]", err.UploadID, err.Expected, err.Offset)
+}
+
+// ErrCodeTaskAttachmentUploadOffsetMismatch holds the unique world-error code of this error
+const ErrCodeTaskAttachmentUploadOffsetMismatch = 4032
+
+// HTTPError holds the http error description
+func (err ErrTaskAttachmentUploadOffsetMismatch) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusConflict,
+		Code:     ErrCodeTaskAttachmentUploadOffsetMismatch,
+		Message:  fmt.Sprintf("The chunk needs to start at offset %d.", err.Expected),
+	}
+}
+
+// ErrTaskAttachmentUploadTooLarge represents an error where more bytes are uploaded than the upload was created with
+type ErrTaskAttachmentUploadTooLarge struct {
+	UploadID int64
+	Size     uint64
+}
+
+// IsErrTaskAttachmentUploadTooLarge checks if an error is ErrTaskAttachmentUploadTooLarge.
+func IsErrTaskAttachmentUploadTooLarge(err error) bool {
+	_, ok := err.(ErrTaskAttachmentUploadTooLarge)
+	return ok
+}
+
+func (err ErrTaskAttachmentUploadTooLarge) Error() string {
+	return fmt.Sprintf("Task attachment upload chunk exceeds the size of the upload [UploadID: %d, Size: %d]", err.UploadID, err.Size)
+}
+
+// ErrCodeTaskAttachmentUploadTooLarge holds the unique world-error code of this error
+const ErrCodeTaskAttachmentUploadTooLarge = 4033
+
+// HTTPError holds the http error description
+func (err ErrTaskAttachmentUploadTooLarge) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeTaskAttachmentUploadTooLarge,
+		Message:  "The chunk is larger than what is left of the upload.",
+	}
+}
+
+// ErrTaskAttachmentUploadIncomplete represents an error where an upload is finished before all chunks were uploaded
+type ErrTaskAttachmentUploadIncomplete struct {
+	UploadID int64
+	Offset   uint64
+	Size     uint64
+}
+
+// IsErrTaskAttachmentUploadIncomplete checks if an error is ErrTaskAttachmentUploadIncomplete.
+func IsErrTaskAttachmentUploadIncomplete(err error) bool {
+	_, ok := err.(ErrTaskAttachmentUploadIncomplete)
+	return ok
+}
+
+func (err ErrTaskAttachmentUploadIncomplete) Error() string {
+	return fmt.Sprintf("Task attachment upload is not complete [UploadID: %d, Offset: %d, Size: %d]", err.UploadID, err.Offset, err.Size)
+}
+
+// ErrCodeTaskAttachmentUploadIncomplete holds the unique world-error code of this error
+const ErrCodeTaskAttachmentUploadIncomplete = 4034
+
+// HTTPError holds the http error description
+func (err ErrTaskAttachmentUploadIncomplete) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusPreconditionFailed,
+		Code:     ErrCodeTaskAttachmentUploadIncomplete,
+		Message:  "The upload can only be finished once all of the file was uploaded.",
+	}
+}
+
 // ============
 // Team errors
 // ============
@@ -1453,6 +1791,62 @@ func (err *ErrOnlyOneDoneBucketPerProject) HTTPError() web.HTTPError {
 	}
 }
 
+// ErrInvalidBucketMoveAction represents an error where the move action of a bucket is invalid.
+type ErrInvalidBucketMoveAction struct {
+	BucketID int64
+	Field    string
+	Value    string
+}
+
+// IsErrInvalidBucketMoveAction checks if an error is ErrInvalidBucketMoveAction.
+func IsErrInvalidBucketMoveAction(err error) bool {
+	_, ok := err.(*ErrInvalidBucketMoveAction)
+	return ok
+}
+
+func (err *ErrInvalidBucketMoveAction) Error() string {
+	return fmt.Sprintf("Bucket move action is invalid [BucketID: %d, Field: %s, Value: %s]", err.BucketID, err.Field, err.Value)
+}
+
+// ErrCodeInvalidBucketMoveAction holds the unique world-error code of this error
+const ErrCodeInvalidBucketMoveAction = 10006
+
+// HTTPError holds the http error description
+func (err *ErrInvalidBucketMoveAction) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidBucketMoveAction,
+		Message:  fmt.Sprintf("The move action of this bucket is invalid. Tasks moved into the bucket cannot set '%s' to '%s'.", err.Field, err.Value),
+	}
+}
+
+// ErrInvalidBucketConfigurationMode represents an error where a kanban view has an invalid bucket configuration mode.
+type ErrInvalidBucketConfigurationMode struct {
+	Mode BucketConfigurationMode
+}
+
+// IsErrInvalidBucketConfigurationMode checks if an error is ErrInvalidBucketConfigurationMode.
+func IsErrInvalidBucketConfigurationMode(err error) bool {
+	_, ok := err.(*ErrInvalidBucketConfigurationMode)
+	return ok
+}
+
+func (err *ErrInvalidBucketConfigurationMode) Error() string {
+	return fmt.Sprintf("Bucket configuration mode is invalid [Mode: %s]", err.Mode)
+}
+
+// ErrCodeInvalidBucketConfigurationMode holds the unique world-error code of this error
+const ErrCodeInvalidBucketConfigurationMode = 10007
+
+// HTTPError holds the http error description
+func (err *ErrInvalidBucketConfigurationMode) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidBucketConfigurationMode,
+		Message:  "The bucket configuration mode is invalid. It must be either 'manual' or 'filter'.",
+	}
+}
+
 // =============
 // Saved Filters
 // =============
@@ -1713,3 +2107,511 @@ func (err ErrInvalidAPITokenPermission) HTTPError() web.HTTPError {
 		Message:  fmt.Sprintf("The permission %s of group %s is invalid.", err.Permission, err.Group),
 	}
 }
+
+// ==============
+// Webhook Errors
+// ==============
+
+// ErrInvalidWebhookEvent represents an error where a webhook event is invalid
+type ErrInvalidWebhookEvent struct {
+	EventName string
+}
+
+// IsErrInvalidWebhookEvent checks if an error is ErrInvalidWebhookEvent.
+func IsErrInvalidWebhookEvent(err error) bool {
+	_, ok := err.(*ErrInvalidWebhookEvent)
+	return ok
+}
+
+func (err *ErrInvalidWebhookEvent) Error() string {
+	return fmt.Sprintf("Webhook event %s is invalid", err.EventName)
+}
+
+// ErrCodeInvalidWebhookEvent holds the unique world-error code of this error
+const ErrCodeInvalidWebhookEvent = 15001
+
+// HTTPError holds the http error description
+func (err ErrInvalidWebhookEvent) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidWebhookEvent,
+		Message:  fmt.Sprintf("The webhook event %s is invalid.", err.EventName),
+	}
+}
+
+// ErrWebhookDoesNotExist represents an error where a webhook does not exist
+type ErrWebhookDoesNotExist struct {
+	WebhookID int64
+}
+
+// IsErrWebhookDoesNotExist checks if an error is ErrWebhookDoesNotExist.
+func IsErrWebhookDoesNotExist(err error) bool {
+	_, ok := err.(*ErrWebhookDoesNotExist)
+	return ok
+}
+
+func (err *ErrWebhookDoesNotExist) Error() string {
+	return fmt.Sprintf("Webhook does not exist [WebhookID: %d]", err.WebhookID)
+}
+
+// ErrCodeWebhookDoesNotExist holds the unique world-error code of this error
+const ErrCodeWebhookDoesNotExist = 15002
+
+// HTTPError holds the http error description
+func (err ErrWebhookDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeWebhookDoesNotExist,
+		Message:  "This webhook does not exist.",
+	}
+}
+
+// ErrWebhookTargetNotAllowed represents an error where a webhook target url points to an address which is not allowed
+type ErrWebhookTargetNotAllowed struct {
+	TargetURL string
+}
+
+// IsErrWebhookTargetNotAllowed checks if an error is ErrWebhookTargetNotAllowed.
+func IsErrWebhookTargetNotAllowed(err error) bool {
+	_, ok := err.(*ErrWebhookTargetNotAllowed)
+	return ok
+}
+
+func (err *ErrWebhookTargetNotAllowed) Error() string {
+	return fmt.Sprintf("Webhook target is not allowed [TargetURL: %s]", err.TargetURL)
+}
+
+// ErrCodeWebhookTargetNotAllowed holds the unique world-error code of this error
+const ErrCodeWebhookTargetNotAllowed = 15003
+
+// HTTPError holds the http error description
+func (err *ErrWebhookTargetNotAllowed) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeWebhookTargetNotAllowed,
+		Message:  "The webhook target url must be a http or https url of a public address.",
+	}
+}
+
+// ===================
+// Custom Field Errors
+// ===================
+
+// ErrCustomFieldDoesNotExist represents an error where a custom field does not exist
+type ErrCustomFieldDoesNotExist struct {
+	CustomFieldID int64
+}
+
+// IsErrCustomFieldDoesNotExist checks if an error is ErrCustomFieldDoesNotExist.
+func IsErrCustomFieldDoesNotExist(err error) bool {
+	_, ok := err.(*ErrCustomFieldDoesNotExist)
+	return ok
+}
+
+func (err *ErrCustomFieldDoesNotExist) Error() string {
+	return fmt.Sprintf("Custom field does not exist [CustomFieldID: %d]", err.CustomFieldID)
+}
+
+// ErrCodeCustomFieldDoesNotExist holds the unique world-error code of this error
+const ErrCodeCustomFieldDoesNotExist = 16001
+
+// HTTPError holds the http error description
+func (err *ErrCustomFieldDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeCustomFieldDoesNotExist,
+		Message:  "This custom field does not exist.",
+	}
+}
+
+// ErrInvalidCustomFieldType represents an error where a custom field has an invalid type
+type ErrInvalidCustomFieldType struct {
+	Type CustomFieldType
+}
+
+// IsErrInvalidCustomFieldType checks if an error is ErrInvalidCustomFieldType.
+func IsErrInvalidCustomFieldType(err error) bool {
+	_, ok := err.(*ErrInvalidCustomFieldType)
+	return ok
+}
+
+func (err *ErrInvalidCustomFieldType) Error() string {
+	return fmt.Sprintf("Custom field type is invalid [Type: %s]", err.Type)
+}
+
+// ErrCodeInvalidCustomFieldType holds the unique world-error code of this error
+const ErrCodeInvalidCustomFieldType = 16002
+
+// HTTPError holds the http error description
+func (err *ErrInvalidCustomFieldType) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidCustomFieldType,
+		Message:  fmt.Sprintf("The custom field type %s is invalid.", err.Type),
+	}
+}
+
+// ErrInvalidCustomFieldValue represents an error where a value does not match the type of its custom field
+type ErrInvalidCustomFieldValue struct {
+	CustomFieldID int64
+	Value         interface{}
+}
+
+// IsErrInvalidCustomFieldValue checks if an error is ErrInvalidCustomFieldValue.
+func IsErrInvalidCustomFieldValue(err error) bool {
+	_, ok := err.(*ErrInvalidCustomFieldValue)
+	return ok
+}
+
+func (err *ErrInvalidCustomFieldValue) Error() string {
+	return fmt.Sprintf("Custom field value is invalid [CustomFieldID: %d, Value: %v]", err.CustomFieldID, err.Value)
+}
+
+// ErrCodeInvalidCustomFieldValue holds the unique world-error code of this error
+const ErrCodeInvalidCustomFieldValue = 16003
+
+// HTTPError holds the http error description
+func (err *ErrInvalidCustomFieldValue) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidCustomFieldValue,
+		Message:  fmt.Sprintf("The value %v is invalid for custom field %d.", err.Value, err.CustomFieldID),
+	}
+}
+
+// ErrCustomFieldSelectNeedsOptions represents an error where a select custom field has no options
+type ErrCustomFieldSelectNeedsOptions struct{}
+
+// IsErrCustomFieldSelectNeedsOptions checks if an error is ErrCustomFieldSelectNeedsOptions.
+func IsErrCustomFieldSelectNeedsOptions(err error) bool {
+	_, ok := err.(*ErrCustomFieldSelectNeedsOptions)
+	return ok
+}
+
+func (err *ErrCustomFieldSelectNeedsOptions) Error() string {
+	return "Select custom field needs options"
+}
+
+// ErrCodeCustomFieldSelectNeedsOptions holds the unique world-error code of this error
+const ErrCodeCustomFieldSelectNeedsOptions = 16004
+
+// HTTPError holds the http error description
+func (err *ErrCustomFieldSelectNeedsOptions) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeCustomFieldSelectNeedsOptions,
+		Message:  "A select custom field needs at least one option.",
+	}
+}
+
+// ===================
+// Project View Errors
+// ===================
+
+// ErrProjectViewDoesNotExist represents an error where a project view does not exist
+type ErrProjectViewDoesNotExist struct {
+	ProjectViewID int64
+}
+
+// IsErrProjectViewDoesNotExist checks if an error is ErrProjectViewDoesNotExist.
+func IsErrProjectViewDoesNotExist(err error) bool {
+	_, ok := err.(*ErrProjectViewDoesNotExist)
+	return ok
+}
+
+func (err *ErrProjectViewDoesNotExist) Error() string {
+	return fmt.Sprintf("Project view does not exist [ProjectViewID: %d]", err.ProjectViewID)
+}
+
+// ErrCodeProjectViewDoesNotExist holds the unique world-error code of this error
+const ErrCodeProjectViewDoesNotExist = 17001
+
+// HTTPError holds the http error description
+func (err *ErrProjectViewDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeProjectViewDoesNotExist,
+		Message:  "This project view does not exist.",
+	}
+}
+
+// ErrInvalidProjectViewKind represents an error where a project view has an invalid kind
+type ErrInvalidProjectViewKind struct {
+	Kind ProjectViewKind
+}
+
+// IsErrInvalidProjectViewKind checks if an error is ErrInvalidProjectViewKind.
+func IsErrInvalidProjectViewKind(err error) bool {
+	_, ok := err.(*ErrInvalidProjectViewKind)
+	return ok
+}
+
+func (err *ErrInvalidProjectViewKind) Error() string {
+	return fmt.Sprintf("Project view kind is invalid [Kind: %s]", err.Kind)
+}
+
+// ErrCodeInvalidProjectViewKind holds the unique world-error code of this error
+const ErrCodeInvalidProjectViewKind = 17002
+
+// HTTPError holds the http error description
+func (err *ErrInvalidProjectViewKind) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidProjectViewKind,
+		Message:  fmt.Sprintf("The project view kind %s is invalid, it must be one of list, gantt, table or kanban.", err.Kind),
+	}
+}
+
+// ErrProjectViewHasNoBuckets represents an error where buckets are used with a project view which is not a kanban view
+type ErrProjectViewHasNoBuckets struct {
+	ProjectViewID int64
+}
+
+// IsErrProjectViewHasNoBuckets checks if an error is ErrProjectViewHasNoBuckets.
+func IsErrProjectViewHasNoBuckets(err error) bool {
+	_, ok := err.(*ErrProjectViewHasNoBuckets)
+	return ok
+}
+
+func (err *ErrProjectViewHasNoBuckets) Error() string {
+	return fmt.Sprintf("Project view is not a kanban view and has no buckets [ProjectViewID: %d]", err.ProjectViewID)
+}
+
+// ErrCodeProjectViewHasNoBuckets holds the unique world-error code of this error
+const ErrCodeProjectViewHasNoBuckets = 17003
+
+// HTTPError holds the http error description
+func (err *ErrProjectViewHasNoBuckets) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeProjectViewHasNoBuckets,
+		Message:  "Only kanban views can have buckets.",
+	}
+}
+
+// ErrBucketDoesNotBelongToProjectView represents an error where a bucket is used with a project view it does not belong to
+type ErrBucketDoesNotBelongToProjectView struct {
+	BucketID      int64
+	ProjectViewID int64
+}
+
+// IsErrBucketDoesNotBelongToProjectView checks if an error is ErrBucketDoesNotBelongToProjectView.
+func IsErrBucketDoesNotBelongToProjectView(err error) bool {
+	_, ok := err.(*ErrBucketDoesNotBelongToProjectView)
+	return ok
+}
+
+func (err *ErrBucketDoesNotBelongToProjectView) Error() string {
+	return fmt.Sprintf("Bucket does not belong to project view [BucketID: %d, ProjectViewID: %d]", err.BucketID, err.ProjectViewID)
+}
+
+// ErrCodeBucketDoesNotBelongToProjectView holds the unique world-error code of this error
+const ErrCodeBucketDoesNotBelongToProjectView = 17004
+
+// HTTPError holds the http error description
+func (err *ErrBucketDoesNotBelongToProjectView) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeBucketDoesNotBelongToProjectView,
+		Message:  "This bucket does not belong to that project view.",
+	}
+}
+
+// ======================
+// Automation Rule Errors
+// ======================
+
+// ErrAutomationRuleDoesNotExist represents an error where an automation rule does not exist
+type ErrAutomationRuleDoesNotExist struct {
+	AutomationRuleID int64
+}
+
+// IsErrAutomationRuleDoesNotExist checks if an error is ErrAutomationRuleDoesNotExist.
+func IsErrAutomationRuleDoesNotExist(err error) bool {
+	_, ok := err.(*ErrAutomationRuleDoesNotExist)
+	return ok
+}
+
+func (err *ErrAutomationRuleDoesNotExist) Error() string {
+	return fmt.Sprintf("Automation rule does not exist [AutomationRuleID: %d]", err.AutomationRuleID)
+}
+
+// ErrCodeAutomationRuleDoesNotExist holds the unique world-error code of this error
+const ErrCodeAutomationRuleDoesNotExist = 18001
+
+// HTTPError holds the http error description
+func (err *ErrAutomationRuleDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeAutomationRuleDoesNotExist,
+		Message:  "This automation rule does not exist.",
+	}
+}
+
+// ErrInvalidAutomationTrigger represents an error where the trigger of an automation rule is invalid
+type ErrInvalidAutomationTrigger struct {
+	TriggerEvent    string
+	TriggerSchedule string
+}
+
+// IsErrInvalidAutomationTrigger checks if an error is ErrInvalidAutomationTrigger.
+func IsErrInvalidAutomationTrigger(err error) bool {
+	_, ok := err.(*ErrInvalidAutomationTrigger)
+	return ok
+}
+
+func (err *ErrInvalidAutomationTrigger) Error() string {
+	return fmt.Sprintf("Automation rule trigger is invalid [TriggerEvent: %s, TriggerSchedule: %s]", err.TriggerEvent, err.TriggerSchedule)
+}
+
+// ErrCodeInvalidAutomationTrigger holds the unique world-error code of this error
+const ErrCodeInvalidAutomationTrigger = 18002
+
+// HTTPError holds the http error description
+func (err *ErrInvalidAutomationTrigger) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidAutomationTrigger,
+		Message:  "An automation rule needs exactly one trigger, either one of the available events or a valid cron schedule.",
+	}
+}
+
+// ErrInvalidAutomationAction represents an error where an action of an automation rule is invalid
+type ErrInvalidAutomationAction struct {
+	Type  AutomationActionType
+	Field string
+	Value string
+}
+
+// IsErrInvalidAutomationAction checks if an error is ErrInvalidAutomationAction.
+func IsErrInvalidAutomationAction(err error) bool {
+	_, ok := err.(*ErrInvalidAutomationAction)
+	return ok
+}
+
+func (err *ErrInvalidAutomationAction) Error() string {
+	return fmt.Sprintf("Automation rule action is invalid [Type: %s, Field: %s, Value: %s]", err.Type, err.Field, err.Value)
+}
+
+// ErrCodeInvalidAutomationAction holds the unique world-error code of this error
+const ErrCodeInvalidAutomationAction = 18003
+
+// HTTPError holds the http error description
+func (err *ErrInvalidAutomationAction) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidAutomationAction,
+		Message:  fmt.Sprintf("The automation action %s with value '%s' is invalid.", err.Type, err.Value),
+	}
+}
+
+// ErrAutomationRuleHasNoActions represents an error where an automation rule does not have any actions
+type ErrAutomationRuleHasNoActions struct{}
+
+// IsErrAutomationRuleHasNoActions checks if an error is ErrAutomationRuleHasNoActions.
+func IsErrAutomationRuleHasNoActions(err error) bool {
+	_, ok := err.(*ErrAutomationRuleHasNoActions)
+	return ok
+}
+
+func (err *ErrAutomationRuleHasNoActions) Error() string {
+	return "Automation rule has no actions"
+}
+
+// ErrCodeAutomationRuleHasNoActions holds the unique world-error code of this error
+const ErrCodeAutomationRuleHasNoActions = 18004
+
+// HTTPError holds the http error description
+func (err *ErrAutomationRuleHasNoActions) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeAutomationRuleHasNoActions,
+		Message:  "An automation rule needs at least one action.",
+	}
+}
+
+// ===============
+// Template Errors
+// ===============
+
+// ErrTaskTemplateDoesNotExist represents an error where a task template does not exist
+type ErrTaskTemplateDoesNotExist struct {
+	TaskTemplateID int64
+}
+
+// IsErrTaskTemplateDoesNotExist checks if an error is ErrTaskTemplateDoesNotExist.
+func IsErrTaskTemplateDoesNotExist(err error) bool {
+	_, ok := err.(*ErrTaskTemplateDoesNotExist)
+	return ok
+}
+
+func (err *ErrTaskTemplateDoesNotExist) Error() string {
+	return fmt.Sprintf("Task template does not exist [TaskTemplateID: %d]", err.TaskTemplateID)
+}
+
+// ErrCodeTaskTemplateDoesNotExist holds the unique world-error code of this error
+const ErrCodeTaskTemplateDoesNotExist = 19001
+
+// HTTPError holds the http error description
+func (err *ErrTaskTemplateDoesNotExist) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeTaskTemplateDoesNotExist,
+		Message:  "This task template does not exist.",
+	}
+}
+
+// ErrInvalidTaskTemplateReminder represents an error where a reminder of a task template is not relative to a date
+// of the template
+type ErrInvalidTaskTemplateReminder struct {
+	RelativeTo ReminderRelation
+}
+
+// IsErrInvalidTaskTemplateReminder checks if an error is ErrInvalidTaskTemplateReminder.
+func IsErrInvalidTaskTemplateReminder(err error) bool {
+	_, ok := err.(*ErrInvalidTaskTemplateReminder)
+	return ok
+}
+
+func (err *ErrInvalidTaskTemplateReminder) Error() string {
+	return fmt.Sprintf("Task template reminder is invalid [RelativeTo: %s]", err.RelativeTo)
+}
+
+// ErrCodeInvalidTaskTemplateReminder holds the unique world-error code of this error
+const ErrCodeInvalidTaskTemplateReminder = 19002
+
+// HTTPError holds the http error description
+func (err *ErrInvalidTaskTemplateReminder) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeInvalidTaskTemplateReminder,
+		Message:  "A task template reminder must be relative to a date which is set in the template.",
+	}
+}
+
+// ErrProjectIsNotATemplate represents an error where a project is used as a template but is not marked as one
+type ErrProjectIsNotATemplate struct {
+	ProjectID int64
+}
+
+// IsErrProjectIsNotATemplate checks if an error is ErrProjectIsNotATemplate.
+func IsErrProjectIsNotATemplate(err error) bool {
+	_, ok := err.(*ErrProjectIsNotATemplate)
+	return ok
+}
+
+func (err *ErrProjectIsNotATemplate) Error() string {
+	return fmt.Sprintf("Project is not a template [ProjectID: %d]", err.ProjectID)
+}
+
+// ErrCodeProjectIsNotATemplate holds the unique world-error code of this error
+const ErrCodeProjectIsNotATemplate = 19003
+
+// HTTPError holds the http error description
+func (err *ErrProjectIsNotATemplate) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusBadRequest,
+		Code:     ErrCodeProjectIsNotATemplate,
+		Message:  "This project is not a template.",
+	}
+}
@@ -97,7 +97,7 @@ func ExportUserData(s *xorm.Session, u *user.User) (err error) {
 		return err
 	}
 
-	exportFile, err := files.CreateWithMimeAndSession(s, exported, tmpFilename, uint64(stat.Size()), u, "application/zip", false)
+	exportFile, err := files.CreateWithMimeAndSession(s, exported, tmpFilename, uint64(stat.Size()), u, "application/zip", 0, false)
 	if err != nil {
 		return err
 	}
@@ -191,12 +191,46 @@ func exportProjectsAndTasks(s *xorm.Session, u *user.User, wr *zip.Writer) (task
 		taskMap[c.TaskID].Comments = append(taskMap[c.TaskID].Comments, c)
 	}
 
+	timeEntries, err := getTaskTimeEntriesByTaskIDs(s, taskIDs)
+	if err != nil {
+		return
+	}
+
+	for _, te := range timeEntries {
+		taskMap[te.TaskID].TimeEntries = append(taskMap[te.TaskID].TimeEntries, te)
+	}
+
+	// Only the buckets of the first kanban view of each project are exported
+	kanbanViewIDs := []int64{}
+	for _, p := range rawProjects {
+		view, err := getFirstKanbanViewForProject(s, p.ID)
+		if err != nil {
+			return taskIDs, err
+		}
+		if view != nil {
+			kanbanViewIDs = append(kanbanViewIDs, view.ID)
+		}
+	}
+
 	buckets := []*Bucket{}
-	err = s.In("project_id", projectIDs).Find(&buckets)
+	err = s.In("project_view_id", kanbanViewIDs).Find(&buckets)
+	if err != nil {
+		return
+	}
+
+	taskBuckets := []*TaskBucket{}
+	err = s.In("project_view_id", kanbanViewIDs).Find(&taskBuckets)
 	if err != nil {
 		return
 	}
 
+	for _, tb := range taskBuckets {
+		if _, exists := taskMap[tb.TaskID]; !exists {
+			continue
+		}
+		taskMap[tb.TaskID].BucketID = tb.BucketID
+	}
+
 	for _, b := range buckets {
 		if _, exists := projectsMap[b.ProjectID]; !exists {
 			log.Debugf("[User Data Export] Project %d does not exist for bucket %d, omitting", b.ProjectID, b.ID)
@@ -0,0 +1,278 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"code.vikunja.io/api/pkg/user"
+
+	"code.vikunja.io/web"
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// HistoryEntityKind is the kind of entity a history entry belongs to
+type HistoryEntityKind string
+
+const (
+	// HistoryEntityKindTask is a change of a task
+	HistoryEntityKindTask HistoryEntityKind = "task"
+	// HistoryEntityKindProject is a change of a project
+	HistoryEntityKindProject HistoryEntityKind = "project"
+	// HistoryEntityKindBucket is a change of a kanban bucket
+	HistoryEntityKindBucket HistoryEntityKind = "bucket"
+	// HistoryEntityKindProjectUser is a change of a user share of a project
+	HistoryEntityKindProjectUser HistoryEntityKind = "project_user"
+	// HistoryEntityKindProjectTeam is a change of a team share of a project
+	HistoryEntityKindProjectTeam HistoryEntityKind = "project_team"
+	// HistoryEntityKindLinkShare is a change of a link share of a project
+	HistoryEntityKindLinkShare HistoryEntityKind = "link_share"
+	// HistoryEntityKindTeamMember is a change of the members of a team
+	HistoryEntityKindTeamMember HistoryEntityKind = "team_member"
+)
+
+// HistoryAction is what happened to an entity
+type HistoryAction string
+
+const (
+	// HistoryActionCreated means the entity was created
+	HistoryActionCreated HistoryAction = "created"
+	// HistoryActionUpdated means a field of the entity was changed
+	HistoryActionUpdated HistoryAction = "updated"
+	// HistoryActionDeleted means the entity was deleted
+	HistoryActionDeleted HistoryAction = "deleted"
+)
+
+// HistoryEntry is one change of a task, project, bucket, share or team membership
+type HistoryEntry struct {
+	// The unique, numeric id of this history entry.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id"`
+	// The kind of entity which was changed. Can be one of `task`, `project`, `bucket`, `project_user`, `project_team`, `link_share` or `team_member`.
+	EntityKind HistoryEntityKind `xorm:"varchar(50) not null index(entity)" json:"entity_kind"`
+	// The id of the entity which was changed. For user and team shares and team memberships this is the id of the user or team.
+	EntityID int64 `xorm:"bigint not null index(entity)" json:"entity_id"`
+	// What happened to the entity. Can be one of `created`, `updated` or `deleted`.
+	Action HistoryAction `xorm:"varchar(50) not null" json:"action"`
+	// The field which was changed. Only set for updates.
+	Field string `xorm:"varchar(250) null" json:"field"`
+	// The value of the field before the change.
+	OldValue string `xorm:"text null" json:"old_value"`
+	// The value of the field after the change.
+	NewValue string `xorm:"text null" json:"new_value"`
+
+	// The task the change belongs to, if any.
+	TaskID int64 `xorm:"bigint not null default 0 index" json:"task_id" param:"task"`
+	// The project the change belongs to, if any.
+	ProjectID int64 `xorm:"bigint not null default 0 index" json:"project_id" param:"project"`
+	// The team the change belongs to, if any.
+	TeamID int64 `xorm:"bigint not null default 0 index" json:"team_id"`
+
+	// The user who made the change.
+	Doer   *user.User `xorm:"-" json:"doer"`
+	DoerID int64      `xorm:"bigint not null" json:"-"`
+
+	// A timestamp when the change was made.
+	Created time.Time `xorm:"created not null index" json:"created"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for history entries
+func (*HistoryEntry) TableName() string {
+	return "history_entries"
+}
+
+func getHistoryDoerID(a web.Auth) int64 {
+	if share, is := a.(*LinkSharing); is {
+		return share.getUserID()
+	}
+	if a == nil {
+		return 0
+	}
+	return a.GetID()
+}
+
+func addHistoryEntries(s *xorm.Session, a web.Auth, entries ...*HistoryEntry) (err error) {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	doerID := getHistoryDoerID(a)
+	for _, e := range entries {
+		e.DoerID = doerID
+	}
+
+	_, err = s.Insert(entries)
+	return
+}
+
+// historyEntriesForChanges creates one update history entry for every field which differs between old and new.
+// The fields are referenced by their json name.
+func historyEntriesForChanges(template HistoryEntry, old, new interface{}, fields []string) (entries []*HistoryEntry) {
+	oldValue := reflect.Indirect(reflect.ValueOf(old))
+	newValue := reflect.Indirect(reflect.ValueOf(new))
+	t := oldValue.Type()
+
+	for _, field := range fields {
+		for i := 0; i < t.NumField(); i++ {
+			jsonName := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			if jsonName != field {
+				continue
+			}
+
+			o := formatHistoryValue(oldValue.Field(i).Interface())
+			n := formatHistoryValue(newValue.Field(i).Interface())
+			if o != n {
+				entry := template
+				entry.Action = HistoryActionUpdated
+				entry.Field = field
+				entry.OldValue = o
+				entry.NewValue = n
+				entries = append(entries, &entry)
+			}
+			break
+		}
+	}
+
+	return
+}
+
+func formatHistoryValue(value interface{}) string {
+	switch v := value.(type) {
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339)
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Ptr, reflect.Struct, reflect.Slice, reflect.Map:
+		if reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil() {
+			return ""
+		}
+		j, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(j)
+	}
+
+	return fmt.Sprint(value)
+}
+
+// ReadAll returns the change history of a task or project
+// @Summary Get the change history of a task or project
+// @Description Returns all changes made to a task or project, newest first. The history of a project includes the changes of its tasks, buckets and shares as well as membership changes of teams the project is shared with.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. This parameter is limited by the configured maximum of items per page."
+// @Param task path int true "Task ID"
+// @Param project path int true "Project ID"
+// @Success 200 {array} models.HistoryEntry "The history entries"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task or project."
+// @Failure 404 {object} web.HTTPError "The task or project does not exist."
+// @Failure 500 {object} models.Message "Internal server error"
+// @Router /tasks/{task}/history [get]
+// @Router /projects/{project}/history [get]
+func (h *HistoryEntry) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	var cond builder.Cond
+	if h.TaskID != 0 {
+		cond, err = getTaskHistoryCond(s, a, h.TaskID)
+	} else {
+		cond, err = getProjectHistoryCond(s, a, h.ProjectID)
+	}
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	entries := []*HistoryEntry{}
+	err = s.
+		Where(cond).
+		OrderBy("created desc, id desc").
+		Limit(getLimitFromPageIndex(page, perPage)).
+		Find(&entries)
+	if err != nil {
+		return
+	}
+
+	doerIDs := make([]int64, 0, len(entries))
+	for _, e := range entries {
+		doerIDs = append(doerIDs, e.DoerID)
+	}
+	doers, err := getUsersOrLinkSharesFromIDs(s, doerIDs)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		e.Doer = doers[e.DoerID]
+	}
+
+	total, err := s.Where(cond).Count(&HistoryEntry{})
+	return entries, len(entries), total, err
+}
+
+func getTaskHistoryCond(s *xorm.Session, a web.Auth, taskID int64) (builder.Cond, error) {
+	task := &Task{ID: taskID}
+	can, _, err := task.CanRead(s, a)
+	if err != nil {
+		return nil, err
+	}
+	if !can {
+		return nil, ErrGenericForbidden{}
+	}
+
+	return builder.Eq{"task_id": taskID}, nil
+}
+
+// getProjectHistoryCond returns the condition for all changes of a project, its tasks, buckets and shares.
+// Membership changes of teams the project is shared with are part of the project history as well.
+func getProjectHistoryCond(s *xorm.Session, a web.Auth, projectID int64) (builder.Cond, error) {
+	project := &Project{ID: projectID}
+	can, _, err := project.CanRead(s, a)
+	if err != nil {
+		return nil, err
+	}
+	if !can {
+		return nil, ErrGenericForbidden{}
+	}
+
+	return builder.Or(
+		builder.Eq{"project_id": projectID},
+		builder.And(
+			builder.Eq{"entity_kind": HistoryEntityKindTeamMember},
+			builder.In("team_id",
+				builder.Select("team_id").
+					From("team_projects").
+					Where(builder.Eq{"project_id": projectID}),
+			),
+		),
+	), nil
+}
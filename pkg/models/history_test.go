@@ -0,0 +1,345 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryEntry_ReadAll(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	readAll := func(t *testing.T, h *HistoryEntry, page int, perPage int) (entries []*HistoryEntry, total int64, err error) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		result, _, total, err := h.ReadAll(s, u, "", page, perPage)
+		if err != nil {
+			return nil, 0, err
+		}
+		return result.([]*HistoryEntry), total, nil
+	}
+
+	t.Run("task", func(t *testing.T) {
+		entries, total, err := readAll(t, &HistoryEntry{TaskID: 1}, 1, 50)
+		require.NoError(t, err)
+		assert.Len(t, entries, 2)
+		assert.Equal(t, int64(2), total)
+		// Newest first
+		assert.Equal(t, int64(2), entries[0].ID)
+		assert.Equal(t, "description", entries[0].Field)
+		assert.Equal(t, int64(1), entries[1].ID)
+		assert.Equal(t, int64(1), entries[0].Doer.ID)
+	})
+	t.Run("project", func(t *testing.T) {
+		entries, total, err := readAll(t, &HistoryEntry{ProjectID: 1}, 1, 50)
+		require.NoError(t, err)
+		assert.Len(t, entries, 3)
+		assert.Equal(t, int64(3), total)
+		assert.Equal(t, int64(3), entries[0].ID)
+	})
+	t.Run("project with pagination", func(t *testing.T) {
+		entries, total, err := readAll(t, &HistoryEntry{ProjectID: 1}, 2, 2)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, int64(3), total)
+		assert.Equal(t, int64(1), entries[0].ID)
+	})
+	t.Run("project with team member changes", func(t *testing.T) {
+		// Project 6 is shared with team 2
+		entries, _, err := readAll(t, &HistoryEntry{ProjectID: 6}, 1, 50)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, int64(5), entries[0].ID)
+		assert.Equal(t, HistoryEntityKindTeamMember, entries[0].EntityKind)
+	})
+	t.Run("no access to task", func(t *testing.T) {
+		_, _, err := readAll(t, &HistoryEntry{TaskID: 14}, 1, 50)
+		require.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+	t.Run("no access to project", func(t *testing.T) {
+		_, _, err := readAll(t, &HistoryEntry{ProjectID: 2}, 1, 50)
+		require.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+	t.Run("nonexisting task", func(t *testing.T) {
+		_, _, err := readAll(t, &HistoryEntry{TaskID: 9999}, 1, 50)
+		require.Error(t, err)
+		assert.True(t, IsErrTaskDoesNotExist(err))
+	})
+}
+
+func TestHistoryEntry_Recording(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("task update", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:          1,
+			Title:       "new title",
+			Description: "Lorem Ipsum",
+			ProjectID:   1,
+			Priority:    3,
+		}
+		err := task.Update(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "history_entries", map[string]interface{}{
+			"entity_kind": HistoryEntityKindTask,
+			"entity_id":   1,
+			"action":      HistoryActionUpdated,
+			"field":       "title",
+			"old_value":   "task #1",
+			"new_value":   "new title",
+			"task_id":     1,
+			"project_id":  1,
+			"doer_id":     1,
+		}, false)
+		db.AssertExists(t, "history_entries", map[string]interface{}{
+			"entity_kind": HistoryEntityKindTask,
+			"field":       "priority",
+			"old_value":   "0",
+			"new_value":   "3",
+			"task_id":     1,
+		}, false)
+		// Unchanged fields are not recorded
+		db.AssertMissing(t, "history_entries", map[string]interface{}{
+			"field":     "description",
+			"task_id":   1,
+			"old_value": "Lorem Ipsum",
+		})
+	})
+	t.Run("bulk task update", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		bt := &BulkTask{
+			IDs:  []int64{10, 11},
+			Task: Task{Title: "bulkupdated"},
+		}
+		allowed, err := bt.CanUpdate(s, u)
+		require.NoError(t, err)
+		require.True(t, allowed)
+		err = bt.Update(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		for _, taskID := range bt.IDs {
+			db.AssertExists(t, "history_entries", map[string]interface{}{
+				"entity_kind": HistoryEntityKindTask,
+				"entity_id":   taskID,
+				"action":      HistoryActionUpdated,
+				"field":       "title",
+				"new_value":   "bulkupdated",
+				"task_id":     taskID,
+				"project_id":  1,
+			}, false)
+		}
+	})
+	t.Run("task create and delete", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			Title:     "Lorem",
+			ProjectID: 1,
+		}
+		err := task.Create(s, u)
+		require.NoError(t, err)
+		err = task.Delete(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "history_entries", map[string]interface{}{
+			"entity_kind": HistoryEntityKindTask,
+			"entity_id":   task.ID,
+			"action":      HistoryActionCreated,
+			"new_value":   "Lorem",
+			"project_id":  1,
+		}, false)
+		db.AssertExists(t, "history_entries", map[string]interface{}{
+			"entity_kind": HistoryEntityKindTask,
+			"entity_id":   task.ID,
+			"action":      HistoryActionDeleted,
+			"old_value":   "Lorem",
+			"project_id":  1,
+		}, false)
+	})
+	t.Run("project update", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		project := &Project{
+			ID:          1,
+			Title:       "Renamed",
+			Description: "Lorem Ipsum",
+			Identifier:  "test1",
+		}
+		err := project.Update(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "history_entries", map[string]interface{}{
+			"entity_kind": HistoryEntityKindProject,
+			"entity_id":   1,
+			"action":      HistoryActionUpdated,
+			"field":       "title",
+			"old_value":   "Test1",
+			"new_value":   "Renamed",
+			"project_id":  1,
+		}, false)
+		db.AssertMissing(t, "history_entries", map[string]interface{}{
+			"entity_kind": HistoryEntityKindProject,
+			"field":       "description",
+			"project_id":  1,
+		})
+	})
+	t.Run("project delete removes the history", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		project := &Project{ID: 1}
+		err := project.Delete(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertMissing(t, "history_entries", map[string]interface{}{
+			"project_id": 1,
+		})
+	})
+	t.Run("bucket update", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		b := &Bucket{
+			ID:            1,
+			Title:         "testbucket1",
+			ProjectID:     1,
+			ProjectViewID: 4,
+			Limit:         5,
+		}
+		err := b.Update(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "history_entries", map[string]interface{}{
+			"entity_kind": HistoryEntityKindBucket,
+			"entity_id":   1,
+			"field":       "limit",
+			"old_value":   "9999999",
+			"new_value":   "5",
+			"project_id":  1,
+		}, false)
+		db.AssertMissing(t, "history_entries", map[string]interface{}{
+			"entity_kind": HistoryEntityKindBucket,
+			"field":       "title",
+		})
+	})
+	t.Run("user share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		lu := &ProjectUser{ProjectID: 3, Username: "user1", Right: RightAdmin}
+		err := lu.Update(s, u)
+		require.NoError(t, err)
+		err = lu.Delete(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "history_entries", map[string]interface{}{
+			"entity_kind": HistoryEntityKindProjectUser,
+			"entity_id":   1,
+			"action":      HistoryActionUpdated,
+			"field":       "right",
+			"old_value":   "0",
+			"new_value":   "2",
+			"project_id":  3,
+		}, false)
+		db.AssertExists(t, "history_entries", map[string]interface{}{
+			"entity_kind": HistoryEntityKindProjectUser,
+			"entity_id":   1,
+			"action":      HistoryActionDeleted,
+			"old_value":   "2",
+			"project_id":  3,
+		}, false)
+	})
+	t.Run("team member", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tm := &TeamMember{TeamID: 1, Username: "user1"}
+		err := tm.Update(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "history_entries", map[string]interface{}{
+			"entity_kind": HistoryEntityKindTeamMember,
+			"entity_id":   1,
+			"action":      HistoryActionUpdated,
+			"field":       "admin",
+			"old_value":   "true",
+			"new_value":   "false",
+			"team_id":     1,
+		}, false)
+	})
+	t.Run("link share as doer", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		linkShare := &LinkSharing{ID: 2, ProjectID: 2, Right: RightWrite}
+		task := &Task{Title: "Lorem", ProjectID: 2}
+		err := task.Create(s, linkShare)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "history_entries", map[string]interface{}{
+			"entity_kind": HistoryEntityKindTask,
+			"entity_id":   task.ID,
+			"action":      HistoryActionCreated,
+			"doer_id":     -2,
+		}, false)
+	})
+}
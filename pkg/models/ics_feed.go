@@ -0,0 +1,64 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/api/pkg/user"
+
+	"xorm.io/xorm"
+)
+
+// GetTasksForICSFeed returns all tasks with a due date which should show up in the calendar feed of a user,
+// together with the name of the feed. Without a saved filter, the feed contains all undone tasks of all projects
+// the user has access to. With a saved filter, it contains all tasks matching that filter.
+func GetTasksForICSFeed(s *xorm.Session, u *user.User, filterID int64) (name string, tasks []*Task, err error) {
+	tc := &TaskCollection{
+		Filter: "done = false",
+	}
+	name = u.GetName()
+
+	if filterID != 0 {
+		sf := &SavedFilter{ID: filterID}
+		can, _, err := sf.CanRead(s, u)
+		if err != nil {
+			return "", nil, err
+		}
+		if !can {
+			return "", nil, ErrGenericForbidden{}
+		}
+
+		tc = &TaskCollection{
+			ProjectID: getProjectIDFromSavedFilterID(filterID),
+		}
+		name = sf.Title
+	}
+
+	result, _, _, err := tc.ReadAll(s, u, "", -1, 0)
+	if err != nil {
+		return "", nil, err
+	}
+
+	allTasks, _ := result.([]*Task)
+	tasks = make([]*Task, 0, len(allTasks))
+	for _, t := range allTasks {
+		if !t.DueDate.IsZero() {
+			tasks = append(tasks, t)
+		}
+	}
+
+	return name, tasks, nil
+}
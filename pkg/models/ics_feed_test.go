@@ -0,0 +1,75 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTasksForICSFeed(t *testing.T) {
+	t.Run("all tasks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 1, Username: "user1"}
+		name, tasks, err := GetTasksForICSFeed(s, u, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "user1", name)
+
+		taskIDs := make([]int64, 0, len(tasks))
+		for _, task := range tasks {
+			assert.False(t, task.Done)
+			assert.False(t, task.DueDate.IsZero())
+			taskIDs = append(taskIDs, task.ID)
+		}
+		assert.Contains(t, taskIDs, int64(5))
+		assert.Contains(t, taskIDs, int64(6))
+		assert.NotContains(t, taskIDs, int64(1))  // No due date
+		assert.NotContains(t, taskIDs, int64(38)) // Done
+		assert.NotContains(t, taskIDs, int64(40)) // No access
+	})
+	t.Run("saved filter", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 1}
+		name, tasks, err := GetTasksForICSFeed(s, u, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "testfilter1", name)
+		require.Len(t, tasks, 2)
+		assert.Equal(t, int64(5), tasks[0].ID)
+		assert.Equal(t, int64(6), tasks[1].ID)
+	})
+	t.Run("saved filter of another user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &user.User{ID: 2}
+		_, _, err := GetTasksForICSFeed(s, u, 1)
+		require.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+}
@@ -17,6 +17,7 @@
 package models
 
 import (
+	"strconv"
 	"time"
 
 	"code.vikunja.io/api/pkg/log"
@@ -33,6 +34,9 @@ type Bucket struct {
 	Title string `xorm:"text not null" valid:"required" minLength:"1" json:"title"`
 	// The project this bucket belongs to.
 	ProjectID int64 `xorm:"bigint not null" json:"project_id" param:"project"`
+	// The kanban view this bucket belongs to. If no view is given when creating or requesting buckets,
+	// the first kanban view of the project is used.
+	ProjectViewID int64 `xorm:"bigint not null default 0 index" json:"project_view_id" param:"view"`
 	// All tasks which belong to this bucket.
 	Tasks []*Task `xorm:"-" json:"tasks"`
 
@@ -45,6 +49,13 @@ type Bucket struct {
 	// The position this bucket has when querying all buckets. See the tasks.position property on how to use this.
 	Position float64 `xorm:"double null" json:"position"`
 
+	// The filter which defines which tasks are in this bucket. Only used when the view of the bucket is in
+	// filter bucket configuration mode. Uses the same syntax as the filter of a project view.
+	Filter string `xorm:"text null" json:"filter"`
+	// What happens to a task when it is moved into this bucket. Only used when the view of the bucket is in
+	// filter bucket configuration mode.
+	MoveAction *BucketMoveAction `xorm:"json null" json:"move_action"`
+
 	// A timestamp when this bucket was created. You cannot change this value.
 	Created time.Time `xorm:"created not null" json:"created"`
 	// A timestamp when this bucket was last updated. You cannot change this value.
@@ -66,6 +77,39 @@ func (b *Bucket) TableName() string {
 	return "buckets"
 }
 
+// BucketMoveAction is the field change which is applied to a task when it is moved into a filter bucket.
+type BucketMoveAction struct {
+	// The task field to change. Can be one of `done`, `priority`, `percent_done` or `hex_color`.
+	Field string `json:"field"`
+	// The new value of the field.
+	Value string `json:"value"`
+}
+
+// validate checks if the move action can be applied to tasks.
+func (ma *BucketMoveAction) validate(bucketID int64) error {
+	return ma.apply(&Task{}, bucketID)
+}
+
+// apply changes the configured field of a task.
+func (ma *BucketMoveAction) apply(task *Task, bucketID int64) error {
+	if !task.setFieldFromString(ma.Field, ma.Value) {
+		return &ErrInvalidBucketMoveAction{BucketID: bucketID, Field: ma.Field, Value: ma.Value}
+	}
+	return nil
+}
+
+// TaskBucket holds which bucket a task is in for a kanban view. A task is in exactly one bucket per kanban view.
+type TaskBucket struct {
+	TaskID        int64 `xorm:"bigint not null unique(task_view)" json:"task_id"`
+	BucketID      int64 `xorm:"bigint not null index" json:"bucket_id"`
+	ProjectViewID int64 `xorm:"bigint not null unique(task_view) index" json:"project_view_id"`
+}
+
+// TableName returns the table name for task buckets.
+func (*TaskBucket) TableName() string {
+	return "task_buckets"
+}
+
 func getBucketByID(s *xorm.Session, id int64) (b *Bucket, err error) {
 	b = &Bucket{}
 	exists, err := s.Where("id = ?", id).Get(b)
@@ -78,14 +122,14 @@ func getBucketByID(s *xorm.Session, id int64) (b *Bucket, err error) {
 	return
 }
 
-func getDefaultBucketID(s *xorm.Session, project *Project) (bucketID int64, err error) {
-	if project.DefaultBucketID != 0 {
-		return project.DefaultBucketID, nil
+func getDefaultBucketID(s *xorm.Session, view *ProjectView) (bucketID int64, err error) {
+	if view.DefaultBucketID != 0 {
+		return view.DefaultBucketID, nil
 	}
 
 	bucket := &Bucket{}
 	_, err = s.
-		Where("project_id = ?", project.ID).
+		Where("project_view_id = ?", view.ID).
 		OrderBy("position asc").
 		Get(bucket)
 	if err != nil {
@@ -95,14 +139,106 @@ func getDefaultBucketID(s *xorm.Session, project *Project) (bucketID int64, err
 	return bucket.ID, nil
 }
 
+func (b *Bucket) validate() (err error) {
+	// Make sure the filter can be used later on
+	_, err = parseTaskFilterExpression(b.Filter)
+	if err != nil {
+		return err
+	}
+
+	if b.MoveAction != nil {
+		return b.MoveAction.validate(b.ID)
+	}
+	return nil
+}
+
+// containsTask checks if a task currently matches the filter of a bucket in a view with filter bucket configuration mode.
+func (b *Bucket) containsTask(s *xorm.Session, view *ProjectView, taskID int64) (bool, error) {
+	filter := combineTaskFilterExpressions(view.Filter, b.Filter)
+	filter = combineTaskFilterExpressions(filter, "id = "+strconv.FormatInt(taskID, 10))
+	opts, err := getTaskFilterOptsFromCollection(&TaskCollection{Filter: filter})
+	if err != nil {
+		return false, err
+	}
+	opts.projectViewID = view.ID
+	opts.filterConcat = filterConcatAnd
+
+	_, _, total, err := getRawTasksForProjects(s, []*Project{{ID: b.ProjectID}}, nil, opts)
+	return total > 0, err
+}
+
+// getKanbanViewForBuckets returns the kanban view buckets of a project are managed in. If no view id is given,
+// the first kanban view of the project is used. Returns nil if the project does not have any kanban view.
+func getKanbanViewForBuckets(s *xorm.Session, projectID int64, projectViewID int64) (view *ProjectView, err error) {
+	if projectViewID == 0 {
+		return getFirstKanbanViewForProject(s, projectID)
+	}
+
+	view, err = getProjectViewByID(s, projectViewID)
+	if err != nil {
+		return nil, err
+	}
+	if view.ProjectID != projectID {
+		return nil, &ErrProjectViewDoesNotExist{ProjectViewID: projectViewID}
+	}
+	if view.ViewKind != ProjectViewKindKanban {
+		return nil, &ErrProjectViewHasNoBuckets{ProjectViewID: projectViewID}
+	}
+
+	return view, nil
+}
+
+// addProjectTasksToBucket puts all tasks of a project into a bucket, for example when a new kanban view was created.
+func addProjectTasksToBucket(s *xorm.Session, projectID int64, bucket *Bucket) (err error) {
+	taskIDs := []int64{}
+	err = s.
+		Table("tasks").
+		Where("project_id = ?", projectID).
+		Cols("id").
+		Find(&taskIDs)
+	if err != nil || len(taskIDs) == 0 {
+		return err
+	}
+
+	taskBuckets := make([]*TaskBucket, 0, len(taskIDs))
+	for _, taskID := range taskIDs {
+		taskBuckets = append(taskBuckets, &TaskBucket{
+			TaskID:        taskID,
+			BucketID:      bucket.ID,
+			ProjectViewID: bucket.ProjectViewID,
+		})
+	}
+
+	_, err = s.Insert(&taskBuckets)
+	return
+}
+
+// usesProjectViewData returns true if tasks are filtered by their bucket or sorted by their position in a view.
+func (opts *taskSearchOptions) usesProjectViewData() bool {
+	if opts.projectViewID != 0 {
+		return true
+	}
+
+	filters := append([]*taskFilter{}, opts.filters...)
+	filters = append(filters, opts.filterExpression.getFilters()...)
+	for _, f := range filters {
+		if f.field == taskPropertyBucketID {
+			return true
+		}
+	}
+
+	return false
+}
+
 // ReadAll returns all buckets with their tasks for a certain project
 // @Summary Get all kanban buckets of a project
-// @Description Returns all kanban buckets with belong to a project including their tasks. Buckets are always sorted by their `position` in ascending order. Tasks are sorted by their `kanban_position` in ascending order.
+// @Description Returns all kanban buckets which belong to a kanban view of a project including their tasks. If no view is given, the first kanban view of the project is used. Only tasks matching the filter of the view are returned. If the view uses the filter bucket configuration mode, every bucket contains all tasks matching its filter, so a task can show up in more than one bucket. Buckets are always sorted by their `position` in ascending order. Tasks are sorted by their `position` in the view in ascending order.
 // @tags task
 // @Accept json
 // @Produce json
 // @Security JWTKeyAuth
 // @Param id path int true "Project Id"
+// @Param view path int false "Project view Id"
 // @Param page query int false "The page number for tasks. Used for pagination. If not provided, the first page of results is returned."
 // @Param per_page query int false "The maximum number of tasks per bucket per page. This parameter is limited by the configured maximum of items per page."
 // @Param s query string false "Search tasks by task text."
@@ -114,6 +250,7 @@ func getDefaultBucketID(s *xorm.Session, project *Project) (bucketID int64, err
 // @Success 200 {array} models.Bucket "The buckets with their tasks"
 // @Failure 500 {object} models.Message "Internal server error"
 // @Router /projects/{id}/buckets [get]
+// @Router /projects/{id}/views/{view}/buckets [get]
 func (b *Bucket) ReadAll(s *xorm.Session, auth web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
 
 	project, err := GetProjectSimpleByID(s, b.ProjectID)
@@ -129,10 +266,18 @@ func (b *Bucket) ReadAll(s *xorm.Session, auth web.Auth, search string, page int
 		return nil, 0, 0, ErrGenericForbidden{}
 	}
 
-	// Get all buckets for this project
+	view, err := getKanbanViewForBuckets(s, b.ProjectID, b.ProjectViewID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if view == nil {
+		return []*Bucket{}, 0, 0, nil
+	}
+
+	// Get all buckets for this view
 	buckets := []*Bucket{}
 	err = s.
-		Where("project_id = ?", b.ProjectID).
+		Where("project_view_id = ?", view.ID).
 		OrderBy("position").
 		Find(&buckets)
 	if err != nil {
@@ -157,23 +302,48 @@ func (b *Bucket) ReadAll(s *xorm.Session, auth web.Auth, search string, page int
 		bb.CreatedBy = users[bb.CreatedByID]
 	}
 
-	tasks := []*Task{}
-
-	opts, err := getTaskFilterOptsFromCollection(&b.TaskCollection)
+	if view.usesFilterBuckets() {
+		err = b.addFilteredTasksToBuckets(s, auth, view, buckets, search, page, perPage)
+	} else {
+		err = b.addTasksToBuckets(s, auth, view, bucketMap, search, page, perPage)
+	}
 	if err != nil {
 		return nil, 0, 0, err
 	}
 
+	return buckets, len(buckets), int64(len(buckets)), nil
+}
+
+func (b *Bucket) getTaskSearchOptions(view *ProjectView, filter string, search string, page int, perPage int) (opts *taskSearchOptions, err error) {
+	tc := b.TaskCollection
+	tc.Filter = filter
+	opts, err = getTaskFilterOptsFromCollection(&tc)
+	if err != nil {
+		return nil, err
+	}
+
 	opts.sortby = []*sortParam{
 		{
 			orderBy: orderAscending,
-			sortBy:  taskPropertyKanbanPosition,
+			sortBy:  taskPropertyPosition,
 		},
 	}
+	opts.projectViewID = view.ID
 	opts.page = page
 	opts.perPage = perPage
 	opts.search = search
 	opts.filterConcat = filterConcatAnd
+	return opts, nil
+}
+
+// addTasksToBuckets puts all tasks into the buckets they were moved into. Used for views in manual bucket configuration mode.
+func (b *Bucket) addTasksToBuckets(s *xorm.Session, auth web.Auth, view *ProjectView, bucketMap map[int64]*Bucket, search string, page int, perPage int) (err error) {
+	tasks := []*Task{}
+
+	opts, err := b.getTaskSearchOptions(view, combineTaskFilterExpressions(view.Filter, b.TaskCollection.Filter), search, page, perPage)
+	if err != nil {
+		return err
+	}
 
 	var bucketFilterIndex int
 	for i, filter := range opts.filters {
@@ -198,11 +368,14 @@ func (b *Bucket) ReadAll(s *xorm.Session, auth web.Auth, search string, page int
 
 		ts, _, total, err := getRawTasksForProjects(s, []*Project{{ID: bucket.ProjectID}}, auth, opts)
 		if err != nil {
-			return nil, 0, 0, err
+			return err
 		}
 
 		bucket.Count = total
 
+		for _, t := range ts {
+			t.BucketID = bucket.ID
+		}
 		tasks = append(tasks, ts...)
 	}
 
@@ -213,7 +386,7 @@ func (b *Bucket) ReadAll(s *xorm.Session, auth web.Auth, search string, page int
 
 	err = addMoreInfoToTasks(s, taskMap, auth)
 	if err != nil {
-		return nil, 0, 0, err
+		return err
 	}
 
 	// Put all tasks in their buckets
@@ -228,24 +401,76 @@ func (b *Bucket) ReadAll(s *xorm.Session, auth web.Auth, search string, page int
 		bucketMap[task.BucketID].Tasks = append(bucketMap[task.BucketID].Tasks, task)
 	}
 
-	return buckets, len(buckets), int64(len(buckets)), nil
+	return nil
+}
+
+// addFilteredTasksToBuckets puts all tasks matching the filter of a bucket into that bucket. Used for views in filter
+// bucket configuration mode. A task can show up in more than one bucket.
+func (b *Bucket) addFilteredTasksToBuckets(s *xorm.Session, auth web.Auth, view *ProjectView, buckets []*Bucket, search string, page int, perPage int) (err error) {
+	taskMap := make(map[int64]*Task)
+
+	for _, bucket := range buckets {
+		filter := combineTaskFilterExpressions(view.Filter, b.TaskCollection.Filter)
+		filter = combineTaskFilterExpressions(filter, bucket.Filter)
+
+		opts, err := b.getTaskSearchOptions(view, filter, search, page, perPage)
+		if err != nil {
+			return err
+		}
+
+		ts, _, total, err := getRawTasksForProjects(s, []*Project{{ID: bucket.ProjectID}}, auth, opts)
+		if err != nil {
+			return err
+		}
+
+		bucket.Count = total
+		bucket.Tasks = make([]*Task, 0, len(ts))
+
+		// Tasks in more than one bucket share the same object so that the details are only fetched once
+		for _, t := range ts {
+			if existing, has := taskMap[t.ID]; has {
+				t = existing
+			} else {
+				t.BucketID = bucket.ID
+				taskMap[t.ID] = t
+			}
+			bucket.Tasks = append(bucket.Tasks, t)
+		}
+	}
+
+	return addMoreInfoToTasks(s, taskMap, auth)
 }
 
 // Create creates a new bucket
 // @Summary Create a new bucket
-// @Description Creates a new kanban bucket on a project.
+// @Description Creates a new kanban bucket in a kanban view of a project. If no view is given, the bucket is created in the first kanban view of the project.
 // @tags task
 // @Accept json
 // @Produce json
 // @Security JWTKeyAuth
 // @Param id path int true "Project Id"
+// @Param view path int false "Project view Id"
 // @Param bucket body models.Bucket true "The bucket object"
 // @Success 200 {object} models.Bucket "The created bucket object."
 // @Failure 400 {object} web.HTTPError "Invalid bucket object provided."
 // @Failure 404 {object} web.HTTPError "The project does not exist."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /projects/{id}/buckets [put]
+// @Router /projects/{id}/views/{view}/buckets [put]
 func (b *Bucket) Create(s *xorm.Session, a web.Auth) (err error) {
+	view, err := getKanbanViewForBuckets(s, b.ProjectID, b.ProjectViewID)
+	if err != nil {
+		return err
+	}
+	if view == nil {
+		return &ErrProjectViewDoesNotExist{ProjectViewID: b.ProjectViewID}
+	}
+	b.ProjectViewID = view.ID
+
+	if err := b.validate(); err != nil {
+		return err
+	}
+
 	b.CreatedBy, err = GetUserOrLinkShareUser(s, a)
 	if err != nil {
 		return
@@ -259,12 +484,22 @@ func (b *Bucket) Create(s *xorm.Session, a web.Auth) (err error) {
 
 	b.Position = calculateDefaultPosition(b.ID, b.Position)
 	_, err = s.Where("id = ?", b.ID).Update(b)
-	return
+	if err != nil {
+		return
+	}
+
+	return addHistoryEntries(s, a, &HistoryEntry{
+		EntityKind: HistoryEntityKindBucket,
+		EntityID:   b.ID,
+		Action:     HistoryActionCreated,
+		NewValue:   b.Title,
+		ProjectID:  b.ProjectID,
+	})
 }
 
 // Update Updates an existing bucket
 // @Summary Update an existing bucket
-// @Description Updates an existing kanban bucket.
+// @Description Updates an existing kanban bucket. The filter and move action of a bucket are only used if its view is in filter bucket configuration mode.
 // @tags task
 // @Accept json
 // @Produce json
@@ -277,21 +512,41 @@ func (b *Bucket) Create(s *xorm.Session, a web.Auth) (err error) {
 // @Failure 404 {object} web.HTTPError "The bucket does not exist."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /projects/{projectID}/buckets/{bucketID} [post]
-func (b *Bucket) Update(s *xorm.Session, _ web.Auth) (err error) {
+// @Router /projects/{projectID}/views/{view}/buckets/{bucketID} [post]
+func (b *Bucket) Update(s *xorm.Session, a web.Auth) (err error) {
+	if err := b.validate(); err != nil {
+		return err
+	}
+
+	oldBucket, err := getBucketByID(s, b.ID)
+	if err != nil {
+		return err
+	}
+
 	_, err = s.
 		Where("id = ?", b.ID).
 		Cols(
 			"title",
 			"limit",
 			"position",
+			"filter",
+			"move_action",
 		).
 		Update(b)
-	return
+	if err != nil {
+		return
+	}
+
+	return addHistoryEntries(s, a, historyEntriesForChanges(HistoryEntry{
+		EntityKind: HistoryEntityKindBucket,
+		EntityID:   b.ID,
+		ProjectID:  oldBucket.ProjectID,
+	}, oldBucket, b, []string{"title", "limit", "filter", "move_action"})...)
 }
 
 // Delete removes a bucket, but no tasks
 // @Summary Deletes an existing bucket
-// @Description Deletes an existing kanban bucket and dissociates all of its task. It does not delete any tasks. You cannot delete the last bucket on a project.
+// @Description Deletes an existing kanban bucket and moves all of its task to the default bucket of its view. It does not delete any tasks. You cannot delete the last bucket of a view unless the view is in filter bucket configuration mode.
 // @tags task
 // @Accept json
 // @Produce json
@@ -302,17 +557,45 @@ func (b *Bucket) Update(s *xorm.Session, _ web.Auth) (err error) {
 // @Failure 404 {object} web.HTTPError "The bucket does not exist."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /projects/{projectID}/buckets/{bucketID} [delete]
-func (b *Bucket) Delete(s *xorm.Session, _ web.Auth) (err error) {
+// @Router /projects/{projectID}/views/{view}/buckets/{bucketID} [delete]
+func (b *Bucket) Delete(s *xorm.Session, a web.Auth) (err error) {
+
+	bucket, err := getBucketByID(s, b.ID)
+	if err != nil {
+		return err
+	}
+
+	err = addHistoryEntries(s, a, &HistoryEntry{
+		EntityKind: HistoryEntityKindBucket,
+		EntityID:   bucket.ID,
+		Action:     HistoryActionDeleted,
+		OldValue:   bucket.Title,
+		ProjectID:  bucket.ProjectID,
+	})
+	if err != nil {
+		return err
+	}
+
+	view, err := getProjectViewByID(s, bucket.ProjectViewID)
+	if err != nil {
+		return
+	}
+
+	// Filter buckets don't hold any tasks, they can simply be removed
+	if view.usesFilterBuckets() {
+		_, err = s.Where("id = ?", b.ID).Delete(&Bucket{})
+		return
+	}
 
 	// Prevent removing the last bucket
-	total, err := s.Where("project_id = ?", b.ProjectID).Count(&Bucket{})
+	total, err := s.Where("project_view_id = ?", bucket.ProjectViewID).Count(&Bucket{})
 	if err != nil {
 		return
 	}
 	if total <= 1 {
 		return ErrCannotRemoveLastBucket{
 			BucketID:  b.ID,
-			ProjectID: b.ProjectID,
+			ProjectID: bucket.ProjectID,
 		}
 	}
 
@@ -322,20 +605,31 @@ func (b *Bucket) Delete(s *xorm.Session, _ web.Auth) (err error) {
 		return
 	}
 
-	// Get the default bucket
-	p, err := GetProjectSimpleByID(s, b.ProjectID)
+	if view.DoneBucketID == b.ID {
+		view.DoneBucketID = 0
+	}
+	if view.DefaultBucketID == b.ID {
+		view.DefaultBucketID = 0
+	}
+	_, err = s.
+		Where("id = ?", view.ID).
+		Cols("done_bucket_id", "default_bucket_id").
+		Update(view)
 	if err != nil {
 		return
 	}
-	defaultBucketID, err := getDefaultBucketID(s, p)
+
+	// Get the default bucket
+	defaultBucketID, err := getDefaultBucketID(s, view)
 	if err != nil {
 		return err
 	}
 
-	// Remove all associations of tasks to that bucket
+	// Move all tasks of that bucket into the default bucket
 	_, err = s.
 		Where("bucket_id = ?", b.ID).
 		Cols("bucket_id").
-		Update(&Task{BucketID: defaultBucketID})
+		NoAutoCondition().
+		Update(&TaskBucket{BucketID: defaultBucketID})
 	return
 }
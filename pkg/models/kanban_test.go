@@ -26,6 +26,19 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+func switchKanbanViewToFilterBuckets(t *testing.T, s *xorm.Session, viewID int64, bucketFilters map[int64]string) {
+	view, err := getProjectViewByID(s, viewID)
+	assert.NoError(t, err)
+	view.BucketConfigurationMode = BucketConfigurationModeFilter
+	err = view.Update(s, &user.User{ID: 1})
+	assert.NoError(t, err)
+
+	for bucketID, filter := range bucketFilters {
+		_, err = s.Where("id = ?", bucketID).Cols("filter").Update(&Bucket{Filter: filter})
+		assert.NoError(t, err)
+	}
+}
+
 func TestBucket_ReadAll(t *testing.T) {
 	t.Run("normal", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
@@ -92,6 +105,41 @@ func TestBucket_ReadAll(t *testing.T) {
 		assert.Equal(t, int64(2), buckets[0].Tasks[0].ID)
 		assert.Equal(t, int64(33), buckets[0].Tasks[1].ID)
 	})
+	t.Run("filter buckets", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		switchKanbanViewToFilterBuckets(t, s, 4, map[int64]string{
+			1: "done = false",
+			2: "priority >= 1",
+			3: "done = true",
+		})
+
+		testuser := &user.User{ID: 1}
+		b := &Bucket{ProjectID: 1, ProjectViewID: 4}
+		bucketsInterface, _, _, err := b.ReadAll(s, testuser, "", 0, 0)
+		assert.NoError(t, err)
+
+		buckets, is := bucketsInterface.([]*Bucket)
+		assert.True(t, is)
+		assert.Len(t, buckets, 3)
+
+		assert.Len(t, buckets[0].Tasks, 17)
+		assert.Len(t, buckets[1].Tasks, 2)
+		assert.Len(t, buckets[2].Tasks, 1)
+		assert.Equal(t, int64(2), buckets[2].Tasks[0].ID)
+
+		// Task 3 matches the filters of the first two buckets
+		var inFirstBucket *Task
+		for _, task := range buckets[0].Tasks {
+			if task.ID == 3 {
+				inFirstBucket = task
+			}
+		}
+		assert.NotNil(t, inFirstBucket)
+		assert.Contains(t, buckets[1].Tasks, inFirstBucket)
+	})
 	t.Run("accessed by link share", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
 		s := db.NewSession()
@@ -144,10 +192,10 @@ func TestBucket_Delete(t *testing.T) {
 		assert.NoError(t, err)
 
 		// Assert all tasks have been moved to bucket 1 as that one is the first
-		tasks := []*Task{}
-		err = s.Where("bucket_id = ?", 1).Find(&tasks)
+		taskBuckets := []*TaskBucket{}
+		err = s.Where("bucket_id = ?", 1).Find(&taskBuckets)
 		assert.NoError(t, err)
-		assert.Len(t, tasks, 16)
+		assert.Len(t, taskBuckets, 16)
 		db.AssertMissing(t, "buckets", map[string]interface{}{
 			"id":         2,
 			"project_id": 1,
@@ -173,6 +221,23 @@ func TestBucket_Delete(t *testing.T) {
 			"project_id": 18,
 		}, false)
 	})
+	t.Run("last bucket in a view with filter buckets", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		switchKanbanViewToFilterBuckets(t, s, 8, nil)
+
+		b := &Bucket{ID: 4, ProjectID: 2}
+		err := b.Delete(s, user)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertMissing(t, "buckets", map[string]interface{}{
+			"id": 4,
+		})
+	})
 }
 
 func TestBucket_Update(t *testing.T) {
@@ -217,4 +282,54 @@ func TestBucket_Update(t *testing.T) {
 
 		testAndAssertBucketUpdate(t, b, s)
 	})
+	t.Run("filter and move action", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		b := &Bucket{
+			ID:         1,
+			Title:      "testbucket1",
+			Filter:     "priority >= 3",
+			MoveAction: &BucketMoveAction{Field: "priority", Value: "3"},
+		}
+
+		testAndAssertBucketUpdate(t, b, s)
+		db.AssertExists(t, "buckets", map[string]interface{}{
+			"id":     1,
+			"filter": "priority >= 3",
+		}, false)
+	})
+	t.Run("invalid move action", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		b := &Bucket{
+			ID:         1,
+			Title:      "testbucket1",
+			MoveAction: &BucketMoveAction{Field: "title", Value: "foo"},
+		}
+		err := b.Update(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidBucketMoveAction(err))
+
+		b.MoveAction = &BucketMoveAction{Field: "percent_done", Value: "2"}
+		err = b.Update(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidBucketMoveAction(err))
+	})
+	t.Run("invalid filter", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		b := &Bucket{
+			ID:     1,
+			Title:  "testbucket1",
+			Filter: "done = ",
+		}
+		err := b.Update(s, &user.User{ID: 1})
+		assert.Error(t, err)
+	})
 }
@@ -155,9 +155,19 @@ func (share *LinkSharing) Create(s *xorm.Session, a web.Auth) (err error) {
 	}
 
 	_, err = s.Insert(share)
+	if err != nil {
+		return
+	}
 	share.Password = ""
 	share.SharedBy, _ = user.GetFromAuth(a)
-	return
+
+	return addHistoryEntries(s, a, &HistoryEntry{
+		EntityKind: HistoryEntityKindLinkShare,
+		EntityID:   share.ID,
+		Action:     HistoryActionCreated,
+		NewValue:   formatHistoryValue(share.Right),
+		ProjectID:  share.ProjectID,
+	})
 }
 
 // ReadOne returns one share
@@ -274,9 +284,25 @@ func (share *LinkSharing) ReadAll(s *xorm.Session, a web.Auth, search string, pa
 // @Failure 404 {object} web.HTTPError "Share Link not found."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /projects/{project}/shares/{share} [delete]
-func (share *LinkSharing) Delete(s *xorm.Session, _ web.Auth) (err error) {
+func (share *LinkSharing) Delete(s *xorm.Session, a web.Auth) (err error) {
+	existing := &LinkSharing{}
+	has, err := s.Where("id = ?", share.ID).Get(existing)
+	if err != nil {
+		return
+	}
+
 	_, err = s.Where("id = ?", share.ID).Delete(share)
-	return
+	if err != nil || !has {
+		return
+	}
+
+	return addHistoryEntries(s, a, &HistoryEntry{
+		EntityKind: HistoryEntityKindLinkShare,
+		EntityID:   existing.ID,
+		Action:     HistoryActionDeleted,
+		OldValue:   formatHistoryValue(existing.Right),
+		ProjectID:  existing.ProjectID,
+	})
 }
 
 // GetLinkShareByHash returns a link share by hash
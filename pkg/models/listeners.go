@@ -19,6 +19,7 @@ package models
 import (
 	"encoding/json"
 	"strconv"
+	"time"
 
 	"code.vikunja.io/api/pkg/config"
 
@@ -59,11 +60,39 @@ func RegisterListeners() {
 	events.RegisterListener((&TaskAssigneeDeletedEvent{}).Name(), &HandleTaskUpdateLastUpdated{})
 	events.RegisterListener((&TaskAttachmentCreatedEvent{}).Name(), &HandleTaskUpdateLastUpdated{})
 	events.RegisterListener((&TaskAttachmentDeletedEvent{}).Name(), &HandleTaskUpdateLastUpdated{})
+	events.RegisterListener((&TaskAttachmentCreatedEvent{}).Name(), &GenerateTaskAttachmentPreviews{})
 	events.RegisterListener((&TaskRelationCreatedEvent{}).Name(), &HandleTaskUpdateLastUpdated{})
 	events.RegisterListener((&TaskRelationDeletedEvent{}).Name(), &HandleTaskUpdateLastUpdated{})
 	if config.TypesenseEnabled.GetBool() {
 		events.RegisterListener((&TaskDeletedEvent{}).Name(), &RemoveTaskFromTypesense{})
 	}
+	if config.WebhooksEnabled.GetBool() {
+		RegisterEventForWebhook(&TaskCreatedEvent{})
+		RegisterEventForWebhook(&TaskUpdatedEvent{})
+		RegisterEventForWebhook(&TaskDeletedEvent{})
+		RegisterEventForWebhook(&TaskAssigneeCreatedEvent{})
+		RegisterEventForWebhook(&TaskAssigneeDeletedEvent{})
+		RegisterEventForWebhook(&TaskCommentCreatedEvent{})
+		RegisterEventForWebhook(&TaskCommentUpdatedEvent{})
+		RegisterEventForWebhook(&TaskCommentDeletedEvent{})
+		RegisterEventForWebhook(&TaskAttachmentCreatedEvent{})
+		RegisterEventForWebhook(&TaskAttachmentDeletedEvent{})
+		RegisterEventForWebhook(&TaskRelationCreatedEvent{})
+		RegisterEventForWebhook(&TaskRelationDeletedEvent{})
+		RegisterEventForWebhook(&ProjectUpdatedEvent{})
+		RegisterEventForWebhook(&ProjectDeletedEvent{})
+		RegisterEventForWebhook(&ProjectSharedWithUserEvent{})
+		RegisterEventForWebhook(&ProjectSharedWithTeamEvent{})
+	}
+	if config.AutomationsEnabled.GetBool() {
+		RegisterEventForAutomations(&TaskCreatedEvent{})
+		RegisterEventForAutomations(&TaskUpdatedEvent{})
+		RegisterEventForAutomations(&TaskAssigneeCreatedEvent{})
+		RegisterEventForAutomations(&TaskAssigneeDeletedEvent{})
+		RegisterEventForAutomations(&TaskCommentCreatedEvent{})
+		RegisterEventForAutomations(&TaskAttachmentCreatedEvent{})
+		RegisterEventForAutomations(&TaskRelationCreatedEvent{})
+	}
 }
 
 //////
@@ -636,6 +665,36 @@ func (s *SendTeamMemberAddedNotification) Handle(msg *message.Message) (err erro
 	})
 }
 
+// GenerateTaskAttachmentPreviews  represents a listener
+type GenerateTaskAttachmentPreviews struct {
+}
+
+// Name defines the name for the GenerateTaskAttachmentPreviews listener
+func (s *GenerateTaskAttachmentPreviews) Name() string {
+	return "generate.task.attachment.previews"
+}
+
+// Handle is executed when the event GenerateTaskAttachmentPreviews listens on is fired
+func (s *GenerateTaskAttachmentPreviews) Handle(msg *message.Message) (err error) {
+	event := &TaskAttachmentCreatedEvent{}
+	err = json.Unmarshal(msg.Payload, event)
+	if err != nil {
+		return err
+	}
+
+	sess := db.NewSession()
+	defer sess.Close()
+
+	// The attachment might not be committed yet when this runs, returning an error makes sure it is retried.
+	err = createTaskAttachmentPreviews(sess, event.Attachment.ID)
+	if err != nil {
+		_ = sess.Rollback()
+		return err
+	}
+
+	return sess.Commit()
+}
+
 // HandleUserDataExport  represents a listener
 type HandleUserDataExport struct {
 }
@@ -673,3 +732,142 @@ func (s *HandleUserDataExport) Handle(msg *message.Message) (err error) {
 	err = sess.Commit()
 	return err
 }
+
+///////
+// Webhooks
+
+// WebhookListener represents a listener which sends the payload of an event to all webhooks subscribed to it
+type WebhookListener struct {
+	EventName string
+}
+
+// Name defines the name for the WebhookListener listener
+func (wl *WebhookListener) Name() string {
+	return "webhook.listener"
+}
+
+func getProjectIDFromAnyEvent(eventPayload map[string]interface{}) int64 {
+	if t, has := eventPayload["Task"]; has {
+		task, is := t.(map[string]interface{})
+		if is {
+			if projectID, has := task["project_id"].(float64); has {
+				return int64(projectID)
+			}
+		}
+	}
+
+	if p, has := eventPayload["Project"]; has {
+		project, is := p.(map[string]interface{})
+		if is {
+			if projectID, has := project["id"].(float64); has {
+				return int64(projectID)
+			}
+		}
+	}
+
+	return 0
+}
+
+// Handle is executed when the event WebhookListener listens on is fired
+func (wl *WebhookListener) Handle(msg *message.Message) (err error) {
+	var event map[string]interface{}
+	err = json.Unmarshal(msg.Payload, &event)
+	if err != nil {
+		return err
+	}
+
+	projectID := getProjectIDFromAnyEvent(event)
+	if projectID == 0 {
+		log.Debugf("event %s does not contain a project id, not handling webhook", wl.EventName)
+		return nil
+	}
+
+	sess := db.NewSession()
+	defer sess.Close()
+
+	ws, err := getWebhooksForProjectAndEvent(sess, projectID, wl.EventName)
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("Sending event %s to %d webhooks for project %d", wl.EventName, len(ws), projectID)
+
+	payload := &WebhookPayload{
+		EventName: wl.EventName,
+		Time:      time.Now(),
+		Data:      event,
+	}
+	for _, webhook := range ws {
+		// Retries wait between attempts, so the payload is sent in the background to not block the handler.
+		// Failures are only logged to avoid resending the event to all other webhooks.
+		// The number of payloads sent at once is limited, the handler waits until a slot is free.
+		acquireWebhookSendSlot()
+		go func(webhook *Webhook) {
+			defer releaseWebhookSendSlot()
+			if err := webhook.sendWebhookPayload(payload); err != nil {
+				log.Errorf("Could not send event %s to webhook %d: %s", wl.EventName, webhook.ID, err)
+			}
+		}(webhook)
+	}
+
+	return nil
+}
+
+///////
+// Automations
+
+// AutomationRuleListener represents a listener which runs all automation rules triggered by an event
+type AutomationRuleListener struct {
+	EventName string
+}
+
+// Name defines the name for the AutomationRuleListener listener
+func (al *AutomationRuleListener) Name() string {
+	return "automation.rule.listener"
+}
+
+func getTaskIDFromAnyEvent(eventPayload map[string]interface{}) int64 {
+	task, is := eventPayload["Task"].(map[string]interface{})
+	if !is {
+		return 0
+	}
+
+	taskID, is := task["id"].(float64)
+	if !is {
+		return 0
+	}
+
+	return int64(taskID)
+}
+
+// Handle is executed when the event AutomationRuleListener listens on is fired
+func (al *AutomationRuleListener) Handle(msg *message.Message) (err error) {
+	var event map[string]interface{}
+	err = json.Unmarshal(msg.Payload, &event)
+	if err != nil {
+		return err
+	}
+
+	taskID := getTaskIDFromAnyEvent(event)
+	projectID := getProjectIDFromAnyEvent(event)
+	if taskID == 0 || projectID == 0 {
+		log.Debugf("event %s does not contain a task and project id, not running automation rules", al.EventName)
+		return nil
+	}
+
+	sess := db.NewSession()
+	rules, err := getAutomationRulesForProjectAndEvent(sess, projectID, al.EventName)
+	sess.Close()
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("[Automations] Running %d automation rules for event %s of task %d", len(rules), al.EventName, taskID)
+
+	// Each rule logs its own failures to avoid running all other rules again when the whole handler is retried.
+	for _, rule := range rules {
+		runAutomationRuleForTask(rule, taskID, al.EventName)
+	}
+
+	return nil
+}
@@ -59,6 +59,19 @@ func GetTables() []interface{} {
 		&Subscription{},
 		&Favorite{},
 		&APIToken{},
+		&Webhook{},
+		&TaskTimeEntry{},
+		&CustomField{},
+		&TaskCustomFieldValue{},
+		&ProjectView{},
+		&TaskBucket{},
+		&TaskPosition{},
+		&AutomationRule{},
+		&AutomationRuleExecution{},
+		&HistoryEntry{},
+		&TaskTemplate{},
+		&TaskSyncChange{},
+		&TaskAttachmentUpload{},
 	}
 }
 
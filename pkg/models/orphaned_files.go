@@ -0,0 +1,98 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/cron"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/log"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// OrphanedFilesMinAge is how old a file needs to be before it is removed when it is not used.
+// Files are created before whatever uses them, so new files would be removed otherwise.
+const OrphanedFilesMinAge = time.Hour
+
+// GetOrphanedFiles returns all files created before the given time which are not used as task attachment,
+// avatar, project background or user export anymore.
+func GetOrphanedFiles(s *xorm.Session, createdBefore time.Time) (orphans []*files.File, err error) {
+	referenced := func(table, column string) builder.Cond {
+		return builder.NotIn("id", builder.Select(column).From(table).Where(builder.NotNull{column}))
+	}
+
+	orphans = []*files.File{}
+	err = s.
+		Where(builder.Lt{"created": createdBefore}).
+		And(referenced("task_attachments", "file_id")).
+		And(referenced("users", "avatar_file_id")).
+		And(referenced("users", "export_file_id")).
+		And(referenced("projects", "background_file_id")).
+		OrderBy("id asc").
+		Find(&orphans)
+	return
+}
+
+// DeleteOrphanedFiles removes all orphaned files created before the given time.
+func DeleteOrphanedFiles(s *xorm.Session, createdBefore time.Time) (deleted int, err error) {
+	orphans, err := GetOrphanedFiles(s, createdBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, f := range orphans {
+		_, err = s.Where("file_id = ?", f.ID).Delete(&UnsplashPhoto{})
+		if err != nil {
+			return deleted, err
+		}
+
+		err = f.Delete()
+		if err != nil && !files.IsErrFileDoesNotExist(err) {
+			return deleted, err
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// RegisterOrphanedFilesCleanupCron registers a cron job which removes all files that are not used anymore.
+func RegisterOrphanedFilesCleanupCron() {
+	const logPrefix = "[Orphaned Files Cleanup Cron] "
+
+	err := cron.Schedule("30 * * * *", func() {
+		s := db.NewSession()
+		defer s.Close()
+
+		deleted, err := DeleteOrphanedFiles(s, time.Now().Add(-OrphanedFilesMinAge))
+		if err != nil {
+			log.Errorf(logPrefix+"Could not remove orphaned files: %s", err)
+			return
+		}
+
+		if deleted > 0 {
+			log.Debugf(logPrefix+"Removed %d orphaned files", deleted)
+		}
+	})
+	if err != nil {
+		log.Fatalf("Could not register orphaned files cleanup cron: %s", err)
+	}
+}
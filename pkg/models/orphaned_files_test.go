@@ -0,0 +1,60 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteOrphanedFiles(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	files.InitTestFileFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	orphan, err := files.Create(bytes.NewReader([]byte("orphan")), "orphan", 6, &user.User{ID: 1})
+	assert.NoError(t, err)
+
+	t.Run("Recently created files are kept", func(t *testing.T) {
+		orphans, err := GetOrphanedFiles(s, time.Now().Add(-time.Hour))
+		assert.NoError(t, err)
+		for _, f := range orphans {
+			assert.NotEqual(t, orphan.ID, f.ID)
+		}
+	})
+	t.Run("Referenced files are kept", func(t *testing.T) {
+		orphans, err := GetOrphanedFiles(s, time.Now().Add(time.Minute))
+		assert.NoError(t, err)
+		assert.Len(t, orphans, 1)
+		assert.Equal(t, orphan.ID, orphans[0].ID)
+	})
+	t.Run("Delete", func(t *testing.T) {
+		deleted, err := DeleteOrphanedFiles(s, time.Now().Add(time.Minute))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, deleted)
+
+		db.AssertMissing(t, "files", map[string]interface{}{"id": orphan.ID})
+		db.AssertExists(t, "files", map[string]interface{}{"id": 1}, false)
+	})
+}
@@ -51,17 +51,18 @@ type Project struct {
 	ParentProjectID int64    `xorm:"bigint INDEX null" json:"parent_project_id"`
 	ParentProject   *Project `xorm:"-" json:"-"`
 
-	// The ID of the bucket where new tasks without a bucket are added to. By default, this is the leftmost bucket in a project.
-	DefaultBucketID int64 `xorm:"bigint INDEX null" json:"default_bucket_id"`
-	// If tasks are moved to the done bucket, they are marked as done. If they are marked as done individually, they are moved into the done bucket.
-	DoneBucketID int64 `xorm:"bigint INDEX null" json:"done_bucket_id"`
-
 	// The user who created this project.
 	Owner *user.User `xorm:"-" json:"owner" valid:"-"`
 
 	// Whether a project is archived.
 	IsArchived bool `xorm:"not null default false" json:"is_archived" query:"is_archived"`
 
+	// Whether a project is a template. Templates can be used to create new projects with the same tasks, buckets, labels and relations.
+	IsTemplate bool `xorm:"not null default false" json:"is_template"`
+
+	// If true, tasks of this project can only be marked as done once all tasks blocking them are done.
+	EnforceDependencies bool `xorm:"not null default false" json:"enforce_dependencies"`
+
 	// The id of the file this project has set as background
 	BackgroundFileID int64 `xorm:"null" json:"-"`
 	// Holds extra information about the background set since some background providers require attribution or similar. If not null, the background can be accessed at /projects/{projectID}/background
@@ -687,7 +688,7 @@ func checkProjectBeforeUpdateOrDelete(s *xorm.Session, project *Project) (err er
 	return nil
 }
 
-func CreateProject(s *xorm.Session, project *Project, auth web.Auth, createBacklogBucket bool) (err error) {
+func CreateProject(s *xorm.Session, project *Project, auth web.Auth, createDefaultViews bool) (err error) {
 	err = project.CheckIsArchived(s)
 	if err != nil {
 		return err
@@ -722,18 +723,24 @@ func CreateProject(s *xorm.Session, project *Project, auth web.Auth, createBackl
 		}
 	}
 
-	if createBacklogBucket {
-		// Create a new first bucket for this project
-		b := &Bucket{
-			ProjectID: project.ID,
-			Title:     "Backlog",
-		}
-		err = b.Create(s, auth)
+	if createDefaultViews {
+		err = createDefaultViewsForProject(s, project, auth)
 		if err != nil {
 			return
 		}
 	}
 
+	err = addHistoryEntries(s, auth, &HistoryEntry{
+		EntityKind: HistoryEntityKindProject,
+		EntityID:   project.ID,
+		Action:     HistoryActionCreated,
+		NewValue:   project.Title,
+		ProjectID:  project.ID,
+	})
+	if err != nil {
+		return
+	}
+
 	return events.Dispatch(&ProjectCreatedEvent{
 		Project: project,
 		Doer:    doer,
@@ -766,6 +773,11 @@ func UpdateProject(s *xorm.Session, project *Project, auth web.Auth, updateProje
 		return
 	}
 
+	oldProject, err := GetProjectSimpleByID(s, project.ID)
+	if err != nil {
+		return
+	}
+
 	if project.IsArchived {
 		isDefaultProject, err := project.isDefaultProject(s)
 		if err != nil {
@@ -781,12 +793,12 @@ func UpdateProject(s *xorm.Session, project *Project, auth web.Auth, updateProje
 	colsToUpdate := []string{
 		"title",
 		"is_archived",
+		"is_template",
+		"enforce_dependencies",
 		"identifier",
 		"hex_color",
 		"parent_project_id",
 		"position",
-		"done_bucket_id",
-		"default_bucket_id",
 	}
 	if project.Description != "" {
 		colsToUpdate = append(colsToUpdate, "description")
@@ -827,6 +839,21 @@ func UpdateProject(s *xorm.Session, project *Project, auth web.Auth, updateProje
 		return err
 	}
 
+	historyFields := []string{}
+	for _, col := range colsToUpdate {
+		if col != "position" && col != "background_blur_hash" {
+			historyFields = append(historyFields, col)
+		}
+	}
+	err = addHistoryEntries(s, auth, historyEntriesForChanges(HistoryEntry{
+		EntityKind: HistoryEntityKindProject,
+		EntityID:   project.ID,
+		ProjectID:  project.ID,
+	}, oldProject, project, historyFields)...)
+	if err != nil {
+		return err
+	}
+
 	err = events.Dispatch(&ProjectUpdatedEvent{
 		Project: project,
 		Doer:    auth,
@@ -922,6 +949,12 @@ func updateProjectByTaskID(s *xorm.Session, taskID int64) (err error) {
 		return err
 	}
 
+	// Labels, assignees and others are part of the task when syncing it
+	err = recordTaskSyncChange(s, task.ProjectID, &task, false)
+	if err != nil {
+		return err
+	}
+
 	return updateProjectLastUpdated(s, &Project{ID: task.ProjectID})
 }
 
@@ -1010,6 +1043,52 @@ func (p *Project) Delete(s *xorm.Session, a web.Auth) (err error) {
 		}
 	}
 
+	// Delete all custom fields, their values were already deleted with the tasks
+	_, err = s.Where("project_id = ?", p.ID).Delete(&CustomField{})
+	if err != nil {
+		return
+	}
+
+	// Delete all views and their buckets, task buckets and positions were already deleted with the tasks
+	_, err = s.Where("project_id = ?", p.ID).Delete(&Bucket{})
+	if err != nil {
+		return
+	}
+	_, err = s.Where("project_id = ?", p.ID).Delete(&ProjectView{})
+	if err != nil {
+		return
+	}
+
+	// Delete all automation rules with their execution log
+	_, err = s.
+		Where(builder.In("automation_rule_id", builder.Select("id").From("automation_rules").Where(builder.Eq{"project_id": p.ID}))).
+		Delete(&AutomationRuleExecution{})
+	if err != nil {
+		return
+	}
+	_, err = s.Where("project_id = ?", p.ID).Delete(&AutomationRule{})
+	if err != nil {
+		return
+	}
+
+	// Delete the change history, it can't be accessed anymore without the project
+	_, err = s.Where("project_id = ?", p.ID).Delete(&HistoryEntry{})
+	if err != nil {
+		return
+	}
+
+	// The tombstones of the deleted tasks are not needed anymore either
+	_, err = s.Where("project_id = ?", p.ID).Delete(&TaskSyncChange{})
+	if err != nil {
+		return
+	}
+
+	// Delete all webhooks, they would otherwise keep their secrets and target urls around
+	_, err = s.Where("project_id = ?", p.ID).Delete(&Webhook{})
+	if err != nil {
+		return
+	}
+
 	// Delete the project
 	_, err = s.ID(p.ID).Delete(&Project{})
 	if err != nil {
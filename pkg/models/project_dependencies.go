@@ -0,0 +1,336 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+	"time"
+
+	"code.vikunja.io/web"
+
+	"xorm.io/xorm"
+)
+
+// ProjectDependencyGraph holds the dependencies between all tasks of a project, together with the critical path
+// through them.
+type ProjectDependencyGraph struct {
+	// The project this graph is for
+	ProjectID int64 `json:"project_id" param:"project"`
+	// All tasks of the project with their earliest dates
+	Tasks []*DependencyGraphTask `json:"tasks"`
+	// All dependencies between tasks of the project. Both blocking and precedes relations are dependencies.
+	Dependencies []*TaskDependency `json:"dependencies"`
+	// The ids of the tasks forming each cycle. If there are cycles, no critical path can be computed.
+	Cycles [][]int64 `json:"cycles"`
+	// The ids of the tasks on the critical path, in the order they need to be done.
+	CriticalPath []int64 `json:"critical_path"`
+	// The earliest date all tasks of the project can be finished, based on their start and end dates.
+	EarliestFinish time.Time `json:"earliest_finish"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// DependencyGraphTask holds the computed dates of a single task in a dependency graph
+type DependencyGraphTask struct {
+	TaskID int64 `json:"task_id"`
+	Done   bool  `json:"done"`
+	// The earliest date the task can start, either its own start date or the date when all tasks it depends on are finished.
+	EarliestStart time.Time `json:"earliest_start"`
+	// The earliest date the task can be finished.
+	EarliestFinish time.Time `json:"earliest_finish"`
+	// How many seconds the task can be delayed without delaying the whole project.
+	Slack int64 `json:"slack"`
+	// Whether the task is on the critical path.
+	Critical bool `json:"critical"`
+
+	duration    time.Duration
+	depth       int
+	predecessor int64
+}
+
+// TaskDependency is a single edge in a dependency graph
+type TaskDependency struct {
+	// The task which needs to be done first
+	TaskID int64 `json:"task_id"`
+	// The task depending on it
+	DependentTaskID int64 `json:"dependent_task_id"`
+	// Either blocking or precedes
+	Kind RelationKind `json:"kind"`
+}
+
+// CanRead checks if a user can see the dependency graph of a project
+func (pdg *ProjectDependencyGraph) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	p := &Project{ID: pdg.ProjectID}
+	return p.CanRead(s, a)
+}
+
+func laterOf(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// ReadOne computes the dependency graph of a project
+// @Summary Get the dependency graph of a project
+// @Description Returns all dependencies between the tasks of a project, cycles in them and the critical path with the earliest finish dates of all tasks, based on their start and end dates.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param projectID path int true "Project ID"
+// @Success 200 {object} models.ProjectDependencyGraph "The dependency graph of the project."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{projectID}/dependencies [get]
+func (pdg *ProjectDependencyGraph) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	tasks := []*Task{}
+	err = s.
+		Where("project_id = ?", pdg.ProjectID).
+		OrderBy("id ASC").
+		Find(&tasks)
+	if err != nil {
+		return err
+	}
+
+	pdg.Tasks = make([]*DependencyGraphTask, 0, len(tasks))
+	pdg.Dependencies = []*TaskDependency{}
+	pdg.Cycles = [][]int64{}
+	pdg.CriticalPath = []int64{}
+
+	nodes := make(map[int64]*DependencyGraphTask, len(tasks))
+	originalTasks := make(map[int64]*Task, len(tasks))
+	taskIDs := make([]int64, 0, len(tasks))
+	for _, t := range tasks {
+		node := &DependencyGraphTask{
+			TaskID: t.ID,
+			Done:   t.Done,
+		}
+		if !t.StartDate.IsZero() && t.EndDate.After(t.StartDate) {
+			node.duration = t.EndDate.Sub(t.StartDate)
+		}
+		nodes[t.ID] = node
+		originalTasks[t.ID] = t
+		taskIDs = append(taskIDs, t.ID)
+		pdg.Tasks = append(pdg.Tasks, node)
+	}
+
+	if len(taskIDs) == 0 {
+		return nil
+	}
+
+	relations := []*TaskRelation{}
+	err = s.
+		In("task_id", taskIDs).
+		In("relation_kind", []RelationKind{RelationKindBlocking, RelationKindBlocked, RelationKindPreceeds, RelationKindFollows}).
+		OrderBy("id ASC").
+		Find(&relations)
+	if err != nil {
+		return err
+	}
+
+	// Each relation exists in both directions, we only need each edge once.
+	predecessors := make(map[int64][]int64)
+	successors := make(map[int64][]int64)
+	seen := make(map[[2]int64]bool)
+	for _, rel := range relations {
+		from, to, kind := rel.TaskID, rel.OtherTaskID, rel.RelationKind
+		switch rel.RelationKind {
+		case RelationKindBlocked:
+			from, to, kind = rel.OtherTaskID, rel.TaskID, RelationKindBlocking
+		case RelationKindFollows:
+			from, to, kind = rel.OtherTaskID, rel.TaskID, RelationKindPreceeds
+		}
+
+		if _, exists := nodes[to]; !exists {
+			continue
+		}
+		if _, exists := nodes[from]; !exists || seen[[2]int64{from, to}] {
+			continue
+		}
+		seen[[2]int64{from, to}] = true
+
+		predecessors[to] = append(predecessors[to], from)
+		successors[from] = append(successors[from], to)
+		pdg.Dependencies = append(pdg.Dependencies, &TaskDependency{
+			TaskID:          from,
+			DependentTaskID: to,
+			Kind:            kind,
+		})
+	}
+
+	order := topologicalTaskOrder(taskIDs, predecessors, successors)
+	if len(order) < len(taskIDs) {
+		pdg.Cycles = findTaskCycles(taskIDs, order, successors)
+		return nil
+	}
+
+	pdg.computeCriticalPath(order, nodes, originalTasks, predecessors, successors)
+	return nil
+}
+
+// topologicalTaskOrder sorts the tasks so that every task comes after all tasks it depends on. Tasks which are
+// part of a cycle are not included.
+func topologicalTaskOrder(taskIDs []int64, predecessors, successors map[int64][]int64) (order []int64) {
+	remaining := make(map[int64]int, len(taskIDs))
+	ready := []int64{}
+	for _, id := range taskIDs {
+		remaining[id] = len(predecessors[id])
+		if remaining[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+
+	order = make([]int64, 0, len(taskIDs))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool {
+			return ready[i] < ready[j]
+		})
+		id := ready[0]
+		ready = ready[1:]
+		order = append(order, id)
+
+		for _, next := range successors[id] {
+			remaining[next]--
+			if remaining[next] == 0 {
+				ready = append(ready, next)
+			}
+		}
+	}
+
+	return order
+}
+
+// findTaskCycles returns the cycles between all tasks which could not be sorted topologically.
+func findTaskCycles(taskIDs, sorted []int64, successors map[int64][]int64) (cycles [][]int64) {
+	visited := make(map[int64]bool, len(taskIDs))
+	for _, id := range sorted {
+		visited[id] = true
+	}
+
+	onStack := make(map[int64]int)
+	stack := []int64{}
+	var visit func(id int64)
+	visit = func(id int64) {
+		visited[id] = true
+		onStack[id] = len(stack)
+		stack = append(stack, id)
+
+		for _, next := range successors[id] {
+			if pos, exists := onStack[next]; exists {
+				cycle := make([]int64, len(stack)-pos)
+				copy(cycle, stack[pos:])
+				cycles = append(cycles, cycle)
+				continue
+			}
+			if !visited[next] {
+				visit(next)
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		delete(onStack, id)
+	}
+
+	for _, id := range taskIDs {
+		if !visited[id] {
+			visit(id)
+		}
+	}
+
+	return cycles
+}
+
+// computeCriticalPath calculates the earliest dates and slack of all tasks and the longest chain of dependent
+// tasks through the project.
+func (pdg *ProjectDependencyGraph) computeCriticalPath(order []int64, nodes map[int64]*DependencyGraphTask, tasks map[int64]*Task, predecessors, successors map[int64][]int64) {
+	var last *DependencyGraphTask
+	for _, id := range order {
+		node := nodes[id]
+		node.depth = 1
+		node.EarliestStart = tasks[id].StartDate
+
+		for _, predID := range predecessors[id] {
+			pred := nodes[predID]
+			node.EarliestStart = laterOf(node.EarliestStart, pred.EarliestFinish)
+			if node.predecessor == 0 || isLongerChain(pred, nodes[node.predecessor]) {
+				node.predecessor = predID
+				node.depth = pred.depth + 1
+			}
+		}
+
+		node.EarliestFinish = node.EarliestStart
+		if !node.EarliestStart.IsZero() {
+			node.EarliestFinish = node.EarliestStart.Add(node.duration)
+		}
+		if tasks[id].StartDate.IsZero() {
+			node.EarliestFinish = laterOf(node.EarliestFinish, tasks[id].EndDate)
+		}
+
+		if last == nil || isLongerChain(node, last) {
+			last = node
+		}
+	}
+
+	if last == nil || (last.EarliestFinish.IsZero() && last.depth == 1) {
+		return
+	}
+
+	pdg.EarliestFinish = last.EarliestFinish
+	for node := last; node != nil; {
+		pdg.CriticalPath = append([]int64{node.TaskID}, pdg.CriticalPath...)
+		node.Critical = true
+		if node.predecessor == 0 {
+			break
+		}
+		node = nodes[node.predecessor]
+	}
+
+	if pdg.EarliestFinish.IsZero() {
+		return
+	}
+
+	// Walk backwards through the tasks to find out how late each of them can finish
+	latestStart := make(map[int64]time.Time, len(order))
+	for i := len(order) - 1; i >= 0; i-- {
+		node := nodes[order[i]]
+		latestFinish := pdg.EarliestFinish
+		for _, succID := range successors[node.TaskID] {
+			if ls, has := latestStart[succID]; has && ls.Before(latestFinish) {
+				latestFinish = ls
+			}
+		}
+		if node.EarliestFinish.IsZero() {
+			continue
+		}
+		latestStart[node.TaskID] = latestFinish.Add(-node.duration)
+		node.Slack = int64(latestFinish.Sub(node.EarliestFinish).Seconds())
+	}
+}
+
+// isLongerChain returns true if the chain of tasks ending with a finishes later (or, if both finish at the same
+// time, contains more tasks) than the one ending with b.
+func isLongerChain(a, b *DependencyGraphTask) bool {
+	if !a.EarliestFinish.Equal(b.EarliestFinish) {
+		return a.EarliestFinish.After(b.EarliestFinish)
+	}
+	if a.depth != b.depth {
+		return a.depth > b.depth
+	}
+	return a.TaskID < b.TaskID
+}
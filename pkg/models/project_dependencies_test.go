@@ -0,0 +1,134 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+)
+
+func addTestTaskRelation(t *testing.T, s *xorm.Session, taskID, otherTaskID int64, kind RelationKind) {
+	rel := &TaskRelation{
+		TaskID:       taskID,
+		OtherTaskID:  otherTaskID,
+		RelationKind: kind,
+	}
+	err := rel.Create(s, &user.User{ID: 1})
+	require.NoError(t, err)
+}
+
+func getDependencyGraphTask(pdg *ProjectDependencyGraph, taskID int64) *DependencyGraphTask {
+	for _, task := range pdg.Tasks {
+		if task.TaskID == taskID {
+			return task
+		}
+	}
+	return nil
+}
+
+func TestProjectDependencyGraph_ReadOne(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("critical path", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		addTestTaskRelation(t, s, 27, 9, RelationKindPreceeds)
+		addTestTaskRelation(t, s, 9, 3, RelationKindBlocking)
+
+		pdg := &ProjectDependencyGraph{ProjectID: 1}
+		err := pdg.ReadOne(s, u)
+		require.NoError(t, err)
+
+		task9, err := GetTaskByIDSimple(s, 9)
+		require.NoError(t, err)
+
+		assert.Len(t, pdg.Tasks, 18)
+		assert.Empty(t, pdg.Cycles)
+		assert.ElementsMatch(t, []*TaskDependency{
+			{TaskID: 27, DependentTaskID: 9, Kind: RelationKindPreceeds},
+			{TaskID: 9, DependentTaskID: 3, Kind: RelationKindBlocking},
+		}, pdg.Dependencies)
+		assert.Equal(t, []int64{27, 9, 3}, pdg.CriticalPath)
+		assert.Equal(t, task9.EndDate.Unix(), pdg.EarliestFinish.Unix())
+
+		node := getDependencyGraphTask(pdg, 3)
+		require.NotNil(t, node)
+		assert.Equal(t, task9.EndDate.Unix(), node.EarliestStart.Unix())
+		assert.Equal(t, task9.EndDate.Unix(), node.EarliestFinish.Unix())
+		assert.True(t, node.Critical)
+
+		node = getDependencyGraphTask(pdg, 9)
+		require.NotNil(t, node)
+		assert.Equal(t, task9.StartDate.Unix(), node.EarliestStart.Unix())
+		assert.Equal(t, int64(0), node.Slack)
+		assert.True(t, node.Critical)
+
+		// Task 7 starts at the same time as task 9 but has no end date
+		node = getDependencyGraphTask(pdg, 7)
+		require.NotNil(t, node)
+		assert.False(t, node.Critical)
+		assert.Equal(t, int64(task9.EndDate.Sub(task9.StartDate).Seconds()), node.Slack)
+	})
+	t.Run("cycle", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		addTestTaskRelation(t, s, 1, 3, RelationKindBlocking)
+		addTestTaskRelation(t, s, 3, 4, RelationKindPreceeds)
+		addTestTaskRelation(t, s, 4, 1, RelationKindBlocking)
+		addTestTaskRelation(t, s, 10, 1, RelationKindBlocking)
+
+		pdg := &ProjectDependencyGraph{ProjectID: 1}
+		err := pdg.ReadOne(s, u)
+		require.NoError(t, err)
+
+		require.Len(t, pdg.Cycles, 1)
+		assert.ElementsMatch(t, []int64{1, 3, 4}, pdg.Cycles[0])
+		assert.Empty(t, pdg.CriticalPath)
+	})
+	t.Run("dependencies to other projects are ignored", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		addTestTaskRelation(t, s, 35, 1, RelationKindBlocking)
+
+		pdg := &ProjectDependencyGraph{ProjectID: 1}
+		err := pdg.ReadOne(s, u)
+		require.NoError(t, err)
+		assert.Empty(t, pdg.Dependencies)
+	})
+	t.Run("no access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pdg := &ProjectDependencyGraph{ProjectID: 20}
+		can, _, err := pdg.CanRead(s, u)
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
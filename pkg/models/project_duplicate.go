@@ -58,7 +58,7 @@ func (pd *ProjectDuplicate) CanCreate(s *xorm.Session, a web.Auth) (canCreate bo
 
 // Create duplicates a project
 // @Summary Duplicate an existing project
-// @Description Copies the project, tasks, files, kanban data, assignees, comments, attachments, lables, relations, backgrounds, user/team rights and link shares from one project to a new one. The user needs read access in the project and write access in the parent of the new project.
+// @Description Copies the project, tasks, files, kanban data, assignees, comments, attachments, lables, relations, custom fields, backgrounds, user/team rights and link shares from one project to a new one. The user needs read access in the project and write access in the parent of the new project.
 // @tags project
 // @Accept json
 // @Produce json
@@ -70,9 +70,20 @@ func (pd *ProjectDuplicate) CanCreate(s *xorm.Session, a web.Auth) (canCreate bo
 // @Failure 403 {object} web.HTTPError "The user does not have access to the project or its parent."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /projects/{projectID}/duplicate [put]
+func (pd *ProjectDuplicate) Create(s *xorm.Session, doer web.Auth) (err error) {
+	_, err = pd.duplicateProject(s, doer)
+	if err != nil {
+		return
+	}
+
+	return pd.duplicateShares(s)
+}
+
+// duplicateProject copies the project with all of its custom fields, tasks, views, buckets and its background.
+// It returns a map of the old task ids to the ids of their copies.
 //
 //nolint:gocyclo
-func (pd *ProjectDuplicate) Create(s *xorm.Session, doer web.Auth) (err error) {
+func (pd *ProjectDuplicate) duplicateProject(s *xorm.Session, doer web.Auth) (taskMap map[int64]int64, err error) {
 
 	log.Debugf("Duplicating project %d", pd.ProjectID)
 
@@ -86,36 +97,41 @@ func (pd *ProjectDuplicate) Create(s *xorm.Session, doer web.Auth) (err error) {
 		if IsErrProjectIdentifierIsNotUnique(err) {
 			pd.Project.Identifier = ""
 		} else {
-			return err
+			return nil, err
 		}
 	}
 
 	log.Debugf("Duplicated project %d into new project %d", pd.ProjectID, pd.Project.ID)
 
-	// Duplicate kanban buckets
-	// Old bucket ID as key, new id as value
-	// Used to map the newly created tasks to their new buckets
-	bucketMap := make(map[int64]int64)
-	buckets := []*Bucket{}
-	err = s.Where("project_id = ?", pd.ProjectID).Find(&buckets)
+	// Duplicate custom fields
+	// Old field ID as key, new id as value
+	fieldMap := make(map[int64]int64)
+	fields, err := GetCustomFieldsByProjectID(s, pd.ProjectID)
 	if err != nil {
-		return
+		return nil, err
 	}
-	for _, b := range buckets {
-		oldID := b.ID
-		b.ID = 0
-		b.ProjectID = pd.Project.ID
-		if err := b.Create(s, doer); err != nil {
-			return err
+	for _, f := range fields {
+		oldID := f.ID
+		f.ID = 0
+		f.ProjectID = pd.Project.ID
+		if err := f.Create(s, doer); err != nil {
+			return nil, err
 		}
-		bucketMap[oldID] = b.ID
+		fieldMap[oldID] = f.ID
 	}
 
-	log.Debugf("Duplicated all buckets from project %d into %d", pd.ProjectID, pd.Project.ID)
+	log.Debugf("Duplicated all custom fields from project %d into %d", pd.ProjectID, pd.Project.ID)
 
-	err = duplicateTasks(s, doer, pd, bucketMap)
+	// The tasks are duplicated before the views so that they don't get default buckets and positions.
+	// Those are copied from the original project afterwards.
+	taskMap, err = duplicateTasks(s, doer, pd, fieldMap)
 	if err != nil {
-		return
+		return nil, err
+	}
+
+	err = duplicateViews(s, pd, taskMap)
+	if err != nil {
+		return nil, err
 	}
 
 	// Background files + unsplash info
@@ -125,38 +141,43 @@ func (pd *ProjectDuplicate) Create(s *xorm.Session, doer web.Auth) (err error) {
 
 		f := &files.File{ID: pd.Project.BackgroundFileID}
 		if err := f.LoadFileMetaByID(); err != nil {
-			return err
+			return nil, err
 		}
 		if err := f.LoadFileByID(); err != nil {
-			return err
+			return nil, err
 		}
 		defer f.File.Close()
 
-		file, err := files.Create(f.File, f.Name, f.Size, doer)
+		file, err := files.CreateForProject(f.File, f.Name, f.Size, doer, pd.Project.ID)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		// Get unsplash info if applicable
 		up, err := GetUnsplashPhotoByFileID(s, pd.Project.BackgroundFileID)
 		if err != nil && files.IsErrFileIsNotUnsplashFile(err) {
-			return err
+			return nil, err
 		}
 		if up != nil {
 			up.ID = 0
 			up.FileID = file.ID
 			if err := up.Save(s); err != nil {
-				return err
+				return nil, err
 			}
 		}
 
 		if err := SetProjectBackground(s, pd.Project.ID, file, pd.Project.BackgroundBlurHash); err != nil {
-			return err
+			return nil, err
 		}
 
 		log.Debugf("Duplicated project background from project %d into %d", pd.ProjectID, pd.Project.ID)
 	}
 
+	return taskMap, nil
+}
+
+// duplicateShares copies all user, team and link shares of the project. Link shares get a new hash.
+func (pd *ProjectDuplicate) duplicateShares(s *xorm.Session) (err error) {
 	// Rights / Shares
 	// To keep it simple(r) we will only copy rights which are directly used with the project, not the parent
 	users := []*ProjectUser{}
@@ -207,31 +228,126 @@ func (pd *ProjectDuplicate) Create(s *xorm.Session, doer web.Auth) (err error) {
 	return
 }
 
-func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ProjectDuplicate, bucketMap map[int64]int64) (err error) {
-	// Get all tasks + all task details
-	tasks, _, _, err := getTasksForProjects(s, []*Project{{ID: ld.ProjectID}}, doer, &taskSearchOptions{})
+func duplicateViews(s *xorm.Session, pd *ProjectDuplicate, taskMap map[int64]int64) (err error) {
+	// Old view ID as key, new id as value
+	viewMap := make(map[int64]int64)
+	views, err := getProjectViewsForProject(s, pd.ProjectID)
 	if err != nil {
-		return err
+		return
+	}
+	for _, v := range views {
+		oldID := v.ID
+		v.ID = 0
+		v.ProjectID = pd.Project.ID
+		if _, err := s.Insert(v); err != nil {
+			return err
+		}
+		viewMap[oldID] = v.ID
 	}
 
-	if len(tasks) == 0 {
+	log.Debugf("Duplicated all views from project %d into %d", pd.ProjectID, pd.Project.ID)
+
+	// Duplicate kanban buckets
+	// Old bucket ID as key, new id as value
+	// Used to map the newly created tasks to their new buckets
+	bucketMap := make(map[int64]int64)
+	buckets := []*Bucket{}
+	err = s.Where("project_id = ?", pd.ProjectID).Find(&buckets)
+	if err != nil {
+		return
+	}
+	for _, b := range buckets {
+		oldID := b.ID
+		b.ID = 0
+		b.ProjectID = pd.Project.ID
+		b.ProjectViewID = viewMap[b.ProjectViewID]
+		if _, err := s.Insert(b); err != nil {
+			return err
+		}
+		bucketMap[oldID] = b.ID
+	}
+
+	for _, v := range views {
+		if v.DefaultBucketID == 0 && v.DoneBucketID == 0 {
+			continue
+		}
+		v.DefaultBucketID = bucketMap[v.DefaultBucketID]
+		v.DoneBucketID = bucketMap[v.DoneBucketID]
+		_, err = s.
+			Where("id = ?", v.ID).
+			Cols("default_bucket_id", "done_bucket_id").
+			Update(v)
+		if err != nil {
+			return
+		}
+	}
+
+	log.Debugf("Duplicated all buckets from project %d into %d", pd.ProjectID, pd.Project.ID)
+
+	if len(taskMap) == 0 {
 		return nil
 	}
 
+	oldTaskIDs := make([]int64, 0, len(taskMap))
+	for oldID := range taskMap {
+		oldTaskIDs = append(oldTaskIDs, oldID)
+	}
+
+	taskBuckets := []*TaskBucket{}
+	err = s.In("task_id", oldTaskIDs).Find(&taskBuckets)
+	if err != nil {
+		return
+	}
+	for _, tb := range taskBuckets {
+		tb.TaskID = taskMap[tb.TaskID]
+		tb.BucketID = bucketMap[tb.BucketID]
+		tb.ProjectViewID = viewMap[tb.ProjectViewID]
+		if _, err := s.Insert(tb); err != nil {
+			return err
+		}
+	}
+
+	positions := []*TaskPosition{}
+	err = s.In("task_id", oldTaskIDs).Find(&positions)
+	if err != nil {
+		return
+	}
+	for _, tp := range positions {
+		tp.TaskID = taskMap[tp.TaskID]
+		tp.ProjectViewID = viewMap[tp.ProjectViewID]
+		if _, err := s.Insert(tp); err != nil {
+			return err
+		}
+	}
+
+	log.Debugf("Duplicated all task buckets and positions from project %d into %d", pd.ProjectID, pd.Project.ID)
+
+	return
+}
+
+func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ProjectDuplicate, fieldMap map[int64]int64) (taskMap map[int64]int64, err error) {
 	// This map contains the old task id as key and the new duplicated task id as value.
 	// It is used to map old task items to new ones.
-	taskMap := make(map[int64]int64)
+	taskMap = make(map[int64]int64)
+
+	// Get all tasks + all task details
+	tasks, _, _, err := getTasksForProjects(s, []*Project{{ID: ld.ProjectID}}, doer, &taskSearchOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tasks) == 0 {
+		return taskMap, nil
+	}
+
 	// Create + update all tasks (includes reminders)
 	oldTaskIDs := make([]int64, 0, len(tasks))
 	for _, t := range tasks {
 		oldID := t.ID
-		t.ID = 0
-		t.ProjectID = ld.Project.ID
-		t.BucketID = bucketMap[t.BucketID]
-		t.UID = ""
-		err := createTask(s, t, doer, false)
+		t.CustomFields = nil // Duplicated separately below since the fields got new ids
+		err := createTaskCopy(s, t, ld.Project.ID, doer)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		taskMap[oldID] = t.ID
 		oldTaskIDs = append(oldTaskIDs, oldID)
@@ -244,7 +360,7 @@ func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ProjectDuplicate, bucket
 	// file changes in the other project which is not something we want.
 	attachments, err := getTaskAttachmentsByTaskIDs(s, oldTaskIDs)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	for _, attachment := range attachments {
@@ -262,15 +378,15 @@ func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ProjectDuplicate, bucket
 				log.Debugf("Not duplicating attachment %d (file %d) because it does not exist from project %d into %d", oldAttachmentID, attachment.FileID, ld.ProjectID, ld.Project.ID)
 				continue
 			}
-			return err
+			return nil, err
 		}
 		if err := attachment.File.LoadFileByID(); err != nil {
-			return err
+			return nil, err
 		}
 
 		err := attachment.NewAttachment(s, attachment.File.File, attachment.File.Name, attachment.File.Size, doer)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		if attachment.File.File != nil {
@@ -290,11 +406,11 @@ func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ProjectDuplicate, bucket
 	}
 
 	for _, lt := range labelTasks {
-		lt.ID = 0
 		lt.TaskID = taskMap[lt.TaskID]
-		if _, err := s.Insert(lt); err != nil {
-			return err
-		}
+	}
+	err = addLabelTasks(s, labelTasks)
+	if err != nil {
+		return nil, err
 	}
 
 	log.Debugf("Duplicated all labels from project %d into %d", ld.ProjectID, ld.Project.ID)
@@ -311,16 +427,34 @@ func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ProjectDuplicate, bucket
 			ID:        taskMap[a.TaskID],
 			ProjectID: ld.Project.ID,
 		}
-		if err := t.addNewAssigneeByID(s, a.UserID, ld.Project, doer); err != nil {
-			if IsErrUserDoesNotHaveAccessToProject(err) {
-				continue
-			}
-			return err
+		if err := addAssigneesWithAccess(s, t, []int64{a.UserID}, ld.Project, doer); err != nil {
+			return nil, err
 		}
 	}
 
 	log.Debugf("Duplicated all assignees from project %d into %d", ld.ProjectID, ld.Project.ID)
 
+	// Custom field values
+	values := []*TaskCustomFieldValue{}
+	err = s.In("task_id", oldTaskIDs).Find(&values)
+	if err != nil {
+		return
+	}
+	for _, v := range values {
+		newFieldID, exists := fieldMap[v.FieldID]
+		if !exists {
+			continue
+		}
+		v.ID = 0
+		v.TaskID = taskMap[v.TaskID]
+		v.FieldID = newFieldID
+		if _, err := s.Insert(v); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Debugf("Duplicated all custom field values from project %d into %d", ld.ProjectID, ld.Project.ID)
+
 	// Comments
 	comments := []*TaskComment{}
 	err = s.In("task_id", oldTaskIDs).Find(&comments)
@@ -331,7 +465,7 @@ func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ProjectDuplicate, bucket
 		c.ID = 0
 		c.TaskID = taskMap[c.TaskID]
 		if _, err := s.Insert(c); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
@@ -354,11 +488,46 @@ func duplicateTasks(s *xorm.Session, doer web.Auth, ld *ProjectDuplicate, bucket
 		r.OtherTaskID = otherTaskID
 		r.TaskID = taskMap[r.TaskID]
 		if _, err := s.Insert(r); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
 	log.Debugf("Duplicated all task relations from project %d into %d", ld.ProjectID, ld.Project.ID)
 
+	return taskMap, nil
+}
+
+// createTaskCopy creates a new task with all values of t in the project. It is used to duplicate tasks and to
+// create tasks from templates.
+func createTaskCopy(s *xorm.Session, t *Task, projectID int64, doer web.Auth) error {
+	t.ID = 0
+	t.ProjectID = projectID
+	t.BucketID = 0
+	t.UID = ""
+	return createTask(s, t, doer, false)
+}
+
+// addLabelTasks saves all label <-> task relations without checking the label rights again.
+func addLabelTasks(s *xorm.Session, labelTasks []*LabelTask) error {
+	for _, lt := range labelTasks {
+		lt.ID = 0
+		if _, err := s.Insert(lt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addAssigneesWithAccess assigns all users to the task which have access to the project. Users without access
+// are skipped.
+func addAssigneesWithAccess(s *xorm.Session, t *Task, userIDs []int64, project *Project, doer web.Auth) error {
+	for _, userID := range userIDs {
+		if err := t.addNewAssigneeByID(s, userID, project, doer); err != nil {
+			if IsErrUserDoesNotHaveAccessToProject(err) {
+				continue
+			}
+			return err
+		}
+	}
 	return nil
 }
@@ -52,6 +52,19 @@ func TestProjectDuplicate(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, numberOfOriginalBuckets, numberOfDuplicatedBuckets, "duplicated project does not have the same amount of buckets as the original one")
 
+	// assert the views and the buckets of the tasks were duplicated as well
+	numberOfOriginalViews, err := s.Where("project_id = ?", l.ProjectID).Count(&ProjectView{})
+	assert.NoError(t, err)
+	numberOfDuplicatedViews, err := s.Where("project_id = ?", l.Project.ID).Count(&ProjectView{})
+	assert.NoError(t, err)
+	assert.Equal(t, numberOfOriginalViews, numberOfDuplicatedViews, "duplicated project does not have the same amount of views as the original one")
+
+	duplicatedKanbanView, err := getFirstKanbanViewForProject(s, l.Project.ID)
+	assert.NoError(t, err)
+	numberOfDuplicatedTaskBuckets, err := s.Where("project_view_id = ?", duplicatedKanbanView.ID).Count(&TaskBucket{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12+3+3), numberOfDuplicatedTaskBuckets)
+
 	// To make this test 100% useful, it would need to assert a lot more stuff, but it is good enough for now.
 	// Also, we're lacking utility functions to do all needed assertions.
 }
@@ -0,0 +1,344 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/xorm"
+)
+
+// ProjectGantt holds everything needed to show the tasks of a project in a gantt chart
+type ProjectGantt struct {
+	// The project this gantt chart is for
+	ProjectID int64 `json:"project_id" param:"project"`
+	// All tasks of the project
+	Tasks []*GanttTask `json:"tasks"`
+	// All precedes relations between tasks of the project. Each relation is only returned once, from the task which needs to be done first.
+	Dependencies []*TaskDependency `json:"dependencies"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// GanttTask holds the parts of a task needed for a gantt chart
+type GanttTask struct {
+	ID          int64     `json:"id"`
+	ProjectID   int64     `json:"project_id"`
+	Title       string    `json:"title"`
+	Done        bool      `json:"done"`
+	PercentDone float64   `json:"percent_done"`
+	HexColor    string    `json:"hex_color"`
+	StartDate   time.Time `json:"start_date"`
+	EndDate     time.Time `json:"end_date"`
+	DueDate     time.Time `json:"due_date"`
+}
+
+func ganttTaskFromTask(t *Task) *GanttTask {
+	return &GanttTask{
+		ID:          t.ID,
+		ProjectID:   t.ProjectID,
+		Title:       t.Title,
+		Done:        t.Done,
+		PercentDone: t.PercentDone,
+		HexColor:    t.HexColor,
+		StartDate:   t.StartDate,
+		EndDate:     t.EndDate,
+		DueDate:     t.DueDate,
+	}
+}
+
+// CanRead checks if a user can see the gantt chart of a project
+func (pg *ProjectGantt) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	p := &Project{ID: pg.ProjectID}
+	return p.CanRead(s, a)
+}
+
+// ReadOne returns all tasks of a project with their dates and the relations between them
+// @Summary Get the gantt chart of a project
+// @Description Returns all tasks of a project with their start, end and due dates, together with all precedes relations between them.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param projectID path int true "Project ID"
+// @Success 200 {object} models.ProjectGantt "The gantt chart of the project."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{projectID}/gantt [get]
+func (pg *ProjectGantt) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	tasks := []*Task{}
+	err = s.
+		Where("project_id = ?", pg.ProjectID).
+		OrderBy("start_date ASC, id ASC").
+		Find(&tasks)
+	if err != nil {
+		return err
+	}
+
+	pg.Tasks = make([]*GanttTask, 0, len(tasks))
+	pg.Dependencies = []*TaskDependency{}
+	taskIDs := make([]int64, 0, len(tasks))
+	for _, t := range tasks {
+		pg.Tasks = append(pg.Tasks, ganttTaskFromTask(t))
+		taskIDs = append(taskIDs, t.ID)
+	}
+
+	if len(taskIDs) == 0 {
+		return nil
+	}
+
+	relations := []*TaskRelation{}
+	err = s.
+		In("task_id", taskIDs).
+		In("other_task_id", taskIDs).
+		And("relation_kind = ?", RelationKindPreceeds).
+		OrderBy("id ASC").
+		Find(&relations)
+	if err != nil {
+		return err
+	}
+
+	for _, rel := range relations {
+		pg.Dependencies = append(pg.Dependencies, &TaskDependency{
+			TaskID:          rel.TaskID,
+			DependentTaskID: rel.OtherTaskID,
+			Kind:            RelationKindPreceeds,
+		})
+	}
+
+	return nil
+}
+
+// TaskReschedule moves the dates of a task and shifts all tasks following it by the same amount of time
+type TaskReschedule struct {
+	// The task to reschedule
+	TaskID int64 `json:"-" param:"task"`
+	// The new start date of the task. If only one of start or end date is provided, the other one keeps its distance to it.
+	StartDate time.Time `json:"start_date"`
+	// The new end date of the task.
+	EndDate time.Time `json:"end_date"`
+	// All tasks which were moved, starting with the rescheduled task.
+	Tasks []*GanttTask `json:"tasks"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// CanUpdate checks if a user can reschedule a task. The rights for all following tasks are checked when
+// they are moved.
+func (tr *TaskReschedule) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	t := &Task{ID: tr.TaskID}
+	return t.CanUpdate(s, a)
+}
+
+// getRescheduleOffset returns the new dates of the task and how far all following tasks need to be moved.
+func (tr *TaskReschedule) getRescheduleOffset(task *Task) (start, end time.Time, offset time.Duration, err error) {
+	invalid := ErrInvalidTaskReschedule{
+		TaskID:    tr.TaskID,
+		StartDate: tr.StartDate,
+		EndDate:   tr.EndDate,
+	}
+
+	if tr.StartDate.IsZero() && tr.EndDate.IsZero() {
+		return start, end, 0, invalid
+	}
+
+	switch {
+	case !tr.StartDate.IsZero() && !task.StartDate.IsZero():
+		offset = tr.StartDate.Sub(task.StartDate)
+	case !tr.EndDate.IsZero() && !task.EndDate.IsZero():
+		offset = tr.EndDate.Sub(task.EndDate)
+	}
+
+	start = tr.StartDate
+	if start.IsZero() && !task.StartDate.IsZero() {
+		start = task.StartDate.Add(offset)
+	}
+	end = tr.EndDate
+	if end.IsZero() && !task.EndDate.IsZero() {
+		end = task.EndDate.Add(offset)
+	}
+
+	if !start.IsZero() && !end.IsZero() && end.Before(start) {
+		return start, end, 0, invalid
+	}
+
+	return
+}
+
+// moveTask saves new dates of a task. All reminders which are not relative to one of the dates are moved
+// by the given offset.
+func moveTask(s *xorm.Session, a web.Auth, task *Task, start, end time.Time, offset time.Duration) (err error) {
+	originalTask := *task
+	cols := []string{"start_date", "end_date", "due_date"}
+
+	task.StartDate = start
+	task.EndDate = end
+	if !task.DueDate.IsZero() {
+		task.DueDate = task.DueDate.Add(offset)
+	}
+
+	_, err = s.
+		ID(task.ID).
+		Cols(cols...).
+		Update(task)
+	if err != nil {
+		return err
+	}
+
+	task.Reminders, err = getRemindersForTasks(s, []int64{task.ID})
+	if err != nil {
+		return err
+	}
+	for _, r := range task.Reminders {
+		if r.RelativeTo == "" {
+			r.Reminder = r.Reminder.Add(offset)
+		}
+	}
+	err = task.updateReminders(s, task)
+	if err != nil {
+		return err
+	}
+
+	err = addHistoryEntries(s, a, historyEntriesForChanges(HistoryEntry{
+		EntityKind: HistoryEntityKindTask,
+		EntityID:   task.ID,
+		TaskID:     task.ID,
+		ProjectID:  task.ProjectID,
+	}, &originalTask, task, cols)...)
+	if err != nil {
+		return err
+	}
+
+	err = recordTaskSyncChange(s, task.ProjectID, task, false)
+	if err != nil {
+		return err
+	}
+
+	doer, _ := user.GetFromAuth(a)
+	return events.Dispatch(&TaskUpdatedEvent{
+		Task: task,
+		Doer: doer,
+	})
+}
+
+// Update reschedules a task and all tasks following it
+// @Summary Reschedule a task
+// @Description Sets new dates for a task and moves all tasks following it (through precedes relations, recursively) by the same amount of time. If the user does not have the right to update one of the following tasks, nothing is changed.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param reschedule body models.TaskReschedule true "The new dates of the task"
+// @Success 200 {object} models.TaskReschedule "All moved tasks."
+// @Failure 400 {object} web.HTTPError "Invalid dates provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task or one of the tasks following it."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/reschedule [post]
+func (tr *TaskReschedule) Update(s *xorm.Session, a web.Auth) (err error) {
+	task, err := GetTaskByIDSimple(s, tr.TaskID)
+	if err != nil {
+		return err
+	}
+
+	start, end, offset, err := tr.getRescheduleOffset(&task)
+	if err != nil {
+		return err
+	}
+
+	err = moveTask(s, a, &task, start, end, offset)
+	if err != nil {
+		return err
+	}
+
+	tr.Tasks = []*GanttTask{ganttTaskFromTask(&task)}
+	projectIDs := map[int64]bool{task.ProjectID: true}
+
+	if offset == 0 {
+		return updateProjectLastUpdated(s, &Project{ID: task.ProjectID})
+	}
+
+	// Move all following tasks, each of them only once, even if there are cycles
+	moved := map[int64]bool{task.ID: true}
+	queue := []int64{task.ID}
+	for len(queue) > 0 {
+		followerIDs := []int64{}
+		err = s.
+			Table("task_relations").
+			In("task_id", queue).
+			And("relation_kind = ?", RelationKindPreceeds).
+			Cols("other_task_id").
+			OrderBy("other_task_id ASC").
+			Find(&followerIDs)
+		if err != nil {
+			return err
+		}
+
+		queue = []int64{}
+		for _, id := range followerIDs {
+			if moved[id] {
+				continue
+			}
+			moved[id] = true
+			queue = append(queue, id)
+
+			follower, err := GetTaskByIDSimple(s, id)
+			if err != nil {
+				return err
+			}
+
+			can, err := follower.CanUpdate(s, a)
+			if err != nil {
+				return err
+			}
+			if !can {
+				return ErrGenericForbidden{}
+			}
+
+			var followerStart, followerEnd time.Time
+			if !follower.StartDate.IsZero() {
+				followerStart = follower.StartDate.Add(offset)
+			}
+			if !follower.EndDate.IsZero() {
+				followerEnd = follower.EndDate.Add(offset)
+			}
+
+			err = moveTask(s, a, &follower, followerStart, followerEnd, offset)
+			if err != nil {
+				return err
+			}
+
+			tr.Tasks = append(tr.Tasks, ganttTaskFromTask(&follower))
+			projectIDs[follower.ProjectID] = true
+		}
+	}
+
+	for projectID := range projectIDs {
+		err = updateProjectLastUpdated(s, &Project{ID: projectID})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
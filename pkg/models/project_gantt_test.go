@@ -0,0 +1,179 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectGantt_ReadOne(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	addTestTaskRelation(t, s, 27, 9, RelationKindPreceeds)
+	addTestTaskRelation(t, s, 9, 3, RelationKindBlocking)
+
+	pg := &ProjectGantt{ProjectID: 1}
+	err := pg.ReadOne(s, &user.User{ID: 1})
+	require.NoError(t, err)
+
+	assert.Len(t, pg.Tasks, 18)
+	assert.Equal(t, []*TaskDependency{
+		{TaskID: 27, DependentTaskID: 9, Kind: RelationKindPreceeds},
+	}, pg.Dependencies)
+}
+
+func TestTaskReschedule_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("cascade to following tasks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		// 9 -> 7 -> 27 -> 9 is a cycle, every task must only be moved once
+		addTestTaskRelation(t, s, 9, 7, RelationKindPreceeds)
+		addTestTaskRelation(t, s, 7, 27, RelationKindPreceeds)
+		addTestTaskRelation(t, s, 27, 9, RelationKindPreceeds)
+
+		before := map[int64]Task{}
+		for _, id := range []int64{7, 9, 27} {
+			task, err := GetTaskByIDSimple(s, id)
+			require.NoError(t, err)
+			before[id] = task
+		}
+		reminders, err := getRemindersForTasks(s, []int64{27})
+		require.NoError(t, err)
+
+		tr := &TaskReschedule{
+			TaskID:    9,
+			StartDate: before[9].StartDate.Add(24 * time.Hour),
+		}
+		err = tr.Update(s, u)
+		require.NoError(t, err)
+
+		require.Len(t, tr.Tasks, 3)
+		assert.Equal(t, int64(9), tr.Tasks[0].ID)
+		assert.Equal(t, int64(7), tr.Tasks[1].ID)
+		assert.Equal(t, int64(27), tr.Tasks[2].ID)
+
+		for _, id := range []int64{7, 9, 27} {
+			task, err := GetTaskByIDSimple(s, id)
+			require.NoError(t, err)
+			assert.Equal(t, before[id].StartDate.Add(24*time.Hour).Unix(), task.StartDate.Unix(), "start date of task %d", id)
+			if !before[id].EndDate.IsZero() {
+				assert.Equal(t, before[id].EndDate.Add(24*time.Hour).Unix(), task.EndDate.Unix(), "end date of task %d", id)
+			}
+		}
+
+		// Absolute reminders are moved, relative ones follow the date they are relative to
+		movedReminders, err := getRemindersForTasks(s, []int64{27})
+		require.NoError(t, err)
+		require.Len(t, movedReminders, len(reminders))
+		for _, r := range movedReminders {
+			if r.RelativeTo == ReminderRelationStartDate {
+				assert.Equal(t, before[27].StartDate.Add(23*time.Hour).Unix(), r.Reminder.Unix())
+				continue
+			}
+			assert.Equal(t, reminders[0].Reminder.Add(24*time.Hour).Unix(), r.Reminder.Unix())
+		}
+
+		err = s.Commit()
+		require.NoError(t, err)
+		db.AssertExists(t, "history_entries", map[string]interface{}{
+			"entity_kind": HistoryEntityKindTask,
+			"entity_id":   27,
+			"field":       "start_date",
+		}, false)
+	})
+	t.Run("only end date", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task, err := GetTaskByIDSimple(s, 8)
+		require.NoError(t, err)
+
+		tr := &TaskReschedule{
+			TaskID:  8,
+			EndDate: task.EndDate.Add(2 * time.Hour),
+		}
+		err = tr.Update(s, u)
+		require.NoError(t, err)
+
+		require.Len(t, tr.Tasks, 1)
+		assert.True(t, tr.Tasks[0].StartDate.IsZero())
+		assert.Equal(t, task.EndDate.Add(2*time.Hour).Unix(), tr.Tasks[0].EndDate.Unix())
+	})
+	t.Run("no dates", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tr := &TaskReschedule{TaskID: 9}
+		err := tr.Update(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrInvalidTaskReschedule(err))
+	})
+	t.Run("end before start", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tr := &TaskReschedule{
+			TaskID:    9,
+			StartDate: time.Date(2018, 12, 10, 0, 0, 0, 0, time.UTC),
+			EndDate:   time.Date(2018, 12, 9, 0, 0, 0, 0, time.UTC),
+		}
+		err := tr.Update(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrInvalidTaskReschedule(err))
+	})
+	t.Run("no rights on a following task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		addTestTaskRelation(t, s, 9, 34, RelationKindPreceeds)
+
+		tr := &TaskReschedule{
+			TaskID:    9,
+			StartDate: time.Date(2018, 12, 10, 0, 0, 0, 0, time.UTC),
+		}
+		err := tr.Update(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrGenericForbidden(err))
+	})
+	t.Run("no rights", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tr := &TaskReschedule{TaskID: 34}
+		can, err := tr.CanUpdate(s, u)
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
@@ -0,0 +1,64 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/web"
+
+	"xorm.io/xorm"
+)
+
+// ProjectStorage holds how much storage the attachments and background of a project use
+type ProjectStorage struct {
+	// The project id.
+	ProjectID int64 `json:"project_id" param:"project"`
+
+	files.StorageUsage
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// CanRead checks if the user can see the storage usage of a project
+func (ps *ProjectStorage) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	p := &Project{ID: ps.ProjectID}
+	return p.CanRead(s, a)
+}
+
+// ReadOne returns how much storage a project uses
+// @Summary Get the storage usage of a project
+// @Description Returns how much storage the attachments and the background of a project use and how much they may use.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Project ID"
+// @Success 200 {object} models.ProjectStorage "The storage usage of the project."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project."
+// @Failure 404 {object} web.HTTPError "The project does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{id}/storage [get]
+func (ps *ProjectStorage) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	usage, err := files.GetProjectStorageUsage(s, ps.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	ps.StorageUsage = *usage
+	return nil
+}
@@ -0,0 +1,56 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectStorage_ReadOne(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	files.InitTestFileFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+	u := &user.User{ID: 1}
+
+	ps := &ProjectStorage{ProjectID: 1}
+	err := ps.ReadOne(s, u)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), ps.Used)
+
+	// Task 1 belongs to project 1
+	ta := &TaskAttachment{TaskID: 1}
+	err = ta.NewAttachment(s, io.NopCloser(bytes.NewReader([]byte("testfile"))), "testfile", 8, u)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), ta.File.ProjectID)
+
+	err = ps.ReadOne(s, u)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(8), ps.Used)
+
+	can, _, err := (&ProjectStorage{ProjectID: 1}).CanRead(s, &user.User{ID: 2})
+	assert.NoError(t, err)
+	assert.False(t, can)
+}
@@ -109,6 +109,18 @@ func (tl *TeamProject) Create(s *xorm.Session, a web.Auth) (err error) {
 		return err
 	}
 
+	err = addHistoryEntries(s, a, &HistoryEntry{
+		EntityKind: HistoryEntityKindProjectTeam,
+		EntityID:   tl.TeamID,
+		Action:     HistoryActionCreated,
+		NewValue:   formatHistoryValue(tl.Right),
+		ProjectID:  tl.ProjectID,
+		TeamID:     tl.TeamID,
+	})
+	if err != nil {
+		return err
+	}
+
 	err = events.Dispatch(&ProjectSharedWithTeamEvent{
 		Project: l,
 		Team:    team,
@@ -135,7 +147,7 @@ func (tl *TeamProject) Create(s *xorm.Session, a web.Auth) (err error) {
 // @Failure 404 {object} web.HTTPError "Team or project does not exist."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /projects/{projectID}/teams/{teamID} [delete]
-func (tl *TeamProject) Delete(s *xorm.Session, _ web.Auth) (err error) {
+func (tl *TeamProject) Delete(s *xorm.Session, a web.Auth) (err error) {
 
 	// Check if the team exists
 	_, err = GetTeamByID(s, tl.TeamID)
@@ -144,9 +156,10 @@ func (tl *TeamProject) Delete(s *xorm.Session, _ web.Auth) (err error) {
 	}
 
 	// Check if the team has access to the project
+	existing := &TeamProject{}
 	has, err := s.
 		Where("team_id = ? AND project_id = ?", tl.TeamID, tl.ProjectID).
-		Get(&TeamProject{})
+		Get(existing)
 	if err != nil {
 		return
 	}
@@ -162,6 +175,18 @@ func (tl *TeamProject) Delete(s *xorm.Session, _ web.Auth) (err error) {
 		return err
 	}
 
+	err = addHistoryEntries(s, a, &HistoryEntry{
+		EntityKind: HistoryEntityKindProjectTeam,
+		EntityID:   tl.TeamID,
+		Action:     HistoryActionDeleted,
+		OldValue:   formatHistoryValue(existing.Right),
+		ProjectID:  tl.ProjectID,
+		TeamID:     tl.TeamID,
+	})
+	if err != nil {
+		return err
+	}
+
 	err = updateProjectLastUpdated(s, &Project{ID: tl.ProjectID})
 	return
 }
@@ -247,13 +272,21 @@ func (tl *TeamProject) ReadAll(s *xorm.Session, a web.Auth, search string, page
 // @Failure 404 {object} web.HTTPError "Team or project does not exist."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /projects/{projectID}/teams/{teamID} [post]
-func (tl *TeamProject) Update(s *xorm.Session, _ web.Auth) (err error) {
+func (tl *TeamProject) Update(s *xorm.Session, a web.Auth) (err error) {
 
 	// Check if the right is valid
 	if err := tl.Right.isValid(); err != nil {
 		return err
 	}
 
+	existing := &TeamProject{}
+	_, err = s.
+		Where("project_id = ? AND team_id = ?", tl.ProjectID, tl.TeamID).
+		Get(existing)
+	if err != nil {
+		return err
+	}
+
 	_, err = s.
 		Where("project_id = ? AND team_id = ?", tl.ProjectID, tl.TeamID).
 		Cols("right").
@@ -262,6 +295,16 @@ func (tl *TeamProject) Update(s *xorm.Session, _ web.Auth) (err error) {
 		return err
 	}
 
+	err = addHistoryEntries(s, a, historyEntriesForChanges(HistoryEntry{
+		EntityKind: HistoryEntityKindProjectTeam,
+		EntityID:   tl.TeamID,
+		ProjectID:  tl.ProjectID,
+		TeamID:     tl.TeamID,
+	}, existing, tl, []string{"right"})...)
+	if err != nil {
+		return err
+	}
+
 	err = updateProjectLastUpdated(s, &Project{ID: tl.ProjectID})
 	return
 }
@@ -0,0 +1,239 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"regexp"
+	"time"
+
+	"code.vikunja.io/api/pkg/log"
+
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// ProjectFromTemplate holds everything needed to create a project from a template
+type ProjectFromTemplate struct {
+	// The id of the template project
+	TemplateID int64 `json:"-" param:"projectid"`
+	// The title of the new project. Defaults to the title of the template. Can contain placeholders.
+	Title string `json:"title" valid:"runelength(0|250)" maxLength:"250"`
+	// The parent project of the new project
+	ParentProjectID int64 `json:"parent_project_id,omitempty"`
+	// All task dates and reminders are shifted so that the earliest task date of the template falls on this date. If not provided, the dates are copied unchanged.
+	StartDate time.Time `json:"start_date"`
+	// Values for placeholders like `{{client}}` in the titles and descriptions of the template, keyed by the placeholder name. Placeholders without a value are kept.
+	Placeholders map[string]string `json:"placeholders"`
+
+	// The created project
+	Project *Project `json:"created_project,omitempty"`
+
+	web.Rights   `json:"-"`
+	web.CRUDable `json:"-"`
+}
+
+var templatePlaceholderRegex = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+
+// replacePlaceholders replaces all placeholders in text with their value
+func replacePlaceholders(text string, placeholders map[string]string) string {
+	if len(placeholders) == 0 {
+		return text
+	}
+
+	return templatePlaceholderRegex.ReplaceAllStringFunc(text, func(match string) string {
+		name := templatePlaceholderRegex.FindStringSubmatch(match)[1]
+		if value, has := placeholders[name]; has {
+			return value
+		}
+		return match
+	})
+}
+
+// CanCreate checks if a user has the right to create a project from a template
+func (pft *ProjectFromTemplate) CanCreate(s *xorm.Session, a web.Auth) (canCreate bool, err error) {
+	// Template exists + user has read access to it
+	template := &Project{ID: pft.TemplateID}
+	canRead, _, err := template.CanRead(s, a)
+	if err != nil || !canRead {
+		return canRead, err
+	}
+
+	if !template.IsTemplate {
+		return false, &ErrProjectIsNotATemplate{ProjectID: template.ID}
+	}
+
+	// Parent project exists + user has write access to it or the user can create top level projects
+	return (&Project{ParentProjectID: pft.ParentProjectID}).CanCreate(s, a)
+}
+
+// Create creates a project from a template
+// @Summary Create a project from a template
+// @Description Creates a new project with the tasks, custom fields, views, buckets, labels and relations of a template project. All task dates and reminders are shifted to the start date and placeholders in the titles and descriptions are replaced. Shares of the template are not copied. The user needs read access to the template and write access to the parent of the new project.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param projectID path int true "The id of the template project"
+// @Param project body models.ProjectFromTemplate true "The title, start date and placeholder values of the new project."
+// @Success 201 {object} models.ProjectFromTemplate "The created project."
+// @Failure 400 {object} web.HTTPError "The project is not a template or the object is invalid."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the template or the parent project."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{projectID}/instantiate [put]
+func (pft *ProjectFromTemplate) Create(s *xorm.Session, doer web.Auth) (err error) {
+	template, err := GetProjectSimpleByID(s, pft.TemplateID)
+	if err != nil {
+		return err
+	}
+	if !template.IsTemplate {
+		return &ErrProjectIsNotATemplate{ProjectID: template.ID}
+	}
+
+	if pft.Title != "" {
+		template.Title = pft.Title
+	}
+	template.Title = replacePlaceholders(template.Title, pft.Placeholders)
+	template.Description = replacePlaceholders(template.Description, pft.Placeholders)
+	template.IsTemplate = false
+
+	pd := &ProjectDuplicate{
+		ProjectID:       pft.TemplateID,
+		ParentProjectID: pft.ParentProjectID,
+		Project:         template,
+	}
+	taskMap, err := pd.duplicateProject(s, doer)
+	if err != nil {
+		return err
+	}
+	pft.Project = pd.Project
+
+	log.Debugf("Created project %d from template %d", pft.Project.ID, pft.TemplateID)
+
+	newTaskIDs := make([]int64, 0, len(taskMap))
+	for _, newID := range taskMap {
+		newTaskIDs = append(newTaskIDs, newID)
+	}
+
+	err = pft.applyToTasks(s, newTaskIDs)
+	if err != nil {
+		return err
+	}
+
+	buckets := []*Bucket{}
+	err = s.Where("project_id = ?", pft.Project.ID).Find(&buckets)
+	if err != nil {
+		return err
+	}
+	for _, b := range buckets {
+		title := replacePlaceholders(b.Title, pft.Placeholders)
+		if title == b.Title {
+			continue
+		}
+		b.Title = title
+		_, err = s.Where("id = ?", b.ID).Cols("title").Update(b)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getDateOffset returns how much all dates of the tasks need to be shifted so that the earliest date falls on
+// the start date.
+func (pft *ProjectFromTemplate) getDateOffset(tasks []*Task) time.Duration {
+	if pft.StartDate.IsZero() {
+		return 0
+	}
+
+	var earliest time.Time
+	for _, t := range tasks {
+		for _, d := range []time.Time{t.DueDate, t.StartDate, t.EndDate} {
+			if !d.IsZero() && (earliest.IsZero() || d.Before(earliest)) {
+				earliest = d
+			}
+		}
+	}
+
+	if earliest.IsZero() {
+		return 0
+	}
+
+	return pft.StartDate.Sub(earliest)
+}
+
+func shiftDate(d time.Time, offset time.Duration) time.Time {
+	if d.IsZero() {
+		return d
+	}
+	return d.Add(offset)
+}
+
+// applyToTasks replaces the placeholders and shifts the dates and reminders of all newly created tasks.
+func (pft *ProjectFromTemplate) applyToTasks(s *xorm.Session, taskIDs []int64) (err error) {
+	if len(taskIDs) == 0 {
+		return nil
+	}
+
+	tasks := []*Task{}
+	err = s.In("id", taskIDs).Find(&tasks)
+	if err != nil {
+		return err
+	}
+
+	offset := pft.getDateOffset(tasks)
+
+	for _, t := range tasks {
+		t.Title = replacePlaceholders(t.Title, pft.Placeholders)
+		t.Description = replacePlaceholders(t.Description, pft.Placeholders)
+		t.DueDate = shiftDate(t.DueDate, offset)
+		t.StartDate = shiftDate(t.StartDate, offset)
+		t.EndDate = shiftDate(t.EndDate, offset)
+
+		_, err = s.
+			Where("id = ?", t.ID).
+			Cols("title", "description", "due_date", "start_date", "end_date").
+			Update(t)
+		if err != nil {
+			return err
+		}
+	}
+
+	if offset == 0 {
+		return nil
+	}
+
+	reminders := []*TaskReminder{}
+	err = s.In("task_id", taskIDs).Find(&reminders)
+	if err != nil {
+		return err
+	}
+	for _, r := range reminders {
+		r.Reminder = shiftDate(r.Reminder, offset)
+		_, err = s.
+			Where("id = ?", r.ID).
+			Cols("reminder").
+			Update(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Debugf("Shifted all task dates of project %d by %s", pft.Project.ID, offset)
+
+	return nil
+}
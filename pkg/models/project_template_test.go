@@ -0,0 +1,200 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"xorm.io/xorm"
+)
+
+func markProjectAsTemplate(t *testing.T, s *xorm.Session, projectID int64) {
+	_, err := s.Where("id = ?", projectID).Cols("is_template").Update(&Project{IsTemplate: true})
+	require.NoError(t, err)
+}
+
+func TestProjectFromTemplate_CanCreate(t *testing.T) {
+	t.Run("template", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		markProjectAsTemplate(t, s, 36)
+
+		pft := &ProjectFromTemplate{TemplateID: 36}
+		can, err := pft.CanCreate(s, &user.User{ID: 15})
+		require.NoError(t, err)
+		assert.True(t, can)
+	})
+	t.Run("not a template", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pft := &ProjectFromTemplate{TemplateID: 36}
+		_, err := pft.CanCreate(s, &user.User{ID: 15})
+		require.Error(t, err)
+		assert.True(t, IsErrProjectIsNotATemplate(err))
+	})
+	t.Run("no access to the template", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		markProjectAsTemplate(t, s, 36)
+
+		pft := &ProjectFromTemplate{TemplateID: 36}
+		can, err := pft.CanCreate(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("no write access to the parent", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		markProjectAsTemplate(t, s, 36)
+
+		pft := &ProjectFromTemplate{TemplateID: 36, ParentProjectID: 1}
+		can, err := pft.CanCreate(s, &user.User{ID: 15})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestProjectFromTemplate_Create(t *testing.T) {
+	u := &user.User{ID: 15}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		markProjectAsTemplate(t, s, 36)
+		_, err := s.Where("id = ?", 40).Cols("title").Update(&Task{Title: "Call {{client}} about {{ topic }}"})
+		require.NoError(t, err)
+		_, err = s.Where("id = ?", 38).Cols("title").Update(&Bucket{Title: "{{client}} backlog"})
+		require.NoError(t, err)
+		_, err = s.Insert(&LinkSharing{ProjectID: 36, Hash: "template", SharedByID: 15, SharingType: SharingTypeWithoutPassword})
+		require.NoError(t, err)
+
+		templateTask, err := GetTaskByIDSimple(s, 40)
+		require.NoError(t, err)
+		startDate := templateTask.DueDate.AddDate(0, 0, 10)
+
+		pft := &ProjectFromTemplate{
+			TemplateID:   36,
+			Title:        "Onboarding {{client}}",
+			StartDate:    startDate,
+			Placeholders: map[string]string{"client": "ACME"},
+		}
+		can, err := pft.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = pft.Create(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		assert.Equal(t, "Onboarding ACME", pft.Project.Title)
+		assert.False(t, pft.Project.IsTemplate)
+		db.AssertExists(t, "projects", map[string]interface{}{
+			"id":          pft.Project.ID,
+			"title":       "Onboarding ACME",
+			"is_template": false,
+			"owner_id":    15,
+		}, false)
+		db.AssertExists(t, "projects", map[string]interface{}{
+			"id":          36,
+			"is_template": true,
+		}, false)
+
+		s = db.NewSession()
+		defer s.Close()
+
+		tasks := []*Task{}
+		err = s.Where("project_id = ?", pft.Project.ID).Find(&tasks)
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		task := tasks[0]
+		// Placeholders without a value are kept
+		assert.Equal(t, "Call ACME about {{ topic }}", task.Title)
+		assert.WithinDuration(t, startDate, task.DueDate, 0)
+
+		reminders, err := getRemindersForTasks(s, []int64{task.ID})
+		require.NoError(t, err)
+		require.Len(t, reminders, 1)
+		// The template reminder is three days after the due date
+		assert.WithinDuration(t, startDate.AddDate(0, 0, 3), reminders[0].Reminder, 0)
+
+		db.AssertExists(t, "label_tasks", map[string]interface{}{
+			"task_id":  task.ID,
+			"label_id": 4,
+		}, false)
+		db.AssertExists(t, "buckets", map[string]interface{}{
+			"project_id": pft.Project.ID,
+			"title":      "ACME backlog",
+		}, false)
+		// Shares of the template are not copied
+		db.AssertMissing(t, "link_shares", map[string]interface{}{
+			"project_id": pft.Project.ID,
+		})
+	})
+	t.Run("without start date", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		markProjectAsTemplate(t, s, 36)
+		templateTask, err := GetTaskByIDSimple(s, 40)
+		require.NoError(t, err)
+
+		pft := &ProjectFromTemplate{TemplateID: 36}
+		err = pft.Create(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, "Project 36 for Caldav tests", pft.Project.Title)
+
+		tasks := []*Task{}
+		err = s.Where("project_id = ?", pft.Project.ID).Find(&tasks)
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		assert.WithinDuration(t, templateTask.DueDate, tasks[0].DueDate, 0)
+	})
+	t.Run("not a template", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pft := &ProjectFromTemplate{TemplateID: 36}
+		err := pft.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrProjectIsNotATemplate(err))
+	})
+}
+
+func TestReplacePlaceholders(t *testing.T) {
+	placeholders := map[string]string{"client": "ACME", "year": "2024"}
+	assert.Equal(t, "ACME 2024", replacePlaceholders("{{client}} {{ year }}", placeholders))
+	assert.Equal(t, "{{unknown}}", replacePlaceholders("{{unknown}}", placeholders))
+	assert.Equal(t, "no placeholders", replacePlaceholders("no placeholders", placeholders))
+	assert.Equal(t, "{{client}}", replacePlaceholders("{{client}}", nil))
+}
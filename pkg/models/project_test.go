@@ -51,6 +51,14 @@ func TestProject_CreateOrUpdate(t *testing.T) {
 				"description":       project.Description,
 				"parent_project_id": 0,
 			}, false)
+			db.AssertExists(t, "project_views", map[string]interface{}{
+				"project_id": project.ID,
+				"view_kind":  "list",
+			}, false)
+			db.AssertExists(t, "project_views", map[string]interface{}{
+				"project_id": project.ID,
+				"view_kind":  "kanban",
+			}, false)
 			db.AssertExists(t, "buckets", map[string]interface{}{
 				"project_id": project.ID,
 			}, false)
@@ -261,6 +269,9 @@ func TestProject_Delete(t *testing.T) {
 		db.AssertMissing(t, "projects", map[string]interface{}{
 			"id": 1,
 		})
+		db.AssertMissing(t, "webhooks", map[string]interface{}{
+			"project_id": 1,
+		})
 	})
 	t.Run("with background", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
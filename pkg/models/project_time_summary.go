@@ -0,0 +1,123 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/xorm"
+)
+
+// ProjectTimeSummary holds the time tracked on all tasks of a project
+type ProjectTimeSummary struct {
+	// The project this summary is for
+	ProjectID int64 `json:"project_id" param:"project"`
+	// The total time tracked on all tasks of the project in seconds. Running timers are counted up until now.
+	TotalDuration int64 `json:"total_duration"`
+	// The time tracked per task
+	Tasks []*TaskTimeSummary `json:"tasks"`
+	// The time tracked per user
+	Users []*UserTimeSummary `json:"users"`
+
+	web.CRUDable `json:"-"`
+	web.Rights   `json:"-"`
+}
+
+// TaskTimeSummary holds the time tracked on a single task
+type TaskTimeSummary struct {
+	TaskID   int64 `json:"task_id"`
+	Duration int64 `json:"duration"`
+}
+
+// UserTimeSummary holds the time a single user tracked
+type UserTimeSummary struct {
+	User     *user.User `json:"user"`
+	Duration int64      `json:"duration"`
+}
+
+// CanRead checks if a user can see the tracked time of a project
+func (pts *ProjectTimeSummary) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	p := &Project{ID: pts.ProjectID}
+	return p.CanRead(s, a)
+}
+
+// ReadOne returns the time tracked on a project
+// @Summary Get the tracked time of a project
+// @Description Returns the total time tracked on all tasks of a project, as well as the time per task and per user.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param projectID path int true "Project ID"
+// @Success 200 {object} models.ProjectTimeSummary "The tracked time of the project."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{projectID}/time [get]
+func (pts *ProjectTimeSummary) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	entries := []*TaskTimeEntry{}
+	err = s.
+		Select("task_time_entries.*").
+		Join("INNER", "tasks", "tasks.id = task_time_entries.task_id").
+		Where("tasks.project_id = ?", pts.ProjectID).
+		Find(&entries)
+	if err != nil {
+		return err
+	}
+
+	err = addUsersToTaskTimeEntries(s, entries)
+	if err != nil {
+		return err
+	}
+
+	pts.TotalDuration = 0
+	pts.Tasks = []*TaskTimeSummary{}
+	pts.Users = []*UserTimeSummary{}
+	tasks := make(map[int64]*TaskTimeSummary)
+	users := make(map[int64]*UserTimeSummary)
+	userIDs := []int64{}
+	for _, te := range entries {
+		pts.TotalDuration += te.Duration
+
+		if _, exists := tasks[te.TaskID]; !exists {
+			tasks[te.TaskID] = &TaskTimeSummary{TaskID: te.TaskID}
+			pts.Tasks = append(pts.Tasks, tasks[te.TaskID])
+		}
+		tasks[te.TaskID].Duration += te.Duration
+
+		if _, exists := users[te.UserID]; !exists {
+			users[te.UserID] = &UserTimeSummary{User: te.User}
+			userIDs = append(userIDs, te.UserID)
+		}
+		users[te.UserID].Duration += te.Duration
+	}
+
+	sort.Slice(userIDs, func(i, j int) bool {
+		return userIDs[i] < userIDs[j]
+	})
+	for _, id := range userIDs {
+		pts.Users = append(pts.Users, users[id])
+	}
+
+	sort.Slice(pts.Tasks, func(i, j int) bool {
+		return pts.Tasks[i].TaskID < pts.Tasks[j].TaskID
+	})
+
+	return nil
+}
@@ -116,6 +116,17 @@ func (lu *ProjectUser) Create(s *xorm.Session, a web.Auth) (err error) {
 		return err
 	}
 
+	err = addHistoryEntries(s, a, &HistoryEntry{
+		EntityKind: HistoryEntityKindProjectUser,
+		EntityID:   lu.UserID,
+		Action:     HistoryActionCreated,
+		NewValue:   formatHistoryValue(lu.Right),
+		ProjectID:  lu.ProjectID,
+	})
+	if err != nil {
+		return err
+	}
+
 	err = events.Dispatch(&ProjectSharedWithUserEvent{
 		Project: l,
 		User:    u,
@@ -142,7 +153,7 @@ func (lu *ProjectUser) Create(s *xorm.Session, a web.Auth) (err error) {
 // @Failure 404 {object} web.HTTPError "user or project does not exist."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /projects/{projectID}/users/{userID} [delete]
-func (lu *ProjectUser) Delete(s *xorm.Session, _ web.Auth) (err error) {
+func (lu *ProjectUser) Delete(s *xorm.Session, a web.Auth) (err error) {
 
 	// Check if the user exists
 	u, err := user.GetUserByUsername(s, lu.Username)
@@ -152,9 +163,10 @@ func (lu *ProjectUser) Delete(s *xorm.Session, _ web.Auth) (err error) {
 	lu.UserID = u.ID
 
 	// Check if the user has access to the project
+	existing := &ProjectUser{}
 	has, err := s.
 		Where("user_id = ? AND project_id = ?", lu.UserID, lu.ProjectID).
-		Get(&ProjectUser{})
+		Get(existing)
 	if err != nil {
 		return
 	}
@@ -169,6 +181,17 @@ func (lu *ProjectUser) Delete(s *xorm.Session, _ web.Auth) (err error) {
 		return err
 	}
 
+	err = addHistoryEntries(s, a, &HistoryEntry{
+		EntityKind: HistoryEntityKindProjectUser,
+		EntityID:   lu.UserID,
+		Action:     HistoryActionDeleted,
+		OldValue:   formatHistoryValue(existing.Right),
+		ProjectID:  lu.ProjectID,
+	})
+	if err != nil {
+		return err
+	}
+
 	err = updateProjectLastUpdated(s, &Project{ID: lu.ProjectID})
 	return
 }
@@ -244,7 +267,7 @@ func (lu *ProjectUser) ReadAll(s *xorm.Session, a web.Auth, search string, page
 // @Failure 404 {object} web.HTTPError "User or project does not exist."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /projects/{projectID}/users/{userID} [post]
-func (lu *ProjectUser) Update(s *xorm.Session, _ web.Auth) (err error) {
+func (lu *ProjectUser) Update(s *xorm.Session, a web.Auth) (err error) {
 
 	// Check if the right is valid
 	if err := lu.Right.isValid(); err != nil {
@@ -258,6 +281,14 @@ func (lu *ProjectUser) Update(s *xorm.Session, _ web.Auth) (err error) {
 	}
 	lu.UserID = u.ID
 
+	existing := &ProjectUser{}
+	_, err = s.
+		Where("project_id = ? AND user_id = ?", lu.ProjectID, lu.UserID).
+		Get(existing)
+	if err != nil {
+		return err
+	}
+
 	_, err = s.
 		Where("project_id = ? AND user_id = ?", lu.ProjectID, lu.UserID).
 		Cols("right").
@@ -266,6 +297,15 @@ func (lu *ProjectUser) Update(s *xorm.Session, _ web.Auth) (err error) {
 		return err
 	}
 
+	err = addHistoryEntries(s, a, historyEntriesForChanges(HistoryEntry{
+		EntityKind: HistoryEntityKindProjectUser,
+		EntityID:   lu.UserID,
+		ProjectID:  lu.ProjectID,
+	}, existing, lu, []string{"right"})...)
+	if err != nil {
+		return err
+	}
+
 	err = updateProjectLastUpdated(s, &Project{ID: lu.ProjectID})
 	return
 }
@@ -0,0 +1,431 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// ProjectViewKind defines how the tasks of a project view are shown
+type ProjectViewKind string
+
+const (
+	ProjectViewKindList   ProjectViewKind = "list"
+	ProjectViewKindGantt  ProjectViewKind = "gantt"
+	ProjectViewKindTable  ProjectViewKind = "table"
+	ProjectViewKindKanban ProjectViewKind = "kanban"
+)
+
+// BucketConfigurationMode defines how tasks end up in the buckets of a kanban view
+type BucketConfigurationMode string
+
+const (
+	// BucketConfigurationModeManual means tasks are explicitly moved into buckets
+	BucketConfigurationModeManual BucketConfigurationMode = "manual"
+	// BucketConfigurationModeFilter means every bucket has a filter and shows all tasks matching it
+	BucketConfigurationModeFilter BucketConfigurationMode = "filter"
+)
+
+// ProjectView is one way to look at the tasks of a project. Every view has its own filter and its own task positions,
+// kanban views also have their own set of buckets.
+type ProjectView struct {
+	// The unique numeric id of this view
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"view"`
+	// The title of this view
+	Title string `xorm:"varchar(255) not null" json:"title" valid:"required,runelength(1|250)" minLength:"1" maxLength:"250"`
+	// The project this view belongs to
+	ProjectID int64 `xorm:"bigint not null index" json:"project_id" param:"project"`
+	// The kind of this view. Can be `list`, `gantt`, `table` or `kanban`. The kind cannot be changed once the view was created.
+	ViewKind ProjectViewKind `xorm:"varchar(20) not null" json:"view_kind"`
+
+	// A filter expression to only show matching tasks in this view, using the same syntax as the `filter` parameter
+	// when listing tasks. It is combined with all filters passed when requesting the tasks of the view.
+	Filter string `xorm:"text null" json:"filter"`
+	// The position of this view in the list. The list of all views will be sorted by this parameter.
+	Position float64 `xorm:"double null" json:"position"`
+
+	// How tasks end up in the buckets of this view. Only used with kanban views.
+	// With `manual`, tasks are moved into buckets explicitly and each task is in exactly one bucket.
+	// With `filter`, every bucket has a filter and contains all tasks matching it. Moving a task into such a bucket
+	// applies the move action of that bucket to the task.
+	BucketConfigurationMode BucketConfigurationMode `xorm:"varchar(50) not null default 'manual'" json:"bucket_configuration_mode"`
+
+	// The ID of the bucket where new tasks without a bucket are added to. By default, this is the leftmost bucket in a view.
+	// Only used with kanban views in manual bucket configuration mode.
+	DefaultBucketID int64 `xorm:"bigint INDEX null" json:"default_bucket_id"`
+	// If tasks are moved to the done bucket, they are marked as done. If they are marked as done individually, they are moved into the done bucket.
+	// Only used with kanban views in manual bucket configuration mode.
+	DoneBucketID int64 `xorm:"bigint INDEX null" json:"done_bucket_id"`
+
+	// A timestamp when this view was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this view was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for project views
+func (*ProjectView) TableName() string {
+	return "project_views"
+}
+
+func (k ProjectViewKind) isValid() bool {
+	switch k {
+	case ProjectViewKindList,
+		ProjectViewKindGantt,
+		ProjectViewKindTable,
+		ProjectViewKindKanban:
+		return true
+	}
+	return false
+}
+
+func (m BucketConfigurationMode) isValid() bool {
+	return m == BucketConfigurationModeManual || m == BucketConfigurationModeFilter
+}
+
+// usesFilterBuckets returns true if the view is a kanban view whose buckets are defined by filters.
+func (pv *ProjectView) usesFilterBuckets() bool {
+	return pv.ViewKind == ProjectViewKindKanban && pv.BucketConfigurationMode == BucketConfigurationModeFilter
+}
+
+func (pv *ProjectView) validate() (err error) {
+	if !pv.ViewKind.isValid() {
+		return &ErrInvalidProjectViewKind{Kind: pv.ViewKind}
+	}
+
+	if pv.BucketConfigurationMode == "" {
+		pv.BucketConfigurationMode = BucketConfigurationModeManual
+	}
+	if !pv.BucketConfigurationMode.isValid() {
+		return &ErrInvalidBucketConfigurationMode{Mode: pv.BucketConfigurationMode}
+	}
+
+	// Make sure the filter can be used later on
+	_, err = parseTaskFilterExpression(pv.Filter)
+	return
+}
+
+func getProjectViewByID(s *xorm.Session, id int64) (view *ProjectView, err error) {
+	view = &ProjectView{}
+	exists, err := s.Where("id = ?", id).Get(view)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrProjectViewDoesNotExist{ProjectViewID: id}
+	}
+	return
+}
+
+func getProjectViewsForProject(s *xorm.Session, projectID int64) (views []*ProjectView, err error) {
+	views = []*ProjectView{}
+	err = s.
+		Where("project_id = ?", projectID).
+		OrderBy("position asc, id asc").
+		Find(&views)
+	return
+}
+
+func getKanbanViewsForProject(s *xorm.Session, projectID int64) (views []*ProjectView, err error) {
+	views = []*ProjectView{}
+	err = s.
+		Where("project_id = ? AND view_kind = ?", projectID, ProjectViewKindKanban).
+		OrderBy("position asc, id asc").
+		Find(&views)
+	return
+}
+
+// getFirstKanbanViewForProject returns the leftmost kanban view of a project. Buckets which are created or requested
+// without a view refer to this view. Returns nil if the project has no kanban view.
+func getFirstKanbanViewForProject(s *xorm.Session, projectID int64) (view *ProjectView, err error) {
+	views, err := getKanbanViewsForProject(s, projectID)
+	if err != nil || len(views) == 0 {
+		return nil, err
+	}
+	return views[0], nil
+}
+
+// createDefaultViewsForProject creates a list, gantt, table and kanban view for a new project. The kanban view
+// gets a first "Backlog" bucket.
+func createDefaultViewsForProject(s *xorm.Session, project *Project, a web.Auth) (err error) {
+	views := []*ProjectView{
+		{Title: "List", ViewKind: ProjectViewKindList, Position: 100},
+		{Title: "Gantt", ViewKind: ProjectViewKindGantt, Position: 200},
+		{Title: "Table", ViewKind: ProjectViewKindTable, Position: 300},
+		{Title: "Kanban", ViewKind: ProjectViewKindKanban, Position: 400},
+	}
+
+	for _, view := range views {
+		view.ProjectID = project.ID
+		err = view.Create(s, a)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// ReadAll returns all views of a project
+// @Summary Get all views of a project
+// @Description Returns all views of a project, sorted by their position.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param projectID path int true "Project ID"
+// @Success 200 {array} models.ProjectView "The project views."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{projectID}/views [get]
+func (pv *ProjectView) ReadAll(s *xorm.Session, a web.Auth, _ string, _ int, _ int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	p := &Project{ID: pv.ProjectID}
+	canRead, _, err := p.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	views, err := getProjectViewsForProject(s, pv.ProjectID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return views, len(views), int64(len(views)), nil
+}
+
+// ReadOne returns a single project view
+// @Summary Get one project view
+// @Description Returns a single view of a project.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param projectID path int true "Project ID"
+// @Param viewID path int true "Project view ID"
+// @Success 200 {object} models.ProjectView "The project view."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the project."
+// @Failure 404 {object} web.HTTPError "The project view does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{projectID}/views/{viewID} [get]
+func (pv *ProjectView) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	view, err := getProjectViewByID(s, pv.ID)
+	if err != nil {
+		return err
+	}
+
+	*pv = *view
+	return
+}
+
+// Create creates a new project view
+// @Summary Create a project view
+// @Description Creates a new view for a project. New kanban views in manual bucket configuration mode get a first bucket which contains all tasks of the project. Kanban views in filter bucket configuration mode start without buckets.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param projectID path int true "Project ID"
+// @Param view body models.ProjectView true "The project view object"
+// @Success 201 {object} models.ProjectView "The created project view."
+// @Failure 400 {object} web.HTTPError "Invalid project view object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the project."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{projectID}/views [put]
+func (pv *ProjectView) Create(s *xorm.Session, a web.Auth) (err error) {
+	if err := pv.validate(); err != nil {
+		return err
+	}
+
+	pv.ID = 0
+	pv.DefaultBucketID = 0
+	pv.DoneBucketID = 0
+	_, err = s.Insert(pv)
+	if err != nil {
+		return err
+	}
+
+	pv.Position = calculateDefaultPosition(pv.ID, pv.Position)
+	_, err = s.Where("id = ?", pv.ID).Cols("position").Update(pv)
+	if err != nil {
+		return err
+	}
+
+	if pv.ViewKind != ProjectViewKindKanban || pv.usesFilterBuckets() {
+		return nil
+	}
+
+	return pv.createBacklogBucketWithAllTasks(s, a)
+}
+
+// createBacklogBucketWithAllTasks creates a first bucket for a manual kanban view and puts all tasks of the project in it.
+func (pv *ProjectView) createBacklogBucketWithAllTasks(s *xorm.Session, a web.Auth) (err error) {
+	backlog := &Bucket{
+		ProjectID:     pv.ProjectID,
+		ProjectViewID: pv.ID,
+		Title:         "Backlog",
+	}
+	err = backlog.Create(s, a)
+	if err != nil {
+		return err
+	}
+
+	// All existing tasks of the project start in the first bucket of the new view
+	return addProjectTasksToBucket(s, pv.ProjectID, backlog)
+}
+
+// switchBucketConfigurationMode makes sure the stored buckets of the tasks match the new bucket configuration mode of a view.
+func (pv *ProjectView) switchBucketConfigurationMode(s *xorm.Session, a web.Auth) (err error) {
+	// Filter buckets are computed, nothing is stored for them
+	_, err = s.Where("project_view_id = ?", pv.ID).Delete(&TaskBucket{})
+	if err != nil || pv.usesFilterBuckets() {
+		return err
+	}
+
+	bucketCount, err := s.Where("project_view_id = ?", pv.ID).Count(&Bucket{})
+	if err != nil {
+		return err
+	}
+	if bucketCount == 0 {
+		return pv.createBacklogBucketWithAllTasks(s, a)
+	}
+
+	defaultBucketID, err := getDefaultBucketID(s, pv)
+	if err != nil {
+		return err
+	}
+	return addProjectTasksToBucket(s, pv.ProjectID, &Bucket{ID: defaultBucketID, ProjectViewID: pv.ID})
+}
+
+// Update updates a project view
+// @Summary Update a project view
+// @Description Updates a project view. The kind of a view cannot be changed. When the bucket configuration mode of a kanban view is changed to `filter`, all tasks are removed from its buckets. When it is changed back to `manual`, all tasks are put in the default bucket.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param projectID path int true "Project ID"
+// @Param viewID path int true "Project view ID"
+// @Param view body models.ProjectView true "The project view object"
+// @Success 200 {object} models.ProjectView "The updated project view."
+// @Failure 400 {object} web.HTTPError "Invalid project view object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the project."
+// @Failure 404 {object} web.HTTPError "The project view does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{projectID}/views/{viewID} [post]
+func (pv *ProjectView) Update(s *xorm.Session, a web.Auth) (err error) {
+	existing, err := getProjectViewByID(s, pv.ID)
+	if err != nil {
+		return err
+	}
+
+	pv.ViewKind = existing.ViewKind
+	if pv.BucketConfigurationMode == "" {
+		pv.BucketConfigurationMode = existing.BucketConfigurationMode
+	}
+	if err := pv.validate(); err != nil {
+		return err
+	}
+
+	if pv.ViewKind != ProjectViewKindKanban {
+		pv.BucketConfigurationMode = BucketConfigurationModeManual
+	}
+
+	if pv.ViewKind != ProjectViewKindKanban || pv.usesFilterBuckets() {
+		pv.DefaultBucketID = 0
+		pv.DoneBucketID = 0
+	}
+
+	for _, bucketID := range []int64{pv.DefaultBucketID, pv.DoneBucketID} {
+		if bucketID == 0 {
+			continue
+		}
+		bucket, err := getBucketByID(s, bucketID)
+		if err != nil {
+			return err
+		}
+		if bucket.ProjectViewID != pv.ID {
+			return &ErrBucketDoesNotBelongToProjectView{BucketID: bucketID, ProjectViewID: pv.ID}
+		}
+	}
+
+	_, err = s.
+		Where("id = ?", pv.ID).
+		Cols(
+			"title",
+			"filter",
+			"position",
+			"bucket_configuration_mode",
+			"default_bucket_id",
+			"done_bucket_id",
+		).
+		Update(pv)
+	if err != nil {
+		return err
+	}
+
+	if pv.ViewKind == ProjectViewKindKanban && pv.BucketConfigurationMode != existing.BucketConfigurationMode {
+		err = pv.switchBucketConfigurationMode(s, a)
+		if err != nil {
+			return err
+		}
+	}
+
+	return pv.ReadOne(s, a)
+}
+
+// Delete removes a project view
+// @Summary Delete a project view
+// @Description Deletes a project view with all of its buckets and task positions. The tasks themselves are not deleted.
+// @tags project
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param projectID path int true "Project ID"
+// @Param viewID path int true "Project view ID"
+// @Success 200 {object} models.Message "The project view was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the project."
+// @Failure 404 {object} web.HTTPError "The project view does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{projectID}/views/{viewID} [delete]
+func (pv *ProjectView) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	_, err = s.Where("project_view_id = ?", pv.ID).Delete(&TaskBucket{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Where("project_view_id = ?", pv.ID).Delete(&TaskPosition{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Where("project_view_id = ?", pv.ID).Delete(&Bucket{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Where("id = ?", pv.ID).Delete(&ProjectView{})
+	return
+}
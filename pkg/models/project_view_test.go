@@ -0,0 +1,373 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectView_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pv := &ProjectView{
+			ProjectID: 1,
+			Title:     "Open tasks",
+			ViewKind:  ProjectViewKindList,
+			Filter:    "done = false",
+		}
+		err := pv.Create(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "project_views", map[string]interface{}{
+			"id":         pv.ID,
+			"project_id": 1,
+			"title":      "Open tasks",
+			"view_kind":  "list",
+			"filter":     "done = false",
+		}, false)
+		db.AssertMissing(t, "buckets", map[string]interface{}{
+			"project_view_id": pv.ID,
+		})
+	})
+	t.Run("kanban view", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pv := &ProjectView{
+			ProjectID: 1,
+			Title:     "Second board",
+			ViewKind:  ProjectViewKindKanban,
+		}
+		err := pv.Create(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "buckets", map[string]interface{}{
+			"project_id":      1,
+			"project_view_id": pv.ID,
+			"title":           "Backlog",
+		}, false)
+
+		// All tasks of the project start in the new backlog bucket
+		count, err := s.Where("project_view_id = ?", pv.ID).Count(&TaskBucket{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(18), count)
+	})
+	t.Run("invalid kind", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pv := &ProjectView{
+			ProjectID: 1,
+			Title:     "Calendar",
+			ViewKind:  "calendar",
+		}
+		err := pv.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidProjectViewKind(err))
+	})
+	t.Run("invalid filter", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pv := &ProjectView{
+			ProjectID: 1,
+			Title:     "Broken",
+			ViewKind:  ProjectViewKindList,
+			Filter:    "done = ",
+		}
+		err := pv.Create(s, u)
+		assert.Error(t, err)
+	})
+}
+
+func TestProjectView_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pv := &ProjectView{
+			ID:        1,
+			ProjectID: 1,
+			Title:     "Everything due",
+			ViewKind:  ProjectViewKindKanban, // Should be ignored
+			Filter:    "due_date < now",
+		}
+		err := pv.Update(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, ProjectViewKindList, pv.ViewKind)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "project_views", map[string]interface{}{
+			"id":        1,
+			"title":     "Everything due",
+			"view_kind": "list",
+			"filter":    "due_date < now",
+		}, false)
+	})
+	t.Run("done bucket", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pv := &ProjectView{
+			ID:           4,
+			ProjectID:    1,
+			Title:        "Kanban",
+			DoneBucketID: 2,
+		}
+		err := pv.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "project_views", map[string]interface{}{
+			"id":             4,
+			"done_bucket_id": 2,
+		}, false)
+	})
+	t.Run("done bucket of another view", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pv := &ProjectView{
+			ID:           4,
+			ProjectID:    1,
+			Title:        "Kanban",
+			DoneBucketID: 4, // Belongs to the kanban view of project 2
+		}
+		err := pv.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrBucketDoesNotBelongToProjectView(err))
+	})
+	t.Run("switch to filter buckets", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pv := &ProjectView{
+			ID:                      4,
+			ProjectID:               1,
+			Title:                   "Kanban",
+			BucketConfigurationMode: BucketConfigurationModeFilter,
+			DoneBucketID:            3,
+		}
+		err := pv.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "project_views", map[string]interface{}{
+			"id":                        4,
+			"bucket_configuration_mode": "filter",
+			"done_bucket_id":            0,
+		}, false)
+		db.AssertMissing(t, "task_buckets", map[string]interface{}{
+			"project_view_id": 4,
+		})
+	})
+	t.Run("switch back to manual buckets", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pv := &ProjectView{
+			ID:                      4,
+			ProjectID:               1,
+			Title:                   "Kanban",
+			BucketConfigurationMode: BucketConfigurationModeFilter,
+		}
+		err := pv.Update(s, u)
+		assert.NoError(t, err)
+
+		pv.BucketConfigurationMode = BucketConfigurationModeManual
+		err = pv.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		// All tasks are in the first bucket of the view
+		count, err := s.Where("project_view_id = ? AND bucket_id = ?", 4, 1).Count(&TaskBucket{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(18), count)
+	})
+	t.Run("filter buckets for a view which is not kanban", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pv := &ProjectView{
+			ID:                      1,
+			ProjectID:               1,
+			Title:                   "List",
+			BucketConfigurationMode: BucketConfigurationModeFilter,
+		}
+		err := pv.Update(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, BucketConfigurationModeManual, pv.BucketConfigurationMode)
+	})
+	t.Run("invalid bucket configuration mode", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pv := &ProjectView{
+			ID:                      4,
+			ProjectID:               1,
+			Title:                   "Kanban",
+			BucketConfigurationMode: "automatic",
+		}
+		err := pv.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidBucketConfigurationMode(err))
+	})
+}
+
+func TestProjectView_Delete(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	pv := &ProjectView{
+		ID:        4,
+		ProjectID: 1,
+	}
+	err := pv.Delete(s, &user.User{ID: 1})
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	db.AssertMissing(t, "project_views", map[string]interface{}{
+		"id": 4,
+	})
+	db.AssertMissing(t, "buckets", map[string]interface{}{
+		"project_view_id": 4,
+	})
+	db.AssertMissing(t, "task_buckets", map[string]interface{}{
+		"project_view_id": 4,
+	})
+	// The tasks themselves should still exist
+	db.AssertExists(t, "tasks", map[string]interface{}{
+		"id": 1,
+	}, false)
+}
+
+func TestProjectView_CanRead(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pv := &ProjectView{ID: 1, ProjectID: 1}
+		can, _, err := pv.CanRead(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.True(t, can)
+	})
+	t.Run("view of another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pv := &ProjectView{ID: 5, ProjectID: 1}
+		can, _, err := pv.CanRead(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("no access to the project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		pv := &ProjectView{ID: 1, ProjectID: 1}
+		can, _, err := pv.CanRead(s, &user.User{ID: 13})
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestTaskCollection_ReadAll_WithProjectView(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("filter of the view", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := s.Where("id = ?", 1).Cols("filter").Update(&ProjectView{Filter: "done = true"})
+		assert.NoError(t, err)
+
+		tc := &TaskCollection{
+			ProjectID:     1,
+			ProjectViewID: 1,
+		}
+		result, _, _, err := tc.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+		tasks := result.([]*Task)
+		assert.Len(t, tasks, 1)
+		assert.Equal(t, int64(2), tasks[0].ID)
+		assert.Equal(t, float64(4), tasks[0].Position)
+	})
+	t.Run("view of another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{
+			ProjectID:     1,
+			ProjectViewID: 5,
+		}
+		_, _, _, err := tc.ReadAll(s, u, "", 0, 50)
+		assert.Error(t, err)
+		assert.True(t, IsErrProjectViewDoesNotExist(err))
+	})
+	t.Run("kanban view returns buckets", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{
+			ProjectID:     1,
+			ProjectViewID: 4,
+		}
+		result, _, _, err := tc.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+		buckets, is := result.([]*Bucket)
+		assert.True(t, is)
+		assert.Len(t, buckets, 3)
+	})
+}
@@ -107,7 +107,7 @@ func (sf *SavedFilter) toProject() *Project {
 
 // Create creates a new saved filter
 // @Summary Creates a new saved filter
-// @Description Creates a new saved filter
+// @Description Creates a new saved filter. Like a new project, it gets a list, gantt, table and kanban view.
 // @tags filter
 // @Accept json
 // @Produce json
@@ -117,8 +117,28 @@ func (sf *SavedFilter) toProject() *Project {
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /filters [put]
 func (sf *SavedFilter) Create(s *xorm.Session, auth web.Auth) error {
+	if err := sf.validateFilterExpression(); err != nil {
+		return err
+	}
+
 	sf.OwnerID = auth.GetID()
 	_, err := s.Insert(sf)
+	if err != nil {
+		return err
+	}
+
+	// Saved filters get the same views as a new project
+	return createDefaultViewsForProject(s, sf.toProject(), auth)
+}
+
+// validateFilterExpression makes sure the filter expression of a saved filter can be parsed
+// before it is stored, so that it does not fail later when reading tasks.
+func (sf *SavedFilter) validateFilterExpression() error {
+	if sf.Filters == nil {
+		return nil
+	}
+
+	_, err := parseTaskFilterExpression(sf.Filters.Filter)
 	return err
 }
 
@@ -178,6 +198,10 @@ func (sf *SavedFilter) Update(s *xorm.Session, _ web.Auth) error {
 		sf.Filters = origFilter.Filters
 	}
 
+	if err := sf.validateFilterExpression(); err != nil {
+		return err
+	}
+
 	_, err = s.
 		Where("id = ?", sf.ID).
 		Cols(
@@ -203,8 +227,19 @@ func (sf *SavedFilter) Update(s *xorm.Session, _ web.Auth) error {
 // @Failure 404 {object} web.HTTPError "The saved filter does not exist."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /filters/{id} [delete]
-func (sf *SavedFilter) Delete(s *xorm.Session, _ web.Auth) error {
-	_, err := s.
+func (sf *SavedFilter) Delete(s *xorm.Session, a web.Auth) error {
+	views, err := getProjectViewsForProject(s, getProjectIDFromSavedFilterID(sf.ID))
+	if err != nil {
+		return err
+	}
+	for _, view := range views {
+		err = view.Delete(s, a)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.
 		Where("id = ?", sf.ID).
 		Delete(sf)
 	return err
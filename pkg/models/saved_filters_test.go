@@ -72,6 +72,18 @@ func TestSavedFilter_Create(t *testing.T) {
 		delete(vals, "filters")
 	}
 	db.AssertExists(t, "saved_filters", vals, true)
+	db.AssertExists(t, "project_views", map[string]interface{}{
+		"project_id": getProjectIDFromSavedFilterID(sf.ID),
+		"view_kind":  ProjectViewKindList,
+	}, false)
+	db.AssertExists(t, "project_views", map[string]interface{}{
+		"project_id": getProjectIDFromSavedFilterID(sf.ID),
+		"view_kind":  ProjectViewKindKanban,
+	}, false)
+	db.AssertExists(t, "buckets", map[string]interface{}{
+		"project_id": getProjectIDFromSavedFilterID(sf.ID),
+		"title":      "Backlog",
+	}, false)
 }
 
 func TestSavedFilter_ReadOne(t *testing.T) {
@@ -149,6 +161,9 @@ func TestSavedFilter_Delete(t *testing.T) {
 	db.AssertMissing(t, "saved_filters", map[string]interface{}{
 		"id": 1,
 	})
+	db.AssertMissing(t, "project_views", map[string]interface{}{
+		"project_id": getProjectIDFromSavedFilterID(1),
+	})
 }
 
 func TestSavedFilter_Rights(t *testing.T) {
@@ -38,6 +38,8 @@ type TaskAttachment struct {
 	CreatedBy   *user.User `xorm:"-" json:"created_by"`
 
 	File *files.File `xorm:"-" json:"file"`
+	// A blurHash of image attachments which clients can show while the preview is loading.
+	BlurHash string `xorm:"varchar(50) null" json:"blur_hash"`
 
 	Created time.Time `xorm:"created" json:"created"`
 
@@ -54,14 +56,42 @@ func (*TaskAttachment) TableName() string {
 // Note: I'm not sure if only accepting an io.ReadCloser and not an afero.File or os.File instead is a good way of doing things.
 func (ta *TaskAttachment) NewAttachment(s *xorm.Session, f io.ReadCloser, realname string, realsize uint64, a web.Auth) error {
 
+	task, err := GetTaskByIDSimple(s, ta.TaskID)
+	if err != nil {
+		return err
+	}
+
 	// Store the file
-	file, err := files.Create(f, realname, realsize, a)
+	file, err := files.CreateForProject(f, realname, realsize, a, task.ProjectID)
+	if err != nil {
+		if files.IsErrFileIsTooLarge(err) {
+			return ErrTaskAttachmentIsTooLarge{Size: realsize}
+		}
+		return err
+	}
+	return ta.saveAttachment(s, file, a)
+}
+
+// NewAttachmentWithMime creates a new task attachment with a known mime type. The file is stored in the same
+// session as the attachment.
+func (ta *TaskAttachment) NewAttachmentWithMime(s *xorm.Session, f io.Reader, realname string, realsize uint64, mime string, a web.Auth) error {
+	task, err := GetTaskByIDSimple(s, ta.TaskID)
+	if err != nil {
+		return err
+	}
+
+	file, err := files.CreateWithMimeAndSession(s, f, realname, realsize, a, mime, task.ProjectID, true)
 	if err != nil {
 		if files.IsErrFileIsTooLarge(err) {
 			return ErrTaskAttachmentIsTooLarge{Size: realsize}
 		}
 		return err
 	}
+
+	return ta.saveAttachment(s, file, a)
+}
+
+func (ta *TaskAttachment) saveAttachment(s *xorm.Session, file *files.File, a web.Auth) (err error) {
 	ta.File = file
 
 	// Add an entry to the db
@@ -86,6 +116,11 @@ func (ta *TaskAttachment) NewAttachment(s *xorm.Session, f io.ReadCloser, realna
 		return err
 	}
 
+	err = updateTaskLastUpdated(s, &Task{ID: ta.TaskID})
+	if err != nil {
+		return err
+	}
+
 	return events.Dispatch(&TaskAttachmentCreatedEvent{
 		Task:       &Task{ID: ta.TaskID},
 		Attachment: ta,
@@ -209,6 +244,13 @@ func (ta *TaskAttachment) Delete(s *xorm.Session, a web.Auth) error {
 		return err
 	}
 
+	err = updateTaskLastUpdated(s, &Task{ID: ta.TaskID})
+	if err != nil {
+		return err
+	}
+
+	invalidatePreviewCache(ta.FileID)
+
 	// Delete the underlying file
 	err = ta.File.Delete()
 	// If the file does not exist, we don't want to error out
@@ -0,0 +1,194 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"strconv"
+	"time"
+
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/modules/keyvalue"
+
+	"github.com/bbrks/go-blurhash"
+	"github.com/disintegration/imaging"
+	"golang.org/x/image/draw"
+	"xorm.io/xorm"
+)
+
+// PreviewSize is the size of a preview of an image attachment
+type PreviewSize string
+
+const (
+	// PreviewSmall is at most 100px wide
+	PreviewSmall PreviewSize = "sm"
+	// PreviewMedium is at most 200px wide
+	PreviewMedium PreviewSize = "md"
+	// PreviewLarge is at most 400px wide
+	PreviewLarge PreviewSize = "lg"
+)
+
+// previewWidths holds the maximum width in pixels for each preview size. Images are never scaled up.
+var previewWidths = map[PreviewSize]int{
+	PreviewSmall:  100,
+	PreviewMedium: 200,
+	PreviewLarge:  400,
+}
+
+// GetPreviewSizeFromString returns the preview size for its name
+func GetPreviewSizeFromString(size string) (PreviewSize, error) {
+	if _, exists := previewWidths[PreviewSize(size)]; !exists {
+		return "", ErrInvalidPreviewSize{Size: size}
+	}
+	return PreviewSize(size), nil
+}
+
+// previewCacheTTL is how long generated previews are kept in the keyvalue store before they are created again
+const previewCacheTTL = 24 * time.Hour
+
+// previewMaxPixels is the maximum number of pixels an image may have to get a preview. Decoding larger images would
+// need too much memory.
+var previewMaxPixels = 50_000_000
+
+func getPreviewCacheKey(fileID int64) string {
+	return "task_attachment_preview_" + strconv.FormatInt(fileID, 10)
+}
+
+func invalidatePreviewCache(fileID int64) {
+	if err := keyvalue.Del(getPreviewCacheKey(fileID)); err != nil {
+		log.Errorf("Could not invalidate preview cache for file %d: %s", fileID, err)
+	}
+}
+
+// GetPreview returns a png preview of the attachment in the given size. The preview is nil if the attachment is not
+// an image.
+func (ta *TaskAttachment) GetPreview(size PreviewSize) (preview []byte, err error) {
+	var cached map[PreviewSize][]byte
+	exists, err := keyvalue.GetWithValue(getPreviewCacheKey(ta.FileID), &cached)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return cached[size], nil
+	}
+
+	log.Debugf("Preview for attachment %d not cached, creating it.", ta.ID)
+
+	previews, _, err := createAttachmentPreviews(ta.FileID)
+	if err != nil {
+		return nil, err
+	}
+	return previews[size], nil
+}
+
+// createAttachmentPreviews creates and caches the previews of an attachment in all sizes and its blurHash.
+// The previews are empty if the file is not an image.
+func createAttachmentPreviews(fileID int64) (previews map[PreviewSize][]byte, blurHash string, err error) {
+	f := &files.File{ID: fileID}
+	if err := f.LoadFileMetaByID(); err != nil {
+		return nil, "", err
+	}
+	if err := f.LoadFileByID(); err != nil {
+		return nil, "", err
+	}
+	defer f.File.Close()
+
+	previews = make(map[PreviewSize][]byte, len(previewWidths))
+
+	img, err := decodePreviewSource(f.File)
+	if err != nil {
+		return nil, "", err
+	}
+	if img != nil {
+		for size, width := range previewWidths {
+			resized := img
+			if img.Bounds().Dx() > width {
+				resized = imaging.Resize(img, width, 0, imaging.Lanczos)
+			}
+
+			buf := &bytes.Buffer{}
+			if err := png.Encode(buf, resized); err != nil {
+				return nil, "", err
+			}
+			previews[size] = buf.Bytes()
+		}
+
+		dst := image.NewRGBA(image.Rect(0, 0, 32, 32))
+		draw.NearestNeighbor.Scale(dst, dst.Rect, img, img.Bounds(), draw.Over, nil)
+		blurHash, err = blurhash.Encode(4, 3, dst)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	err = keyvalue.PutWithTTL(getPreviewCacheKey(fileID), previews, previewCacheTTL)
+	return previews, blurHash, err
+}
+
+// decodePreviewSource decodes the image in r. It returns nil if r is not an image or if the image is too large to
+// create a preview from it.
+func decodePreviewSource(r io.ReadSeeker) (image.Image, error) {
+	config, _, err := image.DecodeConfig(r)
+	if errors.Is(err, image.ErrFormat) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > previewMaxPixels {
+		return nil, nil
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(r)
+	if err != nil && !errors.Is(err, image.ErrFormat) {
+		return nil, err
+	}
+	return img, nil
+}
+
+// createTaskAttachmentPreviews creates the previews of a newly created attachment and saves its blurHash
+func createTaskAttachmentPreviews(s *xorm.Session, attachmentID int64) error {
+	ta := &TaskAttachment{}
+	exists, err := s.Where("id = ?", attachmentID).Get(ta)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrTaskAttachmentDoesNotExist{AttachmentID: attachmentID}
+	}
+
+	_, blurHash, err := createAttachmentPreviews(ta.FileID)
+	if err != nil {
+		return err
+	}
+	if blurHash == "" {
+		return nil
+	}
+
+	ta.BlurHash = blurHash
+	_, err = s.Where("id = ?", ta.ID).Cols("blur_hash").Update(ta)
+	return err
+}
@@ -0,0 +1,148 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestImageAttachment(t *testing.T) *TaskAttachment {
+	return createTestImageAttachmentWithSize(t, 300, 150)
+}
+
+func createTestImageAttachmentWithSize(t *testing.T, width, height int) *TaskAttachment {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 100, A: 255})
+		}
+	}
+	buf := &bytes.Buffer{}
+	err := png.Encode(buf, img)
+	assert.NoError(t, err)
+
+	s := db.NewSession()
+	defer s.Close()
+
+	ta := &TaskAttachment{TaskID: 1}
+	err = ta.NewAttachment(s, io.NopCloser(bytes.NewReader(buf.Bytes())), "image.png", uint64(buf.Len()), &user.User{ID: 1})
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+	return ta
+}
+
+func TestGetPreviewSizeFromString(t *testing.T) {
+	size, err := GetPreviewSizeFromString("md")
+	assert.NoError(t, err)
+	assert.Equal(t, PreviewMedium, size)
+
+	_, err = GetPreviewSizeFromString("xl")
+	assert.Error(t, err)
+	assert.True(t, IsErrInvalidPreviewSize(err))
+}
+
+func TestTaskAttachment_GetPreview(t *testing.T) {
+	t.Run("Image", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		ta := createTestImageAttachment(t)
+
+		preview, err := ta.GetPreview(PreviewSmall)
+		assert.NoError(t, err)
+		config, err := png.DecodeConfig(bytes.NewReader(preview))
+		assert.NoError(t, err)
+		assert.Equal(t, 100, config.Width)
+		assert.Equal(t, 50, config.Height)
+	})
+	t.Run("Image smaller than the preview", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		ta := createTestImageAttachment(t)
+
+		preview, err := ta.GetPreview(PreviewLarge)
+		assert.NoError(t, err)
+		config, err := png.DecodeConfig(bytes.NewReader(preview))
+		assert.NoError(t, err)
+		assert.Equal(t, 300, config.Width)
+	})
+	t.Run("Image too large", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		oldMax := previewMaxPixels
+		previewMaxPixels = 300 * 150
+		defer func() { previewMaxPixels = oldMax }()
+
+		ta := createTestImageAttachmentWithSize(t, 300, 200)
+		invalidatePreviewCache(ta.FileID)
+
+		preview, err := ta.GetPreview(PreviewSmall)
+		assert.NoError(t, err)
+		assert.Nil(t, preview)
+	})
+	t.Run("No image", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		ta := &TaskAttachment{ID: 1, FileID: 1}
+
+		preview, err := ta.GetPreview(PreviewSmall)
+		assert.NoError(t, err)
+		assert.Nil(t, preview)
+	})
+}
+
+func TestCreateTaskAttachmentPreviews(t *testing.T) {
+	t.Run("Image", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		ta := createTestImageAttachment(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := createTaskAttachmentPreviews(s, ta.ID)
+		assert.NoError(t, err)
+
+		updated := &TaskAttachment{ID: ta.ID}
+		err = updated.ReadOne(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, updated.BlurHash)
+	})
+	t.Run("No image", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		err := createTaskAttachmentPreviews(s, 1)
+		assert.NoError(t, err)
+
+		ta := &TaskAttachment{ID: 1}
+		err = ta.ReadOne(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		assert.Empty(t, ta.BlurHash)
+	})
+}
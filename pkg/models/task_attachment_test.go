@@ -19,10 +19,8 @@ package models
 import (
 	"io"
 	"os"
-	"strconv"
 	"testing"
 
-	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/user"
@@ -49,7 +47,9 @@ func TestTaskAttachment_ReadOne(t *testing.T) {
 		// Load the actual attachment file and check its content
 		err = ta.File.LoadFileByID()
 		assert.NoError(t, err)
-		assert.Equal(t, config.FilesBasePath.GetString()+"/1", ta.File.File.Name())
+		stat, err := ta.File.File.Stat()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(9), stat.Size())
 		content := make([]byte, 9)
 		read, err := ta.File.File.Read(content)
 		assert.NoError(t, err)
@@ -120,7 +120,9 @@ func TestTaskAttachment_NewAttachment(t *testing.T) {
 	err := ta.NewAttachment(s, tf, "testfile", 100, testuser)
 	assert.NoError(t, err)
 	assert.NotEqual(t, 0, ta.FileID)
-	_, err = files.FileStat("files/" + strconv.FormatInt(ta.FileID, 10))
+	// The content is stored under its hash
+	assert.NotEmpty(t, ta.File.Sha256)
+	_, err = files.FileStat("files/" + ta.File.Sha256)
 	assert.NoError(t, err)
 	assert.False(t, os.IsNotExist(err))
 	assert.Equal(t, testuser.ID, ta.CreatedByID)
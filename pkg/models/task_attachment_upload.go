@@ -0,0 +1,369 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"io"
+	"time"
+
+	"code.vikunja.io/api/pkg/cron"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// Uploads which did not get a new chunk for this long are removed
+const taskAttachmentUploadExpiry = 24 * time.Hour
+
+// TaskAttachmentUpload is a resumable upload of a task attachment. The file is uploaded in chunks, one request
+// each, and becomes an attachment once the upload is finished.
+type TaskAttachmentUpload struct {
+	// The unique, numeric id of this upload.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"upload"`
+	// The task the attachment will be added to.
+	TaskID int64 `xorm:"bigint not null index" json:"task_id" param:"task"`
+	// The name of the file.
+	Filename string `xorm:"text not null" json:"filename" valid:"required"`
+	// The size of the whole file in bytes.
+	Size uint64 `xorm:"bigint not null" json:"size"`
+	// How many bytes were uploaded already. The next chunk needs to start at this offset.
+	Offset uint64 `xorm:"bigint not null default 0 'upload_offset'" json:"offset"`
+	// The keys the uploaded chunks are stored under, in the order they were uploaded
+	Chunks []uint64 `xorm:"json null" json:"-"`
+
+	CreatedByID int64 `xorm:"bigint not null" json:"-"`
+
+	// When the upload will be removed if no further chunk is uploaded until then.
+	Expires time.Time `xorm:"DATETIME not null" json:"expires"`
+	// The attachment created from the upload once it is finished.
+	Attachment *TaskAttachment `xorm:"-" json:"attachment"`
+
+	// A timestamp when this upload was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this upload was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName holds the table name for the task attachment uploads table
+func (u *TaskAttachmentUpload) TableName() string {
+	return "task_attachment_uploads"
+}
+
+func getTaskAttachmentUploadSimple(s *xorm.Session, u *TaskAttachmentUpload) error {
+	exists, err := s.
+		Where("id = ? AND task_id = ?", u.ID, u.TaskID).
+		NoAutoCondition().
+		Get(u)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrTaskAttachmentUploadDoesNotExist{
+			UploadID: u.ID,
+			TaskID:   u.TaskID,
+		}
+	}
+	return nil
+}
+
+// Create starts a new resumable upload
+// @Summary Start a resumable attachment upload
+// @Description Starts an upload of a task attachment which can be resumed if the connection breaks. Upload the file in chunks with PATCH requests to the returned upload and finish it afterwards. Uploads without new chunks for 24 hours are removed.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param upload body models.TaskAttachmentUpload true "The upload with the name and size of the file"
+// @Success 201 {object} models.TaskAttachmentUpload "The created upload."
+// @Failure 400 {object} web.HTTPError "Invalid upload object provided, the file is too large or does not fit into the storage quota."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/attachments/uploads [put]
+func (u *TaskAttachmentUpload) Create(s *xorm.Session, a web.Auth) (err error) {
+	maxSize, err := files.GetMaxFileSize()
+	if err != nil {
+		return err
+	}
+	if u.Size > maxSize {
+		return ErrTaskAttachmentIsTooLarge{Size: u.Size}
+	}
+
+	doer, err := GetUserOrLinkShareUser(s, a)
+	if err != nil {
+		return err
+	}
+
+	u.ID = 0
+	u.Offset = 0
+	u.Chunks = []uint64{}
+	u.CreatedByID = doer.ID
+	u.Expires = time.Now().Add(taskAttachmentUploadExpiry)
+	u.Attachment = nil
+
+	err = u.checkQuotas(s, a)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Insert(u)
+	return
+}
+
+// checkQuotas makes sure the whole file of the upload fits into the storage quotas. Other unfinished uploads count
+// with their full size since they become files once they are finished.
+func (u *TaskAttachmentUpload) checkQuotas(s *xorm.Session, a web.Auth) error {
+	task, err := GetTaskByIDSimple(s, u.TaskID)
+	if err != nil {
+		return err
+	}
+
+	pendingUser, err := s.
+		Where("created_by_id = ? AND id != ?", u.CreatedByID, u.ID).
+		SumInt(&TaskAttachmentUpload{}, "size")
+	if err != nil {
+		return err
+	}
+
+	pendingProject, err := s.
+		Table("task_attachment_uploads").
+		Join("INNER", "tasks", "tasks.id = task_attachment_uploads.task_id").
+		Where("tasks.project_id = ? AND task_attachment_uploads.id != ?", task.ProjectID, u.ID).
+		SumInt(&TaskAttachmentUpload{}, "task_attachment_uploads.size")
+	if err != nil {
+		return err
+	}
+
+	return files.CheckQuotas(s, a, task.ProjectID, u.Size, uint64(pendingUser), uint64(pendingProject))
+}
+
+// ReadOne returns a resumable upload
+// @Summary Get a resumable attachment upload
+// @Description Returns an upload, most importantly its offset to know where to continue uploading. Only the user who started the upload can see it.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param uploadID path int true "Upload ID"
+// @Success 200 {object} models.TaskAttachmentUpload "The upload."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the upload."
+// @Failure 404 {object} web.HTTPError "The upload does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/attachments/uploads/{uploadID} [get]
+func (u *TaskAttachmentUpload) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	return getTaskAttachmentUploadSimple(s, u)
+}
+
+// AddChunk stores the next chunk of the upload. The chunk has to start at the current offset of the upload.
+// Chunks are only accepted as long as the whole upload still fits into the storage quotas.
+func (u *TaskAttachmentUpload) AddChunk(s *xorm.Session, a web.Auth, offset uint64, content io.Reader) (err error) {
+	err = getTaskAttachmentUploadSimple(s, u)
+	if err != nil {
+		return err
+	}
+
+	if offset != u.Offset {
+		return ErrTaskAttachmentUploadOffsetMismatch{
+			UploadID: u.ID,
+			Expected: u.Offset,
+			Offset:   offset,
+		}
+	}
+
+	err = u.checkQuotas(s, a)
+	if err != nil {
+		return err
+	}
+
+	// Reading one byte more than what's missing lets us notice chunks which are too large
+	chunk := &countingReader{reader: io.LimitReader(content, int64(u.Size-u.Offset)+1)}
+	key, err := files.SaveChunk(u.ID, chunk)
+	if err != nil {
+		return err
+	}
+	if chunk.count > u.Size-u.Offset {
+		_ = files.RemoveChunks(u.ID, []uint64{key})
+		return ErrTaskAttachmentUploadTooLarge{
+			UploadID: u.ID,
+			Size:     u.Size,
+		}
+	}
+	if chunk.count == 0 {
+		return files.RemoveChunks(u.ID, []uint64{key})
+	}
+
+	u.Chunks = append(u.Chunks, key)
+	u.Offset += chunk.count
+	u.Expires = time.Now().Add(taskAttachmentUploadExpiry)
+
+	// Only update the upload if no other chunk was added in the meantime
+	updated, err := s.
+		Where("id = ? AND upload_offset = ?", u.ID, offset).
+		Cols("upload_offset", "chunks", "expires").
+		NoAutoCondition().
+		Update(u)
+	if err != nil {
+		_ = files.RemoveChunks(u.ID, []uint64{key})
+		return err
+	}
+	if updated == 0 {
+		// Another request added a chunk first, this one is not part of the upload
+		_ = files.RemoveChunks(u.ID, []uint64{key})
+
+		current := &TaskAttachmentUpload{ID: u.ID, TaskID: u.TaskID}
+		err = getTaskAttachmentUploadSimple(s, current)
+		if err != nil {
+			return err
+		}
+		return ErrTaskAttachmentUploadOffsetMismatch{
+			UploadID: u.ID,
+			Expected: current.Offset,
+			Offset:   offset,
+		}
+	}
+	return nil
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  uint64
+}
+
+func (r *countingReader) Read(p []byte) (n int, err error) {
+	n, err = r.reader.Read(p)
+	r.count += uint64(n)
+	return
+}
+
+// Update finishes a resumable upload
+// @Summary Finish a resumable attachment upload
+// @Description Creates the attachment from all uploaded chunks and removes the upload. This only works once the whole file was uploaded.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param uploadID path int true "Upload ID"
+// @Success 200 {object} models.TaskAttachmentUpload "The finished upload with the created attachment."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the upload."
+// @Failure 404 {object} web.HTTPError "The upload does not exist."
+// @Failure 412 {object} web.HTTPError "The file was not completely uploaded yet."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/attachments/uploads/{uploadID} [post]
+func (u *TaskAttachmentUpload) Update(s *xorm.Session, a web.Auth) (err error) {
+	// Everything but the ids is ignored when finishing an upload
+	err = getTaskAttachmentUploadSimple(s, u)
+	if err != nil {
+		return err
+	}
+
+	if u.Offset != u.Size {
+		return ErrTaskAttachmentUploadIncomplete{
+			UploadID: u.ID,
+			Offset:   u.Offset,
+			Size:     u.Size,
+		}
+	}
+
+	content := files.OpenChunks(u.ID, u.Chunks)
+	defer content.Close()
+
+	ta := &TaskAttachment{TaskID: u.TaskID}
+	err = ta.NewAttachment(s, content, u.Filename, u.Size, a)
+	if err != nil {
+		return err
+	}
+
+	err = u.remove(s)
+	if err != nil {
+		return err
+	}
+
+	u.Attachment = ta
+	return nil
+}
+
+// Delete aborts a resumable upload
+// @Summary Abort a resumable attachment upload
+// @Description Removes an upload and all chunks uploaded so far.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param uploadID path int true "Upload ID"
+// @Success 200 {object} models.Message "The upload was successfully removed."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the upload."
+// @Failure 404 {object} web.HTTPError "The upload does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/attachments/uploads/{uploadID} [delete]
+func (u *TaskAttachmentUpload) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	err = getTaskAttachmentUploadSimple(s, u)
+	if err != nil {
+		return err
+	}
+
+	return u.remove(s)
+}
+
+func (u *TaskAttachmentUpload) remove(s *xorm.Session) error {
+	_, err := s.Where("id = ?", u.ID).Delete(&TaskAttachmentUpload{})
+	if err != nil {
+		return err
+	}
+
+	return files.RemoveChunks(u.ID, u.Chunks)
+}
+
+// RegisterExpiredTaskAttachmentUploadsCleanupCron registers a cron job which removes abandoned uploads
+func RegisterExpiredTaskAttachmentUploadsCleanupCron() {
+	const logPrefix = "[Task Attachment Upload Cleanup Cron] "
+
+	err := cron.Schedule("0 * * * *", func() {
+		s := db.NewSession()
+		defer s.Close()
+
+		uploads := []*TaskAttachmentUpload{}
+		err := s.Where(builder.Lt{"expires": time.Now()}).Find(&uploads)
+		if err != nil {
+			log.Errorf(logPrefix+"Could not get expired uploads: %s", err)
+			return
+		}
+
+		for _, u := range uploads {
+			if err := u.remove(s); err != nil {
+				log.Errorf(logPrefix+"Could not remove expired upload %d: %s", u.ID, err)
+				return
+			}
+		}
+
+		if len(uploads) > 0 {
+			log.Debugf(logPrefix+"Removed %d expired uploads", len(uploads))
+		}
+	})
+	if err != nil {
+		log.Fatalf("Could not register expired task attachment uploads cleanup cron: %s", err)
+	}
+}
@@ -0,0 +1,68 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanCreate checks if the user can start an upload
+func (u *TaskAttachmentUpload) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	ta := &TaskAttachment{TaskID: u.TaskID}
+	return ta.CanCreate(s, a)
+}
+
+// CanRead checks if the user can see an upload
+func (u *TaskAttachmentUpload) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	isCreator, err := u.isCreator(s, a)
+	if err != nil || !isCreator {
+		return false, 0, err
+	}
+	t := &Task{ID: u.TaskID}
+	return t.CanRead(s, a)
+}
+
+// CanUpdate checks if the user can upload chunks to an upload or finish it
+func (u *TaskAttachmentUpload) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	isCreator, err := u.isCreator(s, a)
+	if err != nil || !isCreator {
+		return false, err
+	}
+	return u.CanCreate(s, a)
+}
+
+// CanDelete checks if the user can abort an upload
+func (u *TaskAttachmentUpload) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return u.CanUpdate(s, a)
+}
+
+// Uploads are only visible to the user or link share who started them
+func (u *TaskAttachmentUpload) isCreator(s *xorm.Session, a web.Auth) (bool, error) {
+	upload := &TaskAttachmentUpload{ID: u.ID, TaskID: u.TaskID}
+	err := getTaskAttachmentUploadSimple(s, upload)
+	if err != nil {
+		return false, err
+	}
+
+	doer, err := GetUserOrLinkShareUser(s, a)
+	if err != nil {
+		return false, err
+	}
+
+	return upload.CreatedByID == doer.ID, nil
+}
@@ -0,0 +1,290 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/files"
+	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestTaskAttachmentUpload(t *testing.T, size uint64) *TaskAttachmentUpload {
+	s := db.NewSession()
+	defer s.Close()
+
+	upload := &TaskAttachmentUpload{
+		TaskID:   1,
+		Filename: "upload.txt",
+		Size:     size,
+	}
+	err := upload.Create(s, &user.User{ID: 1})
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+	return upload
+}
+
+func addTestChunk(upload *TaskAttachmentUpload, offset uint64, content string) error {
+	s := db.NewSession()
+	defer s.Close()
+
+	u := &TaskAttachmentUpload{ID: upload.ID, TaskID: upload.TaskID}
+	err := u.AddChunk(s, &user.User{ID: 1}, offset, strings.NewReader(content))
+	if err != nil {
+		_ = s.Rollback()
+		return err
+	}
+	return s.Commit()
+}
+
+func TestTaskAttachmentUpload_Create(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+
+		upload := createTestTaskAttachmentUpload(t, 10)
+		assert.NotEqual(t, int64(0), upload.ID)
+		assert.Equal(t, uint64(0), upload.Offset)
+		assert.Equal(t, int64(1), upload.CreatedByID)
+		assert.True(t, upload.Expires.After(time.Now()))
+		db.AssertExists(t, "task_attachment_uploads", map[string]interface{}{
+			"id":            upload.ID,
+			"task_id":       1,
+			"upload_offset": 0,
+			"size":          10,
+		}, false)
+	})
+	t.Run("Too large", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		maxSize, err := files.GetMaxFileSize()
+		assert.NoError(t, err)
+		upload := &TaskAttachmentUpload{
+			TaskID:   1,
+			Filename: "upload.txt",
+			Size:     maxSize + 1,
+		}
+		err = upload.Create(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskAttachmentIsTooLarge(err))
+	})
+	t.Run("Quota exceeded by pending uploads", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+		config.FilesUserQuota.Set("150B")
+		defer config.FilesUserQuota.Set("0")
+
+		createTestTaskAttachmentUpload(t, 40)
+
+		s := db.NewSession()
+		defer s.Close()
+		upload := &TaskAttachmentUpload{
+			TaskID:   1,
+			Filename: "upload.txt",
+			Size:     20,
+		}
+		err := upload.Create(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, files.IsErrStorageQuotaExceeded(err))
+	})
+}
+
+func TestTaskAttachmentUpload_AddChunk(t *testing.T) {
+	t.Run("Normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+
+		upload := createTestTaskAttachmentUpload(t, 10)
+		err := addTestChunk(upload, 0, "12345")
+		assert.NoError(t, err)
+		err = addTestChunk(upload, 5, "678")
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_attachment_uploads", map[string]interface{}{
+			"id":            upload.ID,
+			"upload_offset": 8,
+		}, false)
+	})
+	t.Run("Quota exceeded", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+
+		upload := createTestTaskAttachmentUpload(t, 40)
+
+		config.FilesUserQuota.Set("120B")
+		defer config.FilesUserQuota.Set("0")
+
+		err := addTestChunk(upload, 0, "12345")
+		assert.Error(t, err)
+		assert.True(t, files.IsErrStorageQuotaExceeded(err))
+		db.AssertExists(t, "task_attachment_uploads", map[string]interface{}{
+			"id":            upload.ID,
+			"upload_offset": 0,
+		}, false)
+	})
+	t.Run("Offset mismatch", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+
+		upload := createTestTaskAttachmentUpload(t, 10)
+		err := addTestChunk(upload, 0, "12345")
+		assert.NoError(t, err)
+		err = addTestChunk(upload, 0, "12345")
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskAttachmentUploadOffsetMismatch(err))
+		assert.Equal(t, uint64(5), err.(ErrTaskAttachmentUploadOffsetMismatch).Expected)
+	})
+	t.Run("Chunk too large", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+
+		upload := createTestTaskAttachmentUpload(t, 10)
+		err := addTestChunk(upload, 0, "12345678901")
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskAttachmentUploadTooLarge(err))
+
+		db.AssertExists(t, "task_attachment_uploads", map[string]interface{}{
+			"id":            upload.ID,
+			"upload_offset": 0,
+		}, false)
+	})
+	t.Run("Nonexisting upload", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+
+		err := addTestChunk(&TaskAttachmentUpload{ID: 9999, TaskID: 1}, 0, "12345")
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskAttachmentUploadDoesNotExist(err))
+	})
+}
+
+func TestTaskAttachmentUpload_Update(t *testing.T) {
+	t.Run("Finish upload", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+
+		upload := createTestTaskAttachmentUpload(t, 10)
+		assert.NoError(t, addTestChunk(upload, 0, "12345"))
+		assert.NoError(t, addTestChunk(upload, 5, "67890"))
+
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &TaskAttachmentUpload{ID: upload.ID, TaskID: upload.TaskID}
+		err := u.Update(s, &user.User{ID: 1})
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		assert.NotNil(t, u.Attachment)
+		assert.Equal(t, "upload.txt", u.Attachment.File.Name)
+		assert.Equal(t, uint64(10), u.Attachment.File.Size)
+
+		err = u.Attachment.File.LoadFileByID()
+		assert.NoError(t, err)
+		defer u.Attachment.File.File.Close()
+		content, err := io.ReadAll(u.Attachment.File.File)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("1234567890"), content)
+
+		db.AssertMissing(t, "task_attachment_uploads", map[string]interface{}{
+			"id": upload.ID,
+		})
+		require.Len(t, u.Chunks, 2)
+		_, err = files.OpenChunks(upload.ID, u.Chunks).Read(make([]byte, 1))
+		assert.Error(t, err)
+	})
+	t.Run("Incomplete", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		files.InitTestFileFixtures(t)
+
+		upload := createTestTaskAttachmentUpload(t, 10)
+		assert.NoError(t, addTestChunk(upload, 0, "12345"))
+
+		s := db.NewSession()
+		defer s.Close()
+
+		u := &TaskAttachmentUpload{ID: upload.ID, TaskID: upload.TaskID}
+		err := u.Update(s, &user.User{ID: 1})
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskAttachmentUploadIncomplete(err))
+	})
+}
+
+func TestTaskAttachmentUpload_Delete(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	files.InitTestFileFixtures(t)
+
+	upload := createTestTaskAttachmentUpload(t, 10)
+	assert.NoError(t, addTestChunk(upload, 0, "12345"))
+
+	s := db.NewSession()
+	defer s.Close()
+
+	u := &TaskAttachmentUpload{ID: upload.ID, TaskID: upload.TaskID}
+	err := u.Delete(s, &user.User{ID: 1})
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	db.AssertMissing(t, "task_attachment_uploads", map[string]interface{}{
+		"id": upload.ID,
+	})
+	require.Len(t, u.Chunks, 1)
+	_, err = files.OpenChunks(upload.ID, u.Chunks).Read(make([]byte, 1))
+	assert.Error(t, err)
+}
+
+func TestTaskAttachmentUpload_Rights(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	files.InitTestFileFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	// Task 20 is in project 11 which belongs to user 6 and is shared with user 1
+	upload := &TaskAttachmentUpload{
+		TaskID:   20,
+		Filename: "upload.txt",
+		Size:     10,
+	}
+	err := upload.Create(s, &user.User{ID: 1})
+	assert.NoError(t, err)
+
+	u := &TaskAttachmentUpload{ID: upload.ID, TaskID: upload.TaskID}
+	can, err := u.CanUpdate(s, &user.User{ID: 1})
+	assert.NoError(t, err)
+	assert.True(t, can)
+
+	// Only the user who started the upload can access it
+	can, _, err = u.CanRead(s, &user.User{ID: 6})
+	assert.NoError(t, err)
+	assert.False(t, can)
+	can, err = u.CanDelete(s, &user.User{ID: 6})
+	assert.NoError(t, err)
+	assert.False(t, can)
+}
@@ -17,6 +17,9 @@
 package models
 
 import (
+	"strconv"
+	"strings"
+
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
 	"xorm.io/xorm"
@@ -24,7 +27,8 @@ import (
 
 // TaskCollection is a struct used to hold filter details and not clutter the Task struct with information not related to actual tasks.
 type TaskCollection struct {
-	ProjectID int64 `param:"project" json:"-"`
+	ProjectID     int64 `param:"project" json:"-"`
+	ProjectViewID int64 `param:"view" json:"-"`
 
 	// The query parameter to sort by. This is for ex. done, priority, etc.
 	SortBy    []string `query:"sort_by" json:"sort_by"`
@@ -47,6 +51,10 @@ type TaskCollection struct {
 	// If set to true, the result will also include null values
 	FilterIncludeNulls bool `query:"filter_include_nulls" json:"filter_include_nulls"`
 
+	// A filter expression like `(priority >= 3 && label in 4,5) || due_date < now+7d`. It is combined with
+	// all other filters using "and".
+	Filter string `query:"filter" json:"filter,omitempty"`
+
 	web.CRUDable `xorm:"-" json:"-"`
 	web.Rights   `xorm:"-" json:"-"`
 }
@@ -72,7 +80,6 @@ func validateTaskField(fieldName string) error {
 		taskPropertyCreated,
 		taskPropertyUpdated,
 		taskPropertyPosition,
-		taskPropertyKanbanPosition,
 		taskPropertyBucketID,
 		taskPropertyIndex:
 		return nil
@@ -115,32 +122,102 @@ func getTaskFilterOptsFromCollection(tf *TaskCollection) (opts *taskSearchOption
 	}
 
 	opts.filters, err = getTaskFiltersByCollections(tf)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.filterExpression, err = parseTaskFilterExpression(tf.Filter)
 	return opts, err
 }
 
+// combineTaskFilterExpressions joins two filter expressions so that tasks need to match both of them.
+func combineTaskFilterExpressions(a, b string) string {
+	a = strings.TrimSpace(a)
+	b = strings.TrimSpace(b)
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	return "(" + a + ") && (" + b + ")"
+}
+
+// getTaskIDsMatchingFilter returns the ids of all tasks of a project which match a filter expression.
+func getTaskIDsMatchingFilter(s *xorm.Session, projectID int64, filter string) (taskIDs []int64, err error) {
+	opts, err := getTaskFilterOptsFromCollection(&TaskCollection{Filter: filter})
+	if err != nil {
+		return nil, err
+	}
+	opts.filterConcat = filterConcatAnd
+
+	tasks, _, _, err := getRawTasksForProjects(s, []*Project{{ID: projectID}}, nil, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	taskIDs = make([]int64, 0, len(tasks))
+	for _, t := range tasks {
+		taskIDs = append(taskIDs, t.ID)
+	}
+	return taskIDs, nil
+}
+
+// taskMatchesFilter checks if a task of a project matches a filter expression.
+func taskMatchesFilter(s *xorm.Session, projectID int64, taskID int64, filter string) (bool, error) {
+	taskIDs, err := getTaskIDsMatchingFilter(s, projectID, combineTaskFilterExpressions(filter, "id = "+strconv.FormatInt(taskID, 10)))
+	return len(taskIDs) > 0, err
+}
+
 // ReadAll gets all tasks for a collection
 // @Summary Get tasks in a project
-// @Description Returns all tasks for the current project.
+// @Description Returns all tasks for the current project. When requesting the tasks of a view, the filter of the view is applied as well and the tasks are sorted by their position in the view unless a different sort order was requested. Kanban views return all of their buckets with the tasks in them instead.
 // @tags task
 // @Accept json
 // @Produce json
 // @Param projectID path int true "The project ID."
+// @Param viewID path int false "The project view ID."
 // @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
 // @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
 // @Param s query string false "Search tasks by task text."
-// @Param sort_by query string false "The sorting parameter. You can pass this multiple times to get the tasks ordered by multiple different parametes, along with `order_by`. Possible values to sort by are `id`, `title`, `description`, `done`, `done_at`, `due_date`, `created_by_id`, `project_id`, `repeat_after`, `priority`, `start_date`, `end_date`, `hex_color`, `percent_done`, `uid`, `created`, `updated`. Default is `id`."
+// @Param sort_by query string false "The sorting parameter. You can pass this multiple times to get the tasks ordered by multiple different parametes, along with `order_by`. Possible values to sort by are `id`, `title`, `description`, `done`, `done_at`, `due_date`, `created_by_id`, `project_id`, `repeat_after`, `priority`, `start_date`, `end_date`, `hex_color`, `percent_done`, `uid`, `created`, `updated`. Custom fields can be sorted by with `custom_fields.<field id>`. `position` sorts the tasks by their position in the requested view and is ignored without a view. Default is `id`."
 // @Param order_by query string false "The ordering parameter. Possible values to order by are `asc` or `desc`. Default is `asc`."
-// @Param filter_by query string false "The name of the field to filter by. Allowed values are all task properties. Task properties which are their own object require passing in the id of that entity. Accepts an array for multiple filters which will be chanied together, all supplied filter must match."
+// @Param filter_by query string false "The name of the field to filter by. Allowed values are all task properties. Task properties which are their own object require passing in the id of that entity. Custom fields can be filtered by with `custom_fields.<field id>`. Accepts an array for multiple filters which will be chanied together, all supplied filter must match."
 // @Param filter_value query string false "The value to filter for. You can use [grafana](https://grafana.com/docs/grafana/latest/dashboards/time-range-controls)- or [elasticsearch](https://www.elastic.co/guide/en/elasticsearch/reference/7.3/common-options.html#date-math)-style relative dates for all date fields like `due_date`, `start_date`, `end_date`, etc."
 // @Param filter_comparator query string false "The comparator to use for a filter. Available values are `equals`, `greater`, `greater_equals`, `less`, `less_equals`, `like` and `in`. `in` expects comma-separated values in `filter_value`. Defaults to `equals`"
 // @Param filter_concat query string false "The concatinator to use for filters. Available values are `and` or `or`. Defaults to `or`."
 // @Param filter_include_nulls query string false "If set to true the result will include filtered fields whose value is set to `null`. Available values are `true` or `false`. Defaults to `false`."
+// @Param filter query string false "A filter expression like `(priority >= 3 && label in 4,5) || due_date < now+7d`. Supports parentheses, `&&`/`and`, `||`/`or`, negation with `!` or `not` and the comparators `=`, `!=`, `>`, `>=`, `<`, `<=`, `like`, `in` and `not in`. Combined with all other filter parameters using `and`."
 // @Security JWTKeyAuth
 // @Success 200 {array} models.Task "The tasks"
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /projects/{projectID}/tasks [get]
+// @Router /projects/{projectID}/views/{viewID}/tasks [get]
 func (tf *TaskCollection) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, totalItems int64, err error) {
 
+	var view *ProjectView
+	if tf.ProjectViewID != 0 {
+		view, err = getProjectViewByID(s, tf.ProjectViewID)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		if view.ProjectID != tf.ProjectID {
+			return nil, 0, 0, &ErrProjectViewDoesNotExist{ProjectViewID: tf.ProjectViewID}
+		}
+
+		// Kanban views return their buckets with the tasks in them
+		if view.ViewKind == ProjectViewKindKanban {
+			b := &Bucket{
+				ProjectID:      tf.ProjectID,
+				ProjectViewID:  tf.ProjectViewID,
+				TaskCollection: *tf,
+			}
+			return b.ReadAll(s, a, search, page, perPage)
+		}
+
+		tf.Filter = combineTaskFilterExpressions(view.Filter, tf.Filter)
+	}
+
 	// If the project id is < -1 this means we're dealing with a saved filter - in that case we get and populate the filter
 	// -1 is the favorites project which works as intended
 	if tf.ProjectID < -1 {
@@ -177,6 +254,19 @@ func (tf *TaskCollection) ReadAll(s *xorm.Session, a web.Auth, search string, pa
 	taskopts.page = page
 	taskopts.perPage = perPage
 
+	if view != nil {
+		taskopts.projectViewID = view.ID
+		// Tasks in a view are sorted by their position in that view unless something else was requested
+		if len(taskopts.sortby) == 0 {
+			taskopts.sortby = []*sortParam{
+				{
+					sortBy:  taskPropertyPosition,
+					orderBy: orderAscending,
+				},
+			}
+		}
+	}
+
 	shareAuth, is := a.(*LinkSharing)
 	if is {
 		project, err := GetProjectSimpleByID(s, shareAuth.ProjectID)
@@ -22,6 +22,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"code.vikunja.io/api/pkg/config"
 
@@ -54,6 +55,11 @@ type taskFilter struct {
 	value      interface{} // Needs to be an interface to be able to hold the field's native value
 	comparator taskFilterComparator
 	isNumeric  bool
+
+	// Only set when filtering by a custom field. The value is converted to the native type of the field
+	// once its definition was loaded from the db.
+	customFieldID int64
+	customField   *CustomField
 }
 
 func parseTimeFromUserInput(timeString string) (value time.Time, err error) {
@@ -128,19 +134,12 @@ func getTaskFiltersByCollections(c *TaskCollection) (filters []*taskFilter, err
 		}
 
 		// Cast the field value to its native type
-		var reflectValue *reflect.StructField
 		if len(c.FilterValue) > i {
-			reflectValue, filter.value, err = getNativeValueForTaskField(filter.field, filter.comparator, c.FilterValue[i])
+			err = filter.setValueFromString(c.FilterValue[i])
 			if err != nil {
-				return nil, ErrInvalidTaskFilterValue{
-					Value: filter.field,
-					Field: c.FilterValue[i],
-				}
+				return nil, err
 			}
 		}
-		if reflectValue != nil {
-			filter.isNumeric = reflectValue.Type.Kind() == reflect.Int64
-		}
 
 		filters = append(filters, filter)
 	}
@@ -148,6 +147,29 @@ func getTaskFiltersByCollections(c *TaskCollection) (filters []*taskFilter, err
 	return
 }
 
+func (f *taskFilter) setValueFromString(rawValue string) error {
+	if id, is := getCustomFieldIDFromTaskProperty(f.field); is {
+		f.customFieldID = id
+		f.value = rawValue
+		return nil
+	}
+
+	reflectValue, value, err := getNativeValueForTaskField(f.field, f.comparator, rawValue)
+	if err != nil {
+		return ErrInvalidTaskFilterValue{
+			Value: f.field,
+			Field: rawValue,
+		}
+	}
+
+	f.value = value
+	if reflectValue != nil {
+		f.isNumeric = reflectValue.Type.Kind() == reflect.Int64
+	}
+
+	return nil
+}
+
 func validateTaskFieldComparator(comparator taskFilterComparator) error {
 	switch comparator {
 	case
@@ -271,3 +293,382 @@ func getNativeValueForTaskField(fieldName string, comparator taskFilterComparato
 	val, err := getValueForField(field, value)
 	return &field, val, err
 }
+
+// taskFilterNode is a node in the tree of a parsed filter expression. A node either holds a single
+// filter or a group of child nodes which are concatenated with the node's concatinator.
+type taskFilterNode struct {
+	filter   *taskFilter
+	concat   taskFilterConcatinator
+	children []*taskFilterNode
+	negate   bool
+}
+
+// getFilters returns all filters of the node and its children
+func (node *taskFilterNode) getFilters() (filters []*taskFilter) {
+	if node == nil {
+		return nil
+	}
+
+	if node.filter != nil {
+		return []*taskFilter{node.filter}
+	}
+
+	for _, child := range node.children {
+		filters = append(filters, child.getFilters()...)
+	}
+
+	return
+}
+
+// hasNegatedLike checks if the expression contains a like filter which is negated, either directly or through one of
+// its parent groups.
+func (node *taskFilterNode) hasNegatedLike(negate bool) bool {
+	if node == nil {
+		return false
+	}
+
+	negate = negate != node.negate
+	if node.filter != nil {
+		return negate && node.filter.comparator == taskFilterComparatorLike
+	}
+
+	for _, child := range node.children {
+		if child.hasNegatedLike(negate) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type taskFilterTokenKind int
+
+const (
+	taskFilterTokenValue taskFilterTokenKind = iota
+	taskFilterTokenOpenParen
+	taskFilterTokenCloseParen
+	taskFilterTokenAnd
+	taskFilterTokenOr
+	taskFilterTokenNot
+	taskFilterTokenComparator
+)
+
+type taskFilterToken struct {
+	kind  taskFilterTokenKind
+	value string
+}
+
+// These aliases make filter expressions read more natural, for example "label in 4,5".
+var taskFilterFieldAliases = map[string]string{
+	"label":    "labels",
+	"assignee": "assignees",
+	"reminder": "reminders",
+	"project":  "project_id",
+}
+
+func isTaskFilterValueDelimiter(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(`()!=<>&|'"`, r)
+}
+
+func tokenizeTaskFilterExpression(expression string) (tokens []*taskFilterToken, err error) {
+	runes := []rune(expression)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		var next rune
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		switch {
+		case unicode.IsSpace(r):
+			continue
+		case r == '(':
+			tokens = append(tokens, &taskFilterToken{kind: taskFilterTokenOpenParen, value: "("})
+		case r == ')':
+			tokens = append(tokens, &taskFilterToken{kind: taskFilterTokenCloseParen, value: ")"})
+		case r == '&' && next == '&':
+			tokens = append(tokens, &taskFilterToken{kind: taskFilterTokenAnd, value: "&&"})
+			i++
+		case r == '|' && next == '|':
+			tokens = append(tokens, &taskFilterToken{kind: taskFilterTokenOr, value: "||"})
+			i++
+		case r == '!' && next == '=':
+			tokens = append(tokens, &taskFilterToken{kind: taskFilterTokenComparator, value: string(taskFilterComparatorNotEquals)})
+			i++
+		case r == '!':
+			tokens = append(tokens, &taskFilterToken{kind: taskFilterTokenNot, value: "!"})
+		case r == '=':
+			if next == '=' {
+				i++
+			}
+			tokens = append(tokens, &taskFilterToken{kind: taskFilterTokenComparator, value: string(taskFilterComparatorEquals)})
+		case r == '>' || r == '<':
+			comparator := string(r)
+			if next == '=' {
+				comparator += "="
+				i++
+			}
+			tokens = append(tokens, &taskFilterToken{kind: taskFilterTokenComparator, value: comparator})
+		case r == '"' || r == '\'':
+			quote := r
+			value := []rune{}
+			i++
+			for ; i < len(runes) && runes[i] != quote; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				value = append(value, runes[i])
+			}
+			if i >= len(runes) {
+				return nil, &ErrInvalidTaskFilterExpression{Expression: expression, Reason: "unterminated string"}
+			}
+			tokens = append(tokens, &taskFilterToken{kind: taskFilterTokenValue, value: string(value)})
+		case r == '&' || r == '|':
+			return nil, &ErrInvalidTaskFilterExpression{Expression: expression, Reason: "unexpected character " + string(r)}
+		default:
+			start := i
+			for i+1 < len(runes) && !isTaskFilterValueDelimiter(runes[i+1]) {
+				i++
+			}
+			tokens = append(tokens, getTaskFilterWordToken(string(runes[start:i+1])))
+		}
+	}
+
+	return
+}
+
+func getTaskFilterWordToken(word string) *taskFilterToken {
+	switch strings.ToLower(word) {
+	case "and":
+		return &taskFilterToken{kind: taskFilterTokenAnd, value: word}
+	case "or":
+		return &taskFilterToken{kind: taskFilterTokenOr, value: word}
+	case "not":
+		return &taskFilterToken{kind: taskFilterTokenNot, value: word}
+	case "like":
+		return &taskFilterToken{kind: taskFilterTokenComparator, value: string(taskFilterComparatorLike)}
+	case "in":
+		return &taskFilterToken{kind: taskFilterTokenComparator, value: string(taskFilterComparatorIn)}
+	}
+
+	return &taskFilterToken{kind: taskFilterTokenValue, value: word}
+}
+
+type taskFilterParser struct {
+	expression string
+	tokens     []*taskFilterToken
+	pos        int
+}
+
+// parseTaskFilterExpression parses a textual filter expression like
+// "(priority >= 3 && label in 4,5) || due_date < now" into a tree of filters.
+// Supported are parentheses, "&&"/"and", "||"/"or" (with "and" taking precedence), negation with "!" or "not",
+// the comparators =, !=, >, >=, <, <=, like, in and "not in" as well as relative dates like "now+7d".
+func parseTaskFilterExpression(expression string) (node *taskFilterNode, err error) {
+	if strings.TrimSpace(expression) == "" {
+		return nil, nil
+	}
+
+	tokens, err := tokenizeTaskFilterExpression(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &taskFilterParser{
+		expression: expression,
+		tokens:     tokens,
+	}
+
+	node, err = p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, p.error("unexpected " + p.tokens[p.pos].value)
+	}
+
+	return node, nil
+}
+
+func (p *taskFilterParser) error(reason string) error {
+	return &ErrInvalidTaskFilterExpression{Expression: p.expression, Reason: reason}
+}
+
+func (p *taskFilterParser) peek() *taskFilterToken {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *taskFilterParser) next() *taskFilterToken {
+	t := p.peek()
+	if t != nil {
+		p.pos++
+	}
+	return t
+}
+
+func (p *taskFilterParser) parseGroup(kind taskFilterTokenKind, concat taskFilterConcatinator, parseChild func() (*taskFilterNode, error)) (*taskFilterNode, error) {
+	first, err := parseChild()
+	if err != nil {
+		return nil, err
+	}
+
+	children := []*taskFilterNode{first}
+	for t := p.peek(); t != nil && t.kind == kind; t = p.peek() {
+		p.next()
+		child, err := parseChild()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+
+	if len(children) == 1 {
+		return first, nil
+	}
+
+	return &taskFilterNode{
+		concat:   concat,
+		children: children,
+	}, nil
+}
+
+func (p *taskFilterParser) parseOr() (*taskFilterNode, error) {
+	return p.parseGroup(taskFilterTokenOr, filterConcatOr, p.parseAnd)
+}
+
+func (p *taskFilterParser) parseAnd() (*taskFilterNode, error) {
+	return p.parseGroup(taskFilterTokenAnd, filterConcatAnd, p.parseUnary)
+}
+
+func (p *taskFilterParser) parseUnary() (*taskFilterNode, error) {
+	t := p.peek()
+	if t == nil {
+		return nil, p.error("unexpected end of expression")
+	}
+
+	switch t.kind {
+	case taskFilterTokenNot:
+		p.next()
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		node.negate = !node.negate
+		return node, nil
+	case taskFilterTokenOpenParen:
+		p.next()
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		closing := p.next()
+		if closing == nil || closing.kind != taskFilterTokenCloseParen {
+			return nil, p.error("missing closing parenthesis")
+		}
+		return node, nil
+	case taskFilterTokenValue:
+		return p.parseComparison()
+	}
+
+	return nil, p.error("unexpected " + t.value)
+}
+
+func (p *taskFilterParser) parseComparison() (*taskFilterNode, error) {
+	field := strings.ToLower(p.next().value)
+	if alias, has := taskFilterFieldAliases[field]; has {
+		field = alias
+	}
+
+	node := &taskFilterNode{}
+
+	t := p.next()
+	// "not in" and "not like"
+	if t != nil && t.kind == taskFilterTokenNot {
+		node.negate = true
+		t = p.next()
+		if t == nil || t.kind != taskFilterTokenComparator ||
+			(t.value != string(taskFilterComparatorIn) && t.value != string(taskFilterComparatorLike)) {
+			return nil, p.error("expected in or like after not")
+		}
+	}
+	if t == nil || t.kind != taskFilterTokenComparator {
+		return nil, p.error("expected a comparator after " + field)
+	}
+
+	comparator := taskFilterComparator(t.value)
+	value, err := p.parseValue(comparator)
+	if err != nil {
+		return nil, err
+	}
+
+	node.filter = &taskFilter{
+		field:      field,
+		comparator: comparator,
+	}
+	err = node.filter.setValueFromString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+func (p *taskFilterParser) parseValue(comparator taskFilterComparator) (string, error) {
+	t := p.next()
+	if t == nil {
+		return "", p.error("unexpected end of expression, expected a value")
+	}
+
+	if comparator != taskFilterComparatorIn {
+		if t.kind != taskFilterTokenValue {
+			return "", p.error("expected a value instead of " + t.value)
+		}
+		return t.value, nil
+	}
+
+	// Values for "in" can be passed as "4,5", "4, 5" or "(4, 5)"
+	parenthesized := t.kind == taskFilterTokenOpenParen
+	if parenthesized {
+		t = p.next()
+	}
+
+	values := []string{}
+	for {
+		if t == nil || t.kind != taskFilterTokenValue {
+			return "", p.error("expected a list of values")
+		}
+		values = append(values, t.value)
+
+		next := p.peek()
+		moreValues := strings.HasSuffix(t.value, ",") ||
+			(next != nil && next.kind == taskFilterTokenValue && strings.HasPrefix(next.value, ","))
+		if parenthesized {
+			moreValues = next != nil && next.kind == taskFilterTokenValue
+		}
+		if !moreValues {
+			break
+		}
+		t = p.next()
+	}
+
+	if parenthesized {
+		closing := p.next()
+		if closing == nil || closing.kind != taskFilterTokenCloseParen {
+			return "", p.error("missing closing parenthesis")
+		}
+	}
+
+	list := []string{}
+	for _, v := range strings.Split(strings.Join(values, ","), ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			list = append(list, v)
+		}
+	}
+
+	return strings.Join(list, ","), nil
+}
@@ -0,0 +1,165 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTaskFilterExpression(t *testing.T) {
+	t.Run("single comparison", func(t *testing.T) {
+		node, err := parseTaskFilterExpression("priority >= 3")
+		assert.NoError(t, err)
+		assert.NotNil(t, node.filter)
+		assert.Equal(t, "priority", node.filter.field)
+		assert.Equal(t, taskFilterComparatorGreateEquals, node.filter.comparator)
+		assert.Equal(t, int64(3), node.filter.value)
+	})
+	t.Run("and takes precedence over or", func(t *testing.T) {
+		node, err := parseTaskFilterExpression("done = false || priority > 2 && percent_done = 0.5")
+		assert.NoError(t, err)
+		assert.Nil(t, node.filter)
+		assert.Equal(t, taskFilterConcatinator(filterConcatOr), node.concat)
+		assert.Len(t, node.children, 2)
+		assert.Equal(t, "done", node.children[0].filter.field)
+		assert.Equal(t, taskFilterConcatinator(filterConcatAnd), node.children[1].concat)
+		assert.Len(t, node.children[1].children, 2)
+	})
+	t.Run("parentheses and keywords", func(t *testing.T) {
+		node, err := parseTaskFilterExpression("(priority >= 3 and label in 4, 5) or due_date < now+7d")
+		assert.NoError(t, err)
+		assert.Equal(t, taskFilterConcatinator(filterConcatOr), node.concat)
+		assert.Len(t, node.children, 2)
+		group := node.children[0]
+		assert.Equal(t, taskFilterConcatinator(filterConcatAnd), group.concat)
+		assert.Equal(t, "labels", group.children[1].filter.field)
+		assert.Equal(t, taskFilterComparatorIn, group.children[1].filter.comparator)
+		assert.Equal(t, []interface{}{int64(4), int64(5)}, group.children[1].filter.value)
+		assert.Equal(t, "due_date", node.children[1].filter.field)
+	})
+	t.Run("negation", func(t *testing.T) {
+		node, err := parseTaskFilterExpression("!(done = true || priority = 1)")
+		assert.NoError(t, err)
+		assert.True(t, node.negate)
+		assert.Len(t, node.children, 2)
+	})
+	t.Run("not in", func(t *testing.T) {
+		node, err := parseTaskFilterExpression("label not in (4, 5)")
+		assert.NoError(t, err)
+		assert.True(t, node.negate)
+		assert.Equal(t, taskFilterComparatorIn, node.filter.comparator)
+		assert.Equal(t, []interface{}{int64(4), int64(5)}, node.filter.value)
+	})
+	t.Run("quoted value", func(t *testing.T) {
+		node, err := parseTaskFilterExpression(`title like "task #1 && more"`)
+		assert.NoError(t, err)
+		assert.Equal(t, taskFilterComparatorLike, node.filter.comparator)
+		assert.Equal(t, "task #1 && more", node.filter.value)
+	})
+	t.Run("empty", func(t *testing.T) {
+		node, err := parseTaskFilterExpression("  ")
+		assert.NoError(t, err)
+		assert.Nil(t, node)
+	})
+	t.Run("invalid expressions", func(t *testing.T) {
+		for _, expression := range []string{
+			"(priority > 3",
+			"priority > 3)",
+			"priority 3",
+			"priority >",
+			"priority > 3 &&",
+			`title = "unterminated`,
+			"priority > 3 & done = true",
+		} {
+			_, err := parseTaskFilterExpression(expression)
+			assert.Error(t, err, expression)
+			assert.True(t, IsErrInvalidTaskFilterExpression(err), expression)
+		}
+	})
+	t.Run("invalid value", func(t *testing.T) {
+		_, err := parseTaskFilterExpression("priority > lorem")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTaskFilterValue(err))
+	})
+}
+
+func TestTaskCollection_ReadAllWithFilterExpression(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	getTaskIDs := func(t *testing.T, filter string) []int64 {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskCollection{
+			ProjectID: 1,
+			Filter:    filter,
+		}
+		result, _, _, err := tc.ReadAll(s, u, "", 0, 50)
+		assert.NoError(t, err)
+
+		ids := []int64{}
+		for _, task := range result.([]*Task) {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+
+	t.Run("mixed and/or", func(t *testing.T) {
+		ids := getTaskIDs(t, "(priority >= 1 && done = false) || label in 4")
+		assert.Equal(t, []int64{1, 2, 3, 4}, ids)
+	})
+	t.Run("negated label", func(t *testing.T) {
+		ids := getTaskIDs(t, "!(label = 4) && priority > 50")
+		assert.Equal(t, []int64{3}, ids)
+	})
+	t.Run("date", func(t *testing.T) {
+		ids := getTaskIDs(t, "due_date < 2018-12-01 && done = false")
+		assert.Equal(t, []int64{6}, ids)
+	})
+	t.Run("negated like with typesense enabled", func(t *testing.T) {
+		// Typesense can't negate like filters, these searches need to run in the database without reaching typesense
+		config.TypesenseEnabled.Set(true)
+		defer config.TypesenseEnabled.Set(false)
+
+		ids := getTaskIDs(t, "priority >= 1 && title not like high")
+		assert.Equal(t, []int64{4}, ids)
+		ids = getTaskIDs(t, "!(title like high || title like done) && priority >= 1")
+		assert.Equal(t, []int64{4}, ids)
+	})
+}
+
+func TestTaskFilterNode_HasNegatedLike(t *testing.T) {
+	for expression, expected := range map[string]bool{
+		"title like foo":                 false,
+		"title not like foo":             true,
+		"!(title like foo)":              true,
+		"!(done = true || title like a)": true,
+		"!!(title like foo)":             false,
+		"!(done = true) && title like a": false,
+	} {
+		node, err := parseTaskFilterExpression(expression)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, node.hasNegatedLike(false), expression)
+	}
+}
@@ -20,33 +20,35 @@ type (
 	sortParam struct {
 		sortBy  string
 		orderBy sortOrder // asc or desc
+
+		// Only set when sorting by a custom field
+		customField *CustomField
 	}
 
 	sortOrder string
 )
 
 const (
-	taskPropertyID             string = "id"
-	taskPropertyTitle          string = "title"
-	taskPropertyDescription    string = "description"
-	taskPropertyDone           string = "done"
-	taskPropertyDoneAt         string = "done_at"
-	taskPropertyDueDate        string = "due_date"
-	taskPropertyCreatedByID    string = "created_by_id"
-	taskPropertyProjectID      string = "project_id"
-	taskPropertyRepeatAfter    string = "repeat_after"
-	taskPropertyPriority       string = "priority"
-	taskPropertyStartDate      string = "start_date"
-	taskPropertyEndDate        string = "end_date"
-	taskPropertyHexColor       string = "hex_color"
-	taskPropertyPercentDone    string = "percent_done"
-	taskPropertyUID            string = "uid"
-	taskPropertyCreated        string = "created"
-	taskPropertyUpdated        string = "updated"
-	taskPropertyPosition       string = "position"
-	taskPropertyKanbanPosition string = "kanban_position"
-	taskPropertyBucketID       string = "bucket_id"
-	taskPropertyIndex          string = "index"
+	taskPropertyID          string = "id"
+	taskPropertyTitle       string = "title"
+	taskPropertyDescription string = "description"
+	taskPropertyDone        string = "done"
+	taskPropertyDoneAt      string = "done_at"
+	taskPropertyDueDate     string = "due_date"
+	taskPropertyCreatedByID string = "created_by_id"
+	taskPropertyProjectID   string = "project_id"
+	taskPropertyRepeatAfter string = "repeat_after"
+	taskPropertyPriority    string = "priority"
+	taskPropertyStartDate   string = "start_date"
+	taskPropertyEndDate     string = "end_date"
+	taskPropertyHexColor    string = "hex_color"
+	taskPropertyPercentDone string = "percent_done"
+	taskPropertyUID         string = "uid"
+	taskPropertyCreated     string = "created"
+	taskPropertyUpdated     string = "updated"
+	taskPropertyPosition    string = "position"
+	taskPropertyBucketID    string = "bucket_id"
+	taskPropertyIndex       string = "index"
 )
 
 const (
@@ -73,5 +75,12 @@ func (sp *sortParam) validate() error {
 	if sp.orderBy != orderDescending && sp.orderBy != orderAscending {
 		return ErrInvalidSortOrder{OrderBy: sp.orderBy}
 	}
+	if _, is := getCustomFieldIDFromTaskProperty(sp.sortBy); is {
+		return nil
+	}
+	// A task can be in a different bucket in every kanban view, sorting by it does not make sense
+	if sp.sortBy == taskPropertyBucketID {
+		return ErrInvalidTaskField{TaskField: sp.sortBy}
+	}
 	return validateTaskField(sp.sortBy)
 }
@@ -83,6 +83,36 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 		Updated:     testUpdatedTime,
 	}
 
+	customFieldTime := time.Date(2023, 9, 18, 11, 24, 12, 0, loc)
+	customFieldCustomer := &CustomField{
+		ID:          1,
+		ProjectID:   1,
+		Title:       "Customer",
+		Type:        CustomFieldTypeText,
+		CreatedByID: 1,
+		Created:     customFieldTime,
+		Updated:     customFieldTime,
+	}
+	customFieldStoryPoints := &CustomField{
+		ID:          2,
+		ProjectID:   1,
+		Title:       "Story points",
+		Type:        CustomFieldTypeNumber,
+		CreatedByID: 1,
+		Created:     customFieldTime,
+		Updated:     customFieldTime,
+	}
+	customFieldEnvironment := &CustomField{
+		ID:          3,
+		ProjectID:   1,
+		Title:       "Environment",
+		Type:        CustomFieldTypeSelect,
+		Options:     []string{"staging", "production"},
+		CreatedByID: 1,
+		Created:     customFieldTime,
+		Updated:     customFieldTime,
+	}
+
 	// We use individual variables for the tasks here to be able to rearrange or remove ones more easily
 	task1 := &Task{
 		ID:          1,
@@ -153,6 +183,10 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 				},
 			},
 		},
+		CustomFields: []*TaskCustomFieldValue{
+			{ID: 1, TaskID: 1, FieldID: 1, Value: "ACME", TextValue: "ACME", Field: customFieldCustomer, Created: customFieldTime, Updated: customFieldTime},
+			{ID: 2, TaskID: 1, FieldID: 2, Value: float64(8), NumberValue: 8, Field: customFieldStoryPoints, Created: customFieldTime, Updated: customFieldTime},
+		},
 		Created: time.Unix(1543626724, 0).In(loc),
 		Updated: time.Unix(1543626724, 0).In(loc),
 	}
@@ -179,6 +213,9 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 				Created:  time.Unix(1543626724, 0).In(loc),
 			},
 		},
+		CustomFields: []*TaskCustomFieldValue{
+			{ID: 3, TaskID: 2, FieldID: 2, Value: float64(3), NumberValue: 3, Field: customFieldStoryPoints, Created: customFieldTime, Updated: customFieldTime},
+		},
 		Created: time.Unix(1543626724, 0).In(loc),
 		Updated: time.Unix(1543626724, 0).In(loc),
 	}
@@ -195,6 +232,10 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 		Updated:      time.Unix(1543626724, 0).In(loc),
 		Priority:     100,
 		BucketID:     2,
+		CustomFields: []*TaskCustomFieldValue{
+			{ID: 4, TaskID: 3, FieldID: 2, Value: float64(5), NumberValue: 5, Field: customFieldStoryPoints, Created: customFieldTime, Updated: customFieldTime},
+			{ID: 5, TaskID: 3, FieldID: 3, Value: "production", TextValue: "production", Field: customFieldEnvironment, Created: customFieldTime, Updated: customFieldTime},
+		},
 	}
 	task4 := &Task{
 		ID:           4,
@@ -647,21 +688,19 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 					IsFavorite:  true,
 					Created:     time.Unix(1543626724, 0).In(loc),
 					Updated:     time.Unix(1543626724, 0).In(loc),
-					BucketID:    1,
-					Position:    2,
 				},
 			},
 		},
-		BucketID: 19,
-		Created:  time.Unix(1543626724, 0).In(loc),
-		Updated:  time.Unix(1543626724, 0).In(loc),
+		Created: time.Unix(1543626724, 0).In(loc),
+		Updated: time.Unix(1543626724, 0).In(loc),
 	}
 
 	type fields struct {
-		ProjectID int64
-		Projects  []*Project
-		SortBy    []string // Is a string, since this is the place where a query string comes from the user
-		OrderBy   []string
+		ProjectID     int64
+		ProjectViewID int64
+		Projects      []*Project
+		SortBy        []string // Is a string, since this is the place where a query string comes from the user
+		OrderBy       []string
 
 		FilterBy           []string
 		FilterValue        []string
@@ -1161,14 +1200,16 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 		{
 			name: "order by position",
 			fields: fields{
-				SortBy:  []string{"position", "id"},
-				OrderBy: []string{"asc", "asc"},
+				ProjectID:     1,
+				ProjectViewID: 1,
+				SortBy:        []string{"position", "id"},
+				OrderBy:       []string{"asc", "asc"},
 			},
 			args: args{
 				a: &user.User{ID: 1},
 			},
 			want: []*Task{
-				// The only tasks with a position set
+				// The only tasks with a position set in that view
 				task1,
 				task2,
 				// the other ones don't have a position set
@@ -1182,26 +1223,44 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 				task10,
 				task11,
 				task12,
-				task15,
-				task16,
-				task17,
-				task18,
-				task19,
-				task20,
-				task21,
-				task22,
-				task23,
-				task24,
-				task25,
-				task26,
 				task27,
 				task28,
 				task29,
 				task30,
 				task31,
-				task32,
 				task33,
-				task35,
+			},
+		},
+		{
+			name: "order by position without view",
+			fields: fields{
+				ProjectID: 1,
+				SortBy:    []string{"position", "id"},
+				OrderBy:   []string{"desc", "asc"},
+			},
+			args: args{
+				a: &user.User{ID: 1},
+			},
+			want: []*Task{
+				// Positions only exist per view, so the tasks are sorted by id
+				task1,
+				task2,
+				task3,
+				task4,
+				task5,
+				task6,
+				task7,
+				task8,
+				task9,
+				task10,
+				task11,
+				task12,
+				task27,
+				task28,
+				task29,
+				task30,
+				task31,
+				task33,
 			},
 		},
 		{
@@ -1313,9 +1372,10 @@ func TestTaskCollection_ReadAll(t *testing.T) {
 			defer s.Close()
 
 			lt := &TaskCollection{
-				ProjectID: tt.fields.ProjectID,
-				SortBy:    tt.fields.SortBy,
-				OrderBy:   tt.fields.OrderBy,
+				ProjectID:     tt.fields.ProjectID,
+				ProjectViewID: tt.fields.ProjectViewID,
+				SortBy:        tt.fields.SortBy,
+				OrderBy:       tt.fields.OrderBy,
 
 				FilterBy:           tt.fields.FilterBy,
 				FilterValue:        tt.fields.FilterValue,
@@ -80,6 +80,11 @@ func (tc *TaskComment) Create(s *xorm.Session, a web.Auth) (err error) {
 		return
 	}
 
+	err = updateTaskLastUpdated(s, &Task{ID: tc.TaskID})
+	if err != nil {
+		return err
+	}
+
 	return events.Dispatch(&TaskCommentCreatedEvent{
 		Task:    &task,
 		Comment: tc,
@@ -114,6 +119,11 @@ func (tc *TaskComment) Delete(s *xorm.Session, _ web.Auth) error {
 		return err
 	}
 
+	err = updateTaskLastUpdated(s, &Task{ID: tc.TaskID})
+	if err != nil {
+		return err
+	}
+
 	return events.Dispatch(&TaskCommentDeletedEvent{
 		Task:    &Task{ID: tc.TaskID},
 		Comment: tc,
@@ -153,6 +163,11 @@ func (tc *TaskComment) Update(s *xorm.Session, _ web.Auth) error {
 		return err
 	}
 
+	err = updateTaskLastUpdated(s, &Task{ID: tc.TaskID})
+	if err != nil {
+		return err
+	}
+
 	return events.Dispatch(&TaskCommentUpdatedEvent{
 		Task:    &task,
 		Comment: tc,
@@ -276,3 +291,48 @@ func (tc *TaskComment) ReadAll(s *xorm.Session, auth web.Auth, search string, pa
 		Count(&TaskCommentWithAuthor{})
 	return comments, len(comments), numberOfTotalItems, err
 }
+
+// AddCommentsToTasks loads the comments of all tasks including their authors, oldest first.
+// It does not check if the user has access to the tasks.
+func AddCommentsToTasks(s *xorm.Session, tasks []*TaskWithComments) (err error) {
+	if len(tasks) == 0 {
+		return
+	}
+
+	taskMap := make(map[int64]*TaskWithComments, len(tasks))
+	taskIDs := make([]int64, 0, len(tasks))
+	for _, t := range tasks {
+		taskMap[t.ID] = t
+		taskIDs = append(taskIDs, t.ID)
+	}
+
+	comments := []*TaskComment{}
+	err = s.
+		In("task_id", taskIDs).
+		OrderBy("id asc").
+		Find(&comments)
+	if err != nil {
+		return
+	}
+
+	if len(comments) == 0 {
+		return
+	}
+
+	authorIDs := make([]int64, 0, len(comments))
+	for _, comment := range comments {
+		authorIDs = append(authorIDs, comment.AuthorID)
+	}
+
+	authors, err := getUsersOrLinkSharesFromIDs(s, authorIDs)
+	if err != nil {
+		return
+	}
+
+	for _, comment := range comments {
+		comment.Author = authors[comment.AuthorID]
+		taskMap[comment.TaskID].Comments = append(taskMap[comment.TaskID].Comments, comment)
+	}
+
+	return
+}
@@ -266,3 +266,22 @@ func TestTaskComment_ReadAll(t *testing.T) {
 		assert.Equal(t, int64(15), resultComment[0].ID)
 	})
 }
+
+func TestAddCommentsToTasks(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	tasks := []*TaskWithComments{
+		{Task: Task{ID: 1}},
+		{Task: Task{ID: 2}},
+		{Task: Task{ID: 35}},
+	}
+	err := AddCommentsToTasks(s, tasks)
+	assert.NoError(t, err)
+	assert.Len(t, tasks[0].Comments, 1)
+	assert.Equal(t, "Lorem Ipsum Dolor Sit Amet", tasks[0].Comments[0].Comment)
+	assert.NotNil(t, tasks[0].Comments[0].Author)
+	assert.Empty(t, tasks[1].Comments)
+	assert.Len(t, tasks[2].Comments, 2)
+}
@@ -0,0 +1,292 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"math"
+	"strconv"
+
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// TaskPosition holds the position of a task in a project view.
+type TaskPosition struct {
+	// The ID of the task this position is for
+	TaskID int64 `xorm:"bigint not null unique(task_view)" json:"task_id" param:"task"`
+	// The project view this task is related to
+	ProjectViewID int64 `xorm:"bigint not null unique(task_view) index" json:"project_view_id"`
+	// The position of the task - any task project can be sorted as usual by this parameter.
+	// When accessing tasks via views with buckets, this is primarily used to sort them based on a range.
+	// Positions are always saved per view. They will automatically be set if you request the tasks through a view
+	// endpoint, otherwise they will always be 0. To update them, take a look at the Task Position endpoint.
+	// We're using a float64 here to make it possible to put any task within any two other tasks (by changing the number).
+	// You would calculate the new position between two tasks with something like task3.position = (task2.position - task1.position) / 2.
+	// A 64-Bit float leaves plenty of room to initially give tasks a position with 2^16 difference to the previous task
+	// which also leaves a lot of room for rearranging and sorting later.
+	Position float64 `xorm:"double not null" json:"position"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for task positions
+func (*TaskPosition) TableName() string {
+	return "task_positions"
+}
+
+// CanUpdate checks if a user can change the position of a task
+func (tp *TaskPosition) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	t := &Task{ID: tp.TaskID}
+	return t.CanUpdate(s, a)
+}
+
+// Update sets the position of a task in a view
+// @Summary Updates a task position
+// @Description Updates the position of a task in a project view. If the new position is very close to another one, all positions of the view are recalculated.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Task ID"
+// @Param view body models.TaskPosition true "The task position with updated values you want to change."
+// @Success 200 {object} models.TaskPosition "The updated task position."
+// @Failure 400 {object} web.HTTPError "Invalid task position object provided."
+// @Failure 404 {object} web.HTTPError "The project view does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{id}/position [post]
+func (tp *TaskPosition) Update(s *xorm.Session, _ web.Auth) (err error) {
+	task, err := GetTaskByIDSimple(s, tp.TaskID)
+	if err != nil {
+		return err
+	}
+
+	view, err := getProjectViewByID(s, tp.ProjectViewID)
+	if err != nil {
+		return err
+	}
+	if view.ProjectID != task.ProjectID {
+		return &ErrProjectViewDoesNotExist{ProjectViewID: tp.ProjectViewID}
+	}
+
+	err = setTaskPosition(s, tp)
+	if err != nil {
+		return err
+	}
+
+	// Update all positions if the newly saved position is < 0.1
+	if tp.Position < 0.1 {
+		err = recalculateTaskPositions(s, view.ID)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.
+			Where("task_id = ? AND project_view_id = ?", tp.TaskID, tp.ProjectViewID).
+			NoAutoCondition().
+			Get(tp)
+		if err != nil {
+			return err
+		}
+	}
+
+	return updateProjectByTaskID(s, tp.TaskID)
+}
+
+func setTaskPosition(s *xorm.Session, tp *TaskPosition) (err error) {
+	_, err = s.
+		Where("task_id = ? AND project_view_id = ?", tp.TaskID, tp.ProjectViewID).
+		Delete(&TaskPosition{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Insert(tp)
+	return
+}
+
+// createDefaultTaskPositions gives a task a position in every view of its project.
+func createDefaultTaskPositions(s *xorm.Session, task *Task) (err error) {
+	views, err := getProjectViewsForProject(s, task.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	for _, view := range views {
+		err = setTaskPosition(s, &TaskPosition{
+			TaskID:        task.ID,
+			ProjectViewID: view.ID,
+			Position:      calculateDefaultPosition(task.Index, task.Position),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func recalculateTaskPositions(s *xorm.Session, projectViewID int64) (err error) {
+
+	allPositions := []*TaskPosition{}
+	err = s.
+		Where("project_view_id = ?", projectViewID).
+		OrderBy("position asc, task_id asc").
+		Find(&allPositions)
+	if err != nil {
+		return
+	}
+
+	maxPosition := math.Pow(2, 32)
+
+	for i, position := range allPositions {
+
+		currentPosition := maxPosition / float64(len(allPositions)) * (float64(i + 1))
+
+		_, err = s.Cols("position").
+			Where("task_id = ? AND project_view_id = ?", position.TaskID, projectViewID).
+			NoAutoCondition().
+			Update(&TaskPosition{Position: currentPosition})
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// getOrderByColumnForTaskPosition returns a subquery which returns the position of each task in a view
+// so that it can be used to sort tasks.
+func getOrderByColumnForTaskPosition(projectViewID int64) string {
+	return "(SELECT position FROM task_positions " +
+		"WHERE task_positions.task_id = tasks.id " +
+		"AND task_positions.project_view_id = " + strconv.FormatInt(projectViewID, 10) + ")"
+}
+
+// addDefaultViewDataToTasks sets the bucket of the first kanban view and the position in the first list view
+// of their project on all tasks.
+func addDefaultViewDataToTasks(s *xorm.Session, tasks []*Task) (err error) {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	taskIDs := make([]int64, 0, len(tasks))
+	projectIDs := make([]int64, 0, len(tasks))
+	for _, t := range tasks {
+		taskIDs = append(taskIDs, t.ID)
+		projectIDs = append(projectIDs, t.ProjectID)
+	}
+
+	views := []*ProjectView{}
+	err = s.
+		In("project_id", projectIDs).
+		In("view_kind", ProjectViewKindList, ProjectViewKindKanban).
+		OrderBy("position asc, id asc").
+		Find(&views)
+	if err != nil {
+		return err
+	}
+
+	// Project id as key
+	listViews := make(map[int64]int64)
+	kanbanViews := make(map[int64]int64)
+	viewIDs := make([]int64, 0, len(views))
+	for _, view := range views {
+		defaultViews := listViews
+		if view.ViewKind == ProjectViewKindKanban {
+			defaultViews = kanbanViews
+		}
+		if _, has := defaultViews[view.ProjectID]; has {
+			continue
+		}
+		defaultViews[view.ProjectID] = view.ID
+		viewIDs = append(viewIDs, view.ID)
+	}
+
+	if len(viewIDs) == 0 {
+		return nil
+	}
+
+	taskBuckets := []*TaskBucket{}
+	err = s.
+		In("task_id", taskIDs).
+		In("project_view_id", viewIDs).
+		Find(&taskBuckets)
+	if err != nil {
+		return err
+	}
+
+	positions := []*TaskPosition{}
+	err = s.
+		In("task_id", taskIDs).
+		In("project_view_id", viewIDs).
+		Find(&positions)
+	if err != nil {
+		return err
+	}
+
+	type taskView struct {
+		taskID int64
+		viewID int64
+	}
+	bucketMap := make(map[taskView]int64, len(taskBuckets))
+	for _, tb := range taskBuckets {
+		bucketMap[taskView{tb.TaskID, tb.ProjectViewID}] = tb.BucketID
+	}
+	positionMap := make(map[taskView]float64, len(positions))
+	for _, p := range positions {
+		positionMap[taskView{p.TaskID, p.ProjectViewID}] = p.Position
+	}
+
+	for _, t := range tasks {
+		t.BucketID = bucketMap[taskView{t.ID, kanbanViews[t.ProjectID]}]
+		t.Position = positionMap[taskView{t.ID, listViews[t.ProjectID]}]
+	}
+
+	return nil
+}
+
+func addTaskPositionsToTasks(s *xorm.Session, tasks []*Task, projectViewID int64) (err error) {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	taskIDs := make([]int64, 0, len(tasks))
+	for _, t := range tasks {
+		taskIDs = append(taskIDs, t.ID)
+	}
+
+	positions := []*TaskPosition{}
+	err = s.
+		Where("project_view_id = ?", projectViewID).
+		In("task_id", taskIDs).
+		Find(&positions)
+	if err != nil {
+		return err
+	}
+
+	positionMap := make(map[int64]float64, len(positions))
+	for _, p := range positions {
+		positionMap[p.TaskID] = p.Position
+	}
+
+	for _, t := range tasks {
+		t.Position = positionMap[t.ID]
+	}
+
+	return nil
+}
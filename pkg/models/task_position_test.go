@@ -0,0 +1,120 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskPosition_Update(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tp := &TaskPosition{
+			TaskID:        1,
+			ProjectViewID: 1,
+			Position:      100,
+		}
+		err := tp.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_positions", map[string]interface{}{
+			"task_id":         1,
+			"project_view_id": 1,
+			"position":        100,
+		}, false)
+		// Positions in other views should not change
+		db.AssertMissing(t, "task_positions", map[string]interface{}{
+			"task_id":         1,
+			"project_view_id": 2,
+		})
+	})
+	t.Run("recalculate positions", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tp := &TaskPosition{
+			TaskID:        2,
+			ProjectViewID: 1,
+			Position:      0.01,
+		}
+		err := tp.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		// Task 2 is now sorted before task 1 and both got new positions
+		assert.Greater(t, tp.Position, 0.1)
+		positions := []*TaskPosition{}
+		err = s.Where("project_view_id = ?", 1).OrderBy("position asc").Find(&positions)
+		assert.NoError(t, err)
+		assert.Len(t, positions, 2)
+		assert.Equal(t, int64(2), positions[0].TaskID)
+		assert.Equal(t, int64(1), positions[1].TaskID)
+	})
+	t.Run("view of another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tp := &TaskPosition{
+			TaskID:        1,
+			ProjectViewID: 5,
+			Position:      100,
+		}
+		err := tp.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrProjectViewDoesNotExist(err))
+	})
+}
+
+func TestTask_CreateDefaultPositions(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	task := &Task{
+		Title:     "Lorem",
+		ProjectID: 1,
+		Position:  1234,
+	}
+	err := task.Create(s, &user.User{ID: 1})
+	assert.NoError(t, err)
+	err = s.Commit()
+	assert.NoError(t, err)
+
+	// The task should get the same position in every view of its project
+	for _, viewID := range []int64{1, 2, 3, 4} {
+		db.AssertExists(t, "task_positions", map[string]interface{}{
+			"task_id":         task.ID,
+			"project_view_id": viewID,
+			"position":        1234,
+		}, false)
+	}
+}
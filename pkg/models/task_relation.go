@@ -200,6 +200,14 @@ func (rel *TaskRelation) Create(s *xorm.Session, a web.Auth) error {
 		return err
 	}
 
+	// The relation is visible on both tasks
+	for _, taskID := range []int64{rel.TaskID, rel.OtherTaskID} {
+		err = updateTaskLastUpdated(s, &Task{ID: taskID})
+		if err != nil {
+			return err
+		}
+	}
+
 	doer, _ := user.GetFromAuth(a)
 	return events.Dispatch(&TaskRelationCreatedEvent{
 		Task:     &Task{ID: rel.TaskID},
@@ -260,6 +268,14 @@ func (rel *TaskRelation) Delete(s *xorm.Session, a web.Auth) error {
 		return err
 	}
 
+	// The relation is visible on both tasks
+	for _, taskID := range []int64{rel.TaskID, rel.OtherTaskID} {
+		err = updateTaskLastUpdated(s, &Task{ID: taskID})
+		if err != nil {
+			return err
+		}
+	}
+
 	doer, _ := user.GetFromAuth(a)
 	return events.Dispatch(&TaskRelationDeletedEvent{
 		Task:     &Task{ID: rel.TaskID},
@@ -267,3 +283,43 @@ func (rel *TaskRelation) Delete(s *xorm.Session, a web.Auth) error {
 		Doer:     doer,
 	})
 }
+
+// getUndoneBlockerIDs returns the ids of all tasks which block the given task and are not done yet.
+func getUndoneBlockerIDs(s *xorm.Session, taskID int64) (blockerIDs []int64, err error) {
+	blockerIDs = []int64{}
+	err = s.
+		Table("task_relations").
+		Distinct("task_relations.other_task_id").
+		Join("INNER", "tasks", "tasks.id = task_relations.other_task_id").
+		Where("task_relations.task_id = ? AND task_relations.relation_kind = ? AND tasks.done = ?", taskID, RelationKindBlocked, false).
+		OrderBy("task_relations.other_task_id ASC").
+		Find(&blockerIDs)
+	return
+}
+
+// checkTaskBlockers returns an error if the task's project enforces dependencies and the task is still blocked
+// by other tasks which are not done.
+func checkTaskBlockers(s *xorm.Session, task *Task) error {
+	project, err := GetProjectSimpleByID(s, task.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	if !project.EnforceDependencies {
+		return nil
+	}
+
+	blockerIDs, err := getUndoneBlockerIDs(s, task.ID)
+	if err != nil {
+		return err
+	}
+
+	if len(blockerIDs) > 0 {
+		return ErrTaskHasUndoneBlockers{
+			TaskID:     task.ID,
+			BlockerIDs: blockerIDs,
+		}
+	}
+
+	return nil
+}
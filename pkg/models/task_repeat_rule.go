@@ -0,0 +1,535 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RepeatFrequency is the FREQ part of a recurrence rule
+type RepeatFrequency string
+
+// All frequencies of RFC 5545
+const (
+	RepeatFrequencySecondly RepeatFrequency = "SECONDLY"
+	RepeatFrequencyMinutely RepeatFrequency = "MINUTELY"
+	RepeatFrequencyHourly   RepeatFrequency = "HOURLY"
+	RepeatFrequencyDaily    RepeatFrequency = "DAILY"
+	RepeatFrequencyWeekly   RepeatFrequency = "WEEKLY"
+	RepeatFrequencyMonthly  RepeatFrequency = "MONTHLY"
+	RepeatFrequencyYearly   RepeatFrequency = "YEARLY"
+)
+
+// maxRepeatRulePeriods limits how many periods are searched for the next occurrence of a rule, so that rules which
+// never match (like the 30th of February) don't loop forever.
+const maxRepeatRulePeriods = 1000
+
+var repeatRuleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// RepeatWeekday is one entry of the BYDAY part of a recurrence rule, like `MO` or `-1FR`
+type RepeatWeekday struct {
+	// The n-th occurrence of the weekday in the month or year. Negative values count from the end, 0 means every.
+	N       int
+	Weekday time.Weekday
+}
+
+func (wd RepeatWeekday) String() string {
+	day := strings.ToUpper(wd.Weekday.String()[:2])
+	if wd.N == 0 {
+		return day
+	}
+	return strconv.Itoa(wd.N) + day
+}
+
+// RepeatRule is a parsed RFC 5545 recurrence rule
+type RepeatRule struct {
+	Freq       RepeatFrequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []RepeatWeekday
+	ByMonthDay []int
+	ByMonth    []int
+	BySetPos   []int
+	WeekStart  time.Weekday
+}
+
+func parseRepeatRuleInts(value string, min, max int, allowNegative bool) (ints []int, err error) {
+	for _, part := range strings.Split(value, ",") {
+		i, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, err
+		}
+		abs := i
+		if abs < 0 && allowNegative {
+			abs = -abs
+		}
+		if abs < min || abs > max {
+			return nil, strconv.ErrRange
+		}
+		ints = append(ints, i)
+	}
+	return
+}
+
+func parseRepeatRuleUntil(value string) (time.Time, error) {
+	switch {
+	case len(value) == 8:
+		return time.ParseInLocation("20060102", value, time.UTC)
+	case strings.HasSuffix(value, "Z"):
+		return time.Parse("20060102T150405Z", value)
+	default:
+		return time.ParseInLocation("20060102T150405", value, time.UTC)
+	}
+}
+
+// ParseRepeatRule parses a recurrence rule like `FREQ=MONTHLY;BYDAY=2TU`. The `RRULE:` prefix is optional.
+// Supported are FREQ, INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY, BYMONTH, BYSETPOS and WKST.
+//
+//nolint:gocyclo
+func ParseRepeatRule(rule string) (r *RepeatRule, err error) {
+	invalid := ErrInvalidRepeatRule{Rule: rule}
+
+	r = &RepeatRule{
+		Interval:  1,
+		WeekStart: time.Monday,
+	}
+
+	value := strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	if value == "" {
+		return nil, invalid
+	}
+
+	for _, part := range strings.Split(value, ";") {
+		name, val, found := strings.Cut(part, "=")
+		if !found || val == "" {
+			return nil, invalid
+		}
+
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			r.Freq = RepeatFrequency(strings.ToUpper(val))
+			switch r.Freq {
+			case RepeatFrequencySecondly, RepeatFrequencyMinutely, RepeatFrequencyHourly,
+				RepeatFrequencyDaily, RepeatFrequencyWeekly, RepeatFrequencyMonthly, RepeatFrequencyYearly:
+			default:
+				return nil, invalid
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(val)
+			if err != nil || r.Interval < 1 {
+				return nil, invalid
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(val)
+			if err != nil || r.Count < 1 {
+				return nil, invalid
+			}
+		case "UNTIL":
+			r.Until, err = parseRepeatRuleUntil(val)
+			if err != nil {
+				return nil, invalid
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(strings.ToUpper(val), ",") {
+				if len(day) < 2 {
+					return nil, invalid
+				}
+				weekday, exists := repeatRuleWeekdays[day[len(day)-2:]]
+				if !exists {
+					return nil, invalid
+				}
+				wd := RepeatWeekday{Weekday: weekday}
+				if len(day) > 2 {
+					wd.N, err = strconv.Atoi(day[:len(day)-2])
+					if err != nil || wd.N == 0 || wd.N < -53 || wd.N > 53 {
+						return nil, invalid
+					}
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseRepeatRuleInts(val, 1, 31, true)
+			if err != nil {
+				return nil, invalid
+			}
+		case "BYMONTH":
+			r.ByMonth, err = parseRepeatRuleInts(val, 1, 12, false)
+			if err != nil {
+				return nil, invalid
+			}
+		case "BYSETPOS":
+			r.BySetPos, err = parseRepeatRuleInts(val, 1, 366, true)
+			if err != nil {
+				return nil, invalid
+			}
+		case "WKST":
+			weekday, exists := repeatRuleWeekdays[strings.ToUpper(val)]
+			if !exists {
+				return nil, invalid
+			}
+			r.WeekStart = weekday
+		default:
+			// BYSECOND, BYMINUTE, BYHOUR, BYWEEKNO, BYYEARDAY and extensions are not supported
+			return nil, invalid
+		}
+	}
+
+	if r.Freq == "" || (r.Count > 0 && !r.Until.IsZero()) {
+		return nil, invalid
+	}
+
+	if r.isSubDaily() && (len(r.ByDay) > 0 || len(r.ByMonthDay) > 0 || len(r.ByMonth) > 0 || len(r.BySetPos) > 0) {
+		return nil, invalid
+	}
+
+	// Ordinal weekdays only make sense when they relate to a month or year
+	for _, wd := range r.ByDay {
+		if wd.N != 0 && r.Freq != RepeatFrequencyMonthly && r.Freq != RepeatFrequencyYearly {
+			return nil, invalid
+		}
+	}
+
+	return r, nil
+}
+
+func joinRepeatRuleInts(ints []int) string {
+	parts := make([]string, 0, len(ints))
+	for _, i := range ints {
+		parts = append(parts, strconv.Itoa(i))
+	}
+	return strings.Join(parts, ",")
+}
+
+// String returns the rule in its RFC 5545 representation, without the `RRULE:` prefix.
+func (r *RepeatRule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.ByMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinRepeatRuleInts(r.ByMonth))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinRepeatRuleInts(r.ByMonthDay))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, wd := range r.ByDay {
+			days = append(days, wd.String())
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.BySetPos) > 0 {
+		parts = append(parts, "BYSETPOS="+joinRepeatRuleInts(r.BySetPos))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+strings.ToUpper(r.WeekStart.String()[:2]))
+	}
+	return strings.Join(parts, ";")
+}
+
+func (r *RepeatRule) isSubDaily() bool {
+	return r.Freq == RepeatFrequencySecondly || r.Freq == RepeatFrequencyMinutely || r.Freq == RepeatFrequencyHourly
+}
+
+// isPlainInterval returns true if the rule only repeats every n seconds. That is how tasks with repeat_after are
+// exported to caldav.
+func (r *RepeatRule) isPlainInterval() bool {
+	return r.Freq == RepeatFrequencySecondly && r.Count == 0 && r.Until.IsZero()
+}
+
+func (r *RepeatRule) subDailyStep() time.Duration {
+	switch r.Freq {
+	case RepeatFrequencyMinutely:
+		return time.Duration(r.Interval) * time.Minute
+	case RepeatFrequencyHourly:
+		return time.Duration(r.Interval) * time.Hour
+	default:
+		return time.Duration(r.Interval) * time.Second
+	}
+}
+
+func daysIn(year int, month time.Month, loc *time.Location) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func (r *RepeatRule) weekStartOf(t time.Time) time.Time {
+	day := startOfDay(t)
+	return day.AddDate(0, 0, -((int(day.Weekday()) - int(r.WeekStart) + 7) % 7))
+}
+
+// periodsBetween returns the number of whole periods of the rule between start and t.
+func (r *RepeatRule) periodsBetween(start, t time.Time) int {
+	if !t.After(start) {
+		return 0
+	}
+
+	var periods int
+	switch r.Freq {
+	case RepeatFrequencyDaily:
+		periods = int(startOfDay(t).Sub(startOfDay(start)).Hours()/24) / r.Interval
+	case RepeatFrequencyWeekly:
+		periods = int(r.weekStartOf(t).Sub(r.weekStartOf(start)).Hours()/24/7) / r.Interval
+	case RepeatFrequencyMonthly:
+		periods = ((t.Year()-start.Year())*12 + int(t.Month()) - int(start.Month())) / r.Interval
+	case RepeatFrequencyYearly:
+		periods = (t.Year() - start.Year()) / r.Interval
+	}
+	return periods
+}
+
+func containsInt(ints []int, i int) bool {
+	for _, v := range ints {
+		if v == i {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RepeatRule) matchesMonth(t time.Time) bool {
+	return len(r.ByMonth) == 0 || containsInt(r.ByMonth, int(t.Month()))
+}
+
+func (r *RepeatRule) matchesMonthDay(t time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	days := daysIn(t.Year(), t.Month(), t.Location())
+	for _, d := range r.ByMonthDay {
+		if d == t.Day() || (d < 0 && days+d+1 == t.Day()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *RepeatRule) matchesWeekday(t time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range r.ByDay {
+		if wd.Weekday == t.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// expandWeekdays returns all days between first and last (inclusive) matching the BYDAY part of the rule. Ordinals
+// are counted from first or, if negative, from last.
+func (r *RepeatRule) expandWeekdays(first, last time.Time) (days []time.Time) {
+	for _, wd := range r.ByDay {
+		var matching []time.Time
+		for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+			if d.Weekday() == wd.Weekday {
+				matching = append(matching, d)
+			}
+		}
+
+		switch {
+		case wd.N == 0:
+			days = append(days, matching...)
+		case wd.N > 0 && wd.N <= len(matching):
+			days = append(days, matching[wd.N-1])
+		case wd.N < 0 && -wd.N <= len(matching):
+			days = append(days, matching[len(matching)+wd.N])
+		}
+	}
+	return
+}
+
+// expandMonth returns all days of the month of first which match the rule.
+func (r *RepeatRule) expandMonth(first time.Time, start time.Time) (days []time.Time) {
+	last := first.AddDate(0, 1, -1)
+
+	switch {
+	case len(r.ByDay) > 0:
+		for _, d := range r.expandWeekdays(first, last) {
+			if r.matchesMonthDay(d) {
+				days = append(days, d)
+			}
+		}
+	case len(r.ByMonthDay) > 0:
+		for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+			if r.matchesMonthDay(d) {
+				days = append(days, d)
+			}
+		}
+	default:
+		// Months which don't have the day of the start date are skipped
+		if start.Day() <= last.Day() {
+			days = append(days, first.AddDate(0, 0, start.Day()-1))
+		}
+	}
+	return
+}
+
+// expandPeriod returns all occurrences of the rule in the n-th period after start, sorted and limited by BYSETPOS.
+func (r *RepeatRule) expandPeriod(start time.Time, n int) (occurrences []time.Time) {
+	loc := start.Location()
+	var days []time.Time
+
+	switch r.Freq {
+	case RepeatFrequencyDaily:
+		day := time.Date(start.Year(), start.Month(), start.Day()+n*r.Interval, 0, 0, 0, 0, loc)
+		if r.matchesMonthDay(day) && r.matchesWeekday(day) {
+			days = append(days, day)
+		}
+	case RepeatFrequencyWeekly:
+		weekStart := r.weekStartOf(start).AddDate(0, 0, 7*n*r.Interval)
+		if len(r.ByDay) == 0 {
+			days = append(days, weekStart.AddDate(0, 0, (int(start.Weekday())-int(r.WeekStart)+7)%7))
+		}
+		for _, wd := range r.ByDay {
+			days = append(days, weekStart.AddDate(0, 0, (int(wd.Weekday)-int(r.WeekStart)+7)%7))
+		}
+	case RepeatFrequencyMonthly:
+		first := time.Date(start.Year(), start.Month()+time.Month(n*r.Interval), 1, 0, 0, 0, 0, loc)
+		days = r.expandMonth(first, start)
+	case RepeatFrequencyYearly:
+		year := start.Year() + n*r.Interval
+		switch {
+		case len(r.ByMonth) > 0:
+			for _, m := range r.ByMonth {
+				days = append(days, r.expandMonth(time.Date(year, time.Month(m), 1, 0, 0, 0, 0, loc), start)...)
+			}
+		case len(r.ByMonthDay) > 0:
+			for m := time.January; m <= time.December; m++ {
+				days = append(days, r.expandMonth(time.Date(year, m, 1, 0, 0, 0, 0, loc), start)...)
+			}
+		case len(r.ByDay) > 0:
+			days = r.expandWeekdays(time.Date(year, time.January, 1, 0, 0, 0, 0, loc), time.Date(year, time.December, 31, 0, 0, 0, 0, loc))
+		default:
+			// The 29th of February only happens in leap years
+			day := time.Date(year, start.Month(), start.Day(), 0, 0, 0, 0, loc)
+			if day.Month() == start.Month() {
+				days = append(days, day)
+			}
+		}
+	}
+
+	for _, d := range days {
+		if !r.matchesMonth(d) {
+			continue
+		}
+		occurrences = append(occurrences, time.Date(d.Year(), d.Month(), d.Day(), start.Hour(), start.Minute(), start.Second(), 0, loc))
+	}
+
+	sort.Slice(occurrences, func(i, j int) bool {
+		return occurrences[i].Before(occurrences[j])
+	})
+
+	if len(r.BySetPos) == 0 || len(occurrences) == 0 {
+		return occurrences
+	}
+
+	var selected []time.Time
+	for _, pos := range r.BySetPos {
+		index := pos - 1
+		if pos < 0 {
+			index = len(occurrences) + pos
+		}
+		if index >= 0 && index < len(occurrences) {
+			selected = append(selected, occurrences[index])
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].Before(selected[j])
+	})
+	return selected
+}
+
+// nextAfter returns the first occurrence of the rule after t. The rule starts at start. It returns a zero time if
+// the rule does not have any more occurrences. COUNT is not taken into account, it is handled by the caller.
+func (r *RepeatRule) nextAfter(start, t time.Time) (next time.Time) {
+	if r.isSubDaily() {
+		step := r.subDailyStep()
+		next = start.Add(step)
+		if t.After(start) {
+			next = start.Add((t.Sub(start)/step + 1) * step)
+		}
+	} else {
+		first := r.periodsBetween(start, t) - 1
+		if first < 0 {
+			first = 0
+		}
+
+	periods:
+		for n := first; n < first+maxRepeatRulePeriods; n++ {
+			for _, occurrence := range r.expandPeriod(start, n) {
+				if occurrence.Before(start) || !occurrence.After(t) {
+					continue
+				}
+				next = occurrence
+				break periods
+			}
+		}
+	}
+
+	if next.IsZero() || (!r.Until.IsZero() && next.After(r.Until)) {
+		return time.Time{}
+	}
+
+	return next
+}
+
+// normalizeRepeatRule validates the repeat rule of a task and stores it in its canonical form. Rules which only
+// repeat every n seconds are stored as repeat_after, that's how those tasks are exported to caldav.
+func (t *Task) normalizeRepeatRule() error {
+	if strings.TrimSpace(t.RepeatRule) == "" {
+		t.RepeatRule = ""
+		return nil
+	}
+
+	rule, err := ParseRepeatRule(t.RepeatRule)
+	if err != nil {
+		return err
+	}
+
+	if rule.isPlainInterval() {
+		t.RepeatAfter = int64(rule.Interval)
+		t.RepeatMode = TaskRepeatModeDefault
+		t.RepeatRule = ""
+		return nil
+	}
+
+	t.RepeatRule = rule.String()
+	return nil
+}
@@ -0,0 +1,168 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRepeatRule(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		rule, err := ParseRepeatRule("FREQ=MONTHLY;BYDAY=2TU")
+		require.NoError(t, err)
+		assert.Equal(t, RepeatFrequencyMonthly, rule.Freq)
+		assert.Equal(t, 1, rule.Interval)
+		assert.Equal(t, []RepeatWeekday{{N: 2, Weekday: time.Tuesday}}, rule.ByDay)
+		assert.Equal(t, "FREQ=MONTHLY;BYDAY=2TU", rule.String())
+	})
+	t.Run("with prefix and lowercase", func(t *testing.T) {
+		rule, err := ParseRepeatRule("RRULE:freq=weekly;byday=mo,we,fr;interval=2")
+		require.NoError(t, err)
+		assert.Equal(t, "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE,FR", rule.String())
+	})
+	t.Run("all supported parts", func(t *testing.T) {
+		rule, err := ParseRepeatRule("FREQ=YEARLY;UNTIL=21000101;BYMONTH=3,9;BYMONTHDAY=-1;BYDAY=-1FR;BYSETPOS=1;WKST=SU")
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC), rule.Until)
+		assert.Equal(t, "FREQ=YEARLY;UNTIL=21000101T000000Z;BYMONTH=3,9;BYMONTHDAY=-1;BYDAY=-1FR;BYSETPOS=1;WKST=SU", rule.String())
+	})
+	t.Run("invalid", func(t *testing.T) {
+		for _, rule := range []string{
+			"",
+			"FREQ=FOO",
+			"INTERVAL=2",
+			"FREQ=DAILY;INTERVAL=0",
+			"FREQ=DAILY;BYHOUR=9",
+			"FREQ=DAILY;COUNT=2;UNTIL=21000101",
+			"FREQ=WEEKLY;BYDAY=2TU",
+			"FREQ=MONTHLY;BYDAY=XX",
+			"FREQ=MONTHLY;BYMONTHDAY=32",
+			"FREQ=HOURLY;BYDAY=MO",
+		} {
+			_, err := ParseRepeatRule(rule)
+			assert.Error(t, err, rule)
+			assert.True(t, IsErrInvalidRepeatRule(err), rule)
+		}
+	})
+}
+
+func TestRepeatRule_nextAfter(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 30, 0, 0, time.UTC)
+	}
+
+	for _, c := range []struct {
+		name     string
+		rule     string
+		start    time.Time
+		after    time.Time
+		expected time.Time
+	}{
+		{
+			name:     "every 2nd tuesday",
+			rule:     "FREQ=MONTHLY;BYDAY=2TU",
+			start:    date(2100, time.January, 12),
+			after:    date(2100, time.January, 12),
+			expected: date(2100, time.February, 9),
+		},
+		{
+			name:     "every 2nd tuesday, far in the future",
+			rule:     "FREQ=MONTHLY;BYDAY=2TU",
+			start:    date(2100, time.January, 12),
+			after:    date(2101, time.June, 1),
+			expected: date(2101, time.June, 14),
+		},
+		{
+			name:     "last weekday of the month",
+			rule:     "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+			start:    date(2100, time.January, 29),
+			after:    date(2100, time.January, 29),
+			expected: date(2100, time.February, 26),
+		},
+		{
+			name:     "last friday of the month",
+			rule:     "FREQ=MONTHLY;BYDAY=-1FR",
+			start:    date(2100, time.January, 1),
+			after:    date(2100, time.January, 1),
+			expected: date(2100, time.January, 29),
+		},
+		{
+			name:     "last day of the month",
+			rule:     "FREQ=MONTHLY;BYMONTHDAY=-1",
+			start:    date(2100, time.January, 31),
+			after:    date(2100, time.January, 31),
+			expected: date(2100, time.February, 28),
+		},
+		{
+			name:     "months without the day are skipped",
+			rule:     "FREQ=MONTHLY",
+			start:    date(2100, time.January, 31),
+			after:    date(2100, time.January, 31),
+			expected: date(2100, time.March, 31),
+		},
+		{
+			name:     "monday, wednesday and friday",
+			rule:     "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+			start:    date(2100, time.January, 1),
+			after:    date(2100, time.January, 1),
+			expected: date(2100, time.January, 4),
+		},
+		{
+			name:     "monday, wednesday and friday every other week",
+			rule:     "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE,FR",
+			start:    date(2100, time.January, 1),
+			after:    date(2100, time.January, 1),
+			expected: date(2100, time.January, 11),
+		},
+		{
+			name:     "29th of february",
+			rule:     "FREQ=YEARLY",
+			start:    date(2096, time.February, 29),
+			after:    date(2096, time.February, 29),
+			expected: date(2104, time.February, 29),
+		},
+		{
+			name:     "every two hours",
+			rule:     "FREQ=HOURLY;INTERVAL=2",
+			start:    date(2100, time.January, 1),
+			after:    date(2100, time.January, 1).Add(3 * time.Hour),
+			expected: date(2100, time.January, 1).Add(4 * time.Hour),
+		},
+		{
+			name:  "until passed",
+			rule:  "FREQ=DAILY;UNTIL=21000102T000000Z",
+			start: date(2100, time.January, 1),
+			after: date(2100, time.January, 1),
+		},
+		{
+			name:  "never matching",
+			rule:  "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30",
+			start: date(2100, time.January, 1),
+			after: date(2100, time.January, 1),
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			rule, err := ParseRepeatRule(c.rule)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, rule.nextAfter(c.start, c.after))
+		})
+	}
+}
@@ -19,6 +19,7 @@ package models
 import (
 	"strconv"
 	"strings"
+	"time"
 
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/log"
@@ -51,14 +52,30 @@ func getOrderByDBStatement(opts *taskSearchOptions) (orderby string, err error)
 			return "", err
 		}
 
+		column := "`" + param.sortBy + "`"
+		if _, is := getCustomFieldIDFromTaskProperty(param.sortBy); is {
+			if param.customField == nil {
+				return "", ErrInvalidTaskField{TaskField: param.sortBy}
+			}
+			column = getOrderByColumnForCustomField(param.customField)
+		}
+
+		// Positions only exist per view
+		if param.sortBy == taskPropertyPosition {
+			if opts.projectViewID == 0 {
+				continue
+			}
+			column = getOrderByColumnForTaskPosition(opts.projectViewID)
+		}
+
 		// Mysql sorts columns with null values before ones without null value.
 		// Because it does not have support for NULLS FIRST or NULLS LAST we work around this by
 		// first sorting for null (or not null) values and then the order we actually want to.
 		if db.Type() == schemas.MYSQL {
-			orderby += "`" + param.sortBy + "` IS NULL, "
+			orderby += column + " IS NULL, "
 		}
 
-		orderby += "`" + param.sortBy + "` " + param.orderBy.String()
+		orderby += column + " " + param.orderBy.String()
 
 		// Postgres and sqlite allow us to control how columns with null values are sorted.
 		// To make that consistent with the sort order we have and other dbms, we're adding a separate clause here.
@@ -71,7 +88,7 @@ func getOrderByDBStatement(opts *taskSearchOptions) (orderby string, err error)
 		}
 	}
 
-	return
+	return strings.TrimSuffix(orderby, ", "), nil
 }
 
 //nolint:gocyclo
@@ -87,10 +104,20 @@ func (d *dbTaskSearcher) Search(opts *taskSearchOptions) (tasks []*Task, totalCo
 	assigneeFilters := []builder.Cond{}
 	labelFilters := []builder.Cond{}
 	projectFilters := []builder.Cond{}
+	bucketFilters := []builder.Cond{}
 
 	var filters = make([]builder.Cond, 0, len(opts.filters))
 	// To still find tasks with nil values, we exclude 0s when comparing with >/< values.
 	for _, f := range opts.filters {
+		if f.customFieldID > 0 {
+			filter, err := getFilterCondForCustomField(f, opts.filterIncludeNulls)
+			if err != nil {
+				return nil, totalCount, err
+			}
+			filters = append(filters, filter)
+			continue
+		}
+
 		if f.field == "reminders" {
 			f.field = "reminder" // This is the name in the db
 			filter, err := getFilterCond(f, opts.filterIncludeNulls)
@@ -134,6 +161,15 @@ func (d *dbTaskSearcher) Search(opts *taskSearchOptions) (tasks []*Task, totalCo
 			continue
 		}
 
+		if f.field == taskPropertyBucketID {
+			filter, err := getFilterCond(f, opts.filterIncludeNulls)
+			if err != nil {
+				return nil, totalCount, err
+			}
+			bucketFilters = append(bucketFilters, filter)
+			continue
+		}
+
 		filter, err := getFilterCond(f, opts.filterIncludeNulls)
 		if err != nil {
 			return nil, totalCount, err
@@ -195,6 +231,10 @@ func (d *dbTaskSearcher) Search(opts *taskSearchOptions) (tasks []*Task, totalCo
 		filters = append(filters, getFilterCondForSeparateTable("label_tasks", opts.filterConcat, labelFilters))
 	}
 
+	if len(bucketFilters) > 0 {
+		filters = append(filters, getFilterCondForSeparateTable("task_buckets", opts.filterConcat, bucketFilters))
+	}
+
 	if len(projectFilters) > 0 {
 		var filtercond builder.Cond
 		if opts.filterConcat == filterConcatOr {
@@ -224,6 +264,14 @@ func (d *dbTaskSearcher) Search(opts *taskSearchOptions) (tasks []*Task, totalCo
 		}
 	}
 
+	if opts.filterExpression != nil {
+		expressionCond, err := getFilterCondForTaskFilterNode(opts.filterExpression, opts.filterIncludeNulls)
+		if err != nil {
+			return nil, totalCount, err
+		}
+		filterCond = builder.And(filterCond, expressionCond)
+	}
+
 	limit, start := getLimitFromPageIndex(opts.page, opts.perPage)
 	cond := builder.And(builder.Or(projectIDCond, favoritesCond), where, filterCond)
 
@@ -249,6 +297,100 @@ func (d *dbTaskSearcher) Search(opts *taskSearchOptions) (tasks []*Task, totalCo
 	return
 }
 
+// getFilterCondForSingleTaskFilter returns the condition for one filter of a filter expression.
+// Filters on properties stored in a separate table are translated to a subquery on that table.
+func getFilterCondForSingleTaskFilter(f *taskFilter, includeNulls bool) (cond builder.Cond, err error) {
+	if f.customFieldID > 0 {
+		return getFilterCondForCustomField(f, includeNulls)
+	}
+
+	// Copying the filter to not modify the parsed expression
+	filter := *f
+
+	switch filter.field {
+	case "reminders":
+		filter.field = "reminder" // This is the name in the db
+		cond, err = getFilterCond(&filter, includeNulls)
+		if err != nil {
+			return nil, err
+		}
+		return getFilterCondForSeparateTable("task_reminders", filterConcatAnd, []builder.Cond{cond}), nil
+	case "assignees":
+		if filter.comparator == taskFilterComparatorLike {
+			return nil, ErrInvalidTaskFilterComparator{Comparator: filter.comparator}
+		}
+		filter.field = "username"
+		cond, err = getFilterCond(&filter, includeNulls)
+		if err != nil {
+			return nil, err
+		}
+		assigneeFilter := builder.In("user_id",
+			builder.Select("id").
+				From("users").
+				Where(cond),
+		)
+		return getFilterCondForSeparateTable("task_assignees", filterConcatAnd, []builder.Cond{assigneeFilter}), nil
+	case "labels", "label_id":
+		filter.field = "label_id"
+		cond, err = getFilterCond(&filter, includeNulls)
+		if err != nil {
+			return nil, err
+		}
+		return getFilterCondForSeparateTable("label_tasks", filterConcatAnd, []builder.Cond{cond}), nil
+	case taskPropertyBucketID:
+		cond, err = getFilterCond(&filter, includeNulls)
+		if err != nil {
+			return nil, err
+		}
+		return getFilterCondForSeparateTable("task_buckets", filterConcatAnd, []builder.Cond{cond}), nil
+	case "parent_project", "parent_project_id":
+		filter.field = "parent_project_id"
+		cond, err = getFilterCond(&filter, includeNulls)
+		if err != nil {
+			return nil, err
+		}
+		return builder.In(
+			"project_id",
+			builder.
+				Select("id").
+				From("projects").
+				Where(cond),
+		), nil
+	}
+
+	return getFilterCond(&filter, includeNulls)
+}
+
+func getFilterCondForTaskFilterNode(node *taskFilterNode, includeNulls bool) (cond builder.Cond, err error) {
+	if node.filter != nil {
+		cond, err = getFilterCondForSingleTaskFilter(node.filter, includeNulls)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		conds := make([]builder.Cond, 0, len(node.children))
+		for _, child := range node.children {
+			childCond, err := getFilterCondForTaskFilterNode(child, includeNulls)
+			if err != nil {
+				return nil, err
+			}
+			conds = append(conds, childCond)
+		}
+
+		if node.concat == filterConcatOr {
+			cond = builder.Or(conds...)
+		} else {
+			cond = builder.And(conds...)
+		}
+	}
+
+	if node.negate {
+		cond = builder.Not{cond}
+	}
+
+	return
+}
+
 type typesenseTaskSearcher struct {
 	s *xorm.Session
 }
@@ -270,6 +412,8 @@ func convertFilterValues(value interface{}) string {
 		return strconv.Itoa(v)
 	case int64:
 		return strconv.FormatInt(v, 10)
+	case time.Time:
+		return strconv.FormatInt(v.UTC().Unix(), 10)
 	case bool:
 		if v {
 			return "true"
@@ -282,6 +426,115 @@ func convertFilterValues(value interface{}) string {
 	return ""
 }
 
+// getTypesenseFilterForTaskFilter converts a single filter to typesense's filter syntax.
+// Because typesense has no generic negation, negated filters are converted by inverting their comparator.
+// Negated like filters can't be expressed at all, searches using them are done in the database instead.
+func getTypesenseFilterForTaskFilter(f *taskFilter, negate bool) (filter string, err error) {
+	field := f.field
+	switch field {
+	case "reminders":
+		field = "reminders.reminder"
+	case "assignees":
+		field = "assignees.username"
+	case "labels", "label_id":
+		field = "labels.id"
+	}
+
+	comparator := f.comparator
+	if negate {
+		switch comparator {
+		case taskFilterComparatorEquals:
+			comparator = taskFilterComparatorNotEquals
+		case taskFilterComparatorNotEquals:
+			comparator = taskFilterComparatorEquals
+		case taskFilterComparatorGreater:
+			comparator = taskFilterComparatorLessEquals
+		case taskFilterComparatorGreateEquals:
+			comparator = taskFilterComparatorLess
+		case taskFilterComparatorLess:
+			comparator = taskFilterComparatorGreateEquals
+		case taskFilterComparatorLessEquals:
+			comparator = taskFilterComparatorGreater
+		case taskFilterComparatorIn:
+		// Handled below
+		default:
+			return "", ErrInvalidTaskFilterComparator{Comparator: comparator}
+		}
+	}
+
+	filter = field
+
+	switch comparator {
+	case taskFilterComparatorEquals:
+		filter += ":="
+	case taskFilterComparatorNotEquals:
+		filter += ":!="
+	case taskFilterComparatorGreater:
+		filter += ":>"
+	case taskFilterComparatorGreateEquals:
+		filter += ":>="
+	case taskFilterComparatorLess:
+		filter += ":<"
+	case taskFilterComparatorLessEquals:
+		filter += ":<="
+	case taskFilterComparatorLike:
+		filter += ":"
+	case taskFilterComparatorIn:
+		if negate {
+			filter += ":!=["
+		} else {
+			filter += ":["
+		}
+	case taskFilterComparatorInvalid:
+	// Nothing to do
+	default:
+		filter += ":="
+	}
+
+	filter += convertFilterValues(f.value)
+
+	if comparator == taskFilterComparatorIn {
+		filter += "]"
+	}
+
+	return filter, nil
+}
+
+// getTypesenseFilterForTaskFilterNode converts a filter expression tree to typesense's filter syntax.
+// Negations are pushed down to the single filters using De Morgan's laws.
+func getTypesenseFilterForTaskFilterNode(node *taskFilterNode, negate bool) (filter string, err error) {
+	negate = negate != node.negate
+
+	if node.filter != nil {
+		return getTypesenseFilterForTaskFilter(node.filter, negate)
+	}
+
+	concat := node.concat
+	if negate {
+		if concat == filterConcatOr {
+			concat = filterConcatAnd
+		} else {
+			concat = filterConcatOr
+		}
+	}
+
+	children := make([]string, 0, len(node.children))
+	for _, child := range node.children {
+		childFilter, err := getTypesenseFilterForTaskFilterNode(child, negate)
+		if err != nil {
+			return "", err
+		}
+		children = append(children, childFilter)
+	}
+
+	separator := " && "
+	if concat == filterConcatOr {
+		separator = " || "
+	}
+
+	return "(" + strings.Join(children, separator) + ")", nil
+}
+
 func (t *typesenseTaskSearcher) Search(opts *taskSearchOptions) (tasks []*Task, totalCount int64, err error) {
 
 	var sortbyFields []string
@@ -291,6 +544,11 @@ func (t *typesenseTaskSearcher) Search(opts *taskSearchOptions) (tasks []*Task,
 			return nil, totalCount, err
 		}
 
+		// Positions are not indexed in typesense, they only exist per view
+		if param.sortBy == taskPropertyPosition {
+			continue
+		}
+
 		// Typesense does not allow sorting by ID, so we sort by created timestamp instead
 		if param.sortBy == "id" {
 			param.sortBy = "created"
@@ -316,50 +574,18 @@ func (t *typesenseTaskSearcher) Search(opts *taskSearchOptions) (tasks []*Task,
 	}
 
 	for _, f := range opts.filters {
-
-		if f.field == "reminders" {
-			f.field = "reminders.reminder"
-		}
-
-		if f.field == "assignees" {
-			f.field = "assignees.username"
-		}
-
-		if f.field == "labels" || f.field == "label_id" {
-			f.field = "labels.id"
-		}
-
-		filter := f.field
-
-		switch f.comparator {
-		case taskFilterComparatorEquals:
-			filter += ":="
-		case taskFilterComparatorNotEquals:
-			filter += ":!="
-		case taskFilterComparatorGreater:
-			filter += ":>"
-		case taskFilterComparatorGreateEquals:
-			filter += ":>="
-		case taskFilterComparatorLess:
-			filter += ":<"
-		case taskFilterComparatorLessEquals:
-			filter += ":<="
-		case taskFilterComparatorLike:
-			filter += ":"
-		case taskFilterComparatorIn:
-			filter += ":["
-		case taskFilterComparatorInvalid:
-		// Nothing to do
-		default:
-			filter += ":="
+		filter, err := getTypesenseFilterForTaskFilter(f, false)
+		if err != nil {
+			return nil, totalCount, err
 		}
+		filterBy = append(filterBy, filter)
+	}
 
-		filter += convertFilterValues(f.value)
-
-		if f.comparator == taskFilterComparatorIn {
-			filter += "]"
+	if opts.filterExpression != nil {
+		filter, err := getTypesenseFilterForTaskFilterNode(opts.filterExpression, false)
+		if err != nil {
+			return nil, totalCount, err
 		}
-
 		filterBy = append(filterBy, filter)
 	}
 
@@ -0,0 +1,160 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"sort"
+	"time"
+
+	"code.vikunja.io/web"
+
+	"xorm.io/xorm"
+)
+
+// TaskSyncChange records that a task was created, changed or removed in a project. There is only one entry per task
+// and project. Every change replaces it with a new one, so the ids of the entries increase with every change.
+// CalDAV clients use the id of the latest change in a project as sync token to only fetch what changed since their
+// last sync.
+type TaskSyncChange struct {
+	ID        int64  `xorm:"bigint autoincr not null unique pk" json:"-"`
+	ProjectID int64  `xorm:"bigint not null index" json:"-"`
+	TaskID    int64  `xorm:"bigint not null index" json:"-"`
+	TaskUID   string `xorm:"varchar(250) null" json:"-"`
+	// If the task was deleted or moved to another project. The entry is kept as tombstone so clients can remove
+	// the task on their next sync.
+	Removed bool      `xorm:"not null default false" json:"-"`
+	Created time.Time `xorm:"created not null" json:"-"`
+}
+
+// TableName returns the table name for task sync changes
+func (*TaskSyncChange) TableName() string {
+	return "task_sync_changes"
+}
+
+func recordTaskSyncChange(s *xorm.Session, projectID int64, task *Task, removed bool) (err error) {
+	if projectID <= 0 {
+		return nil
+	}
+
+	_, err = s.
+		Where("project_id = ? AND task_id = ?", projectID, task.ID).
+		Delete(&TaskSyncChange{})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Insert(&TaskSyncChange{
+		ProjectID: projectID,
+		TaskID:    task.ID,
+		TaskUID:   task.UID,
+		Removed:   removed,
+	})
+	return
+}
+
+func recordTaskSyncChangeByTaskID(s *xorm.Session, taskID int64) error {
+	task, err := GetTaskByIDSimple(s, taskID)
+	if err != nil {
+		// The task might have been deleted in the meantime, which already was recorded
+		if IsErrTaskDoesNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	return recordTaskSyncChange(s, task.ProjectID, &task, false)
+}
+
+// GetProjectSyncTokens returns the id of the latest task change for each of the projects. Projects without any
+// recorded change are not included.
+func GetProjectSyncTokens(s *xorm.Session, projectIDs []int64) (tokens map[int64]int64, err error) {
+	tokens = make(map[int64]int64, len(projectIDs))
+	if len(projectIDs) == 0 {
+		return
+	}
+
+	type projectSyncToken struct {
+		ProjectID int64
+		Token     int64
+	}
+	projectTokens := []*projectSyncToken{}
+	err = s.
+		Table("task_sync_changes").
+		Select("project_id, max(id) AS token").
+		In("project_id", projectIDs).
+		GroupBy("project_id").
+		Find(&projectTokens)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range projectTokens {
+		tokens[t.ProjectID] = t.Token
+	}
+
+	return
+}
+
+// GetTaskSyncChanges returns all tasks of a project which were created or changed after the sync token and the
+// changes of all tasks which were removed from the project since then.
+func GetTaskSyncChanges(s *xorm.Session, a web.Auth, projectID int64, since int64) (tasks []*Task, removed []*TaskSyncChange, err error) {
+	changes := []*TaskSyncChange{}
+	err = s.
+		Where("project_id = ? AND id > ?", projectID, since).
+		OrderBy("id asc").
+		Find(&changes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	taskIDs := []int64{}
+	for _, c := range changes {
+		if c.Removed {
+			removed = append(removed, c)
+			continue
+		}
+		taskIDs = append(taskIDs, c.TaskID)
+	}
+
+	if len(taskIDs) == 0 {
+		return
+	}
+
+	taskMap := make(map[int64]*Task, len(taskIDs))
+	err = s.
+		In("id", taskIDs).
+		And("project_id = ?", projectID).
+		Find(&taskMap)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = addMoreInfoToTasks(s, taskMap, a)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tasks = make([]*Task, 0, len(taskMap))
+	for _, t := range taskMap {
+		tasks = append(tasks, t)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].ID < tasks[j].ID
+	})
+
+	return
+}
@@ -0,0 +1,271 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetProjectSyncTokens(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	tokens, err := GetProjectSyncTokens(s, []int64{1, 2, 3})
+	require.NoError(t, err)
+	assert.Equal(t, map[int64]int64{1: 4, 2: 5}, tokens)
+}
+
+func TestGetTaskSyncChanges(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("since token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tasks, removed, err := GetTaskSyncChanges(s, u, 1, 1)
+		require.NoError(t, err)
+		require.Len(t, tasks, 2)
+		assert.Equal(t, int64(2), tasks[0].ID)
+		assert.Equal(t, int64(3), tasks[1].ID)
+		require.Len(t, removed, 1)
+		assert.Equal(t, "uid-deleted-task", removed[0].TaskUID)
+	})
+	t.Run("latest token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tasks, removed, err := GetTaskSyncChanges(s, u, 1, 4)
+		require.NoError(t, err)
+		assert.Empty(t, tasks)
+		assert.Empty(t, removed)
+	})
+}
+
+func TestRecordTaskSyncChange(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("create task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			Title:     "Lorem",
+			ProjectID: 1,
+		}
+		err := task.Create(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "task_sync_changes", map[string]interface{}{
+			"project_id": 1,
+			"task_id":    task.ID,
+			"task_uid":   task.UID,
+			"removed":    false,
+		}, false)
+	})
+	t.Run("update task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:    1,
+			Title: "Lorem",
+		}
+		err := task.Update(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		tokens, err := GetProjectSyncTokens(s, []int64{1})
+		require.NoError(t, err)
+		assert.Greater(t, tokens[1], int64(5))
+
+		// The old change of the task is replaced
+		db.AssertMissing(t, "task_sync_changes", map[string]interface{}{
+			"id": 1,
+		})
+		db.AssertExists(t, "task_sync_changes", map[string]interface{}{
+			"id":         tokens[1],
+			"project_id": 1,
+			"task_id":    1,
+			"removed":    false,
+		}, false)
+	})
+	t.Run("move task to another project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:        1,
+			ProjectID: 2,
+		}
+		err := task.Update(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "task_sync_changes", map[string]interface{}{
+			"project_id": 1,
+			"task_id":    1,
+			"removed":    true,
+		}, false)
+		db.AssertExists(t, "task_sync_changes", map[string]interface{}{
+			"project_id": 2,
+			"task_id":    1,
+			"removed":    false,
+		}, false)
+	})
+	t.Run("comment on a task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tc := &TaskComment{
+			TaskID:  1,
+			Comment: "Lorem",
+		}
+		err := tc.Create(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		tokens, err := GetProjectSyncTokens(s, []int64{1})
+		require.NoError(t, err)
+		db.AssertExists(t, "task_sync_changes", map[string]interface{}{
+			"id":      tokens[1],
+			"task_id": 1,
+		}, false)
+	})
+	t.Run("relate two tasks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		rel := &TaskRelation{
+			TaskID:       1,
+			OtherTaskID:  2,
+			RelationKind: RelationKindSubtask,
+		}
+		err := rel.Create(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		// Both tasks show the relation, so the old changes of both are replaced
+		db.AssertMissing(t, "task_sync_changes", map[string]interface{}{
+			"id": 1,
+		})
+		db.AssertMissing(t, "task_sync_changes", map[string]interface{}{
+			"id": 2,
+		})
+	})
+	t.Run("bulk update tasks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tokens, err := GetProjectSyncTokens(s, []int64{1})
+		require.NoError(t, err)
+
+		bt := &BulkTask{
+			IDs:  []int64{10, 11},
+			Task: Task{Title: "bulkupdated"},
+		}
+		allowed, err := bt.CanUpdate(s, u)
+		require.NoError(t, err)
+		require.True(t, allowed)
+		err = bt.Update(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		tasks, _, err := GetTaskSyncChanges(s, u, 1, tokens[1])
+		require.NoError(t, err)
+		taskIDs := []int64{}
+		for _, task := range tasks {
+			taskIDs = append(taskIDs, task.ID)
+		}
+		assert.ElementsMatch(t, []int64{10, 11}, taskIDs)
+	})
+	t.Run("reschedule tasks", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		addTestTaskRelation(t, s, 9, 7, RelationKindPreceeds)
+		tokens, err := GetProjectSyncTokens(s, []int64{1})
+		require.NoError(t, err)
+
+		task, err := GetTaskByIDSimple(s, 9)
+		require.NoError(t, err)
+		tr := &TaskReschedule{
+			TaskID:    9,
+			StartDate: task.StartDate.Add(24 * time.Hour),
+		}
+		err = tr.Update(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		// The following task was moved as well
+		tasks, _, err := GetTaskSyncChanges(s, u, 1, tokens[1])
+		require.NoError(t, err)
+		taskIDs := []int64{}
+		for _, task := range tasks {
+			taskIDs = append(taskIDs, task.ID)
+		}
+		assert.ElementsMatch(t, []int64{7, 9}, taskIDs)
+	})
+	t.Run("delete task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID: 1,
+		}
+		err := task.Delete(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "task_sync_changes", map[string]interface{}{
+			"project_id": 1,
+			"task_id":    1,
+			"removed":    true,
+		}, false)
+
+		_, removed, err := GetTaskSyncChanges(s, u, 1, 4)
+		require.NoError(t, err)
+		require.Len(t, removed, 1)
+		assert.Equal(t, int64(1), removed[0].TaskID)
+	})
+}
@@ -0,0 +1,444 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// TaskTemplateReminder is a reminder of a task template. It is always relative to one of the dates of the template.
+type TaskTemplateReminder struct {
+	// A period in seconds relative to the date in relative_to. Negative values mean the reminder triggers before the date.
+	RelativePeriod int64 `json:"relative_period"`
+	// The date of the task the reminder relates to. Can be one of `due_date`, `start_date` or `end_date`.
+	RelativeTo ReminderRelation `json:"relative_to"`
+}
+
+// TaskTemplateSubtask is a subtask which is created together with the task from a template
+type TaskTemplateSubtask struct {
+	// The title of the subtask.
+	Title string `json:"title"`
+	// The description of the subtask.
+	Description string `json:"description"`
+	// The priority of the subtask.
+	Priority int64 `json:"priority"`
+	// The due date of the subtask in seconds after the moment the task was created from the template. 0 means no due date.
+	RelativeDueDate int64 `json:"relative_due_date"`
+}
+
+// TaskTemplate holds everything needed to create the same task over and over again
+type TaskTemplate struct {
+	// The unique, numeric id of this task template.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"tasktemplate"`
+	// The title of the tasks created from this template.
+	Title string `xorm:"varchar(250) not null" json:"title" valid:"required,runelength(1|250)" minLength:"1" maxLength:"250"`
+	// The description of the tasks created from this template.
+	Description string `xorm:"longtext null" json:"description"`
+	// The priority of the tasks created from this template.
+	Priority int64 `xorm:"bigint null" json:"priority"`
+	// The color of the tasks created from this template.
+	HexColor string `xorm:"varchar(6) null" json:"hex_color" valid:"runelength(0|6)" maxLength:"6"`
+
+	// The due date of the task in seconds after the moment the task was created from the template. 0 means no due date.
+	RelativeDueDate int64 `xorm:"bigint null" json:"relative_due_date"`
+	// The start date of the task in seconds after the moment the task was created from the template. 0 means no start date.
+	RelativeStartDate int64 `xorm:"bigint null" json:"relative_start_date"`
+	// The end date of the task in seconds after the moment the task was created from the template. 0 means no end date.
+	RelativeEndDate int64 `xorm:"bigint null" json:"relative_end_date"`
+
+	// The ids of all labels which are added to the tasks created from this template.
+	LabelIDs []int64 `xorm:"JSON null 'label_ids'" json:"label_ids"`
+	// The ids of all users who are assigned to the tasks created from this template. Users without access to the project of the task are skipped.
+	AssigneeIDs []int64 `xorm:"JSON null 'assignee_ids'" json:"assignee_ids"`
+	// The reminders of the tasks created from this template.
+	Reminders []*TaskTemplateReminder `xorm:"JSON null" json:"reminders"`
+	// All subtasks which are created together with the task.
+	Subtasks []*TaskTemplateSubtask `xorm:"JSON null" json:"subtasks"`
+
+	OwnerID int64 `xorm:"bigint not null INDEX" json:"-"`
+	// The user who owns this template.
+	Owner *user.User `xorm:"-" json:"owner" valid:"-"`
+
+	// A timestamp when this template was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this template was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for task templates
+func (*TaskTemplate) TableName() string {
+	return "task_templates"
+}
+
+func getTaskTemplateByID(s *xorm.Session, id int64) (tt *TaskTemplate, err error) {
+	tt = &TaskTemplate{}
+	exists, err := s.
+		Where("id = ?", id).
+		Get(tt)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &ErrTaskTemplateDoesNotExist{TaskTemplateID: id}
+	}
+	return
+}
+
+func (tt *TaskTemplate) relativeDateIsSet(relation ReminderRelation) bool {
+	switch relation {
+	case ReminderRelationDueDate:
+		return tt.RelativeDueDate != 0
+	case ReminderRelationStartDate:
+		return tt.RelativeStartDate != 0
+	case ReminderRelationEndDate:
+		return tt.RelativeEndDate != 0
+	}
+	return false
+}
+
+func (tt *TaskTemplate) validate(s *xorm.Session, a web.Auth) error {
+	if tt.Title == "" {
+		return ErrTaskCannotBeEmpty{}
+	}
+
+	for _, r := range tt.Reminders {
+		if !tt.relativeDateIsSet(r.RelativeTo) {
+			return &ErrInvalidTaskTemplateReminder{RelativeTo: r.RelativeTo}
+		}
+	}
+
+	for _, labelID := range tt.LabelIDs {
+		has, _, err := (&Label{ID: labelID}).hasAccessToLabel(s, a)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return ErrUserHasNoAccessToLabel{LabelID: labelID, UserID: a.GetID()}
+		}
+	}
+
+	for _, userID := range tt.AssigneeIDs {
+		if _, err := user.GetUserByID(s, userID); err != nil {
+			return err
+		}
+	}
+
+	for _, st := range tt.Subtasks {
+		if st.Title == "" {
+			return ErrTaskCannotBeEmpty{}
+		}
+	}
+
+	return nil
+}
+
+// Create creates a new task template
+// @Summary Create a task template
+// @Description Creates a new task template. Tasks can be created from it in any project the user has write access to.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param template body models.TaskTemplate true "The task template"
+// @Success 201 {object} models.TaskTemplate "The created task template"
+// @Failure 400 {object} web.HTTPError "Invalid task template object provided."
+// @Failure 403 {object} web.HTTPError "Link shares cannot create task templates."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates [put]
+func (tt *TaskTemplate) Create(s *xorm.Session, a web.Auth) (err error) {
+	if err := tt.validate(s, a); err != nil {
+		return err
+	}
+
+	tt.ID = 0
+	tt.OwnerID = a.GetID()
+	_, err = s.Insert(tt)
+	if err != nil {
+		return
+	}
+
+	tt.Owner, err = user.GetUserByID(s, tt.OwnerID)
+	return
+}
+
+// ReadOne returns one task template
+// @Summary Get one task template
+// @Description Returns a task template by its ID.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param tasktemplate path int true "Task template ID"
+// @Success 200 {object} models.TaskTemplate "The task template"
+// @Failure 403 {object} web.HTTPError "The user does not have access to that task template."
+// @Failure 404 {object} web.HTTPError "The task template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates/{tasktemplate} [get]
+func (tt *TaskTemplate) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	// tt already contains the full template from the rights check, we only need to add the user
+	tt.Owner, err = user.GetUserByID(s, tt.OwnerID)
+	return
+}
+
+// ReadAll returns all task templates of the current user
+// @Summary Get all task templates
+// @Description Returns all task templates of the current user.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. This parameter is limited by the configured maximum of items per page."
+// @Param s query string false "Search task templates by title."
+// @Success 200 {array} models.TaskTemplate "The task templates"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates [get]
+func (tt *TaskTemplate) ReadAll(s *xorm.Session, a web.Auth, search string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	if _, is := a.(*LinkSharing); is {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	owner, err := user.GetUserByID(s, a.GetID())
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	cond := db.ILIKE("title", search)
+
+	templates := []*TaskTemplate{}
+	err = s.
+		Where("owner_id = ?", a.GetID()).
+		And(cond).
+		OrderBy("title asc, id asc").
+		Limit(getLimitFromPageIndex(page, perPage)).
+		Find(&templates)
+	if err != nil {
+		return
+	}
+
+	for _, t := range templates {
+		t.Owner = owner
+	}
+
+	total, err := s.
+		Where("owner_id = ?", a.GetID()).
+		And(cond).
+		Count(&TaskTemplate{})
+	return templates, len(templates), total, err
+}
+
+// Update updates a task template
+// @Summary Update a task template
+// @Description Updates a task template by its ID.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param tasktemplate path int true "Task template ID"
+// @Param template body models.TaskTemplate true "The task template"
+// @Success 200 {object} models.TaskTemplate "The updated task template"
+// @Failure 400 {object} web.HTTPError "Invalid task template object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to that task template."
+// @Failure 404 {object} web.HTTPError "The task template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates/{tasktemplate} [post]
+func (tt *TaskTemplate) Update(s *xorm.Session, a web.Auth) (err error) {
+	if err := tt.validate(s, a); err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where("id = ?", tt.ID).
+		Cols(
+			"title",
+			"description",
+			"priority",
+			"hex_color",
+			"relative_due_date",
+			"relative_start_date",
+			"relative_end_date",
+			"label_ids",
+			"assignee_ids",
+			"reminders",
+			"subtasks",
+		).
+		Update(tt)
+	return
+}
+
+// Delete removes a task template
+// @Summary Delete a task template
+// @Description Deletes a task template. Tasks created from it are not changed.
+// @tags task
+// @Produce json
+// @Security JWTKeyAuth
+// @Param tasktemplate path int true "Task template ID"
+// @Success 200 {object} models.Message "The task template was deleted successfully."
+// @Failure 403 {object} web.HTTPError "The user does not have access to that task template."
+// @Failure 404 {object} web.HTTPError "The task template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates/{tasktemplate} [delete]
+func (tt *TaskTemplate) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	_, err = s.Where("id = ?", tt.ID).Delete(&TaskTemplate{})
+	return
+}
+
+func relativeDate(base time.Time, seconds int64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return base.Add(time.Duration(seconds) * time.Second)
+}
+
+// toTask returns a new task with all values of the template. All relative dates are based on now.
+func (tt *TaskTemplate) toTask(now time.Time) *Task {
+	t := &Task{
+		Title:       tt.Title,
+		Description: tt.Description,
+		Priority:    tt.Priority,
+		HexColor:    tt.HexColor,
+		DueDate:     relativeDate(now, tt.RelativeDueDate),
+		StartDate:   relativeDate(now, tt.RelativeStartDate),
+		EndDate:     relativeDate(now, tt.RelativeEndDate),
+	}
+
+	for _, r := range tt.Reminders {
+		t.Reminders = append(t.Reminders, &TaskReminder{
+			RelativePeriod: r.RelativePeriod,
+			RelativeTo:     r.RelativeTo,
+		})
+	}
+
+	return t
+}
+
+// TaskFromTemplate holds everything needed to create a task from a template
+type TaskFromTemplate struct {
+	// The id of the template the task is created from
+	TemplateID int64 `json:"-" param:"tasktemplate"`
+	// The project the task should be created in
+	ProjectID int64 `json:"project_id"`
+
+	// The created task
+	Task *Task `json:"task,omitempty"`
+
+	web.Rights   `json:"-"`
+	web.CRUDable `json:"-"`
+}
+
+// CanCreate checks if a user has the right to create a task from a template
+func (tft *TaskFromTemplate) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	template := &TaskTemplate{ID: tft.TemplateID}
+	can, err := template.canDoTaskTemplate(s, a)
+	if err != nil || !can {
+		return can, err
+	}
+
+	return (&Task{ProjectID: tft.ProjectID}).CanCreate(s, a)
+}
+
+// Create creates a task from a template
+// @Summary Create a task from a template
+// @Description Creates a new task with all values of the template in a project. All relative dates are based on the moment the task is created. Labels, assignees, reminders and subtasks of the template are created as well. Labels the user has no access to anymore and assignees without access to the project are skipped. The user needs write access to the target project.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param tasktemplate path int true "Task template ID"
+// @Param task body models.TaskFromTemplate true "The project the task should be created in."
+// @Success 201 {object} models.TaskFromTemplate "The created task."
+// @Failure 400 {object} web.HTTPError "Invalid object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the template or the project."
+// @Failure 404 {object} web.HTTPError "The task template does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasktemplates/{tasktemplate}/tasks [put]
+func (tft *TaskFromTemplate) Create(s *xorm.Session, doer web.Auth) (err error) {
+	template, err := getTaskTemplateByID(s, tft.TemplateID)
+	if err != nil {
+		return err
+	}
+
+	project, err := GetProjectSimpleByID(s, tft.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	task := template.toTask(now)
+	err = createTaskCopy(s, task, project.ID, doer)
+	if err != nil {
+		return err
+	}
+
+	labelTasks := make([]*LabelTask, 0, len(template.LabelIDs))
+	for _, labelID := range template.LabelIDs {
+		// The user might have lost access to a label since the template was created
+		has, _, err := (&Label{ID: labelID}).hasAccessToLabel(s, doer)
+		if err != nil {
+			return err
+		}
+		if !has {
+			continue
+		}
+		labelTasks = append(labelTasks, &LabelTask{TaskID: task.ID, LabelID: labelID})
+	}
+	err = addLabelTasks(s, labelTasks)
+	if err != nil {
+		return err
+	}
+
+	err = addAssigneesWithAccess(s, task, template.AssigneeIDs, project, doer)
+	if err != nil {
+		return err
+	}
+
+	for _, st := range template.Subtasks {
+		subtask := &Task{
+			Title:       st.Title,
+			Description: st.Description,
+			Priority:    st.Priority,
+			DueDate:     relativeDate(now, st.RelativeDueDate),
+		}
+		err = createTaskCopy(s, subtask, project.ID, doer)
+		if err != nil {
+			return err
+		}
+
+		rel := &TaskRelation{
+			TaskID:       task.ID,
+			OtherTaskID:  subtask.ID,
+			RelationKind: RelationKindSubtask,
+		}
+		err = rel.Create(s, doer)
+		if err != nil {
+			return err
+		}
+	}
+
+	tft.Task = &Task{ID: task.ID}
+	return tft.Task.ReadOne(s, doer)
+}
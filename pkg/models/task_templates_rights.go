@@ -0,0 +1,68 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user has the right to read a task template
+func (tt *TaskTemplate) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	can, err := tt.canDoTaskTemplate(s, a)
+	return can, int(RightAdmin), err
+}
+
+// CanDelete checks if a user has the right to delete a task template
+func (tt *TaskTemplate) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return tt.canDoTaskTemplate(s, a)
+}
+
+// CanUpdate checks if a user has the right to update a task template
+func (tt *TaskTemplate) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	// A normal check would replace the passed struct which in our case would override the values we want to update.
+	ttt := &TaskTemplate{ID: tt.ID}
+	return ttt.canDoTaskTemplate(s, a)
+}
+
+// CanCreate checks if a user has the right to create a task template
+func (tt *TaskTemplate) CanCreate(_ *xorm.Session, a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// Only owners are allowed to do something with a task template
+func (tt *TaskTemplate) canDoTaskTemplate(s *xorm.Session, a web.Auth) (bool, error) {
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	ttt, err := getTaskTemplateByID(s, tt.ID)
+	if err != nil {
+		return false, err
+	}
+
+	if ttt.OwnerID != a.GetID() {
+		return false, nil
+	}
+
+	*tt = *ttt
+	return true, nil
+}
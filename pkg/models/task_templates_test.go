@@ -0,0 +1,326 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskTemplate_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{
+			Title:           "Onboarding",
+			RelativeDueDate: 3600,
+			LabelIDs:        []int64{1},
+			Reminders: []*TaskTemplateReminder{
+				{RelativePeriod: -600, RelativeTo: ReminderRelationDueDate},
+			},
+			Subtasks: []*TaskTemplateSubtask{
+				{Title: "Create accounts"},
+			},
+		}
+		err := tt.Create(s, u)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), tt.OwnerID)
+		assert.Equal(t, int64(1), tt.Owner.ID)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		db.AssertExists(t, "task_templates", map[string]interface{}{
+			"id":                tt.ID,
+			"title":             "Onboarding",
+			"relative_due_date": 3600,
+			"owner_id":          1,
+		}, false)
+	})
+	t.Run("empty title", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{}
+		err := tt.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrTaskCannotBeEmpty(err))
+	})
+	t.Run("empty subtask title", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{
+			Title:    "Lorem",
+			Subtasks: []*TaskTemplateSubtask{{}},
+		}
+		err := tt.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrTaskCannotBeEmpty(err))
+	})
+	t.Run("reminder relative to unset date", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{
+			Title:           "Lorem",
+			RelativeDueDate: 3600,
+			Reminders: []*TaskTemplateReminder{
+				{RelativePeriod: -600, RelativeTo: ReminderRelationStartDate},
+			},
+		}
+		err := tt.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrInvalidTaskTemplateReminder(err))
+	})
+	t.Run("reminder without relation", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{
+			Title:           "Lorem",
+			RelativeDueDate: 3600,
+			Reminders: []*TaskTemplateReminder{
+				{RelativePeriod: -600},
+			},
+		}
+		err := tt.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrInvalidTaskTemplateReminder(err))
+	})
+	t.Run("label without access", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{
+			Title:    "Lorem",
+			LabelIDs: []int64{9999},
+		}
+		err := tt.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, IsErrUserHasNoAccessToLabel(err))
+	})
+	t.Run("nonexisting assignee", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{
+			Title:       "Lorem",
+			AssigneeIDs: []int64{9999},
+		}
+		err := tt.Create(s, u)
+		require.Error(t, err)
+		assert.True(t, user.IsErrUserDoesNotExist(err))
+	})
+}
+
+func TestTaskTemplate_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	tt := &TaskTemplate{}
+	result, count, total, err := tt.ReadAll(s, &user.User{ID: 1}, "", 1, 50)
+	require.NoError(t, err)
+	templates := result.([]*TaskTemplate)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, int64(1), templates[0].ID)
+	assert.Equal(t, []int64{1}, templates[0].LabelIDs)
+	assert.Len(t, templates[0].Subtasks, 2)
+	assert.Equal(t, ReminderRelationDueDate, templates[0].Reminders[0].RelativeTo)
+}
+
+func TestTaskTemplate_Update(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	tt := &TaskTemplate{
+		ID:    1,
+		Title: "Monthly release",
+	}
+	err := tt.Update(s, &user.User{ID: 1})
+	require.NoError(t, err)
+	err = s.Commit()
+	require.NoError(t, err)
+
+	db.AssertExists(t, "task_templates", map[string]interface{}{
+		"id":                1,
+		"title":             "Monthly release",
+		"relative_due_date": 0,
+	}, false)
+}
+
+func TestTaskTemplate_Delete(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	tt := &TaskTemplate{ID: 1}
+	err := tt.Delete(s, &user.User{ID: 1})
+	require.NoError(t, err)
+	err = s.Commit()
+	require.NoError(t, err)
+
+	db.AssertMissing(t, "task_templates", map[string]interface{}{
+		"id": 1,
+	})
+}
+
+func TestTaskTemplate_CanRead(t *testing.T) {
+	t.Run("owner", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{ID: 1}
+		can, _, err := tt.CanRead(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		assert.True(t, can)
+		assert.Equal(t, "Weekly release", tt.Title)
+	})
+	t.Run("other user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{ID: 2}
+		can, _, err := tt.CanRead(s, &user.User{ID: 1})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("link share", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{ID: 1}
+		can, _, err := tt.CanRead(s, &LinkSharing{ID: 1})
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTemplate{ID: 9999}
+		_, _, err := tt.CanRead(s, &user.User{ID: 1})
+		require.Error(t, err)
+		assert.True(t, IsErrTaskTemplateDoesNotExist(err))
+	})
+}
+
+func TestTaskFromTemplate_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("normal", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		before := time.Now()
+		tft := &TaskFromTemplate{TemplateID: 1, ProjectID: 1}
+		can, err := tft.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.True(t, can)
+		err = tft.Create(s, u)
+		require.NoError(t, err)
+		err = s.Commit()
+		require.NoError(t, err)
+
+		task := tft.Task
+		assert.Equal(t, "Weekly release", task.Title)
+		assert.Equal(t, "Ship everything that is done", task.Description)
+		assert.Equal(t, int64(3), task.Priority)
+		assert.Equal(t, int64(1), task.ProjectID)
+		assert.WithinDuration(t, before.Add(24*time.Hour), task.DueDate, time.Minute)
+		require.Len(t, task.Reminders, 1)
+		assert.Equal(t, ReminderRelationDueDate, task.Reminders[0].RelativeTo)
+		assert.WithinDuration(t, task.DueDate.Add(-time.Hour), task.Reminders[0].Reminder, time.Second)
+		require.Len(t, task.Labels, 1)
+		assert.Equal(t, int64(1), task.Labels[0].ID)
+		// User 2 does not have access to project 1 and is skipped
+		require.Len(t, task.Assignees, 1)
+		assert.Equal(t, int64(1), task.Assignees[0].ID)
+		require.Len(t, task.RelatedTasks[RelationKindSubtask], 2)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"title":      "Write changelog",
+			"project_id": 1,
+		}, false)
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"title":      "Tag release",
+			"project_id": 1,
+			"priority":   2,
+		}, false)
+		db.AssertExists(t, "task_relations", map[string]interface{}{
+			"task_id":       task.ID,
+			"relation_kind": RelationKindSubtask,
+		}, false)
+	})
+	t.Run("label without access anymore", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := s.Where("id = ?", 1).Cols("label_ids").Update(&TaskTemplate{LabelIDs: []int64{1, 3}})
+		require.NoError(t, err)
+
+		tft := &TaskFromTemplate{TemplateID: 1, ProjectID: 1}
+		err = tft.Create(s, u)
+		require.NoError(t, err)
+		require.Len(t, tft.Task.Labels, 1)
+		assert.Equal(t, int64(1), tft.Task.Labels[0].ID)
+	})
+	t.Run("no write access to project", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tft := &TaskFromTemplate{TemplateID: 1, ProjectID: 2}
+		can, err := tft.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("template of other user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tft := &TaskFromTemplate{TemplateID: 2, ProjectID: 1}
+		can, err := tft.CanCreate(s, u)
+		require.NoError(t, err)
+		assert.False(t, can)
+	})
+}
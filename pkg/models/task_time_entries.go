@@ -0,0 +1,432 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"time"
+
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web"
+
+	"xorm.io/builder"
+	"xorm.io/xorm"
+)
+
+// TaskTimeEntry represents time a user spent working on a task
+type TaskTimeEntry struct {
+	// The unique, numeric id of this time entry.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"timeentry"`
+	// The task this time entry belongs to.
+	TaskID int64 `xorm:"bigint not null index" json:"task_id" param:"task"`
+	// The user who tracked this time.
+	UserID int64      `xorm:"bigint not null index" json:"-"`
+	User   *user.User `xorm:"-" json:"user"`
+
+	// When the tracked time started.
+	Start time.Time `xorm:"DATETIME not null 'start_time'" json:"start"`
+	// When the tracked time ended. If this is null, the timer is still running.
+	End time.Time `xorm:"DATETIME null 'end_time'" json:"end"`
+	// The tracked time in seconds. When creating a time entry, you can provide this instead of an end date.
+	// For running timers, this is the time since the timer was started.
+	Duration int64 `xorm:"-" json:"duration"`
+	// An optional note about what was done in this time.
+	Note string `xorm:"text null" json:"note"`
+
+	// A timestamp when this time entry was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this time entry was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName holds the table name for the task time entries table
+func (te *TaskTimeEntry) TableName() string {
+	return "task_time_entries"
+}
+
+// IsRunning returns whether the time entry is a timer which has not been stopped yet
+func (te *TaskTimeEntry) IsRunning() bool {
+	return te.End.IsZero()
+}
+
+// getDuration returns the tracked time. For running timers, that's the time until now.
+func (te *TaskTimeEntry) getDuration() time.Duration {
+	if te.IsRunning() {
+		return time.Since(te.Start)
+	}
+	return te.End.Sub(te.Start)
+}
+
+func (te *TaskTimeEntry) setEndFromDuration() error {
+	if te.Start.IsZero() {
+		return ErrInvalidTaskTimeEntryRange{Start: te.Start, End: te.End}
+	}
+
+	if te.End.IsZero() && te.Duration > 0 {
+		te.End = te.Start.Add(time.Duration(te.Duration) * time.Second)
+	}
+
+	if !te.End.IsZero() && te.End.Before(te.Start) {
+		return ErrInvalidTaskTimeEntryRange{Start: te.Start, End: te.End}
+	}
+
+	return nil
+}
+
+func getTaskTimeEntrySimple(s *xorm.Session, te *TaskTimeEntry) error {
+	exists, err := s.
+		Where("id = ? AND task_id = ?", te.ID, te.TaskID).
+		NoAutoCondition().
+		Get(te)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrTaskTimeEntryDoesNotExist{
+			ID:     te.ID,
+			TaskID: te.TaskID,
+		}
+	}
+
+	te.Duration = int64(te.getDuration().Seconds())
+	return nil
+}
+
+func addUsersToTaskTimeEntries(s *xorm.Session, entries []*TaskTimeEntry) error {
+	userIDs := make([]int64, 0, len(entries))
+	for _, te := range entries {
+		userIDs = append(userIDs, te.UserID)
+	}
+
+	users, err := user.GetUsersByIDs(s, userIDs)
+	if err != nil {
+		return err
+	}
+
+	for _, te := range entries {
+		te.User = users[te.UserID]
+		te.Duration = int64(te.getDuration().Seconds())
+	}
+
+	return nil
+}
+
+func getTaskTimeEntriesByTaskIDs(s *xorm.Session, taskIDs []int64) (entries []*TaskTimeEntry, err error) {
+	entries = []*TaskTimeEntry{}
+	if len(taskIDs) == 0 {
+		return
+	}
+
+	err = s.
+		In("task_id", taskIDs).
+		OrderBy("start_time asc, id asc").
+		Find(&entries)
+	if err != nil {
+		return
+	}
+
+	err = addUsersToTaskTimeEntries(s, entries)
+	return
+}
+
+// Create creates a new time entry
+// @Summary Create a new time entry
+// @Description Tracks time on a task. Provide either an end date or a duration in seconds, without both the entry is a running timer and any other running timer of the user is stopped. The user doing this needs to have at least write access to the task.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param entry body models.TaskTimeEntry true "The time entry object"
+// @Success 201 {object} models.TaskTimeEntry "The created time entry object."
+// @Failure 400 {object} web.HTTPError "Invalid time entry object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time [put]
+func (te *TaskTimeEntry) Create(s *xorm.Session, a web.Auth) (err error) {
+	// Check if the task exists
+	_, err = GetTaskByIDSimple(s, te.TaskID)
+	if err != nil {
+		return err
+	}
+
+	err = te.setEndFromDuration()
+	if err != nil {
+		return err
+	}
+
+	// Every user can only have one running timer at a time
+	if te.IsRunning() {
+		err = stopRunningTimeEntriesForUser(s, a.GetID(), 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	te.ID = 0
+	te.UserID = a.GetID()
+	_, err = s.Insert(te)
+	if err != nil {
+		return err
+	}
+
+	te.Duration = int64(te.getDuration().Seconds())
+	te.User, err = user.GetUserByID(s, te.UserID)
+	return
+}
+
+// ReadOne returns a single time entry
+// @Summary Get one time entry
+// @Description Returns a single time entry of a task. The user doing this needs to have at least read access to the task.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param entryID path int true "Time entry ID"
+// @Success 200 {object} models.TaskTimeEntry "The time entry object."
+// @Failure 404 {object} web.HTTPError "The time entry does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time/{entryID} [get]
+func (te *TaskTimeEntry) ReadOne(s *xorm.Session, _ web.Auth) (err error) {
+	err = getTaskTimeEntrySimple(s, te)
+	if err != nil {
+		return err
+	}
+
+	te.User, err = user.GetUserByID(s, te.UserID)
+	return
+}
+
+// ReadAll returns all time entries of a task
+// @Summary Get all time entries of a task
+// @Description Returns all time entries of a task, ordered by their start date. The user doing this needs to have at least read access to the task.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per page. Note this parameter is limited by the configured maximum of items per page."
+// @Success 200 {array} models.TaskTimeEntry "The time entries"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time [get]
+func (te *TaskTimeEntry) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	canRead, _, err := te.CanRead(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !canRead {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	limit, start := getLimitFromPageIndex(page, perPage)
+	entries := []*TaskTimeEntry{}
+	query := s.
+		Where(builder.Eq{"task_id": te.TaskID}).
+		OrderBy("start_time asc, id asc")
+	if limit > 0 {
+		query = query.Limit(limit, start)
+	}
+	err = query.Find(&entries)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	err = addUsersToTaskTimeEntries(s, entries)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	numberOfTotalItems, err = s.
+		Where(builder.Eq{"task_id": te.TaskID}).
+		Count(&TaskTimeEntry{})
+	return entries, len(entries), numberOfTotalItems, err
+}
+
+// Update updates a time entry
+// @Summary Update a time entry
+// @Description Updates the start, end or note of a time entry. Only the user who tracked the time can change it.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param entryID path int true "Time entry ID"
+// @Param entry body models.TaskTimeEntry true "The time entry object"
+// @Success 200 {object} models.TaskTimeEntry "The updated time entry object."
+// @Failure 400 {object} web.HTTPError "Invalid time entry object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the time entry."
+// @Failure 404 {object} web.HTTPError "The time entry does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time/{entryID} [post]
+func (te *TaskTimeEntry) Update(s *xorm.Session, a web.Auth) (err error) {
+	err = te.setEndFromDuration()
+	if err != nil {
+		return err
+	}
+
+	// Every user can only have one running timer at a time
+	if te.IsRunning() {
+		err = stopRunningTimeEntriesForUser(s, a.GetID(), te.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.
+		Where("id = ? AND task_id = ?", te.ID, te.TaskID).
+		Cols("start_time", "end_time", "note").
+		NoAutoCondition().
+		Update(te)
+	if err != nil {
+		return err
+	}
+
+	return te.ReadOne(s, nil)
+}
+
+// Delete removes a time entry
+// @Summary Delete a time entry
+// @Description Removes a time entry. Only the user who tracked the time can delete it.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param entryID path int true "Time entry ID"
+// @Success 200 {object} models.Message "The time entry was successfully deleted."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the time entry."
+// @Failure 404 {object} web.HTTPError "The time entry does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time/{entryID} [delete]
+func (te *TaskTimeEntry) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	_, err = s.
+		Where("id = ? AND task_id = ?", te.ID, te.TaskID).
+		NoAutoCondition().
+		Delete(&TaskTimeEntry{})
+	return
+}
+
+// TaskTimer starts or stops the running timer of the current user on a task.
+type TaskTimer struct {
+	TaskTimeEntry
+}
+
+func getRunningTimeEntryForUser(s *xorm.Session, userID int64) (te *TaskTimeEntry, exists bool, err error) {
+	te = &TaskTimeEntry{}
+	exists, err = s.
+		Where("user_id = ?", userID).
+		And(builder.IsNull{"end_time"}).
+		OrderBy("start_time desc, id desc").
+		Get(te)
+	return
+}
+
+// stopRunningTimeEntriesForUser stops all running timers of a user, except the time entry with the given id.
+func stopRunningTimeEntriesForUser(s *xorm.Session, userID int64, exceptID int64) error {
+	_, err := s.
+		Where("user_id = ? AND id != ?", userID, exceptID).
+		And(builder.IsNull{"end_time"}).
+		Cols("end_time").
+		NoAutoCondition().
+		Update(&TaskTimeEntry{End: time.Now()})
+	return err
+}
+
+// Create starts a timer
+// @Summary Start a timer on a task
+// @Description Starts a timer on a task for the current user. Every user can only have one running timer at a time, if there is already a timer running on another task it will be stopped.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Param timer body models.TaskTimer true "The timer. Only the note can be set."
+// @Success 201 {object} models.TaskTimer "The started timer."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time/timer [put]
+func (tt *TaskTimer) Create(s *xorm.Session, a web.Auth) (err error) {
+	now := time.Now()
+
+	running, exists, err := getRunningTimeEntryForUser(s, a.GetID())
+	if err != nil {
+		return err
+	}
+	if exists {
+		// The timer is already running on this task, nothing to do
+		if running.TaskID == tt.TaskID {
+			tt.TaskTimeEntry = *running
+			return addUsersToTaskTimeEntries(s, []*TaskTimeEntry{&tt.TaskTimeEntry})
+		}
+
+		running.End = now
+		_, err = s.
+			Where("id = ?", running.ID).
+			Cols("end_time").
+			NoAutoCondition().
+			Update(running)
+		if err != nil {
+			return err
+		}
+	}
+
+	tt.Start = now
+	tt.End = time.Time{}
+	tt.Duration = 0
+	return tt.TaskTimeEntry.Create(s, a)
+}
+
+// Update stops a timer
+// @Summary Stop the timer on a task
+// @Description Stops the running timer of the current user on a task. The resulting time entry is returned.
+// @tags task
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param taskID path int true "Task ID"
+// @Success 200 {object} models.TaskTimer "The stopped timer."
+// @Failure 403 {object} web.HTTPError "The user does not have access to the task."
+// @Failure 404 {object} web.HTTPError "There is no running timer for this task."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/time/timer [post]
+func (tt *TaskTimer) Update(s *xorm.Session, a web.Auth) (err error) {
+	running, exists, err := getRunningTimeEntryForUser(s, a.GetID())
+	if err != nil {
+		return err
+	}
+	if !exists || running.TaskID != tt.TaskID {
+		return ErrNoRunningTaskTimer{TaskID: tt.TaskID, UserID: a.GetID()}
+	}
+
+	running.End = time.Now()
+	_, err = s.
+		Where("id = ?", running.ID).
+		Cols("end_time").
+		NoAutoCondition().
+		Update(running)
+	if err != nil {
+		return err
+	}
+
+	tt.TaskTimeEntry = *running
+	return addUsersToTaskTimeEntries(s, []*TaskTimeEntry{&tt.TaskTimeEntry})
+}
@@ -0,0 +1,77 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanRead checks if a user can read a time entry
+func (te *TaskTimeEntry) CanRead(s *xorm.Session, a web.Auth) (bool, int, error) {
+	t := Task{ID: te.TaskID}
+	return t.CanRead(s, a)
+}
+
+// CanCreate checks if a user can track time on a task
+func (te *TaskTimeEntry) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	// Link shares can't track time
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	t := Task{ID: te.TaskID}
+	return t.CanWrite(s, a)
+}
+
+func (te *TaskTimeEntry) canUserModifyTaskTimeEntry(s *xorm.Session, a web.Auth) (bool, error) {
+	canCreate, err := te.CanCreate(s, a)
+	if err != nil || !canCreate {
+		return false, err
+	}
+
+	savedEntry := &TaskTimeEntry{
+		ID:     te.ID,
+		TaskID: te.TaskID,
+	}
+	err = getTaskTimeEntrySimple(s, savedEntry)
+	if err != nil {
+		return false, err
+	}
+
+	return a.GetID() == savedEntry.UserID, nil
+}
+
+// CanUpdate checks if a user can update a time entry
+func (te *TaskTimeEntry) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return te.canUserModifyTaskTimeEntry(s, a)
+}
+
+// CanDelete checks if a user can delete a time entry
+func (te *TaskTimeEntry) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return te.canUserModifyTaskTimeEntry(s, a)
+}
+
+// CanCreate checks if a user can start a timer on a task
+func (tt *TaskTimer) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	return tt.TaskTimeEntry.CanCreate(s, a)
+}
+
+// CanUpdate checks if a user can stop a timer on a task
+func (tt *TaskTimer) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return tt.TaskTimeEntry.CanCreate(s, a)
+}
@@ -0,0 +1,290 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaskTimeEntry_Create(t *testing.T) {
+	u := &user.User{ID: 1}
+	start := time.Date(2023, 9, 15, 8, 0, 0, 0, time.UTC)
+
+	t.Run("with duration", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			TaskID:   1,
+			Start:    start,
+			Duration: 3600,
+			Note:     "test",
+		}
+		err := te.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, start.Add(time.Hour), te.End)
+		assert.Equal(t, int64(3600), te.Duration)
+		assert.Equal(t, int64(1), te.User.ID)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_time_entries", map[string]interface{}{
+			"id":      te.ID,
+			"task_id": 1,
+			"user_id": 1,
+			"note":    "test",
+		}, false)
+	})
+	t.Run("running entry stops the running timer", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		running := &TaskTimer{TaskTimeEntry{TaskID: 1}}
+		err := running.Create(s, u)
+		assert.NoError(t, err)
+
+		te := &TaskTimeEntry{
+			TaskID: 2,
+			Start:  time.Now(),
+		}
+		err = te.Create(s, u)
+		assert.NoError(t, err)
+		assert.True(t, te.IsRunning())
+
+		stopped := &TaskTimeEntry{ID: running.ID, TaskID: 1}
+		err = stopped.ReadOne(s, u)
+		assert.NoError(t, err)
+		assert.False(t, stopped.IsRunning())
+
+		current, exists, err := getRunningTimeEntryForUser(s, u.ID)
+		assert.NoError(t, err)
+		assert.True(t, exists)
+		assert.Equal(t, te.ID, current.ID)
+	})
+	t.Run("end before start", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			TaskID: 1,
+			Start:  start,
+			End:    start.Add(-time.Hour),
+		}
+		err := te.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTaskTimeEntryRange(err))
+	})
+	t.Run("without start", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			TaskID:   1,
+			Duration: 60,
+		}
+		err := te.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidTaskTimeEntryRange(err))
+	})
+	t.Run("nonexisting task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{
+			TaskID:   99999,
+			Start:    start,
+			Duration: 60,
+		}
+		err := te.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskDoesNotExist(err))
+	})
+}
+
+func TestTaskTimeEntry_ReadAll(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	te := &TaskTimeEntry{TaskID: 1}
+	result, resultCount, total, err := te.ReadAll(s, &user.User{ID: 1}, "", 0, 50)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, resultCount)
+	assert.Equal(t, int64(2), total)
+	entries := result.([]*TaskTimeEntry)
+	assert.Equal(t, int64(1), entries[0].ID)
+	assert.Equal(t, int64(3600), entries[0].Duration)
+	assert.Equal(t, int64(1), entries[0].User.ID)
+	assert.Equal(t, int64(3), entries[1].ID)
+	assert.Equal(t, int64(900), entries[1].Duration)
+}
+
+func TestTaskTimeEntry_CanDelete(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("own entry", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{ID: 1, TaskID: 1}
+		can, err := te.CanDelete(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+	})
+	t.Run("entry of another user", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{ID: 3, TaskID: 1}
+		can, err := te.CanDelete(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("entry of another task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{ID: 2, TaskID: 1}
+		_, err := te.CanDelete(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrTaskTimeEntryDoesNotExist(err))
+	})
+	t.Run("no access to task", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		te := &TaskTimeEntry{ID: 4, TaskID: 14}
+		can, err := te.CanDelete(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+}
+
+func TestTaskTimer(t *testing.T) {
+	u := &user.User{ID: 1}
+
+	t.Run("start and stop", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTimer{TaskTimeEntry{TaskID: 1}}
+		err := tt.Create(s, u)
+		assert.NoError(t, err)
+		assert.True(t, tt.IsRunning())
+		startedID := tt.ID
+
+		tt = &TaskTimer{TaskTimeEntry{TaskID: 1}}
+		err = tt.Update(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, startedID, tt.ID)
+		assert.False(t, tt.IsRunning())
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "task_time_entries", map[string]interface{}{
+			"id":      startedID,
+			"task_id": 1,
+			"user_id": 1,
+		}, false)
+	})
+	t.Run("starting a timer stops the running one", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		first := &TaskTimer{TaskTimeEntry{TaskID: 1}}
+		err := first.Create(s, u)
+		assert.NoError(t, err)
+
+		second := &TaskTimer{TaskTimeEntry{TaskID: 2}}
+		err = second.Create(s, u)
+		assert.NoError(t, err)
+		assert.NotEqual(t, first.ID, second.ID)
+
+		stopped := &TaskTimeEntry{ID: first.ID, TaskID: 1}
+		err = stopped.ReadOne(s, u)
+		assert.NoError(t, err)
+		assert.False(t, stopped.IsRunning())
+
+		err = (&TaskTimer{TaskTimeEntry{TaskID: 1}}).Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrNoRunningTaskTimer(err))
+	})
+	t.Run("starting twice keeps the timer", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		first := &TaskTimer{TaskTimeEntry{TaskID: 1}}
+		err := first.Create(s, u)
+		assert.NoError(t, err)
+
+		second := &TaskTimer{TaskTimeEntry{TaskID: 1}}
+		err = second.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, first.ID, second.ID)
+	})
+	t.Run("stop without running timer", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		tt := &TaskTimer{TaskTimeEntry{TaskID: 1}}
+		err := tt.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrNoRunningTaskTimer(err))
+	})
+}
+
+func TestProjectTimeSummary_ReadOne(t *testing.T) {
+	db.LoadAndAssertFixtures(t)
+	s := db.NewSession()
+	defer s.Close()
+
+	pts := &ProjectTimeSummary{ProjectID: 1}
+	err := pts.ReadOne(s, &user.User{ID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6300), pts.TotalDuration)
+
+	assert.Len(t, pts.Tasks, 2)
+	assert.Equal(t, int64(1), pts.Tasks[0].TaskID)
+	assert.Equal(t, int64(4500), pts.Tasks[0].Duration)
+	assert.Equal(t, int64(2), pts.Tasks[1].TaskID)
+	assert.Equal(t, int64(1800), pts.Tasks[1].Duration)
+
+	assert.Len(t, pts.Users, 2)
+	assert.Equal(t, int64(1), pts.Users[0].User.ID)
+	assert.Equal(t, int64(5400), pts.Users[0].Duration)
+	assert.Equal(t, int64(2), pts.Users[1].User.ID)
+	assert.Equal(t, int64(900), pts.Users[1].Duration)
+}
@@ -17,6 +17,7 @@
 package models
 
 import (
+	"encoding/hex"
 	"math"
 	"regexp"
 	"sort"
@@ -26,6 +27,7 @@ import (
 
 	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/user"
 	"code.vikunja.io/web"
@@ -67,6 +69,8 @@ type Task struct {
 	RepeatAfter int64 `xorm:"bigint INDEX null" json:"repeat_after" valid:"range(0|9223372036854775807)"`
 	// Can have three possible values which will trigger when the task is marked as done: 0 = repeats after the amount specified in repeat_after, 1 = repeats all dates each months (ignoring repeat_after), 3 = repeats from the current date rather than the last set date.
 	RepeatMode TaskRepeatMode `xorm:"not null default 0" json:"repeat_mode"`
+	// An RFC 5545 recurrence rule like `FREQ=MONTHLY;BYDAY=2TU` or `FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=10`. If set, it takes precedence over repeat_after and repeat_mode when the task is marked as done. Supported parts are FREQ, INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY, BYMONTH, BYSETPOS and WKST.
+	RepeatRule string `xorm:"varchar(500) null" json:"repeat_rule"`
 	// The task priority. Can be anything you want, it is possible to sort by this later.
 	Priority int64 `xorm:"bigint null" json:"priority"`
 	// When this task starts.
@@ -96,6 +100,10 @@ type Task struct {
 	// All attachments this task has
 	Attachments []*TaskAttachment `xorm:"-" json:"attachments"`
 
+	// The values of the project's custom fields for this task. When updating a task, only the values of the passed
+	// fields are changed. Set a value to null to remove it.
+	CustomFields []*TaskCustomFieldValue `xorm:"-" json:"custom_fields"`
+
 	// If this task has a cover image, the field will return the id of the attachment that is the cover image.
 	CoverImageAttachmentID int64 `xorm:"bigint default 0" json:"cover_image_attachment_id"`
 
@@ -111,18 +119,17 @@ type Task struct {
 	// A timestamp when this task was last updated. You cannot change this value.
 	Updated time.Time `xorm:"updated not null" json:"updated"`
 
-	// BucketID is the ID of the kanban bucket this task belongs to.
-	BucketID int64 `xorm:"bigint null" json:"bucket_id"`
+	// BucketID is the ID of the kanban bucket this task belongs to. Tasks are in one bucket per kanban view of
+	// their project. When returned, this contains the bucket of the first kanban view of the project or the bucket
+	// of the view the task was requested through. Set it to move the task into another bucket of that bucket's view.
+	BucketID int64 `xorm:"-" json:"bucket_id"`
 
 	// The position of the task - any task project can be sorted as usual by this parameter.
-	// When accessing tasks via kanban buckets, this is primarily used to sort them based on a range
-	// We're using a float64 here to make it possible to put any task within any two other tasks (by changing the number).
-	// You would calculate the new position between two tasks with something like task3.position = (task2.position - task1.position) / 2.
-	// A 64-Bit float leaves plenty of room to initially give tasks a position with 2^16 difference to the previous task
-	// which also leaves a lot of room for rearranging and sorting later.
-	Position float64 `xorm:"double null" json:"position"`
-	// The position of tasks in the kanban board. See the docs for the `position` property on how to use this.
-	KanbanPosition float64 `xorm:"double null" json:"kanban_position"`
+	// Positions are saved per project view. When returned, this contains the position in the first list view of
+	// the project or the position in the view the tasks were requested through.
+	// When creating a task, this is used as the initial position in all views of the project.
+	// To change the position of a task in a view, use the task position endpoint.
+	Position float64 `xorm:"-" json:"position"`
 
 	// The user who initially created the task.
 	CreatedBy   *user.User `xorm:"-" json:"created_by" valid:"-"`
@@ -134,7 +141,8 @@ type Task struct {
 
 type TaskWithComments struct {
 	Task
-	Comments []*TaskComment `xorm:"-" json:"comments"`
+	Comments    []*TaskComment   `xorm:"-" json:"comments"`
+	TimeEntries []*TaskTimeEntry `xorm:"-" json:"time_entries"`
 }
 
 // TableName returns the table name for tasks
@@ -173,7 +181,10 @@ type taskSearchOptions struct {
 	filters            []*taskFilter
 	filterConcat       taskFilterConcatinator
 	filterIncludeNulls bool
+	filterExpression   *taskFilterNode
 	projectIDs         []int64
+	// The view the tasks are requested for, used to sort them by their position in it
+	projectViewID int64
 }
 
 // ReadAll is a dummy function to still have that endpoint documented
@@ -192,6 +203,7 @@ type taskSearchOptions struct {
 // @Param filter_comparator query string false "The comparator to use for a filter. Available values are `equals`, `greater`, `greater_equals`, `less`, `less_equals`, `like` and `in`. `in` expects comma-separated values in `filter_value`. Defaults to `equals`"
 // @Param filter_concat query string false "The concatinator to use for filters. Available values are `and` or `or`. Defaults to `or`."
 // @Param filter_include_nulls query string false "If set to true the result will include filtered fields whose value is set to `null`. Available values are `true` or `false`. Defaults to `false`."
+// @Param filter query string false "A filter expression like `(priority >= 3 && label in 4,5) || due_date < now+7d`. Supports parentheses, `&&`/`and`, `||`/`or`, negation with `!` or `not` and the comparators `=`, `!=`, `>`, `>=`, `<`, `<=`, `like`, `in` and `not in`. Combined with all other filter parameters using `and`."
 // @Security JWTKeyAuth
 // @Success 200 {array} models.Task "The tasks"
 // @Failure 500 {object} models.Message "Internal error"
@@ -297,18 +309,39 @@ func getRawTasksForProjects(s *xorm.Session, projects []*Project, a web.Auth, op
 		})
 	}
 
+	err = resolveCustomFieldsInSearchOptions(s, opts)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
 	var searcher taskSearcher = &dbTaskSearcher{
 		s:                   s,
 		a:                   a,
 		hasFavoritesProject: hasFavoritesProject,
 	}
-	if config.TypesenseEnabled.GetBool() {
+	// Custom fields, buckets and positions are not indexed in typesense and it can't negate a like filter
+	if config.TypesenseEnabled.GetBool() &&
+		!opts.usesCustomFields() &&
+		!opts.usesProjectViewData() &&
+		!opts.filterExpression.hasNegatedLike(false) {
 		searcher = &typesenseTaskSearcher{
 			s: s,
 		}
 	}
 
 	tasks, totalItems, err = searcher.Search(opts)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	err = addDefaultViewDataToTasks(s, tasks)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	if opts.projectViewID != 0 {
+		err = addTaskPositionsToTasks(s, tasks, opts.projectViewID)
+	}
 	return tasks, len(tasks), totalItems, err
 }
 
@@ -497,6 +530,15 @@ func addRelatedTasksToTasks(s *xorm.Session, taskIDs []int64, taskMap map[int64]
 		return
 	}
 
+	relatedTasksList := make([]*Task, 0, len(fullRelatedTasks))
+	for _, rt := range fullRelatedTasks {
+		relatedTasksList = append(relatedTasksList, rt)
+	}
+	err = addDefaultViewDataToTasks(s, relatedTasksList)
+	if err != nil {
+		return
+	}
+
 	taskFavorites, err := getFavorites(s, relatedTaskIDs, a, FavoriteKindTask)
 	if err != nil {
 		return err
@@ -562,6 +604,11 @@ func addMoreInfoToTasks(s *xorm.Session, taskMap map[int64]*Task, a web.Auth) (e
 		return
 	}
 
+	err = addCustomFieldValuesToTasks(s, taskIDs, taskMap)
+	if err != nil {
+		return
+	}
+
 	users, err := getUsersOrLinkSharesFromIDs(s, userIDs)
 	if err != nil {
 		return
@@ -606,15 +653,39 @@ func addMoreInfoToTasks(s *xorm.Session, taskMap map[int64]*Task, a web.Auth) (e
 	return
 }
 
-func checkBucketAndTaskBelongToSameProject(fullTask *Task, bucket *Bucket) (err error) {
-	if fullTask.ProjectID != bucket.ProjectID {
-		return ErrBucketDoesNotBelongToProject{
-			ProjectID: fullTask.ProjectID,
-			BucketID:  fullTask.BucketID,
+// setFieldFromString changes one of the fields done, priority, percent_done or hex_color of a task to a value
+// given as string. Returns false if the field cannot be changed this way or the value is invalid for that field.
+func (t *Task) setFieldFromString(field, value string) bool {
+	switch field {
+	case taskPropertyDone:
+		done, err := strconv.ParseBool(value)
+		if err != nil {
+			return false
+		}
+		t.Done = done
+	case taskPropertyPriority:
+		priority, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || priority < 0 {
+			return false
 		}
+		t.Priority = priority
+	case taskPropertyPercentDone:
+		percentDone, err := strconv.ParseFloat(value, 64)
+		if err != nil || percentDone < 0 || percentDone > 1 {
+			return false
+		}
+		t.PercentDone = percentDone
+	case taskPropertyHexColor:
+		color := strings.TrimPrefix(value, "#")
+		if _, err := hex.DecodeString(color); err != nil || (color != "" && len(color) != 6) {
+			return false
+		}
+		t.HexColor = color
+	default:
+		return false
 	}
 
-	return
+	return true
 }
 
 // Checks if adding a new task would exceed the bucket limit
@@ -622,7 +693,7 @@ func checkBucketLimit(s *xorm.Session, t *Task, bucket *Bucket) (err error) {
 	if bucket.Limit > 0 {
 		taskCount, err := s.
 			Where("bucket_id = ?", bucket.ID).
-			Count(&Task{})
+			Count(&TaskBucket{})
 		if err != nil {
 			return err
 		}
@@ -633,59 +704,201 @@ func checkBucketLimit(s *xorm.Session, t *Task, bucket *Bucket) (err error) {
 	return nil
 }
 
-// Contains all the task logic to figure out what bucket to use for this task.
-func setTaskBucket(s *xorm.Session, task *Task, originalTask *Task, doCheckBucketLimit bool, project *Project) (targetBucket *Bucket, err error) {
+// Contains all the task logic to figure out which bucket to use for this task in every kanban view of its project.
+// Kanban views in filter bucket configuration mode don't store buckets for their tasks, moving a task into one of
+// their buckets applies the move action of that bucket to the task instead.
+// The returned task buckets still need to be saved with saveTaskBuckets.
+//
+//nolint:gocyclo
+func setTaskBuckets(s *xorm.Session, task *Task, originalTask *Task) (taskBuckets []*TaskBucket, err error) {
+
+	views, err := getKanbanViewsForProject(s, task.ProjectID)
+	if err != nil {
+		return nil, err
+	}
 
-	if project == nil {
-		project, err = GetProjectSimpleByID(s, task.ProjectID)
+	movedBetweenProjects := originalTask != nil && originalTask.ProjectID != task.ProjectID
+
+	var explicitBucket *Bucket
+	if task.BucketID != 0 {
+		explicitBucket, err = getBucketByID(s, task.BucketID)
 		if err != nil {
 			return nil, err
 		}
-	}
 
-	var bucket *Bucket
-	if task.Done && originalTask != nil && !originalTask.Done {
-		task.BucketID = project.DoneBucketID
+		// If there is a bucket set, make sure they belong to the same project as the task
+		if explicitBucket.ProjectID != task.ProjectID {
+			// When the task is moved to another project, the bucket it was in before is simply ignored
+			if !movedBetweenProjects || explicitBucket.ProjectID != originalTask.ProjectID {
+				return nil, ErrBucketDoesNotBelongToProject{
+					ProjectID: task.ProjectID,
+					BucketID:  task.BucketID,
+				}
+			}
+			explicitBucket = nil
+		}
 	}
 
-	if task.BucketID == 0 && originalTask != nil && originalTask.BucketID != 0 {
-		task.BucketID = originalTask.BucketID
+	var explicitFilterBucket *Bucket
+	if explicitBucket != nil {
+		for _, view := range views {
+			if view.ID != explicitBucket.ProjectViewID || !view.usesFilterBuckets() {
+				continue
+			}
+
+			explicitFilterBucket = explicitBucket
+			explicitBucket = nil
+
+			// Only tasks which are not already in the bucket are moved into it
+			isInBucket := false
+			if originalTask != nil {
+				isInBucket, err = explicitFilterBucket.containsTask(s, view, task.ID)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if explicitFilterBucket.MoveAction != nil && !isInBucket {
+				err = explicitFilterBucket.MoveAction.apply(task, explicitFilterBucket.ID)
+				if err != nil {
+					return nil, err
+				}
+			}
+			break
+		}
 	}
 
-	// Either no bucket was provided or the task was moved between projects
-	if task.BucketID == 0 || (originalTask != nil && task.ProjectID != 0 && originalTask.ProjectID != task.ProjectID) {
-		task.BucketID, err = getDefaultBucketID(s, project)
+	currentBuckets := make(map[int64]int64)
+	if originalTask != nil && !movedBetweenProjects {
+		existing := []*TaskBucket{}
+		err = s.Where("task_id = ?", task.ID).Find(&existing)
 		if err != nil {
-			return
+			return nil, err
+		}
+		for _, tb := range existing {
+			currentBuckets[tb.ProjectViewID] = tb.BucketID
 		}
 	}
 
-	if bucket == nil {
-		bucket, err = getBucketByID(s, task.BucketID)
-		if err != nil {
-			return
+	// Figure out where the task would end up without considering its done state
+	targetBuckets := make(map[int64]int64, len(views))
+	explicitMoves := make(map[int64]bool, len(views))
+	for _, view := range views {
+		if view.usesFilterBuckets() {
+			continue
+		}
+
+		bucketID := currentBuckets[view.ID]
+		if explicitBucket != nil && explicitBucket.ProjectViewID == view.ID && explicitBucket.ID != bucketID {
+			bucketID = explicitBucket.ID
+			explicitMoves[view.ID] = true
+		}
+
+		if bucketID == 0 {
+			bucketID, err = getDefaultBucketID(s, view)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// Moving a task into the done bucket of a view marks it as done
+		if originalTask != nil && !originalTask.Done && bucketID != 0 &&
+			bucketID == view.DoneBucketID && bucketID != currentBuckets[view.ID] {
+			task.Done = true
 		}
+
+		targetBuckets[view.ID] = bucketID
 	}
 
-	// If there is a bucket set, make sure they belong to the same project as the task
-	err = checkBucketAndTaskBelongToSameProject(task, bucket)
-	if err != nil {
-		return
+	markedDone := task.Done && originalTask != nil && !originalTask.Done
+
+	taskBuckets = make([]*TaskBucket, 0, len(views))
+	for _, view := range views {
+		if view.usesFilterBuckets() {
+			continue
+		}
+
+		currentBucketID := currentBuckets[view.ID]
+		bucketID := targetBuckets[view.ID]
+		explicitMove := explicitMoves[view.ID]
+
+		if markedDone && view.DoneBucketID != 0 {
+			bucketID = view.DoneBucketID
+		}
+
+		// If the task was moved into the done bucket and the task has a repeating cycle we should not update
+		// the bucket.
+		if (task.RepeatAfter > 0 || task.RepeatRule != "") && view.DoneBucketID != 0 && bucketID == view.DoneBucketID && bucketID != currentBucketID {
+			task.Done = true // This will trigger the correct re-scheduling of the task (happening in updateDone later)
+			bucketID = currentBucketID
+			explicitMove = false
+			if bucketID == 0 {
+				bucketID, err = getDefaultBucketID(s, view)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		// The view does not have any buckets
+		if bucketID == 0 {
+			continue
+		}
+
+		// Only check the bucket limit if the task is being moved between buckets, allow reordering the task within a bucket
+		if (originalTask == nil || explicitMove) && bucketID != currentBucketID {
+			bucket, err := getBucketByID(s, bucketID)
+			if err != nil {
+				return nil, err
+			}
+			if err := checkBucketLimit(s, task, bucket); err != nil {
+				return nil, err
+			}
+		}
+
+		taskBuckets = append(taskBuckets, &TaskBucket{
+			TaskID:        task.ID,
+			BucketID:      bucketID,
+			ProjectViewID: view.ID,
+		})
 	}
 
-	// Check the bucket limit
-	// Only check the bucket limit if the task is being moved between buckets, allow reordering the task within a bucket
-	if doCheckBucketLimit {
-		if err := checkBucketLimit(s, task, bucket); err != nil {
-			return nil, err
+	if explicitFilterBucket != nil {
+		task.BucketID = explicitFilterBucket.ID
+		return taskBuckets, nil
+	}
+
+	task.BucketID = 0
+	for _, tb := range taskBuckets {
+		if explicitBucket != nil && tb.ProjectViewID == explicitBucket.ProjectViewID {
+			task.BucketID = tb.BucketID
+			break
+		}
+		if explicitBucket == nil && tb.ProjectViewID == views[0].ID {
+			task.BucketID = tb.BucketID
+			break
 		}
 	}
 
-	if bucket.ID == project.DoneBucketID && originalTask != nil && !originalTask.Done {
-		task.Done = true
+	return taskBuckets, nil
+}
+
+// saveTaskBuckets replaces all buckets of a task with the ones provided.
+func saveTaskBuckets(s *xorm.Session, taskID int64, taskBuckets []*TaskBucket) (err error) {
+	_, err = s.Where("task_id = ?", taskID).Delete(&TaskBucket{})
+	if err != nil {
+		return err
+	}
+
+	if len(taskBuckets) == 0 {
+		return nil
+	}
+
+	for _, tb := range taskBuckets {
+		tb.TaskID = taskID
 	}
 
-	return bucket, nil
+	_, err = s.Insert(&taskBuckets)
+	return
 }
 
 func calculateDefaultPosition(entityID int64, position float64) float64 {
@@ -748,13 +961,17 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool) (err
 	}
 	t.CreatedByID = createdBy.ID
 
+	if err := t.normalizeRepeatRule(); err != nil {
+		return err
+	}
+
 	// Generate a uuid if we don't already have one
 	if t.UID == "" {
 		t.UID = uuid.NewString()
 	}
 
-	// Get the default bucket and move the task there
-	_, err = setTaskBucket(s, t, nil, true, nil)
+	// Get the default bucket of every kanban view and move the task there
+	taskBuckets, err := setTaskBuckets(s, t, nil)
 	if err != nil {
 		return
 	}
@@ -765,10 +982,17 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool) (err
 		return err
 	}
 
+	if _, err = s.Insert(t); err != nil {
+		return err
+	}
+
+	if err := saveTaskBuckets(s, t.ID, taskBuckets); err != nil {
+		return err
+	}
+
 	// If no position was supplied, set a default one
 	t.Position = calculateDefaultPosition(t.Index, t.Position)
-	t.KanbanPosition = calculateDefaultPosition(t.Index, t.KanbanPosition)
-	if _, err = s.Insert(t); err != nil {
+	if err := createDefaultTaskPositions(s, t); err != nil {
 		return err
 	}
 
@@ -786,6 +1010,15 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool) (err
 		return err
 	}
 
+	// Update the custom field values
+	if err := t.updateCustomFieldValues(s, t.CustomFields); err != nil {
+		return err
+	}
+	t.CustomFields, err = getCustomFieldValuesForTasks(s, []int64{t.ID})
+	if err != nil {
+		return err
+	}
+
 	t.setIdentifier(p)
 
 	if t.IsFavorite {
@@ -794,6 +1027,18 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool) (err
 		}
 	}
 
+	err = addHistoryEntries(s, a, &HistoryEntry{
+		EntityKind: HistoryEntityKindTask,
+		EntityID:   t.ID,
+		Action:     HistoryActionCreated,
+		NewValue:   t.Title,
+		TaskID:     t.ID,
+		ProjectID:  t.ProjectID,
+	})
+	if err != nil {
+		return err
+	}
+
 	err = events.Dispatch(&TaskCreatedEvent{
 		Task: t,
 		Doer: createdBy,
@@ -802,6 +1047,11 @@ func createTask(s *xorm.Session, t *Task, a web.Auth, updateAssignees bool) (err
 		return err
 	}
 
+	err = recordTaskSyncChange(s, t.ProjectID, t, false)
+	if err != nil {
+		return err
+	}
+
 	err = updateProjectLastUpdated(s, &Project{ID: t.ProjectID})
 	return
 }
@@ -829,6 +1079,7 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 	if err != nil {
 		return
 	}
+	originalTask := ot
 
 	if t.ProjectID == 0 {
 		t.ProjectID = ot.ProjectID
@@ -843,20 +1094,19 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 	// Old task has the stored reminders
 	ot.Reminders = reminders
 
-	targetBucket, err := setTaskBucket(s, t, &ot, t.BucketID != 0 && t.BucketID != ot.BucketID, nil)
-	if err != nil {
+	if err := t.normalizeRepeatRule(); err != nil {
 		return err
 	}
 
-	// If the task was moved into the done bucket and the task has a repeating cycle we should not update
-	// the bucket.
-	project, err := GetProjectSimpleByID(s, t.ProjectID)
+	taskBuckets, err := setTaskBuckets(s, t, &ot)
 	if err != nil {
 		return err
 	}
-	if targetBucket.ID == project.DoneBucketID && t.RepeatAfter > 0 {
-		t.Done = true // This will trigger the correct re-scheduling of the task (happening in updateDone later)
-		t.BucketID = ot.BucketID
+
+	if !ot.Done && t.Done {
+		if err := checkTaskBlockers(s, t); err != nil {
+			return err
+		}
 	}
 
 	// When a repeating task is marked as done, we update all deadlines and reminders and set it as undone
@@ -886,10 +1136,8 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 		"done_at",
 		"percent_done",
 		"project_id",
-		"bucket_id",
-		"position",
 		"repeat_mode",
-		"kanban_position",
+		"repeat_rule",
 		"cover_image_attachment_id",
 	}
 
@@ -900,6 +1148,39 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 			return err
 		}
 		colsToUpdate = append(colsToUpdate, "index")
+
+		// Custom fields are defined per project
+		if err := t.deleteCustomFieldValuesFromOtherProjects(s); err != nil {
+			return err
+		}
+
+		// Views are defined per project as well
+		_, err = s.Where("task_id = ?", t.ID).Delete(&TaskPosition{})
+		if err != nil {
+			return err
+		}
+		if err := createDefaultTaskPositions(s, t); err != nil {
+			return err
+		}
+
+		// Attachments count towards the storage quota of the project the task is in
+		_, err = s.
+			Where(builder.In("id", builder.Select("file_id").From("task_attachments").Where(builder.Eq{"task_id": t.ID}))).
+			Cols("project_id").
+			NoAutoCondition().
+			Update(&files.File{ProjectID: t.ProjectID})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := saveTaskBuckets(s, t.ID, taskBuckets); err != nil {
+		return err
+	}
+
+	// Update the custom field values
+	if err := t.updateCustomFieldValues(s, t.CustomFields); err != nil {
+		return err
 	}
 
 	// If a task attachment is being set as cover image, check if the attachment actually belongs to the task
@@ -999,17 +1280,14 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 	if t.PercentDone == 0 {
 		ot.PercentDone = 0
 	}
-	// Position
-	if t.Position == 0 {
-		ot.Position = 0
-	}
-	if t.KanbanPosition == 0 {
-		ot.KanbanPosition = 0
-	}
 	// Repeat from current date
 	if t.RepeatMode == TaskRepeatModeDefault {
 		ot.RepeatMode = TaskRepeatModeDefault
 	}
+	// Repeat rule
+	if t.RepeatRule == "" {
+		ot.RepeatRule = ""
+	}
 	// Is Favorite
 	if !t.IsFavorite {
 		ot.IsFavorite = false
@@ -1027,20 +1305,6 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 		return err
 	}
 
-	// Update all positions if the newly saved position is < 0.1
-	if ot.Position < 0.1 {
-		err = recalculateTaskPositions(s, t.ProjectID)
-		if err != nil {
-			return err
-		}
-	}
-	if ot.KanbanPosition < 0.1 {
-		err = recalculateTaskKanbanPositions(s, t.BucketID)
-		if err != nil {
-			return err
-		}
-	}
-
 	// Get the task updated timestamp in a new struct - if we'd just try to put it into t which we already have, it
 	// would still contain the old updated date.
 	nt := &Task{}
@@ -1049,8 +1313,21 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 		return err
 	}
 	t.Updated = nt.Updated
-	t.Position = nt.Position
-	t.KanbanPosition = nt.KanbanPosition
+
+	t.CustomFields, err = getCustomFieldValuesForTasks(s, []int64{t.ID})
+	if err != nil {
+		return err
+	}
+
+	err = addHistoryEntries(s, a, historyEntriesForChanges(HistoryEntry{
+		EntityKind: HistoryEntityKindTask,
+		EntityID:   t.ID,
+		TaskID:     t.ID,
+		ProjectID:  t.ProjectID,
+	}, &originalTask, t, colsToUpdate)...)
+	if err != nil {
+		return err
+	}
 
 	doer, _ := user.GetFromAuth(a)
 	err = events.Dispatch(&TaskUpdatedEvent{
@@ -1061,63 +1338,21 @@ func (t *Task) Update(s *xorm.Session, a web.Auth) (err error) {
 		return err
 	}
 
-	return updateProjectLastUpdated(s, &Project{ID: t.ProjectID})
-}
-
-func recalculateTaskKanbanPositions(s *xorm.Session, bucketID int64) (err error) {
-
-	allTasks := []*Task{}
-	err = s.
-		Where("bucket_id = ?", bucketID).
-		OrderBy("kanban_position asc").
-		Find(&allTasks)
-	if err != nil {
-		return
-	}
-
-	maxPosition := math.Pow(2, 32)
-
-	for i, task := range allTasks {
-
-		currentPosition := maxPosition / float64(len(allTasks)) * (float64(i + 1))
-
-		_, err = s.Cols("kanban_position").
-			Where("id = ?", task.ID).
-			Update(&Task{KanbanPosition: currentPosition})
+	// A task moved to another project is gone from the old one. ot was merged with the new values above,
+	// so the old project is only known from the original task.
+	if originalTask.ProjectID != t.ProjectID {
+		err = recordTaskSyncChange(s, originalTask.ProjectID, &originalTask, true)
 		if err != nil {
-			return
+			return err
 		}
 	}
 
-	return
-}
-
-func recalculateTaskPositions(s *xorm.Session, projectID int64) (err error) {
-
-	allTasks := []*Task{}
-	err = s.
-		Where("project_id = ?", projectID).
-		OrderBy("position asc").
-		Find(&allTasks)
+	err = recordTaskSyncChange(s, t.ProjectID, &Task{ID: t.ID, UID: ot.UID}, false)
 	if err != nil {
-		return
-	}
-
-	maxPosition := math.Pow(2, 32)
-
-	for i, task := range allTasks {
-
-		currentPosition := maxPosition / float64(len(allTasks)) * (float64(i + 1))
-
-		_, err = s.Cols("position").
-			Where("id = ?", task.ID).
-			Update(&Task{Position: currentPosition})
-		if err != nil {
-			return
-		}
+		return err
 	}
 
-	return
+	return updateProjectLastUpdated(s, &Project{ID: t.ProjectID})
 }
 
 func addOneMonthToDate(d time.Time) time.Time {
@@ -1272,6 +1507,76 @@ func setTaskDatesFromCurrentDateRepeat(oldTask, newTask *Task) {
 	newTask.Done = false
 }
 
+// setTaskDatesFromRepeatRule moves all dates and reminders of the task to the next occurrence of its repeat rule.
+// The first date set out of due, start and end date (or the earliest reminder) is used as the start of the rule,
+// all other dates keep their difference to it.
+func setTaskDatesFromRepeatRule(oldTask, newTask *Task) {
+	rule, err := ParseRepeatRule(oldTask.RepeatRule)
+	if err != nil {
+		return
+	}
+
+	// The task was done for the last time
+	if rule.Count == 1 {
+		return
+	}
+
+	var reference time.Time
+	switch {
+	case !oldTask.DueDate.IsZero():
+		reference = oldTask.DueDate
+	case !oldTask.StartDate.IsZero():
+		reference = oldTask.StartDate
+	case !oldTask.EndDate.IsZero():
+		reference = oldTask.EndDate
+	}
+	if reference.IsZero() {
+		for _, r := range oldTask.Reminders {
+			if reference.IsZero() || r.Reminder.Before(reference) {
+				reference = r.Reminder
+			}
+		}
+	}
+	if reference.IsZero() {
+		return
+	}
+
+	reference = reference.In(config.GetTimeZone())
+	after := reference
+	if now := time.Now(); now.After(after) {
+		after = now
+	}
+
+	next := rule.nextAfter(reference, after)
+	if next.IsZero() {
+		return
+	}
+	diff := next.Sub(reference)
+
+	if !oldTask.DueDate.IsZero() {
+		newTask.DueDate = oldTask.DueDate.Add(diff)
+	}
+	if !oldTask.StartDate.IsZero() {
+		newTask.StartDate = oldTask.StartDate.Add(diff)
+	}
+	if !oldTask.EndDate.IsZero() {
+		newTask.EndDate = oldTask.EndDate.Add(diff)
+	}
+
+	newTask.Reminders = oldTask.Reminders
+	for in, r := range oldTask.Reminders {
+		newTask.Reminders[in].Reminder = r.Reminder.Add(diff)
+	}
+
+	newTask.RepeatRule = oldTask.RepeatRule
+	if rule.Count > 1 {
+		rule.Count--
+		newTask.RepeatRule = rule.String()
+	}
+
+	newTask.Done = false
+}
+
 // This helper function updates the reminders, doneAt, start and end dates of the *old* task
 // and saves the new values in the newTask object.
 // We make a few assumptions here:
@@ -1279,12 +1584,14 @@ func setTaskDatesFromCurrentDateRepeat(oldTask, newTask *Task) {
 //  2. Because of 1., this functions should not be used to update values other than Done in the same go
 func updateDone(oldTask *Task, newTask *Task) {
 	if !oldTask.Done && newTask.Done {
-		switch oldTask.RepeatMode {
-		case TaskRepeatModeMonth:
+		switch {
+		case oldTask.RepeatRule != "":
+			setTaskDatesFromRepeatRule(oldTask, newTask)
+		case oldTask.RepeatMode == TaskRepeatModeMonth:
 			setTaskDatesMonthRepeat(oldTask, newTask)
-		case TaskRepeatModeFromCurrentDate:
+		case oldTask.RepeatMode == TaskRepeatModeFromCurrentDate:
 			setTaskDatesFromCurrentDateRepeat(oldTask, newTask)
-		case TaskRepeatModeDefault:
+		case oldTask.RepeatMode == TaskRepeatModeDefault:
 			setTaskDatesDefault(oldTask, newTask)
 		}
 
@@ -1384,7 +1691,11 @@ func (t *Task) updateReminders(s *xorm.Session, task *Task) (err error) {
 
 func updateTaskLastUpdated(s *xorm.Session, task *Task) error {
 	_, err := s.ID(task.ID).Cols("updated").Update(task)
-	return err
+	if err != nil {
+		return err
+	}
+
+	return recordTaskSyncChangeByTaskID(s, task.ID)
 }
 
 // Delete implements the delete method for a task
@@ -1460,6 +1771,40 @@ func (t *Task) Delete(s *xorm.Session, a web.Auth) (err error) {
 		return
 	}
 
+	// Delete all time entries
+	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskTimeEntry{})
+	if err != nil {
+		return
+	}
+
+	// Delete all custom field values
+	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskCustomFieldValue{})
+	if err != nil {
+		return
+	}
+
+	// Delete all buckets and positions of the task
+	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskBucket{})
+	if err != nil {
+		return
+	}
+	_, err = s.Where("task_id = ?", t.ID).Delete(&TaskPosition{})
+	if err != nil {
+		return
+	}
+
+	err = addHistoryEntries(s, a, &HistoryEntry{
+		EntityKind: HistoryEntityKindTask,
+		EntityID:   t.ID,
+		Action:     HistoryActionDeleted,
+		OldValue:   fullTask.Title,
+		TaskID:     t.ID,
+		ProjectID:  fullTask.ProjectID,
+	})
+	if err != nil {
+		return
+	}
+
 	doer, _ := user.GetFromAuth(a)
 	err = events.Dispatch(&TaskDeletedEvent{
 		Task: fullTask,
@@ -1469,6 +1814,11 @@ func (t *Task) Delete(s *xorm.Session, a web.Auth) (err error) {
 		return
 	}
 
+	err = recordTaskSyncChange(s, fullTask.ProjectID, fullTask, true)
+	if err != nil {
+		return
+	}
+
 	err = updateProjectLastUpdated(s, &Project{ID: t.ProjectID})
 	return
 }
@@ -1491,6 +1841,12 @@ func (t *Task) ReadOne(s *xorm.Session, a web.Auth) (err error) {
 	if err != nil {
 		return
 	}
+
+	err = addDefaultViewDataToTasks(s, []*Task{t})
+	if err != nil {
+		return
+	}
+
 	taskMap := make(map[int64]*Task, 1)
 	taskMap[t.ID] = t
 
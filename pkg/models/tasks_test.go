@@ -20,12 +20,15 @@ import (
 	"testing"
 	"time"
 
+	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/events"
 	"code.vikunja.io/api/pkg/user"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"xorm.io/builder"
+	"xorm.io/xorm"
 )
 
 func TestTask_Create(t *testing.T) {
@@ -65,7 +68,10 @@ func TestTask_Create(t *testing.T) {
 			"description":   "Lorem Ipsum Dolor",
 			"project_id":    1,
 			"created_by_id": 1,
-			"bucket_id":     1,
+		}, false)
+		db.AssertExists(t, "task_buckets", map[string]interface{}{
+			"task_id":   task.ID,
+			"bucket_id": 1,
 		}, false)
 
 		events.AssertDispatched(t, &TaskCreatedEvent{})
@@ -182,8 +188,8 @@ func TestTask_Create(t *testing.T) {
 		}
 		err := task.Create(s, usr)
 		assert.NoError(t, err)
-		db.AssertExists(t, "tasks", map[string]interface{}{
-			"id":        task.ID,
+		db.AssertExists(t, "task_buckets", map[string]interface{}{
+			"task_id":   task.ID,
 			"bucket_id": 22, // default bucket of project 6 but with a position of 2
 		}, false)
 	})
@@ -252,12 +258,11 @@ func TestTask_Update(t *testing.T) {
 		defer s.Close()
 
 		task := &Task{
-			ID:             4,
-			Title:          "test10000",
-			Description:    "Lorem Ipsum Dolor",
-			KanbanPosition: 10,
-			ProjectID:      1,
-			BucketID:       2, // Bucket 2 already has 3 tasks and a limit of 3
+			ID:          4,
+			Title:       "test10000",
+			Description: "Lorem Ipsum Dolor",
+			ProjectID:   1,
+			BucketID:    2, // Bucket 2 already has 3 tasks and a limit of 3
 		}
 		err := task.Update(s, u)
 		assert.NoError(t, err)
@@ -300,7 +305,10 @@ func TestTask_Update(t *testing.T) {
 			"done":       true,
 			"title":      "test",
 			"project_id": 1,
-			"bucket_id":  3,
+		}, false)
+		db.AssertExists(t, "task_buckets", map[string]interface{}{
+			"task_id":   1,
+			"bucket_id": 3,
 		}, false)
 	})
 	t.Run("moving a repeating task to the done bucket", func(t *testing.T) {
@@ -327,7 +335,10 @@ func TestTask_Update(t *testing.T) {
 			"done":       false,
 			"title":      "test updated",
 			"project_id": 1,
-			"bucket_id":  1,
+		}, false)
+		db.AssertExists(t, "task_buckets", map[string]interface{}{
+			"task_id":   28,
+			"bucket_id": 1,
 		}, false)
 	})
 	t.Run("default bucket when moving a task between projects", func(t *testing.T) {
@@ -364,8 +375,11 @@ func TestTask_Update(t *testing.T) {
 		assert.Equal(t, int64(3), task.BucketID)
 
 		db.AssertExists(t, "tasks", map[string]interface{}{
-			"id":        1,
-			"done":      true,
+			"id":   1,
+			"done": true,
+		}, false)
+		db.AssertExists(t, "task_buckets", map[string]interface{}{
+			"task_id":   1,
 			"bucket_id": 3,
 		}, false)
 	})
@@ -386,7 +400,10 @@ func TestTask_Update(t *testing.T) {
 		db.AssertExists(t, "tasks", map[string]interface{}{
 			"id":         1,
 			"project_id": 2,
-			"bucket_id":  4,
+		}, false)
+		db.AssertExists(t, "task_buckets", map[string]interface{}{
+			"task_id":   1,
+			"bucket_id": 4,
 		}, false)
 	})
 	t.Run("repeating tasks should not be moved to the done bucket", func(t *testing.T) {
@@ -407,11 +424,68 @@ func TestTask_Update(t *testing.T) {
 		assert.Equal(t, int64(1), task.BucketID)
 
 		db.AssertExists(t, "tasks", map[string]interface{}{
-			"id":        28,
-			"done":      false,
+			"id":   28,
+			"done": false,
+		}, false)
+		db.AssertExists(t, "task_buckets", map[string]interface{}{
+			"task_id":   28,
 			"bucket_id": 1,
 		}, false)
 	})
+	t.Run("moving a task into a filter bucket applies its move action", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		switchKanbanViewToFilterBuckets(t, s, 4, map[int64]string{
+			2: "priority >= 3",
+		})
+		_, err := s.Where("id = ?", 2).Cols("move_action").Update(&Bucket{MoveAction: &BucketMoveAction{Field: "priority", Value: "3"}})
+		assert.NoError(t, err)
+
+		task := &Task{
+			ID:        1,
+			Title:     "test",
+			ProjectID: 1,
+			BucketID:  2,
+		}
+		err = task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), task.Priority)
+		assert.Equal(t, int64(2), task.BucketID)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":       1,
+			"priority": 3,
+		}, false)
+		db.AssertMissing(t, "task_buckets", map[string]interface{}{
+			"task_id": 1,
+		})
+	})
+	t.Run("updating a task already in a filter bucket does not apply its move action", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		switchKanbanViewToFilterBuckets(t, s, 4, map[int64]string{
+			2: "priority >= 3",
+		})
+		_, err := s.Where("id = ?", 2).Cols("move_action").Update(&Bucket{MoveAction: &BucketMoveAction{Field: "priority", Value: "3"}})
+		assert.NoError(t, err)
+
+		task := &Task{
+			ID:        3,
+			Title:     "test",
+			ProjectID: 1,
+			Priority:  100,
+			BucketID:  2,
+		}
+		err = task.Update(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(100), task.Priority)
+	})
 	t.Run("moving a task between projects should give it a correct index", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
 		s := db.NewSession()
@@ -495,6 +569,122 @@ func TestTask_Update(t *testing.T) {
 		assert.NoError(t, err)
 		db.AssertCount(t, "task_reminders", builder.Eq{"task_id": 1}, 1)
 	})
+	t.Run("repeat rule", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:         1,
+			Title:      "test",
+			ProjectID:  1,
+			RepeatRule: "RRULE:freq=monthly;byday=2tu",
+		}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":          1,
+			"repeat_rule": "FREQ=MONTHLY;BYDAY=2TU",
+		}, false)
+	})
+	t.Run("repeat rule with only an interval in seconds", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:         1,
+			Title:      "test",
+			ProjectID:  1,
+			RepeatRule: "FREQ=SECONDLY;INTERVAL=3600",
+		}
+		err := task.Update(s, u)
+		assert.NoError(t, err)
+		err = s.Commit()
+		assert.NoError(t, err)
+
+		db.AssertExists(t, "tasks", map[string]interface{}{
+			"id":           1,
+			"repeat_after": 3600,
+		}, false)
+	})
+	t.Run("invalid repeat rule", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		task := &Task{
+			ID:         1,
+			Title:      "test",
+			ProjectID:  1,
+			RepeatRule: "FREQ=DAILY;BYHOUR=9",
+		}
+		err := task.Update(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidRepeatRule(err))
+	})
+	t.Run("dependencies", func(t *testing.T) {
+		enforceDependencies := func(t *testing.T, s *xorm.Session) {
+			_, err := s.Where("id = ?", 1).Cols("enforce_dependencies").Update(&Project{EnforceDependencies: true})
+			require.NoError(t, err)
+		}
+
+		t.Run("undone blocker", func(t *testing.T) {
+			db.LoadAndAssertFixtures(t)
+			s := db.NewSession()
+			defer s.Close()
+
+			enforceDependencies(t, s)
+			addTestTaskRelation(t, s, 3, 1, RelationKindBlocking)
+
+			task := &Task{
+				ID:        1,
+				Title:     "test",
+				ProjectID: 1,
+				Done:      true,
+			}
+			err := task.Update(s, u)
+			require.Error(t, err)
+			assert.True(t, IsErrTaskHasUndoneBlockers(err))
+			assert.Equal(t, []int64{3}, err.(ErrTaskHasUndoneBlockers).BlockerIDs)
+		})
+		t.Run("done blocker", func(t *testing.T) {
+			db.LoadAndAssertFixtures(t)
+			s := db.NewSession()
+			defer s.Close()
+
+			enforceDependencies(t, s)
+			addTestTaskRelation(t, s, 1, 2, RelationKindBlocked)
+
+			task := &Task{
+				ID:        1,
+				Title:     "test",
+				ProjectID: 1,
+				Done:      true,
+			}
+			err := task.Update(s, u)
+			require.NoError(t, err)
+		})
+		t.Run("not enforced", func(t *testing.T) {
+			db.LoadAndAssertFixtures(t)
+			s := db.NewSession()
+			defer s.Close()
+
+			addTestTaskRelation(t, s, 3, 1, RelationKindBlocking)
+
+			task := &Task{
+				ID:        1,
+				Title:     "test",
+				ProjectID: 1,
+				Done:      true,
+			}
+			err := task.Update(s, u)
+			require.NoError(t, err)
+		})
+	})
 	t.Run("update relative reminder when start_date changes", func(t *testing.T) {
 		db.LoadAndAssertFixtures(t)
 		s := db.NewSession()
@@ -908,6 +1098,129 @@ func TestUpdateDone(t *testing.T) {
 			})
 		})
 	})
+	t.Run("repeat rule", func(t *testing.T) {
+		date := func(month time.Month, day int) time.Time {
+			return time.Date(2100, month, day, 9, 30, 0, 0, config.GetTimeZone())
+		}
+
+		t.Run("every 2nd tuesday", func(t *testing.T) {
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=MONTHLY;BYDAY=2TU",
+				DueDate:    date(time.January, 12),
+				Reminders: []*TaskReminder{
+					{
+						Reminder: date(time.January, 11),
+					},
+				},
+			}
+			newTask := &Task{
+				Done: true,
+			}
+
+			updateDone(oldTask, newTask)
+
+			assert.Equal(t, date(time.February, 9), newTask.DueDate)
+			assert.Len(t, newTask.Reminders, 1)
+			assert.Equal(t, date(time.February, 8), newTask.Reminders[0].Reminder)
+			assert.Equal(t, "FREQ=MONTHLY;BYDAY=2TU", newTask.RepeatRule)
+			assert.False(t, newTask.Done)
+		})
+		t.Run("last weekday of month", func(t *testing.T) {
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+				DueDate:    date(time.January, 29),
+			}
+			newTask := &Task{
+				Done: true,
+			}
+
+			updateDone(oldTask, newTask)
+
+			assert.Equal(t, date(time.February, 26), newTask.DueDate)
+			assert.False(t, newTask.Done)
+		})
+		t.Run("start and end date keep their difference", func(t *testing.T) {
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+				StartDate:  date(time.January, 1),
+				EndDate:    date(time.January, 1).Add(2 * time.Hour),
+			}
+			newTask := &Task{
+				Done: true,
+			}
+
+			updateDone(oldTask, newTask)
+
+			assert.Equal(t, date(time.January, 4), newTask.StartDate)
+			assert.Equal(t, date(time.January, 4).Add(2*time.Hour), newTask.EndDate)
+			assert.False(t, newTask.Done)
+		})
+		t.Run("count is decreased", func(t *testing.T) {
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=DAILY;COUNT=3",
+				DueDate:    date(time.January, 1),
+			}
+			newTask := &Task{
+				Done: true,
+			}
+
+			updateDone(oldTask, newTask)
+
+			assert.Equal(t, date(time.January, 2), newTask.DueDate)
+			assert.Equal(t, "FREQ=DAILY;COUNT=2", newTask.RepeatRule)
+			assert.False(t, newTask.Done)
+		})
+		t.Run("last occurrence", func(t *testing.T) {
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=DAILY;COUNT=1",
+				DueDate:    date(time.January, 1),
+			}
+			newTask := &Task{
+				Done: true,
+			}
+
+			updateDone(oldTask, newTask)
+
+			assert.True(t, newTask.DueDate.IsZero())
+			assert.True(t, newTask.Done)
+		})
+		t.Run("until passed", func(t *testing.T) {
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=DAILY;UNTIL=21000101T120000Z",
+				DueDate:    date(time.January, 1),
+			}
+			newTask := &Task{
+				Done: true,
+			}
+
+			updateDone(oldTask, newTask)
+
+			assert.True(t, newTask.DueDate.IsZero())
+			assert.True(t, newTask.Done)
+		})
+		t.Run("due date in the past", func(t *testing.T) {
+			oldTask := &Task{
+				Done:       false,
+				RepeatRule: "FREQ=WEEKLY;BYDAY=MO,WE,FR",
+				DueDate:    time.Date(2018, time.January, 1, 9, 30, 0, 0, config.GetTimeZone()),
+			}
+			newTask := &Task{
+				Done: true,
+			}
+
+			updateDone(oldTask, newTask)
+
+			assert.True(t, newTask.DueDate.After(time.Now()))
+			assert.Contains(t, []time.Weekday{time.Monday, time.Wednesday, time.Friday}, newTask.DueDate.Weekday())
+			assert.False(t, newTask.Done)
+		})
+	})
 }
 
 func TestTask_ReadOne(t *testing.T) {
@@ -69,6 +69,17 @@ func (tm *TeamMember) Create(s *xorm.Session, a web.Auth) (err error) {
 		return err
 	}
 
+	err = addHistoryEntries(s, a, &HistoryEntry{
+		EntityKind: HistoryEntityKindTeamMember,
+		EntityID:   tm.UserID,
+		Action:     HistoryActionCreated,
+		NewValue:   formatHistoryValue(tm.Admin),
+		TeamID:     tm.TeamID,
+	})
+	if err != nil {
+		return err
+	}
+
 	doer, _ := user2.GetFromAuth(a)
 	return events.Dispatch(&TeamMemberAddedEvent{
 		Team:   team,
@@ -88,7 +99,7 @@ func (tm *TeamMember) Create(s *xorm.Session, a web.Auth) (err error) {
 // @Success 200 {object} models.Message "The user was successfully removed from the team."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /teams/{id}/members/{userID} [delete]
-func (tm *TeamMember) Delete(s *xorm.Session, _ web.Auth) (err error) {
+func (tm *TeamMember) Delete(s *xorm.Session, a web.Auth) (err error) {
 
 	total, err := s.Where("team_id = ?", tm.TeamID).Count(&TeamMember{})
 	if err != nil {
@@ -106,7 +117,16 @@ func (tm *TeamMember) Delete(s *xorm.Session, _ web.Auth) (err error) {
 	tm.UserID = user.ID
 
 	_, err = s.Where("team_id = ? AND user_id = ?", tm.TeamID, tm.UserID).Delete(&TeamMember{})
-	return
+	if err != nil {
+		return
+	}
+
+	return addHistoryEntries(s, a, &HistoryEntry{
+		EntityKind: HistoryEntityKindTeamMember,
+		EntityID:   tm.UserID,
+		Action:     HistoryActionDeleted,
+		TeamID:     tm.TeamID,
+	})
 }
 
 // Update toggles a team member's admin status
@@ -120,7 +140,7 @@ func (tm *TeamMember) Delete(s *xorm.Session, _ web.Auth) (err error) {
 // @Success 200 {object} models.Message "The member right was successfully changed."
 // @Failure 500 {object} models.Message "Internal error"
 // @Router /teams/{id}/members/{userID}/admin [post]
-func (tm *TeamMember) Update(s *xorm.Session, _ web.Auth) (err error) {
+func (tm *TeamMember) Update(s *xorm.Session, a web.Auth) (err error) {
 	// Find the numeric user id
 	user, err := user2.GetUserByUsername(s, tm.Username)
 	if err != nil {
@@ -143,6 +163,18 @@ func (tm *TeamMember) Update(s *xorm.Session, _ web.Auth) (err error) {
 		Where("team_id = ? AND user_id = ?", tm.TeamID, tm.UserID).
 		Cols("admin").
 		Update(ttm)
+	if err != nil {
+		return err
+	}
 	tm.Admin = ttm.Admin // Since we're returning the updated rights object
-	return
+
+	return addHistoryEntries(s, a, &HistoryEntry{
+		EntityKind: HistoryEntityKindTeamMember,
+		EntityID:   tm.UserID,
+		Action:     HistoryActionUpdated,
+		Field:      "admin",
+		OldValue:   formatHistoryValue(!ttm.Admin),
+		NewValue:   formatHistoryValue(ttm.Admin),
+		TeamID:     tm.TeamID,
+	})
 }
@@ -145,18 +145,6 @@ func CreateTypesenseCollections() error {
 				Name: "updated",
 				Type: "int64", // unix timestamp
 			},
-			{
-				Name: "bucket_id",
-				Type: "int64",
-			},
-			{
-				Name: "position",
-				Type: "float",
-			},
-			{
-				Name: "kanban_position",
-				Type: "float",
-			},
 			{
 				Name: "created_by_id",
 				Type: "int64",
@@ -307,9 +295,6 @@ type typesenseTask struct {
 	CoverImageAttachmentID int64       `json:"cover_image_attachment_id"`
 	Created                int64       `json:"created"`
 	Updated                int64       `json:"updated"`
-	BucketID               int64       `json:"bucket_id"`
-	Position               float64     `json:"position"`
-	KanbanPosition         float64     `json:"kanban_position"`
 	CreatedByID            int64       `json:"created_by_id"`
 	Reminders              interface{} `json:"reminders"`
 	Assignees              interface{} `json:"assignees"`
@@ -341,9 +326,6 @@ func convertTaskToTypesenseTask(task *Task) *typesenseTask {
 		CoverImageAttachmentID: task.CoverImageAttachmentID,
 		Created:                task.Created.UTC().Unix(),
 		Updated:                task.Updated.UTC().Unix(),
-		BucketID:               task.BucketID,
-		Position:               task.Position,
-		KanbanPosition:         task.KanbanPosition,
 		CreatedByID:            task.CreatedByID,
 		Reminders:              task.Reminders,
 		Assignees:              task.Assignees,
@@ -65,6 +65,18 @@ func SetupTests() {
 		"subscriptions",
 		"favorites",
 		"api_tokens",
+		"webhooks",
+		"task_time_entries",
+		"custom_fields",
+		"task_custom_field_values",
+		"project_views",
+		"task_buckets",
+		"task_positions",
+		"automation_rules",
+		"automation_rule_executions",
+		"history_entries",
+		"task_templates",
+		"task_sync_changes",
 	)
 	if err != nil {
 		log.Fatal(err)
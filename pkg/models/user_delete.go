@@ -145,6 +145,11 @@ func DeleteUser(s *xorm.Session, u *user.User) (err error) {
 		}
 	}
 
+	_, err = s.Where("owner_id = ?", u.ID).Delete(&TaskTemplate{})
+	if err != nil {
+		return err
+	}
+
 	_, err = s.Where("id = ?", u.ID).Delete(&user.User{})
 	if err != nil {
 		return err
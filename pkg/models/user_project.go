@@ -114,3 +114,129 @@ func ListUsersFromProject(s *xorm.Session, l *Project, search string) (users []*
 	})
 	return
 }
+
+// ListUsersSharingProjectsWith returns all users who have access to at least one of the projects the user has
+// access to, regardless of how they got it, and all users who are in a team with them.
+// The user themselves and disabled users are not included.
+func ListUsersSharingProjectsWith(s *xorm.Session, u *user.User) (users []*user.User, err error) {
+	projects, _, _, err := getRawProjectsForUser(s, &projectOptions{
+		user:        u,
+		page:        -1,
+		getArchived: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uidmap := make(map[int64]bool)
+	knownProjects := make(map[int64]bool, len(projects))
+	projectIDs := make([]int64, 0, len(projects))
+	for _, p := range projects {
+		// Skip pseudo projects like favorites
+		if p.ID <= 0 {
+			continue
+		}
+		knownProjects[p.ID] = true
+		projectIDs = append(projectIDs, p.ID)
+		uidmap[p.OwnerID] = true
+	}
+
+	// Everyone with access to a parent project has access to its child projects as well.
+	// The projects of the user don't contain the parents they can't access, so these are looked up again.
+	parentIDs := []int64{}
+	if len(projectIDs) > 0 {
+		allParentIDs := []int64{}
+		err = s.
+			Table("projects").
+			Cols("parent_project_id").
+			In("id", projectIDs).
+			And("parent_project_id > 0").
+			Find(&allParentIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range allParentIDs {
+			if !knownProjects[id] {
+				parentIDs = append(parentIDs, id)
+			}
+		}
+	}
+	for len(parentIDs) > 0 {
+		parents := []*Project{}
+		err = s.In("id", parentIDs).Find(&parents)
+		if err != nil {
+			return nil, err
+		}
+
+		parentIDs = []int64{}
+		for _, p := range parents {
+			if knownProjects[p.ID] {
+				continue
+			}
+			knownProjects[p.ID] = true
+			projectIDs = append(projectIDs, p.ID)
+			uidmap[p.OwnerID] = true
+			if p.ParentProjectID != 0 && !knownProjects[p.ParentProjectID] {
+				parentIDs = append(parentIDs, p.ParentProjectID)
+			}
+		}
+	}
+
+	userIDs := []int64{}
+	if len(projectIDs) > 0 {
+		projectUserIDs := []int64{}
+		err = s.
+			Table("users_projects").
+			Cols("user_id").
+			In("project_id", projectIDs).
+			Find(&projectUserIDs)
+		if err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, projectUserIDs...)
+
+		teamUserIDs := []int64{}
+		err = s.
+			Table("team_members").
+			Cols("user_id").
+			In("team_id", builder.Select("team_id").From("team_projects").Where(builder.In("project_id", projectIDs))).
+			Find(&teamUserIDs)
+		if err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, teamUserIDs...)
+	}
+
+	teamMemberIDs := []int64{}
+	err = s.
+		Table("team_members").
+		Cols("user_id").
+		In("team_id", builder.Select("team_id").From("team_members").Where(builder.Eq{"user_id": u.ID})).
+		Find(&teamMemberIDs)
+	if err != nil {
+		return nil, err
+	}
+	userIDs = append(userIDs, teamMemberIDs...)
+
+	for _, id := range userIDs {
+		uidmap[id] = true
+	}
+	delete(uidmap, u.ID)
+
+	if len(uidmap) == 0 {
+		return []*user.User{}, nil
+	}
+
+	uids := make([]int64, 0, len(uidmap))
+	for id := range uidmap {
+		uids = append(uids, id)
+	}
+
+	users = []*user.User{}
+	err = s.
+		In("id", uids).
+		And("status != ?", user.StatusDisabled).
+		OrderBy("id asc").
+		Find(&users)
+	return
+}
@@ -21,6 +21,7 @@ import (
 
 	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/user"
+	"github.com/stretchr/testify/assert"
 	"gopkg.in/d4l3k/messagediff.v1"
 )
 
@@ -246,3 +247,33 @@ func TestListUsersFromProject(t *testing.T) {
 		})
 	}
 }
+
+func TestListUsersSharingProjectsWith(t *testing.T) {
+	getIDs := func(users []*user.User) (ids []int64) {
+		ids = []int64{}
+		for _, u := range users {
+			ids = append(ids, u.ID)
+		}
+		return
+	}
+
+	t.Run("shared project with parent", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		users, err := ListUsersSharingProjectsWith(s, &user.User{ID: 15})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{1, 6}, getIDs(users))
+	})
+	t.Run("does not include the user themselves", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		users, err := ListUsersSharingProjectsWith(s, &user.User{ID: 6})
+		assert.NoError(t, err)
+		assert.NotContains(t, getIDs(users), int64(6))
+		assert.Contains(t, getIDs(users), int64(15))
+	})
+}
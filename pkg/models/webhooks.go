@@ -0,0 +1,430 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/events"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/version"
+
+	"code.vikunja.io/web"
+	"github.com/labstack/echo/v4"
+	"xorm.io/xorm"
+)
+
+// Webhook represents a target url which is called with a json payload whenever one of the configured events
+// happens in the project the webhook belongs to.
+type Webhook struct {
+	// The unique, numeric id of this webhook.
+	ID int64 `xorm:"bigint autoincr not null unique pk" json:"id" param:"webhook"`
+	// The webhook target url. All configured events will be POSTed to this url.
+	TargetURL string `xorm:"not null" valid:"required,url" minLength:"1" json:"target_url"`
+	// The events this webhook is subscribed to. Check /webhooks/events for a list of all available events.
+	Events []string `xorm:"JSON not null" valid:"required" json:"events"`
+	// The project this webhook belongs to.
+	ProjectID int64 `xorm:"bigint not null index" json:"project_id" param:"project"`
+	// If provided, webhook requests will be signed using HMAC. Check out the docs about how to use this: https://vikunja.io/docs/webhooks/#signing
+	// The secret is only returned when the webhook is created.
+	Secret string `xorm:"null" json:"secret,omitempty"`
+
+	// The user who initially created the webhook target.
+	CreatedBy   *user.User `xorm:"-" json:"created_by" valid:"-"`
+	CreatedByID int64      `xorm:"bigint not null" json:"-"`
+
+	// A timestamp when this webhook target was created. You cannot change this value.
+	Created time.Time `xorm:"created not null" json:"created"`
+	// A timestamp when this webhook target was last updated. You cannot change this value.
+	Updated time.Time `xorm:"updated not null" json:"updated"`
+
+	web.CRUDable `xorm:"-" json:"-"`
+	web.Rights   `xorm:"-" json:"-"`
+}
+
+// TableName returns the table name for webhooks
+func (w *Webhook) TableName() string {
+	return "webhooks"
+}
+
+// WebhookPayload is the body which is POSTed to a webhook target url
+type WebhookPayload struct {
+	EventName string      `json:"event_name"`
+	Time      time.Time   `json:"time"`
+	Data      interface{} `json:"data"`
+}
+
+// webhookRetryInitialInterval is the time to wait before the first retry of a failed webhook request.
+// The interval doubles with every following retry.
+var webhookRetryInitialInterval = time.Second
+
+// webhookSendSlots limits how many webhook payloads are sent at the same time
+var webhookSendSlots chan struct{}
+var webhookSendSlotsOnce sync.Once
+
+// acquireWebhookSendSlot blocks until less than webhooks.maxconcurrentrequests payloads are being sent.
+// Every acquired slot has to be released with releaseWebhookSendSlot once the payload was sent.
+func acquireWebhookSendSlot() {
+	webhookSendSlotsOnce.Do(func() {
+		limit := config.WebhooksMaxConcurrentRequests.GetInt()
+		if limit < 1 {
+			limit = 1
+		}
+		webhookSendSlots = make(chan struct{}, limit)
+	})
+	webhookSendSlots <- struct{}{}
+}
+
+func releaseWebhookSendSlot() {
+	<-webhookSendSlots
+}
+
+var availableWebhookEvents map[string]bool
+var availableWebhookEventsLock *sync.Mutex
+
+func init() {
+	availableWebhookEvents = make(map[string]bool)
+	availableWebhookEventsLock = &sync.Mutex{}
+}
+
+// RegisterEventForWebhook makes an event available for webhooks and registers the listener which
+// sends the event payload to all webhooks subscribed to it.
+func RegisterEventForWebhook(event events.Event) {
+	availableWebhookEventsLock.Lock()
+	defer availableWebhookEventsLock.Unlock()
+
+	availableWebhookEvents[event.Name()] = true
+	events.RegisterListener(event.Name(), &WebhookListener{
+		EventName: event.Name(),
+	})
+}
+
+// GetAvailableWebhookEvents returns a list of all events webhooks can subscribe to
+// @Summary Get all possible webhook events
+// @Description Get all possible webhook events to use when creating or updating a webhook target.
+// @tags webhooks
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {array} string "The list of all possible webhook events"
+// @Failure 500 {object} models.Message "Internal server error"
+// @Router /webhooks/events [get]
+func GetAvailableWebhookEvents(c echo.Context) error {
+	availableWebhookEventsLock.Lock()
+	defer availableWebhookEventsLock.Unlock()
+
+	evts := []string{}
+	for e := range availableWebhookEvents {
+		evts = append(evts, e)
+	}
+	sort.Strings(evts)
+
+	return c.JSON(http.StatusOK, evts)
+}
+
+// validateWebhookTarget makes sure the target is a http url. Targets with a host name are checked again
+// when the request is sent, after the name was resolved.
+func validateWebhookTarget(targetURL string) error {
+	u, err := url.Parse(targetURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return &ErrWebhookTargetNotAllowed{TargetURL: targetURL}
+	}
+
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !isAllowedWebhookIP(ip) {
+		return &ErrWebhookTargetNotAllowed{TargetURL: targetURL}
+	}
+
+	return nil
+}
+
+// isAllowedWebhookIP prevents webhooks from being used to reach services in the internal network of the server
+func isAllowedWebhookIP(ip net.IP) bool {
+	if config.WebhooksAllowNonRoutableIPs.GetBool() {
+		return true
+	}
+
+	return !ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified()
+}
+
+// webhookClient checks the address of every connection it opens, this also covers redirects
+// and host names resolving to internal addresses.
+var webhookClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout: 30 * time.Second,
+			Control: func(_, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || !isAllowedWebhookIP(ip) {
+					return fmt.Errorf("webhook target address %s is not allowed", address)
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+}
+
+func validateWebhookEvents(evts []string) error {
+	availableWebhookEventsLock.Lock()
+	defer availableWebhookEventsLock.Unlock()
+
+	for _, event := range evts {
+		if _, has := availableWebhookEvents[event]; !has {
+			return &ErrInvalidWebhookEvent{EventName: event}
+		}
+	}
+
+	return nil
+}
+
+// Create creates a webhook target
+// @Summary Create a webhook target
+// @Description Create a webhook target which receives POST requests about specified events from a project.
+// @tags webhooks
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Project ID"
+// @Param webhook body models.Webhook true "The webhook target object with required fields"
+// @Success 200 {object} models.Webhook "The created webhook target."
+// @Failure 400 {object} web.HTTPError "Invalid webhook object provided."
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the project."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{id}/webhooks [put]
+func (w *Webhook) Create(s *xorm.Session, a web.Auth) (err error) {
+	if err := validateWebhookEvents(w.Events); err != nil {
+		return err
+	}
+	if err := validateWebhookTarget(w.TargetURL); err != nil {
+		return err
+	}
+
+	w.ID = 0
+	w.CreatedByID = a.GetID()
+	_, err = s.Insert(w)
+	if err != nil {
+		return err
+	}
+
+	w.CreatedBy, err = user.GetUserByID(s, a.GetID())
+	return
+}
+
+// ReadAll returns all webhook targets for a project
+// @Summary Get all api webhook targets for the specified project
+// @Description Get all api webhook targets for the specified project.
+// @tags webhooks
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param page query int false "The page number. Used for pagination. If not provided, the first page of results is returned."
+// @Param per_page query int false "The maximum number of items per bucket per page. This parameter is limited by the configured maximum of items per page."
+// @Param id path int true "Project ID"
+// @Success 200 {array} models.Webhook "The list of all webhook targets"
+// @Failure 403 {object} web.HTTPError "The user does not have admin access to the project."
+// @Failure 500 {object} models.Message "Internal server error"
+// @Router /projects/{id}/webhooks [get]
+func (w *Webhook) ReadAll(s *xorm.Session, a web.Auth, _ string, page int, perPage int) (result interface{}, resultCount int, numberOfTotalItems int64, err error) {
+	can, err := w.canDoWebhook(s, a)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !can {
+		return nil, 0, 0, ErrGenericForbidden{}
+	}
+
+	ws := []*Webhook{}
+	err = s.
+		Where("project_id = ?", w.ProjectID).
+		Limit(getLimitFromPageIndex(page, perPage)).
+		Find(&ws)
+	if err != nil {
+		return
+	}
+
+	userIDs := []int64{}
+	for _, webhook := range ws {
+		userIDs = append(userIDs, webhook.CreatedByID)
+	}
+
+	users, err := user.GetUsersByIDs(s, userIDs)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	for _, webhook := range ws {
+		webhook.CreatedBy = users[webhook.CreatedByID]
+		webhook.Secret = ""
+	}
+
+	total, err := s.Where("project_id = ?", w.ProjectID).Count(&Webhook{})
+	return ws, len(ws), total, err
+}
+
+// Update updates a project webhook target
+// @Summary Change a webhook target's events.
+// @Description Change a webhook target's events or target url. The secret can't be changed after the webhook was created.
+// @tags webhooks
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Project ID"
+// @Param webhookID path int true "Webhook ID"
+// @Param webhook body models.Webhook true "The webhook target object with required fields"
+// @Success 200 {object} models.Webhook "Updated webhook target"
+// @Failure 400 {object} web.HTTPError "Invalid webhook object provided."
+// @Failure 404 {object} web.HTTPError "The webhook target does not exist"
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{id}/webhooks/{webhookID} [post]
+func (w *Webhook) Update(s *xorm.Session, _ web.Auth) (err error) {
+	if err := validateWebhookEvents(w.Events); err != nil {
+		return err
+	}
+	if err := validateWebhookTarget(w.TargetURL); err != nil {
+		return err
+	}
+
+	_, err = s.
+		Where("id = ?", w.ID).
+		Cols("events", "target_url").
+		Update(w)
+	w.Secret = ""
+	return
+}
+
+// Delete deletes a project webhook target
+// @Summary Deletes an existing webhook target
+// @Description Delete any of the project's webhook targets.
+// @tags webhooks
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Project ID"
+// @Param webhookID path int true "Webhook ID"
+// @Success 200 {object} models.Message "Successfully deleted."
+// @Failure 404 {object} web.HTTPError "The webhook target does not exist."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /projects/{id}/webhooks/{webhookID} [delete]
+func (w *Webhook) Delete(s *xorm.Session, _ web.Auth) (err error) {
+	_, err = s.Where("id = ?", w.ID).Delete(&Webhook{})
+	return
+}
+
+func getWebhooksForProjectAndEvent(s *xorm.Session, projectID int64, eventName string) (ws []*Webhook, err error) {
+	all := []*Webhook{}
+	err = s.Where("project_id = ?", projectID).Find(&all)
+	if err != nil {
+		return
+	}
+
+	for _, w := range all {
+		for _, e := range w.Events {
+			if e == eventName {
+				ws = append(ws, w)
+				break
+			}
+		}
+	}
+
+	return
+}
+
+func getWebhookHMAC(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// sendWebhookPayload sends the payload to the webhook target. Failed requests are retried with an
+// exponential backoff up to the configured number of retries, so this blocks until all retries are done.
+func (w *Webhook) sendWebhookPayload(p *WebhookPayload) (err error) {
+	payload, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	interval := webhookRetryInitialInterval
+	maxRetries := config.WebhooksMaxRetries.GetInt()
+	for attempt := 0; ; attempt++ {
+		err = w.doSendWebhookRequest(payload)
+		if err == nil {
+			log.Debugf("Sent webhook payload for webhook %d for event %s", w.ID, p.EventName)
+			return nil
+		}
+
+		if attempt >= maxRetries {
+			return err
+		}
+
+		log.Debugf("Could not send webhook payload for webhook %d for event %s, retrying in %s: %s", w.ID, p.EventName, interval, err)
+		time.Sleep(interval)
+		interval *= 2
+	}
+}
+
+func (w *Webhook) doSendWebhookRequest(payload []byte) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.WebhooksTimeoutSeconds.GetInt())*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.TargetURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("User-Agent", "Vikunja/"+version.Version)
+	if w.Secret != "" {
+		req.Header.Add("X-Vikunja-Signature", getWebhookHMAC(w.Secret, payload))
+	}
+
+	res, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook target %s responded with status %d", w.TargetURL, res.StatusCode)
+	}
+
+	return nil
+}
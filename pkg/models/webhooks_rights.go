@@ -0,0 +1,62 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"code.vikunja.io/web"
+	"xorm.io/xorm"
+)
+
+// CanCreate checks if a user can create a webhook for a project
+func (w *Webhook) CanCreate(s *xorm.Session, a web.Auth) (bool, error) {
+	return w.canDoWebhook(s, a)
+}
+
+// CanUpdate checks if a user can update a webhook
+func (w *Webhook) CanUpdate(s *xorm.Session, a web.Auth) (bool, error) {
+	return w.canDoWebhook(s, a)
+}
+
+// CanDelete checks if a user can delete a webhook
+func (w *Webhook) CanDelete(s *xorm.Session, a web.Auth) (bool, error) {
+	return w.canDoWebhook(s, a)
+}
+
+func (w *Webhook) canDoWebhook(s *xorm.Session, a web.Auth) (bool, error) {
+	// Link shares can't manage webhooks
+	if _, is := a.(*LinkSharing); is {
+		return false, nil
+	}
+
+	// Make sure the webhook actually belongs to the project from the url
+	if w.ID > 0 {
+		existing := &Webhook{}
+		has, err := s.Where("id = ?", w.ID).Get(existing)
+		if err != nil {
+			return false, err
+		}
+		if !has {
+			return false, &ErrWebhookDoesNotExist{WebhookID: w.ID}
+		}
+		if existing.ProjectID != w.ProjectID {
+			return false, nil
+		}
+	}
+
+	p := &Project{ID: w.ProjectID}
+	return p.IsAdmin(s, a)
+}
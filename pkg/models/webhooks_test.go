@@ -0,0 +1,226 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package models
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/user"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook_ReadAll(t *testing.T) {
+	t.Run("normal", func(t *testing.T) {
+		u := &user.User{ID: 1}
+		s := db.NewSession()
+		defer s.Close()
+		db.LoadAndAssertFixtures(t)
+
+		w := &Webhook{ProjectID: 1}
+		result, count, total, err := w.ReadAll(s, u, "", 1, 50)
+		assert.NoError(t, err)
+		ws, is := result.([]*Webhook)
+		assert.True(t, is)
+		assert.Len(t, ws, 1)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, int64(1), ws[0].ID)
+		assert.Equal(t, int64(1), ws[0].CreatedBy.ID)
+	})
+	t.Run("does not return the secret", func(t *testing.T) {
+		u := &user.User{ID: 1}
+		s := db.NewSession()
+		defer s.Close()
+		db.LoadAndAssertFixtures(t)
+
+		_, err := s.Where("id = ?", 1).Cols("secret").Update(&Webhook{Secret: "secret"})
+		assert.NoError(t, err)
+
+		w := &Webhook{ProjectID: 1}
+		result, _, _, err := w.ReadAll(s, u, "", 1, 50)
+		assert.NoError(t, err)
+		ws := result.([]*Webhook)
+		assert.Len(t, ws, 1)
+		assert.Empty(t, ws[0].Secret)
+	})
+	t.Run("no admin access", func(t *testing.T) {
+		u := &user.User{ID: 1}
+		s := db.NewSession()
+		defer s.Close()
+		db.LoadAndAssertFixtures(t)
+
+		w := &Webhook{ProjectID: 2}
+		_, _, _, err := w.ReadAll(s, u, "", 1, 50)
+		assert.Error(t, err)
+		assert.IsType(t, ErrGenericForbidden{}, err)
+	})
+}
+
+func TestWebhook_Create(t *testing.T) {
+	RegisterEventForWebhook(&TaskCreatedEvent{})
+
+	t.Run("normal", func(t *testing.T) {
+		u := &user.User{ID: 1}
+		s := db.NewSession()
+		defer s.Close()
+		db.LoadAndAssertFixtures(t)
+
+		w := &Webhook{
+			ProjectID: 1,
+			TargetURL: "https://example.com",
+			Events:    []string{"task.created"},
+		}
+		err := w.Create(s, u)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), w.CreatedByID)
+		err = s.Commit()
+		assert.NoError(t, err)
+		db.AssertExists(t, "webhooks", map[string]interface{}{
+			"id":            w.ID,
+			"project_id":    1,
+			"target_url":    "https://example.com",
+			"created_by_id": 1,
+		}, false)
+	})
+	t.Run("internal target", func(t *testing.T) {
+		u := &user.User{ID: 1}
+		s := db.NewSession()
+		defer s.Close()
+		db.LoadAndAssertFixtures(t)
+
+		for _, target := range []string{"http://127.0.0.1:8080", "http://192.168.1.1", "http://[::1]", "http://169.254.169.254/latest", "file:///etc/passwd"} {
+			w := &Webhook{
+				ProjectID: 1,
+				TargetURL: target,
+				Events:    []string{"task.created"},
+			}
+			err := w.Create(s, u)
+			assert.Error(t, err, target)
+			assert.True(t, IsErrWebhookTargetNotAllowed(err), target)
+		}
+	})
+	t.Run("invalid event", func(t *testing.T) {
+		u := &user.User{ID: 1}
+		s := db.NewSession()
+		defer s.Close()
+		db.LoadAndAssertFixtures(t)
+
+		w := &Webhook{
+			ProjectID: 1,
+			TargetURL: "https://example.com",
+			Events:    []string{"task.lorem"},
+		}
+		err := w.Create(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidWebhookEvent(err))
+	})
+}
+
+func TestWebhook_CanDelete(t *testing.T) {
+	t.Run("own project", func(t *testing.T) {
+		u := &user.User{ID: 1}
+		s := db.NewSession()
+		defer s.Close()
+		db.LoadAndAssertFixtures(t)
+
+		w := &Webhook{ID: 1, ProjectID: 1}
+		can, err := w.CanDelete(s, u)
+		assert.NoError(t, err)
+		assert.True(t, can)
+	})
+	t.Run("webhook of another project", func(t *testing.T) {
+		u := &user.User{ID: 1}
+		s := db.NewSession()
+		defer s.Close()
+		db.LoadAndAssertFixtures(t)
+
+		w := &Webhook{ID: 2, ProjectID: 1}
+		can, err := w.CanDelete(s, u)
+		assert.NoError(t, err)
+		assert.False(t, can)
+	})
+	t.Run("nonexisting", func(t *testing.T) {
+		u := &user.User{ID: 1}
+		s := db.NewSession()
+		defer s.Close()
+		db.LoadAndAssertFixtures(t)
+
+		w := &Webhook{ID: 9999, ProjectID: 1}
+		_, err := w.CanDelete(s, u)
+		assert.Error(t, err)
+		assert.True(t, IsErrWebhookDoesNotExist(err))
+	})
+}
+
+func TestWebhook_sendWebhookPayload(t *testing.T) {
+	webhookRetryInitialInterval = time.Millisecond
+
+	t.Run("internal target", func(t *testing.T) {
+		calls := 0
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+		}))
+		defer srv.Close()
+
+		w := &Webhook{TargetURL: srv.URL}
+		err := w.doSendWebhookRequest([]byte("{}"))
+		assert.Error(t, err)
+		assert.Equal(t, 0, calls)
+	})
+
+	// The test server listens on localhost
+	config.WebhooksAllowNonRoutableIPs.Set(true)
+	defer config.WebhooksAllowNonRoutableIPs.Set(false)
+
+	t.Run("signed payload", func(t *testing.T) {
+		var body []byte
+		var signature string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ = io.ReadAll(r.Body)
+			signature = r.Header.Get("X-Vikunja-Signature")
+		}))
+		defer srv.Close()
+
+		w := &Webhook{TargetURL: srv.URL, Secret: "secret"}
+		err := w.sendWebhookPayload(&WebhookPayload{EventName: "task.created"})
+		assert.NoError(t, err)
+		assert.Contains(t, string(body), `"event_name":"task.created"`)
+		assert.Equal(t, getWebhookHMAC("secret", body), signature)
+	})
+	t.Run("retries failed requests", func(t *testing.T) {
+		calls := 0
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls < 3 {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}))
+		defer srv.Close()
+
+		w := &Webhook{TargetURL: srv.URL}
+		err := w.sendWebhookPayload(&WebhookPayload{EventName: "task.created"})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+}
@@ -65,11 +65,11 @@ func (p *Provider) GetAvatar(u *user.User, size int64) (avatar []byte, mimeType
 
 	// If we get this far, the avatar is either not cached at all or not in this size
 	f := &files.File{ID: u.AvatarFileID}
-	if err := f.LoadFileByID(); err != nil {
+	if err := f.LoadFileMetaByID(); err != nil {
 		return nil, "", err
 	}
 
-	if err := f.LoadFileMetaByID(); err != nil {
+	if err := f.LoadFileByID(); err != nil {
 		return nil, "", err
 	}
 
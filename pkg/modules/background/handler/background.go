@@ -213,7 +213,7 @@ func (bp *BackgroundProvider) UploadBackground(c echo.Context) error {
 
 func SaveBackgroundFile(s *xorm.Session, auth web.Auth, project *models.Project, srcf io.ReadSeeker, filename string, filesize uint64) (err error) {
 	_, _ = srcf.Seek(0, io.SeekStart)
-	f, err := files.Create(srcf, filename, filesize, auth)
+	f, err := files.CreateForProject(srcf, filename, filesize, auth, project.ID)
 	if err != nil {
 		return err
 	}
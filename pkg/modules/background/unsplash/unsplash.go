@@ -283,7 +283,7 @@ func (p *Provider) Set(s *xorm.Session, image *background.Image, project *models
 	log.Debugf("Pinged unsplash download endpoint for photo %s", image.ID)
 
 	// Save it as a file in vikunja
-	file, err := files.Create(resp.Body, "", 0, auth)
+	file, err := files.CreateForProject(resp.Body, "", 0, auth, project.ID)
 	if err != nil {
 		return
 	}
@@ -178,6 +178,15 @@ func Restore(filename string) error {
 		}
 
 		f := &files.File{ID: id}
+		// The file is stored under its hash if it has one
+		err = f.LoadFileMetaByID()
+		if files.IsErrFileDoesNotExist(err) {
+			log.Warningf("Not restoring file %s because it does not exist in the database", i)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("could not load file %s: %w", i, err)
+		}
 
 		fc, err := file.Open()
 		if err != nil {
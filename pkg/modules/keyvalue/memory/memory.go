@@ -19,20 +19,23 @@ package memory
 import (
 	"reflect"
 	"sync"
+	"time"
 
 	e "code.vikunja.io/api/pkg/modules/keyvalue/error"
 )
 
 // Storage is the memory implementation of a storage backend
 type Storage struct {
-	store map[string]interface{}
-	mutex sync.Mutex
+	store    map[string]interface{}
+	expiries map[string]time.Time
+	mutex    sync.Mutex
 }
 
 // NewStorage creates a new memory storage
 func NewStorage() *Storage {
 	s := &Storage{}
 	s.store = make(map[string]interface{})
+	s.expiries = make(map[string]time.Time)
 	return s
 }
 
@@ -41,15 +44,42 @@ func (s *Storage) Put(key string, value interface{}) (err error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	delete(s.expiries, key)
+	s.put(key, value)
+	return nil
+}
+
+// PutWithTTL puts a value into the memory storage which expires after ttl
+func (s *Storage) PutWithTTL(key string, value interface{}, ttl time.Duration) (err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.removeExpired()
+	s.expiries[key] = time.Now().Add(ttl)
+	s.put(key, value)
+	return nil
+}
+
+// removeExpired removes all values whose ttl has passed so they don't pile up in memory
+func (s *Storage) removeExpired() {
+	now := time.Now()
+	for key, expiry := range s.expiries {
+		if now.After(expiry) {
+			delete(s.store, key)
+			delete(s.expiries, key)
+		}
+	}
+}
+
+func (s *Storage) put(key string, value interface{}) {
 	val := reflect.ValueOf(value)
 	// Make sure to store the underlying value when value is a pointer to a value
 	if val.Kind() == reflect.Ptr {
 		s.store[key] = val.Elem().Interface()
-		return nil
+		return
 	}
 
 	s.store[key] = value
-	return nil
 }
 
 // Get retrieves a saved value from memory storage
@@ -57,6 +87,12 @@ func (s *Storage) Get(key string) (value interface{}, exists bool, err error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	if expiry, has := s.expiries[key]; has && time.Now().After(expiry) {
+		delete(s.store, key)
+		delete(s.expiries, key)
+		return nil, false, nil
+	}
+
 	value, exists = s.store[key]
 	return
 }
@@ -85,6 +121,7 @@ func (s *Storage) Del(key string) (err error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 	delete(s.store, key)
+	delete(s.expiries, key)
 	return nil
 }
 
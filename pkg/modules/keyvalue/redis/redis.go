@@ -21,6 +21,7 @@ import (
 	"context"
 	"encoding/gob"
 	"errors"
+	"time"
 
 	"code.vikunja.io/api/pkg/red"
 	"github.com/redis/go-redis/v9"
@@ -42,6 +43,11 @@ func NewStorage() *Storage {
 
 // Put puts a value into redis
 func (s *Storage) Put(key string, value interface{}) (err error) {
+	return s.PutWithTTL(key, value, 0)
+}
+
+// PutWithTTL puts a value into redis which expires after ttl. A ttl of 0 means the value never expires.
+func (s *Storage) PutWithTTL(key string, value interface{}, ttl time.Duration) (err error) {
 
 	var v interface{}
 
@@ -63,10 +69,10 @@ func (s *Storage) Put(key string, value interface{}) (err error) {
 		if err != nil {
 			return err
 		}
-		return s.client.Set(context.Background(), key, buf.Bytes(), 0).Err()
+		return s.client.Set(context.Background(), key, buf.Bytes(), ttl).Err()
 	}
 
-	return s.client.Set(context.Background(), key, v, 0).Err()
+	return s.client.Set(context.Background(), key, v, ttl).Err()
 }
 
 // Get retrieves a saved value from redis
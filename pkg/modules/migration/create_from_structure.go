@@ -311,6 +311,16 @@ func createProjectWithEverything(s *xorm.Session, project *models.ProjectWithTas
 			}
 			log.Debugf("[creating structure] Created new comment %d", comment.ID)
 		}
+
+		for _, entry := range t.TimeEntries {
+			entry.TaskID = t.ID
+			entry.ID = 0
+			err = entry.Create(s, user)
+			if err != nil {
+				return
+			}
+			log.Debugf("[creating structure] Created new time entry %d", entry.ID)
+		}
 	}
 
 	// All tasks brought their own bucket with them, therefore the newly created default bucket is just extra space
@@ -212,11 +212,11 @@ func TestConvertTrelloToVikunja(t *testing.T) {
 					Tasks: []*models.TaskWithComments{
 						{
 							Task: models.Task{
-								Title:          "Test Card 1",
-								Description:    "Card Description",
-								BucketID:       1,
-								KanbanPosition: 123,
-								DueDate:        time1,
+								Title:       "Test Card 1",
+								Description: "Card Description",
+								BucketID:    1,
+								Position:    123,
+								DueDate:     time1,
 								Labels: []*models.Label{
 									{
 										Title:    "Label 1",
@@ -253,22 +253,22 @@ func TestConvertTrelloToVikunja(t *testing.T) {
 
 * [ ] Pending Task
 * [ ] Another Pending Task`,
-								BucketID:       1,
-								KanbanPosition: 124,
+								BucketID: 1,
+								Position: 124,
 							},
 						},
 						{
 							Task: models.Task{
-								Title:          "Test Card 3",
-								BucketID:       1,
-								KanbanPosition: 126,
+								Title:    "Test Card 3",
+								BucketID: 1,
+								Position: 126,
 							},
 						},
 						{
 							Task: models.Task{
-								Title:          "Test Card 4",
-								BucketID:       1,
-								KanbanPosition: 127,
+								Title:    "Test Card 4",
+								BucketID: 1,
+								Position: 127,
 								Labels: []*models.Label{
 									{
 										Title:    "Label 2",
@@ -279,9 +279,9 @@ func TestConvertTrelloToVikunja(t *testing.T) {
 						},
 						{
 							Task: models.Task{
-								Title:          "Test Card 5",
-								BucketID:       2,
-								KanbanPosition: 111,
+								Title:    "Test Card 5",
+								BucketID: 2,
+								Position: 111,
 								Labels: []*models.Label{
 									{
 										Title:    "Label 3",
@@ -292,24 +292,24 @@ func TestConvertTrelloToVikunja(t *testing.T) {
 						},
 						{
 							Task: models.Task{
-								Title:          "Test Card 6",
-								BucketID:       2,
-								KanbanPosition: 222,
-								DueDate:        time1,
+								Title:    "Test Card 6",
+								BucketID: 2,
+								Position: 222,
+								DueDate:  time1,
 							},
 						},
 						{
 							Task: models.Task{
-								Title:          "Test Card 7",
-								BucketID:       2,
-								KanbanPosition: 333,
+								Title:    "Test Card 7",
+								BucketID: 2,
+								Position: 333,
 							},
 						},
 						{
 							Task: models.Task{
-								Title:          "Test Card 8",
-								BucketID:       2,
-								KanbanPosition: 444,
+								Title:    "Test Card 8",
+								BucketID: 2,
+								Position: 444,
 							},
 						},
 					},
@@ -327,9 +327,9 @@ func TestConvertTrelloToVikunja(t *testing.T) {
 					Tasks: []*models.TaskWithComments{
 						{
 							Task: models.Task{
-								Title:          "Test Card 634",
-								BucketID:       3,
-								KanbanPosition: 123,
+								Title:    "Test Card 634",
+								BucketID: 3,
+								Position: 123,
 							},
 						},
 					},
@@ -348,9 +348,9 @@ func TestConvertTrelloToVikunja(t *testing.T) {
 					Tasks: []*models.TaskWithComments{
 						{
 							Task: models.Task{
-								Title:          "Test Card 63423",
-								BucketID:       4,
-								KanbanPosition: 123,
+								Title:    "Test Card 63423",
+								BucketID: 4,
+								Position: 123,
 							},
 						},
 					},
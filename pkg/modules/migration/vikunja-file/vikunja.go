@@ -245,6 +245,11 @@ func addDetailsToProject(l *models.ProjectWithTasksAndBuckets, storedFiles map[i
 		for _, comment := range t.Comments {
 			comment.ID = 0
 		}
+		for _, entry := range t.TimeEntries {
+			entry.ID = 0
+		}
+		// Custom field definitions are not part of the export
+		t.CustomFields = nil
 		for _, attachment := range t.Attachments {
 			attachmentFile, exists := storedFiles[attachment.File.ID]
 			if !exists {
@@ -49,7 +49,9 @@ type vikunjaInfos struct {
 	EmailRemindersEnabled      bool      `json:"email_reminders_enabled"`
 	UserDeletionEnabled        bool      `json:"user_deletion_enabled"`
 	TaskCommentsEnabled        bool      `json:"task_comments_enabled"`
+	TimeTrackingEnabled        bool      `json:"time_tracking_enabled"`
 	DemoModeEnabled            bool      `json:"demo_mode_enabled"`
+	WebhooksEnabled            bool      `json:"webhooks_enabled"`
 }
 
 type authInfo struct {
@@ -93,7 +95,9 @@ func Info(c echo.Context) error {
 		EmailRemindersEnabled:  config.ServiceEnableEmailReminders.GetBool(),
 		UserDeletionEnabled:    config.ServiceEnableUserDeletion.GetBool(),
 		TaskCommentsEnabled:    config.ServiceEnableTaskComments.GetBool(),
+		TimeTrackingEnabled:    config.ServiceEnableTimeTracking.GetBool(),
 		DemoModeEnabled:        config.ServiceDemoMode.GetBool(),
+		WebhooksEnabled:        config.WebhooksEnabled.GetBool(),
 		AvailableMigrators: []string{
 			(&vikunja_file.FileMigrator{}).Name(),
 			(&ticktick.Migrator{}).Name(),
@@ -115,6 +115,7 @@ func UploadTaskAttachment(c echo.Context) error {
 // @Produce octet-stream
 // @Param id path int true "Task ID"
 // @Param attachmentID path int true "Attachment ID"
+// @Param preview_size query string false "If set to sm, md or lg, returns a png preview of image attachments in that size instead of the attachment itself. Other attachments are returned unchanged."
 // @Security JWTKeyAuth
 // @Success 200 {file} blob "The attachment file."
 // @Failure 403 {object} models.Message "No access to this task."
@@ -153,6 +154,27 @@ func GetTaskAttachment(c echo.Context) error {
 		return handler.HandleHTTPError(err, c)
 	}
 
+	if previewSize := c.QueryParam("preview_size"); previewSize != "" {
+		size, err := models.GetPreviewSizeFromString(previewSize)
+		if err != nil {
+			_ = s.Rollback()
+			return handler.HandleHTTPError(err, c)
+		}
+
+		preview, err := taskAttachment.GetPreview(size)
+		if err != nil {
+			_ = s.Rollback()
+			return handler.HandleHTTPError(err, c)
+		}
+		if preview != nil {
+			if err := s.Commit(); err != nil {
+				_ = s.Rollback()
+				return handler.HandleHTTPError(err, c)
+			}
+			return c.Blob(http.StatusOK, "image/png", preview)
+		}
+	}
+
 	// Open an send the file to the client
 	err = taskAttachment.File.LoadFileByID()
 	if err != nil {
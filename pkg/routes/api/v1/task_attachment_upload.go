@@ -0,0 +1,99 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/models"
+	auth2 "code.vikunja.io/api/pkg/modules/auth"
+
+	"code.vikunja.io/web/handler"
+	"github.com/labstack/echo/v4"
+)
+
+// UploadTaskAttachmentChunk stores the next chunk of a resumable attachment upload
+// @Summary Upload a chunk of a resumable attachment upload
+// @Description Appends the request body to the upload. The Upload-Offset header has to match the current offset of the upload, if it does not, get the upload to find out where to continue. Returns the new offset in the Upload-Offset header.
+// @tags task
+// @Accept octet-stream
+// @Produce json
+// @Param taskID path int true "Task ID"
+// @Param uploadID path int true "Upload ID"
+// @Param Upload-Offset header int true "The offset at which this chunk starts."
+// @Security JWTKeyAuth
+// @Success 200 {object} models.TaskAttachmentUpload "The upload with the new offset."
+// @Failure 400 {object} web.HTTPError "Invalid offset or the chunk exceeds the size of the upload."
+// @Failure 403 {object} web.HTTPError "No access to the upload."
+// @Failure 404 {object} web.HTTPError "The upload does not exist."
+// @Failure 409 {object} web.HTTPError "The offset does not match the offset of the upload."
+// @Failure 500 {object} models.Message "Internal error"
+// @Router /tasks/{taskID}/attachments/uploads/{uploadID} [patch]
+func UploadTaskAttachmentChunk(c echo.Context) error {
+	// The body is the raw chunk, so we can't use c.Bind here
+	taskID, err := strconv.ParseInt(c.Param("task"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid task id")
+	}
+	uploadID, err := strconv.ParseInt(c.Param("upload"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid upload id")
+	}
+	offset, err := strconv.ParseUint(c.Request().Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid or missing Upload-Offset header")
+	}
+
+	upload := &models.TaskAttachmentUpload{
+		ID:     uploadID,
+		TaskID: taskID,
+	}
+
+	// Rights check
+	auth, err := auth2.GetAuthFromClaims(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	can, err := upload.CanUpdate(s, auth)
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+	if !can {
+		return echo.ErrForbidden
+	}
+
+	err = upload.AddChunk(s, auth, offset, c.Request().Body)
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	if err := s.Commit(); err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	c.Response().Header().Set("Upload-Offset", strconv.FormatUint(upload.Offset, 10))
+	return c.JSON(http.StatusOK, upload)
+}
@@ -0,0 +1,156 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package v1
+
+import (
+	"net/http"
+	"strconv"
+
+	"code.vikunja.io/api/pkg/caldav"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/models"
+	"code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/web/handler"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GenerateICSFeedToken is the handler to create a token for an ics calendar feed
+// @Summary Generate a calendar feed token
+// @Description Generates a token which can be used to subscribe to the tasks of the current user as an iCalendar feed. It is not possible to see the token again after it was generated.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {object} user.Token
+// @Failure 400 {object} web.HTTPError "Something's invalid."
+// @Failure 404 {object} web.HTTPError "User does not exist."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/settings/token/ics [put]
+func GenerateICSFeedToken(c echo.Context) (err error) {
+
+	u, err := user.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	token, err := user.GenerateNewICSFeedToken(u)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusCreated, token)
+}
+
+// GetICSFeedTokens is the handler to return all calendar feed tokens of the current user
+// @Summary Returns the calendar feed tokens for the current user
+// @Description Return the IDs and created dates of all calendar feed tokens for the current user.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Success 200 {array} user.Token
+// @Failure 400 {object} web.HTTPError "Something's invalid."
+// @Failure 404 {object} web.HTTPError "User does not exist."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/settings/token/ics [get]
+func GetICSFeedTokens(c echo.Context) error {
+	u, err := user.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	tokens, err := user.GetICSFeedTokens(u)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, tokens)
+}
+
+// DeleteICSFeedToken is the handler to revoke a calendar feed token
+// @Summary Delete a calendar feed token by id
+// @Description Revokes a calendar feed token. All feed urls using this token stop working immediately.
+// @tags user
+// @Accept json
+// @Produce json
+// @Security JWTKeyAuth
+// @Param id path int true "Token ID"
+// @Success 200 {object} models.Message
+// @Failure 400 {object} web.HTTPError "Something's invalid."
+// @Failure 404 {object} web.HTTPError "User does not exist."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /user/settings/token/ics/{id} [delete]
+func DeleteICSFeedToken(c echo.Context) error {
+	u, err := user.GetCurrentUser(c)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	err = user.DeleteICSFeedTokenByID(u, id)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	return c.JSON(http.StatusOK, &models.Message{Message: "The token was deleted successfully."})
+}
+
+// GetICSFeed returns the tasks of a user as an iCalendar feed
+// @Summary Calendar feed
+// @Description Returns all undone tasks with a due date the owner of the token has access to as an iCalendar feed. Each task is an event at its due date. This endpoint does not need authentication besides the token in the url so it can be used to subscribe to the feed in calendar apps.
+// @tags user
+// @Produce text/calendar
+// @Param token path string true "The calendar feed token"
+// @Param filter path int false "The id of a saved filter. If provided, the feed only contains tasks matching that filter."
+// @Param type query string false "Set to `todo` to get the tasks as VTODO instead of VEVENT entries."
+// @Success 200 {file} blob "The calendar feed"
+// @Failure 403 {object} web.HTTPError "The user does not have access to the saved filter."
+// @Failure 404 {object} web.HTTPError "The token does not exist."
+// @Failure 500 {object} models.Message "Internal server error."
+// @Router /feeds/ics/{token} [get]
+// @Router /feeds/ics/{token}/filters/{filter} [get]
+func GetICSFeed(c echo.Context) error {
+	var filterID int64
+	if c.Param("filter") != "" {
+		var err error
+		filterID, err = strconv.ParseInt(c.Param("filter"), 10, 64)
+		if err != nil {
+			return handler.HandleHTTPError(err, c)
+		}
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	u, err := user.GetUserByICSFeedToken(s, c.Param("token"))
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	name, tasks, err := models.GetTasksForICSFeed(s, u, filterID)
+	if err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	feed := caldav.GetICSFeedForTasks(name, tasks, c.QueryParam("type") == "todo")
+	return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", []byte(feed))
+}
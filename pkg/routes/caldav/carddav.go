@@ -0,0 +1,382 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package caldav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"code.vikunja.io/api/pkg/caldav"
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/models"
+	user2 "code.vikunja.io/api/pkg/user"
+	"code.vikunja.io/api/pkg/utils"
+
+	"github.com/labstack/echo/v4"
+	"github.com/samedi/caldav-go/ixml"
+)
+
+// AddressbookHomePath is the path of the address book home of every user
+const AddressbookHomePath = DavBasePath + `addressbooks/`
+
+// addressbookName is the name of the only address book in the address book home
+const addressbookName = `contacts`
+
+// AddressbookPath is the path of the address book with all users the current user shares a project or team with
+const AddressbookPath = AddressbookHomePath + addressbookName + `/`
+
+const carddavNS = `urn:ietf:params:xml:ns:carddav`
+
+var carddavPrefixes = map[string]string{
+	ixml.DAV_NS:     "D",
+	carddavNS:       "CR",
+	ixml.CALSERV_NS: "CS",
+}
+
+var (
+	addressbookHomeSetTag      = xml.Name{Space: carddavNS, Local: "addressbook-home-set"}
+	addressbookTag             = xml.Name{Space: carddavNS, Local: "addressbook"}
+	addressDataTag             = xml.Name{Space: carddavNS, Local: "address-data"}
+	addressbookMultigetTag     = xml.Name{Space: carddavNS, Local: "addressbook-multiget"}
+	addressbookQueryTag        = xml.Name{Space: carddavNS, Local: "addressbook-query"}
+	supportedAddressDataTag    = xml.Name{Space: carddavNS, Local: "supported-address-data"}
+	currentUserPrivilegeSetTag = xml.Name{Space: ixml.DAV_NS, Local: "current-user-privilege-set"}
+
+	errUnsupportedReport = errors.New("unsupported report")
+)
+
+// defaultCarddavProps are returned if a client does not ask for specific properties
+var defaultCarddavProps = []xml.Name{
+	ixml.RESOURCE_TYPE_TG,
+	ixml.DISPLAY_NAME_TG,
+	ixml.GET_ETAG_TG,
+	ixml.GET_CONTENT_TYPE_TG,
+}
+
+type carddavRequest struct {
+	XMLName xml.Name
+	Prop    struct {
+		Tags []xml.Name `xml:",any"`
+	} `xml:"DAV: prop"`
+	Hrefs []string `xml:"DAV: href"`
+}
+
+// AddressbookHandler handles all requests to the address book home and the address book in it. The address book is
+// read-only and contains a vcard of every user the current user shares a project or a team with.
+func AddressbookHandler(c echo.Context) error {
+	u, err := getBasicAuthUserFromContext(c)
+	if err != nil {
+		log.Error(err)
+		return echo.ErrInternalServerError
+	}
+
+	isHome := c.Param("addressbook") == ""
+	if !isHome && c.Param("addressbook") != addressbookName {
+		return echo.ErrNotFound
+	}
+
+	switch c.Request().Method {
+	case http.MethodOptions:
+		return carddavOptions(c)
+	case "PROPFIND":
+	case "REPORT":
+		if isHome {
+			return writeDavError(c, http.StatusForbidden, xml.Name{Space: ixml.DAV_NS, Local: "supported-report"})
+		}
+	default:
+		return echo.ErrMethodNotAllowed
+	}
+
+	request, err := parseCarddavRequest(c)
+	if err != nil {
+		return err
+	}
+
+	contacts, err := getContacts(u)
+	if err != nil {
+		log.Errorf("[CARDDAV] Could not get contacts for user %d: %s", u.ID, err)
+		return echo.ErrInternalServerError
+	}
+
+	depth := c.Request().Header.Get("Depth")
+	var responses string
+	switch {
+	case isHome:
+		responses = carddavResponse(AddressbookHomePath, request.Prop.Tags, getAddressbookHomeProps(u))
+		if depth == "1" {
+			responses += carddavResponse(AddressbookPath, request.Prop.Tags, getAddressbookProps(u, contacts))
+		}
+	case c.Request().Method == "REPORT":
+		responses, err = getAddressbookReport(request, contacts)
+		if err != nil {
+			return writeDavError(c, http.StatusForbidden, xml.Name{Space: ixml.DAV_NS, Local: "supported-report"})
+		}
+	default:
+		responses = carddavResponse(AddressbookPath, request.Prop.Tags, getAddressbookProps(u, contacts))
+		if depth == "1" {
+			for _, contact := range contacts {
+				responses += carddavResponse(getContactURL(contact), request.Prop.Tags, getContactProps(contact, false))
+			}
+		}
+	}
+
+	return c.Blob(
+		http.StatusMultiStatus,
+		"application/xml; charset=utf-8",
+		[]byte(`<?xml version="1.0" encoding="UTF-8"?><D:multistatus `+carddavNamespaces()+`>`+responses+`</D:multistatus>`),
+	)
+}
+
+// ContactHandler returns the vcard of a single user
+func ContactHandler(c echo.Context) error {
+	u, err := getBasicAuthUserFromContext(c)
+	if err != nil {
+		log.Error(err)
+		return echo.ErrInternalServerError
+	}
+
+	if c.Param("addressbook") != addressbookName {
+		return echo.ErrNotFound
+	}
+
+	switch c.Request().Method {
+	case http.MethodOptions:
+		return carddavOptions(c)
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPut, http.MethodDelete, "PROPPATCH":
+		// The address book is read-only
+		return echo.ErrForbidden
+	default:
+		return echo.ErrMethodNotAllowed
+	}
+
+	contacts, err := getContacts(u)
+	if err != nil {
+		log.Errorf("[CARDDAV] Could not get contacts for user %d: %s", u.ID, err)
+		return echo.ErrInternalServerError
+	}
+
+	uid := strings.TrimSuffix(c.Param("contact"), ".vcf")
+	for _, contact := range contacts {
+		if caldav.GetVCardUID(contact) != uid {
+			continue
+		}
+
+		c.Response().Header().Set("ETag", getContactEtag(contact))
+		c.Response().Header().Set("Last-Modified", contact.Updated.UTC().Format(http.TimeFormat))
+		return c.Blob(http.StatusOK, "text/vcard; charset=utf-8", []byte(caldav.GetVCardForUser(contact)))
+	}
+
+	return echo.ErrNotFound
+}
+
+// addAddressbookHomeSetToPropfind adds the address book home to the principal in a PROPFIND response. caldav-go does
+// not know about CardDAV and reports it as not found.
+func addAddressbookHomeSetToPropfind(body string) string {
+	return resolveNotFoundProps(body, func(_ string, prop string) (string, bool) {
+		if prop != ixml.Tag(addressbookHomeSetTag, "") {
+			return "", false
+		}
+		return `<CR:addressbook-home-set xmlns:CR="` + carddavNS + `">` + ixml.HrefTag(AddressbookHomePath) + `</CR:addressbook-home-set>`, true
+	})
+}
+
+func carddavOptions(c echo.Context) error {
+	c.Response().Header().Set("DAV", "1, 3, addressbook")
+	c.Response().Header().Set("Allow", "OPTIONS, GET, HEAD, PROPFIND, REPORT")
+	return c.NoContent(http.StatusOK)
+}
+
+func parseCarddavRequest(c echo.Context) (*carddavRequest, error) {
+	request := &carddavRequest{}
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debugf("[CARDDAV] Request Body: %v\n", string(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		return request, nil
+	}
+	if err := xml.Unmarshal(body, request); err != nil {
+		return nil, echo.ErrBadRequest
+	}
+	return request, nil
+}
+
+func getContacts(u *user2.User) ([]*user2.User, error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	contacts, err := models.ListUsersSharingProjectsWith(s, u)
+	if err != nil {
+		_ = s.Rollback()
+		return nil, err
+	}
+
+	return contacts, s.Commit()
+}
+
+func getContactURL(contact *user2.User) string {
+	return AddressbookPath + caldav.GetVCardUID(contact) + `.vcf`
+}
+
+func getContactEtag(contact *user2.User) string {
+	return `"` + strconv.FormatInt(contact.ID, 10) + `-` + strconv.FormatInt(contact.Updated.Unix(), 10) + `"`
+}
+
+func getAddressbookHomeProps(u *user2.User) map[xml.Name]string {
+	return map[xml.Name]string{
+		ixml.RESOURCE_TYPE_TG:          carddavTag(ixml.COLLECTION_TG, ""),
+		ixml.DISPLAY_NAME_TG:           "Address books",
+		ixml.CURRENT_USER_PRINCIPAL_TG: ixml.HrefTag(DavBasePath + `principals/` + u.Username + `/`),
+		addressbookHomeSetTag:          ixml.HrefTag(AddressbookHomePath),
+	}
+}
+
+func getAddressbookProps(u *user2.User, contacts []*user2.User) map[xml.Name]string {
+	etags := make([]string, 0, len(contacts))
+	for _, contact := range contacts {
+		etags = append(etags, getContactEtag(contact))
+	}
+	// The etag of the address book needs to change when a contact is added, changed or removed
+	etag := `"` + utils.Sha256(strings.Join(etags, ",")) + `"`
+
+	return map[xml.Name]string{
+		ixml.RESOURCE_TYPE_TG:          carddavTag(ixml.COLLECTION_TG, "") + carddavTag(addressbookTag, ""),
+		ixml.DISPLAY_NAME_TG:           "Vikunja",
+		ixml.GET_ETAG_TG:               etag,
+		ixml.GET_CTAG_TG:               etag,
+		ixml.CURRENT_USER_PRINCIPAL_TG: ixml.HrefTag(DavBasePath + `principals/` + u.Username + `/`),
+		currentUserPrivilegeSetTag:     `<D:privilege><D:read/></D:privilege>`,
+		supportedReportSetTag: supportedCarddavReport(addressbookMultigetTag) +
+			supportedCarddavReport(addressbookQueryTag),
+		supportedAddressDataTag: `<CR:address-data-type content-type="text/vcard" version="3.0"/>`,
+	}
+}
+
+func getContactProps(contact *user2.User, withAddressData bool) map[xml.Name]string {
+	vcard := caldav.GetVCardForUser(contact)
+	props := map[xml.Name]string{
+		ixml.RESOURCE_TYPE_TG:      "",
+		ixml.GET_ETAG_TG:           getContactEtag(contact),
+		ixml.GET_CONTENT_TYPE_TG:   "text/vcard; charset=utf-8",
+		ixml.GET_CONTENT_LENGTH_TG: strconv.Itoa(len(vcard)),
+		ixml.GET_LAST_MODIFIED_TG:  contact.Updated.UTC().Format(http.TimeFormat),
+	}
+	if withAddressData {
+		props[addressDataTag] = ixml.EscapeText(vcard)
+	}
+	return props
+}
+
+// getAddressbookReport answers an addressbook-multiget or addressbook-query report. Queries return all contacts
+// since there are only a few of them.
+func getAddressbookReport(request *carddavRequest, contacts []*user2.User) (responses string, err error) {
+	switch request.XMLName {
+	case addressbookQueryTag:
+		for _, contact := range contacts {
+			responses += carddavResponse(getContactURL(contact), request.Prop.Tags, getContactProps(contact, true))
+		}
+	case addressbookMultigetTag:
+		contactsByURL := make(map[string]*user2.User, len(contacts))
+		for _, contact := range contacts {
+			contactsByURL[getContactURL(contact)] = contact
+		}
+		for _, href := range request.Hrefs {
+			contact, has := contactsByURL[href]
+			if !has {
+				responses += `<D:response>` + ixml.HrefTag(href) + ixml.StatusTag(http.StatusNotFound) + `</D:response>`
+				continue
+			}
+			responses += carddavResponse(href, request.Prop.Tags, getContactProps(contact, true))
+		}
+	default:
+		return "", errUnsupportedReport
+	}
+
+	return
+}
+
+// carddavResponse renders the requested properties of a resource. Properties the resource does not have are
+// reported as not found.
+func carddavResponse(href string, requested []xml.Name, props map[xml.Name]string) string {
+	if len(requested) == 0 {
+		for _, name := range defaultCarddavProps {
+			if _, has := props[name]; has {
+				requested = append(requested, name)
+			}
+		}
+	}
+
+	var found, missing string
+	for _, name := range requested {
+		if value, has := props[name]; has {
+			found += carddavTag(name, value)
+			continue
+		}
+		missing += carddavTag(name, "")
+	}
+
+	response := `<D:response>` + ixml.HrefTag(href)
+	if found != "" {
+		response += `<D:propstat><D:prop>` + found + `</D:prop>` + ixml.StatusTag(http.StatusOK) + `</D:propstat>`
+	}
+	if missing != "" {
+		response += `<D:propstat><D:prop>` + missing + `</D:prop>` + ixml.StatusTag(http.StatusNotFound) + `</D:propstat>`
+	}
+	return response + `</D:response>`
+}
+
+func carddavNamespaces() string {
+	prefixes := make([]string, 0, len(carddavPrefixes))
+	for ns, prefix := range carddavPrefixes {
+		prefixes = append(prefixes, `xmlns:`+prefix+`="`+ns+`"`)
+	}
+	sort.Strings(prefixes)
+	return strings.Join(prefixes, " ")
+}
+
+// carddavTag renders a tag like ixml.Tag, but knows about the CardDAV namespace. Tags from other unknown
+// namespaces get their namespace declared on themselves.
+func carddavTag(name xml.Name, content string) string {
+	tag := name.Local
+	declaration := ""
+	if prefix, has := carddavPrefixes[name.Space]; has {
+		tag = prefix + ":" + tag
+	} else if name.Space != "" {
+		tag = "X:" + tag
+		declaration = ` xmlns:X="` + ixml.EscapeText(name.Space) + `"`
+	}
+
+	if content == "" {
+		return `<` + tag + declaration + `/>`
+	}
+	return `<` + tag + declaration + `>` + content + `</` + tag + `>`
+}
+
+func supportedCarddavReport(report xml.Name) string {
+	return `<D:supported-report><D:report>` + carddavTag(report, "") + `</D:report></D:supported-report>`
+}
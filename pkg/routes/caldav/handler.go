@@ -20,11 +20,14 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"net/http"
 	"reflect"
 	"strconv"
 	"strings"
 
 	caldav2 "code.vikunja.io/api/pkg/caldav"
+	"code.vikunja.io/api/pkg/config"
+	"code.vikunja.io/api/pkg/db"
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/models"
 	"code.vikunja.io/api/pkg/user"
@@ -64,6 +67,12 @@ func ProjectHandler(c echo.Context) error {
 	body, _ := io.ReadAll(c.Request().Body)
 	// Restore the io.ReadCloser to its original state
 	c.Request().Body = io.NopCloser(bytes.NewBuffer(body))
+	if c.Request().Method == "REPORT" && projectID != 0 {
+		if request, is := parseSyncCollectionRequest(body); is {
+			return handleSyncCollection(c, u, projectID, request)
+		}
+	}
+
 	// Parse it
 	vtodo := string(body)
 	if vtodo != "" && strings.HasPrefix(vtodo, `BEGIN:VCALENDAR`) {
@@ -81,6 +90,9 @@ func ProjectHandler(c echo.Context) error {
 	caldav.SetupUser("dav/projects")
 	caldav.SetupSupportedComponents([]string{lib.VCALENDAR, lib.VTODO})
 	response := caldav.HandleRequest(c.Request())
+	if c.Request().Method == "PROPFIND" && response.Status == http.StatusMultiStatus {
+		response.Body = addSyncPropertiesToPropfind(response.Body)
+	}
 	response.Write(c.Response())
 	return nil
 }
@@ -113,6 +125,70 @@ func TaskHandler(c echo.Context) error {
 	return nil
 }
 
+// AttachmentHandler serves the file of a task attachment to caldav clients. Clients get the url of these files from
+// the ATTACH properties of a task and can't use the api since they only have their caldav credentials.
+func AttachmentHandler(c echo.Context) error {
+	taskID, err := getIntParam(c, "task")
+	if err != nil {
+		return err
+	}
+	attachmentID, err := getIntParam(c, "attachment")
+	if err != nil {
+		return err
+	}
+
+	u, err := getBasicAuthUserFromContext(c)
+	if err != nil {
+		log.Error(err)
+		return echo.ErrInternalServerError
+	}
+
+	if !config.ServiceEnableTaskAttachments.GetBool() {
+		return echo.ErrNotFound
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	attachment := &models.TaskAttachment{ID: attachmentID, TaskID: taskID}
+	can, _, err := attachment.CanRead(s, u)
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+	if !can {
+		_ = s.Rollback()
+		return echo.ErrForbidden
+	}
+
+	err = attachment.ReadOne(s, u)
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+	// The access check only covers the task from the url
+	if attachment.TaskID != taskID {
+		_ = s.Rollback()
+		return echo.ErrNotFound
+	}
+
+	err = attachment.File.LoadFileByID()
+	if err != nil {
+		_ = s.Rollback()
+		return handler.HandleHTTPError(err, c)
+	}
+
+	if err := s.Commit(); err != nil {
+		return handler.HandleHTTPError(err, c)
+	}
+
+	if attachment.File.Mime != "" {
+		c.Response().Header().Set(echo.HeaderContentType, attachment.File.Mime)
+	}
+	http.ServeContent(c.Response(), c.Request(), attachment.File.Name, attachment.File.Created, attachment.File.File)
+	return nil
+}
+
 // PrincipalHandler handles all request to principal resources
 func PrincipalHandler(c echo.Context) error {
 	u, err := getBasicAuthUserFromContext(c)
@@ -139,6 +215,9 @@ func PrincipalHandler(c echo.Context) error {
 	caldav.SetupSupportedComponents([]string{lib.VCALENDAR, lib.VTODO})
 
 	response := caldav.HandleRequest(c.Request())
+	if c.Request().Method == "PROPFIND" && response.Status == http.StatusMultiStatus {
+		response.Body = addAddressbookHomeSetToPropfind(response.Body)
+	}
 	response.Write(c.Response())
 	return nil
 }
@@ -169,6 +248,9 @@ func EntryHandler(c echo.Context) error {
 	caldav.SetupSupportedComponents([]string{lib.VCALENDAR, lib.VTODO})
 
 	response := caldav.HandleRequest(c.Request())
+	if c.Request().Method == "PROPFIND" && response.Status == http.StatusMultiStatus {
+		response.Body = addAddressbookHomeSetToPropfind(response.Body)
+	}
 	response.Write(c.Response())
 	return nil
 }
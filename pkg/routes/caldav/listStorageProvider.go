@@ -17,12 +17,15 @@
 package caldav
 
 import (
+	"bytes"
 	"strconv"
 	"strings"
 	"time"
 
 	"code.vikunja.io/api/pkg/caldav"
+	"code.vikunja.io/api/pkg/config"
 	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/files"
 	"code.vikunja.io/api/pkg/log"
 	"code.vikunja.io/api/pkg/models"
 	user2 "code.vikunja.io/api/pkg/user"
@@ -103,10 +106,17 @@ func (vcls *VikunjaCaldavProjectStorage) GetResources(rpath string, _ bool) ([]d
 		_ = s.Rollback()
 		return nil, err
 	}
-	if err := s.Commit(); err != nil {
+	projects := theprojects.([]*models.Project)
+
+	projectIDs := make([]int64, 0, len(projects))
+	for _, l := range projects {
+		projectIDs = append(projectIDs, l.ID)
+	}
+	syncTokens, err := models.GetProjectSyncTokens(s, projectIDs)
+	if err != nil {
+		_ = s.Rollback()
 		return nil, err
 	}
-	projects := theprojects.([]*models.Project)
 
 	var resources []data.Resource
 	for _, l := range projects {
@@ -114,13 +124,30 @@ func (vcls *VikunjaCaldavProjectStorage) GetResources(rpath string, _ bool) ([]d
 			project: &models.ProjectWithTasksAndBuckets{
 				Project: *l,
 			},
+			syncToken:    syncTokens[l.ID],
 			isCollection: true,
 		}
+
+		// Saved filters and favorites have no update timestamp which changes with their tasks,
+		// so we need their tasks to build an etag clients can use to check for changes.
+		if isPseudoProject(l.ID) {
+			rr.projectTasks, err = getTasksForProject(s, vcls.user, l.ID)
+			if err != nil {
+				_ = s.Rollback()
+				return nil, err
+			}
+			updatePseudoProjectModTime(rr.project, rr.projectTasks)
+		}
+
 		r := data.NewResource(ProjectBasePath+"/"+strconv.FormatInt(l.ID, 10), &rr)
 		r.Name = l.Title
 		resources = append(resources, r)
 	}
 
+	if err := s.Commit(); err != nil {
+		return nil, err
+	}
+
 	return resources, nil
 }
 
@@ -131,9 +158,12 @@ func (vcls *VikunjaCaldavProjectStorage) GetResourcesByList(rpaths []string) ([]
 	// A path looks like this: /dav/projects/10/a6eb526d5748a5c499da202fe74f36ed1aea2aef.ics
 	// So we split the url in parts, take the last one and strip the ".ics" at the end
 	var uids []string
+	paths := make(map[string]string, len(rpaths))
 	for _, path := range rpaths {
 		parts := strings.Split(path, "/")
-		uids = append(uids, strings.TrimSuffix(parts[4], ".ics"))
+		uid := strings.TrimSuffix(parts[4], ".ics")
+		uids = append(uids, uid)
+		paths[uid] = path
 	}
 
 	s := db.NewSession()
@@ -146,6 +176,11 @@ func (vcls *VikunjaCaldavProjectStorage) GetResourcesByList(rpaths []string) ([]
 		_ = s.Rollback()
 		return nil, err
 	}
+	comments, err := getCommentsForTasks(s, tasks)
+	if err != nil {
+		_ = s.Rollback()
+		return nil, err
+	}
 	if err := s.Commit(); err != nil {
 		return nil, err
 	}
@@ -153,9 +188,16 @@ func (vcls *VikunjaCaldavProjectStorage) GetResourcesByList(rpaths []string) ([]
 	var resources []data.Resource
 	for _, t := range tasks {
 		rr := VikunjaProjectResourceAdapter{
-			task: t,
+			task:     t,
+			comments: comments[t.ID],
+		}
+		// Tasks in saved filters or favorites are requested with the url of the pseudo project
+		// and need to be returned with the same url.
+		path, has := paths[t.UID]
+		if !has {
+			path = getTaskURL(t.ProjectID, t)
 		}
-		r := data.NewResource(getTaskURL(t), &rr)
+		r := data.NewResource(path, &rr)
 		r.Name = t.Title
 		resources = append(resources, r)
 	}
@@ -175,9 +217,10 @@ func (vcls *VikunjaCaldavProjectStorage) GetResourcesByFilters(rpath string, _ *
 			rr := VikunjaProjectResourceAdapter{
 				project:      vcls.project,
 				task:         &t.Task,
+				comments:     t.Comments,
 				isCollection: false,
 			}
-			r := data.NewResource(getTaskURL(&t.Task), &rr)
+			r := data.NewResource(getTaskURL(vcls.project.ID, &t.Task), &rr)
 			r.Name = t.Title
 			resources = append(resources, r)
 		}
@@ -196,8 +239,26 @@ func (vcls *VikunjaCaldavProjectStorage) GetResourcesByFilters(rpath string, _ *
 	// return vcls.GetResources(rpath, false)
 }
 
-func getTaskURL(task *models.Task) string {
-	return ProjectBasePath + "/" + strconv.FormatInt(task.ProjectID, 10) + `/` + task.UID + `.ics`
+// getTaskURL returns the url of a task in a project collection. For saved filters and favorites, this is the
+// url in the pseudo project and not in the project the task actually belongs to.
+func getTaskURL(projectID int64, task *models.Task) string {
+	return ProjectBasePath + "/" + strconv.FormatInt(projectID, 10) + `/` + task.UID + `.ics`
+}
+
+// isPseudoProject checks if a project id belongs to a saved filter or the favorites pseudo project.
+// Both have negative ids.
+func isPseudoProject(projectID int64) bool {
+	return projectID < 0
+}
+
+// updatePseudoProjectModTime sets the updated timestamp of a saved filter or favorites project to the one of its
+// most recently updated task. Without it, the etag of these projects would not change when one of their tasks changes.
+func updatePseudoProjectModTime(project *models.ProjectWithTasksAndBuckets, tasks []*models.TaskWithComments) {
+	for _, t := range tasks {
+		if t.Updated.After(project.Updated) {
+			project.Updated = t.Updated
+		}
+	}
 }
 
 // GetResource fetches a single resource
@@ -218,6 +279,11 @@ func (vcls *VikunjaCaldavProjectStorage) GetResource(rpath string) (*data.Resour
 			}
 			return nil, false, err
 		}
+		comments, err := getCommentsForTasks(s, tasks)
+		if err != nil {
+			_ = s.Rollback()
+			return nil, false, err
+		}
 		if err := s.Commit(); err != nil {
 			return nil, false, err
 		}
@@ -232,8 +298,9 @@ func (vcls *VikunjaCaldavProjectStorage) GetResource(rpath string) (*data.Resour
 		}
 
 		rr := VikunjaProjectResourceAdapter{
-			project: vcls.project,
-			task:    vcls.task,
+			project:  vcls.project,
+			task:     vcls.task,
+			comments: comments[vcls.task.ID],
 		}
 		r := data.NewResource(rpath, &rr)
 		return &r, true, nil
@@ -269,6 +336,11 @@ func (vcls *VikunjaCaldavProjectStorage) CreateResource(rpath, content string) (
 
 	vTask.ProjectID = vcls.project.ID
 
+	// Saved filters and favorites are read-only
+	if isPseudoProject(vTask.ProjectID) {
+		return nil, errs.ForbiddenError
+	}
+
 	// Check the rights
 	canCreate, err := vTask.CanCreate(s, vcls.user)
 	if err != nil {
@@ -279,6 +351,8 @@ func (vcls *VikunjaCaldavProjectStorage) CreateResource(rpath, content string) (
 	}
 
 	// Create the task
+	relatedTasks := vTask.RelatedTasks
+	attachments := vTask.Attachments
 	err = vTask.Create(s, vcls.user)
 	if err != nil {
 		_ = s.Rollback()
@@ -292,6 +366,18 @@ func (vcls *VikunjaCaldavProjectStorage) CreateResource(rpath, content string) (
 		return nil, err
 	}
 
+	err = persistRelations(s, vcls.user, vcls.task, relatedTasks)
+	if err != nil {
+		_ = s.Rollback()
+		return nil, err
+	}
+
+	err = persistAttachments(s, vcls.user, vcls.task, attachments)
+	if err != nil {
+		_ = s.Rollback()
+		return nil, err
+	}
+
 	if err := s.Commit(); err != nil {
 		return nil, err
 	}
@@ -313,6 +399,11 @@ func (vcls *VikunjaCaldavProjectStorage) UpdateResource(rpath, content string) (
 		return nil, err
 	}
 
+	// Saved filters and favorites are read-only
+	if vcls.project != nil && isPseudoProject(vcls.project.ID) {
+		return nil, errs.ForbiddenError
+	}
+
 	// At this point, we already have the right task in vcls.task, so we can use that ID directly
 	vTask.ID = vcls.task.ID
 
@@ -331,6 +422,8 @@ func (vcls *VikunjaCaldavProjectStorage) UpdateResource(rpath, content string) (
 	}
 
 	// Update the task
+	relatedTasks := vTask.RelatedTasks
+	attachments := vTask.Attachments
 	err = vTask.Update(s, vcls.user)
 	if err != nil {
 		_ = s.Rollback()
@@ -343,6 +436,18 @@ func (vcls *VikunjaCaldavProjectStorage) UpdateResource(rpath, content string) (
 		return nil, err
 	}
 
+	err = persistRelations(s, vcls.user, vcls.task, relatedTasks)
+	if err != nil {
+		_ = s.Rollback()
+		return nil, err
+	}
+
+	err = persistAttachments(s, vcls.user, vcls.task, attachments)
+	if err != nil {
+		_ = s.Rollback()
+		return nil, err
+	}
+
 	if err := s.Commit(); err != nil {
 		return nil, err
 	}
@@ -359,6 +464,11 @@ func (vcls *VikunjaCaldavProjectStorage) UpdateResource(rpath, content string) (
 // DeleteResource deletes a resource
 func (vcls *VikunjaCaldavProjectStorage) DeleteResource(_ string) error {
 	if vcls.task != nil {
+		// Saved filters and favorites are read-only
+		if vcls.project != nil && isPseudoProject(vcls.project.ID) {
+			return errs.ForbiddenError
+		}
+
 		s := db.NewSession()
 		defer s.Close()
 
@@ -430,11 +540,145 @@ func persistLabels(s *xorm.Session, a web.Auth, task *models.Task, labels []*mod
 	return task.UpdateTaskLabels(s, a, labels)
 }
 
+// persistRelations creates the relations a client sent as RELATED-TO properties. The related tasks only have their
+// uid set and are looked up in all projects. Tasks which don't exist (yet) or which the user can't see are ignored.
+// Relations to tasks the client knows about which it does not send anymore are removed.
+func persistRelations(s *xorm.Session, a web.Auth, task *models.Task, relatedTasks models.RelatedTaskMap) (err error) {
+
+	existing := &models.Task{ID: task.ID}
+	err = existing.ReadOne(s, a)
+	if err != nil {
+		return err
+	}
+
+	uids := []string{}
+	for _, tasks := range relatedTasks {
+		for _, t := range tasks {
+			uids = append(uids, t.UID)
+		}
+	}
+
+	taskIDsByUID := make(map[string]int64, len(uids))
+	if len(uids) > 0 {
+		found, err := models.GetTasksByUIDs(s, uids, a)
+		if err != nil {
+			return err
+		}
+		for _, t := range found {
+			taskIDsByUID[t.UID] = t.ID
+		}
+	}
+
+	isRelated := func(kind models.RelationKind, otherTaskID int64) bool {
+		for _, t := range existing.RelatedTasks[kind] {
+			if t.ID == otherTaskID {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Relation kind and other task id as key
+	sent := make(map[models.RelationKind]map[int64]bool)
+	for kind, tasks := range relatedTasks {
+		for _, t := range tasks {
+			otherTaskID, has := taskIDsByUID[t.UID]
+			if !has || otherTaskID == task.ID {
+				log.Debugf("[CALDAV] Ignoring relation of task %d to unknown task %s", task.ID, t.UID)
+				continue
+			}
+
+			if sent[kind] == nil {
+				sent[kind] = make(map[int64]bool)
+			}
+			sent[kind][otherTaskID] = true
+
+			if isRelated(kind, otherTaskID) {
+				continue
+			}
+
+			// A sibling can be any relation which is not a parent or subtask relation
+			if kind == models.RelationKindRelated {
+				alreadyRelated := false
+				for existingKind := range existing.RelatedTasks {
+					if existingKind != models.RelationKindParenttask &&
+						existingKind != models.RelationKindSubtask &&
+						isRelated(existingKind, otherTaskID) {
+						alreadyRelated = true
+						break
+					}
+				}
+				if alreadyRelated {
+					continue
+				}
+			}
+
+			rel := &models.TaskRelation{
+				TaskID:       task.ID,
+				OtherTaskID:  otherTaskID,
+				RelationKind: kind,
+			}
+			can, err := rel.CanCreate(s, a)
+			if err != nil {
+				return err
+			}
+			if !can {
+				log.Debugf("[CALDAV] Ignoring relation of task %d to task %d the user can't access", task.ID, otherTaskID)
+				continue
+			}
+
+			err = rel.Create(s, a)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for kind, tasks := range existing.RelatedTasks {
+		// All kinds apart from parent and subtask are sent to clients as siblings
+		sentKind := kind
+		if kind != models.RelationKindParenttask && kind != models.RelationKindSubtask {
+			sentKind = models.RelationKindRelated
+		}
+
+		for _, t := range tasks {
+			// Tasks without a uid are not sent to clients, so they can't send them back either
+			if t.UID == "" || sent[sentKind][t.ID] {
+				continue
+			}
+
+			rel := &models.TaskRelation{
+				TaskID:       task.ID,
+				OtherTaskID:  t.ID,
+				RelationKind: kind,
+			}
+			can, err := rel.CanDelete(s, a)
+			if err != nil {
+				return err
+			}
+			if !can {
+				continue
+			}
+
+			err = rel.Delete(s, a)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 // VikunjaProjectResourceAdapter holds the actual resource
 type VikunjaProjectResourceAdapter struct {
 	project      *models.ProjectWithTasksAndBuckets
 	projectTasks []*models.TaskWithComments
 	task         *models.Task
+	comments     []*models.TaskComment
+	// The id of the latest task change in the project, changes with every task change even if the project
+	// itself did not change.
+	syncToken int64
 
 	isPrincipal  bool
 	isCollection bool
@@ -469,7 +713,11 @@ func (vlra *VikunjaProjectResourceAdapter) CalculateEtag() string {
 	// This also returns the etag of the project, and not of the task,
 	// which becomes problematic because the client uses this etag (= the one from the project) to make
 	// Requests to update a task. These do not match and thus updating a task fails.
-	return `"` + strconv.FormatInt(vlra.project.ID, 10) + `-` + strconv.FormatInt(vlra.project.Updated.Unix(), 10) + `"`
+	etag := strconv.FormatInt(vlra.project.ID, 10) + `-` + strconv.FormatInt(vlra.project.Updated.Unix(), 10)
+	if vlra.syncToken > 0 {
+		etag += `-` + strconv.FormatInt(vlra.syncToken, 10)
+	}
+	return `"` + etag + `"`
 }
 
 // GetContent returns the content string of a resource (a task in our case)
@@ -479,7 +727,7 @@ func (vlra *VikunjaProjectResourceAdapter) GetContent() string {
 	}
 
 	if vlra.task != nil {
-		project := models.ProjectWithTasksAndBuckets{Tasks: []*models.TaskWithComments{{Task: *vlra.task}}}
+		project := models.ProjectWithTasksAndBuckets{Tasks: []*models.TaskWithComments{{Task: *vlra.task, Comments: vlra.comments}}}
 		return caldav.GetCaldavTodosForTasks(&project, project.Tasks)
 	}
 
@@ -530,25 +778,24 @@ func (vcls *VikunjaCaldavProjectStorage) getProjectRessource(isCollection bool)
 
 	projectTasks := vcls.project.Tasks
 	if projectTasks == nil {
-		tk := models.TaskCollection{
-			ProjectID: vcls.project.ID,
-		}
-		iface, _, _, err := tk.ReadAll(s, vcls.user, "", 1, 1000)
+		projectTasks, err = getTasksForProject(s, vcls.user, vcls.project.ID)
 		if err != nil {
 			_ = s.Rollback()
 			return rr, err
 		}
-		tasks, ok := iface.([]*models.Task)
-		if !ok {
-			panic("Tasks returned from TaskCollection.ReadAll are not []*models.Task!")
-		}
-
-		for _, t := range tasks {
-			projectTasks = append(projectTasks, &models.TaskWithComments{Task: *t})
-		}
 		vcls.project.Tasks = projectTasks
 	}
 
+	if isPseudoProject(vcls.project.ID) {
+		updatePseudoProjectModTime(vcls.project, projectTasks)
+	}
+
+	syncTokens, err := models.GetProjectSyncTokens(s, []int64{vcls.project.ID})
+	if err != nil {
+		_ = s.Rollback()
+		return rr, err
+	}
+
 	if err := s.Commit(); err != nil {
 		return rr, err
 	}
@@ -556,8 +803,106 @@ func (vcls *VikunjaCaldavProjectStorage) getProjectRessource(isCollection bool)
 	rr = VikunjaProjectResourceAdapter{
 		project:      vcls.project,
 		projectTasks: projectTasks,
+		syncToken:    syncTokens[vcls.project.ID],
 		isCollection: isCollection,
 	}
 
 	return
 }
+
+func getTasksForProject(s *xorm.Session, u *user2.User, projectID int64) (projectTasks []*models.TaskWithComments, err error) {
+	tk := models.TaskCollection{
+		ProjectID: projectID,
+	}
+	iface, _, _, err := tk.ReadAll(s, u, "", 1, 1000)
+	if err != nil {
+		return nil, err
+	}
+	tasks, ok := iface.([]*models.Task)
+	if !ok {
+		panic("Tasks returned from TaskCollection.ReadAll are not []*models.Task!")
+	}
+
+	for _, t := range tasks {
+		projectTasks = append(projectTasks, &models.TaskWithComments{Task: *t})
+	}
+
+	if config.ServiceEnableTaskComments.GetBool() {
+		err = models.AddCommentsToTasks(s, projectTasks)
+	}
+
+	return
+}
+
+// getCommentsForTasks returns the comments of all tasks by task id
+func getCommentsForTasks(s *xorm.Session, tasks []*models.Task) (comments map[int64][]*models.TaskComment, err error) {
+	comments = make(map[int64][]*models.TaskComment, len(tasks))
+	if !config.ServiceEnableTaskComments.GetBool() {
+		return
+	}
+
+	tasksWithComments := make([]*models.TaskWithComments, 0, len(tasks))
+	for _, t := range tasks {
+		tasksWithComments = append(tasksWithComments, &models.TaskWithComments{Task: *t})
+	}
+
+	err = models.AddCommentsToTasks(s, tasksWithComments)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range tasksWithComments {
+		comments[t.ID] = t.Comments
+	}
+
+	return
+}
+
+// persistAttachments creates attachments from the files a client sent inline as ATTACH properties. Clients send
+// them again with every update, that's why files with the same name and size as an existing attachment are skipped.
+// Attachments are never removed through caldav.
+func persistAttachments(s *xorm.Session, a web.Auth, task *models.Task, attachments []*models.TaskAttachment) (err error) {
+	if len(attachments) == 0 || !config.ServiceEnableTaskAttachments.GetBool() {
+		return nil
+	}
+
+	ta := &models.TaskAttachment{TaskID: task.ID}
+	canCreate, err := ta.CanCreate(s, a)
+	if err != nil {
+		return err
+	}
+	if !canCreate {
+		return errs.ForbiddenError
+	}
+
+	result, _, _, err := ta.ReadAll(s, a, "", -1, 0)
+	if err != nil {
+		return err
+	}
+	existing, _ := result.([]*models.TaskAttachment)
+
+	isExisting := func(file *files.File) bool {
+		for _, e := range existing {
+			if e.File != nil && e.File.Name == file.Name && e.File.Size == file.Size {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, attachment := range attachments {
+		file := attachment.File
+		if file == nil || isExisting(file) {
+			continue
+		}
+
+		attachment.TaskID = task.ID
+		err = attachment.NewAttachmentWithMime(s, bytes.NewReader(file.FileContent), file.Name, file.Size, file.Mime, a)
+		if err != nil {
+			return err
+		}
+		existing = append(existing, attachment)
+	}
+
+	return nil
+}
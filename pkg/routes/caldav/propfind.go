@@ -0,0 +1,71 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package caldav
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/samedi/caldav-go/ixml"
+)
+
+var (
+	davResponseRegex = regexp.MustCompile(`(?s)<D:response><D:href>([^<]*)</D:href>(.*?)</D:response>`)
+	davPropstatRegex = regexp.MustCompile(`(?s)<D:propstat><D:prop>(.*?)</D:prop>(<D:status>[^<]*</D:status>)</D:propstat>`)
+	davEmptyTagRegex = regexp.MustCompile(`<[^<>]*/>`)
+)
+
+// propResolver returns the value of a property caldav-go does not know for the resource at href. The property is
+// passed as the empty tag caldav-go rendered for it. If the property does not exist for the resource, it
+// returns false.
+type propResolver func(href string, prop string) (string, bool)
+
+// resolveNotFoundProps moves the properties caldav-go reported as not found in a multistatus response to a
+// new propstat with the value the resolver returns for them.
+func resolveNotFoundProps(body string, resolve propResolver) string {
+	return davResponseRegex.ReplaceAllStringFunc(body, func(response string) string {
+		parts := davResponseRegex.FindStringSubmatch(response)
+
+		var found string
+		propstats := davPropstatRegex.ReplaceAllStringFunc(parts[2], func(propstat string) string {
+			match := davPropstatRegex.FindStringSubmatch(propstat)
+			if match[2] != ixml.StatusTag(http.StatusNotFound) {
+				return propstat
+			}
+
+			var missing string
+			for _, prop := range davEmptyTagRegex.FindAllString(match[1], -1) {
+				if value, has := resolve(parts[1], prop); has {
+					found += value
+					continue
+				}
+				missing += prop
+			}
+			if missing == "" {
+				return ""
+			}
+			return "<D:propstat><D:prop>" + missing + "</D:prop>" + ixml.StatusTag(http.StatusNotFound) + "</D:propstat>"
+		})
+		if found == "" {
+			return response
+		}
+
+		return "<D:response>" + ixml.HrefTag(parts[1]) +
+			"<D:propstat><D:prop>" + found + "</D:prop>" + ixml.StatusTag(http.StatusOK) + "</D:propstat>" +
+			propstats + "</D:response>"
+	})
+}
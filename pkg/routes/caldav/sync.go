@@ -0,0 +1,295 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package caldav
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/log"
+	"code.vikunja.io/api/pkg/models"
+	user2 "code.vikunja.io/api/pkg/user"
+
+	"github.com/labstack/echo/v4"
+	"github.com/samedi/caldav-go/data"
+	"github.com/samedi/caldav-go/ixml"
+)
+
+// syncTokenPrefix is prepended to the id of the latest task change of a project to build its sync token.
+// RFC 6578 requires sync tokens to be URIs.
+const syncTokenPrefix = `http://vikunja.io/ns/sync/`
+
+var (
+	syncCollectionTag     = xml.Name{Space: ixml.DAV_NS, Local: "sync-collection"}
+	syncTokenTag          = xml.Name{Space: ixml.DAV_NS, Local: "sync-token"}
+	supportedReportSetTag = xml.Name{Space: ixml.DAV_NS, Local: "supported-report-set"}
+	validSyncTokenTag     = xml.Name{Space: ixml.DAV_NS, Local: "valid-sync-token"}
+
+	errInvalidSyncToken = errors.New("invalid sync token")
+
+	davProjectHrefRegex = regexp.MustCompile(`^` + regexp.QuoteMeta(ProjectBasePath) + `/(\d+)/?$`)
+)
+
+type syncCollectionRequest struct {
+	XMLName   xml.Name
+	SyncToken string `xml:"DAV: sync-token"`
+	SyncLevel string `xml:"DAV: sync-level"`
+	Prop      struct {
+		Tags []xml.Name `xml:",any"`
+	} `xml:"DAV: prop"`
+}
+
+// parseSyncCollectionRequest returns the parsed request if the body is a sync-collection REPORT.
+func parseSyncCollectionRequest(body []byte) (*syncCollectionRequest, bool) {
+	request := &syncCollectionRequest{}
+	if err := xml.Unmarshal(body, request); err != nil {
+		return nil, false
+	}
+	return request, request.XMLName == syncCollectionTag
+}
+
+func makeSyncToken(token int64) string {
+	return syncTokenPrefix + strconv.FormatInt(token, 10)
+}
+
+// parseSyncToken returns the id of the task change a sync token was issued for. An empty token means the client
+// did not sync before.
+func parseSyncToken(token string) (int64, error) {
+	if token == "" {
+		return 0, nil
+	}
+	if !strings.HasPrefix(token, syncTokenPrefix) {
+		return 0, errInvalidSyncToken
+	}
+	id, err := strconv.ParseInt(strings.TrimPrefix(token, syncTokenPrefix), 10, 64)
+	if err != nil || id < 0 {
+		return 0, errInvalidSyncToken
+	}
+	return id, nil
+}
+
+func writeDavError(c echo.Context, status int, condition xml.Name) error {
+	body := `<?xml version="1.0" encoding="UTF-8"?>` +
+		`<D:error ` + ixml.Namespaces() + `>` + ixml.Tag(condition, "") + `</D:error>`
+	return c.Blob(status, "application/xml; charset=utf-8", []byte(body))
+}
+
+// handleSyncCollection answers a sync-collection REPORT (RFC 6578) for a project with all tasks which changed
+// since the sync token the client sent. caldav-go only knows calendar-query and calendar-multiget reports,
+// that's why we need to handle it ourselves.
+func handleSyncCollection(c echo.Context, u *user2.User, projectID int64, request *syncCollectionRequest) error {
+	// Saved filters and favorites don't have a change log of their own
+	if isPseudoProject(projectID) {
+		return writeDavError(c, http.StatusForbidden, xml.Name{Space: ixml.DAV_NS, Local: "supported-report"})
+	}
+
+	since, err := parseSyncToken(request.SyncToken)
+	if err != nil {
+		return writeDavError(c, http.StatusForbidden, validSyncTokenTag)
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	project := &models.Project{ID: projectID}
+	can, _, err := project.CanRead(s, u)
+	if err != nil {
+		_ = s.Rollback()
+		if models.IsErrProjectDoesNotExist(err) {
+			return c.NoContent(http.StatusNotFound)
+		}
+		log.Errorf("[CALDAV] Could not check access to project %d: %s", projectID, err)
+		return echo.ErrInternalServerError
+	}
+	if !can {
+		_ = s.Rollback()
+		return c.NoContent(http.StatusForbidden)
+	}
+
+	tokens, err := models.GetProjectSyncTokens(s, []int64{projectID})
+	if err != nil {
+		_ = s.Rollback()
+		log.Errorf("[CALDAV] Could not get sync token for project %d: %s", projectID, err)
+		return echo.ErrInternalServerError
+	}
+	token := tokens[projectID]
+	if since > token {
+		_ = s.Rollback()
+		return writeDavError(c, http.StatusForbidden, validSyncTokenTag)
+	}
+
+	var tasks []*models.Task
+	var removed []*models.TaskSyncChange
+	if since == 0 {
+		projectTasks, err := getTasksForProject(s, u, projectID)
+		if err != nil {
+			_ = s.Rollback()
+			log.Errorf("[CALDAV] Could not get tasks for project %d: %s", projectID, err)
+			return echo.ErrInternalServerError
+		}
+		for _, t := range projectTasks {
+			tasks = append(tasks, &t.Task)
+		}
+	} else {
+		tasks, removed, err = models.GetTaskSyncChanges(s, u, projectID, since)
+		if err != nil {
+			_ = s.Rollback()
+			log.Errorf("[CALDAV] Could not get task changes for project %d: %s", projectID, err)
+			return echo.ErrInternalServerError
+		}
+	}
+
+	comments, err := getCommentsForTasks(s, tasks)
+	if err != nil {
+		_ = s.Rollback()
+		log.Errorf("[CALDAV] Could not get comments for project %d: %s", projectID, err)
+		return echo.ErrInternalServerError
+	}
+
+	if err := s.Commit(); err != nil {
+		return err
+	}
+
+	props := request.Prop.Tags
+	if len(props) == 0 {
+		props = []xml.Name{ixml.GET_ETAG_TG}
+	}
+
+	var bf strings.Builder
+	bf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+	bf.WriteString(`<D:multistatus ` + ixml.Namespaces() + `>`)
+	for _, t := range tasks {
+		r := data.NewResource(getTaskURL(projectID, t), &VikunjaProjectResourceAdapter{task: t, comments: comments[t.ID]})
+		bf.WriteString("<D:response>")
+		bf.WriteString(ixml.HrefTag(r.Path))
+		bf.WriteString(taskPropstats(&r, props))
+		bf.WriteString("</D:response>")
+	}
+	for _, change := range removed {
+		bf.WriteString("<D:response>")
+		bf.WriteString(ixml.HrefTag(getTaskURL(projectID, &models.Task{UID: change.TaskUID})))
+		bf.WriteString(ixml.StatusTag(http.StatusNotFound))
+		bf.WriteString("</D:response>")
+	}
+	bf.WriteString(ixml.Tag(syncTokenTag, makeSyncToken(token)))
+	bf.WriteString("</D:multistatus>")
+
+	return c.Blob(http.StatusMultiStatus, "application/xml; charset=utf-8", []byte(bf.String()))
+}
+
+func taskPropstats(r *data.Resource, props []xml.Name) string {
+	var found, missing string
+	for _, prop := range props {
+		var content string
+		var ok bool
+		switch prop {
+		case ixml.GET_ETAG_TG:
+			content, ok = r.GetEtag()
+		case ixml.CALENDAR_DATA_TG:
+			content, ok = r.GetContentData()
+			content = ixml.EscapeText(content)
+		case ixml.GET_CONTENT_TYPE_TG:
+			content, ok = r.GetContentType()
+		case ixml.GET_CONTENT_LENGTH_TG:
+			content, ok = r.GetContentLength()
+		case ixml.GET_LAST_MODIFIED_TG:
+			content, ok = r.GetLastModified(http.TimeFormat)
+		}
+
+		if ok {
+			found += ixml.Tag(prop, content)
+		} else {
+			missing += ixml.Tag(prop, "")
+		}
+	}
+
+	var propstats string
+	if found != "" {
+		propstats += "<D:propstat><D:prop>" + found + "</D:prop>" + ixml.StatusTag(http.StatusOK) + "</D:propstat>"
+	}
+	if missing != "" {
+		propstats += "<D:propstat><D:prop>" + missing + "</D:prop>" + ixml.StatusTag(http.StatusNotFound) + "</D:propstat>"
+	}
+	return propstats
+}
+
+// addSyncPropertiesToPropfind fills in the sync-token and supported-report-set properties of all projects in a
+// PROPFIND response. caldav-go does not know about them and reports them as not found.
+func addSyncPropertiesToPropfind(body string) string {
+	if !strings.Contains(body, ixml.Tag(syncTokenTag, "")) && !strings.Contains(body, ixml.Tag(supportedReportSetTag, "")) {
+		return body
+	}
+
+	projectIDs := []int64{}
+	for _, match := range davResponseRegex.FindAllStringSubmatch(body, -1) {
+		if projectID, is := projectIDFromHref(match[1]); is {
+			projectIDs = append(projectIDs, projectID)
+		}
+	}
+	if len(projectIDs) == 0 {
+		return body
+	}
+
+	s := db.NewSession()
+	defer s.Close()
+
+	tokens, err := models.GetProjectSyncTokens(s, projectIDs)
+	if err != nil {
+		log.Errorf("[CALDAV] Could not get sync tokens for projects %v: %s", projectIDs, err)
+		return body
+	}
+
+	return resolveNotFoundProps(body, func(href string, prop string) (string, bool) {
+		projectID, is := projectIDFromHref(href)
+		if !is {
+			return "", false
+		}
+
+		switch prop {
+		case ixml.Tag(syncTokenTag, ""):
+			return ixml.Tag(syncTokenTag, makeSyncToken(tokens[projectID])), true
+		case ixml.Tag(supportedReportSetTag, ""):
+			return ixml.Tag(supportedReportSetTag, supportedReport(ixml.CALENDAR_MULTIGET_TG)+
+				supportedReport(ixml.CALENDAR_QUERY_TG)+
+				supportedReport(syncCollectionTag)), true
+		}
+		return "", false
+	})
+}
+
+// projectIDFromHref returns the id of the project if the href points to a project collection. Saved filters and
+// favorites are not included since they don't support sync-collection reports.
+func projectIDFromHref(href string) (int64, bool) {
+	match := davProjectHrefRegex.FindStringSubmatch(href)
+	if match == nil {
+		return 0, false
+	}
+	projectID, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return projectID, true
+}
+
+func supportedReport(report xml.Name) string {
+	return "<D:supported-report><D:report>" + ixml.Tag(report, "") + "</D:report></D:supported-report>"
+}
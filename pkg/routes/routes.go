@@ -171,6 +171,8 @@ func RegisterRoutes(e *echo.Echo) {
 		wkg.Use(middleware.BasicAuth(caldav.BasicAuth))
 		wkg.Any("/caldav", caldav.PrincipalHandler)
 		wkg.Any("/caldav/", caldav.PrincipalHandler)
+		wkg.Any("/carddav", caldav.PrincipalHandler)
+		wkg.Any("/carddav/", caldav.PrincipalHandler)
 		c := e.Group("/dav")
 		registerCalDavRoutes(c)
 	}
@@ -275,6 +277,10 @@ func registerAPIRoutes(a *echo.Group) {
 	// Avatar endpoint
 	n.GET("/avatar/:username", apiv1.GetAvatar)
 
+	// Calendar feeds, authenticated through the token in the url
+	n.GET("/feeds/ics/:token", apiv1.GetICSFeed)
+	n.GET("/feeds/ics/:token/filters/:filter", apiv1.GetICSFeed)
+
 	// Link share auth
 	if config.ServiceEnableLinkSharing.GetBool() {
 		ur.POST("/shares/:share/auth", apiv1.AuthenticateLinkShare)
@@ -307,9 +313,13 @@ func registerAPIRoutes(a *echo.Group) {
 	u.POST("/export/request", apiv1.RequestUserDataExport)
 	u.POST("/export/download", apiv1.DownloadUserDataExport)
 	u.GET("/timezones", apiv1.GetAvailableTimezones)
+	u.GET("/storage", apiv1.GetUserStorage)
 	u.PUT("/settings/token/caldav", apiv1.GenerateCaldavToken)
 	u.GET("/settings/token/caldav", apiv1.GetCaldavTokens)
 	u.DELETE("/settings/token/caldav/:id", apiv1.DeleteCaldavToken)
+	u.PUT("/settings/token/ics", apiv1.GenerateICSFeedToken)
+	u.GET("/settings/token/ics", apiv1.GetICSFeedTokens)
+	u.DELETE("/settings/token/ics/:id", apiv1.DeleteICSFeedToken)
 
 	if config.ServiceEnableTotp.GetBool() {
 		u.GET("/settings/totp", apiv1.UserTOTP)
@@ -357,6 +367,13 @@ func registerAPIRoutes(a *echo.Group) {
 	}
 	a.GET("/projects/:project/tasks", taskCollectionHandler.ReadAllWeb)
 
+	projectStorageHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ProjectStorage{}
+		},
+	}
+	a.GET("/projects/:project/storage", projectStorageHandler.ReadOneWeb)
+
 	kanbanBucketHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.Bucket{}
@@ -367,6 +384,22 @@ func registerAPIRoutes(a *echo.Group) {
 	a.POST("/projects/:project/buckets/:bucket", kanbanBucketHandler.UpdateWeb)
 	a.DELETE("/projects/:project/buckets/:bucket", kanbanBucketHandler.DeleteWeb)
 
+	projectViewHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ProjectView{}
+		},
+	}
+	a.GET("/projects/:project/views", projectViewHandler.ReadAllWeb)
+	a.PUT("/projects/:project/views", projectViewHandler.CreateWeb)
+	a.GET("/projects/:project/views/:view", projectViewHandler.ReadOneWeb)
+	a.POST("/projects/:project/views/:view", projectViewHandler.UpdateWeb)
+	a.DELETE("/projects/:project/views/:view", projectViewHandler.DeleteWeb)
+	a.GET("/projects/:project/views/:view/tasks", taskCollectionHandler.ReadAllWeb)
+	a.GET("/projects/:project/views/:view/buckets", kanbanBucketHandler.ReadAllWeb)
+	a.PUT("/projects/:project/views/:view/buckets", kanbanBucketHandler.CreateWeb)
+	a.POST("/projects/:project/views/:view/buckets/:bucket", kanbanBucketHandler.UpdateWeb)
+	a.DELETE("/projects/:project/views/:view/buckets/:bucket", kanbanBucketHandler.DeleteWeb)
+
 	projectDuplicateHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.ProjectDuplicate{}
@@ -374,6 +407,13 @@ func registerAPIRoutes(a *echo.Group) {
 	}
 	a.PUT("/projects/:projectid/duplicate", projectDuplicateHandler.CreateWeb)
 
+	projectFromTemplateHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ProjectFromTemplate{}
+		},
+	}
+	a.PUT("/projects/:projectid/instantiate", projectFromTemplateHandler.CreateWeb)
+
 	taskHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.Task{}
@@ -392,6 +432,20 @@ func registerAPIRoutes(a *echo.Group) {
 	}
 	a.POST("/tasks/bulk", bulkTaskHandler.UpdateWeb)
 
+	taskRescheduleHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskReschedule{}
+		},
+	}
+	a.POST("/tasks/:task/reschedule", taskRescheduleHandler.UpdateWeb)
+
+	taskPositionHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskPosition{}
+		},
+	}
+	a.POST("/tasks/:task/position", taskPositionHandler.UpdateWeb)
+
 	assigneeTaskHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.TaskAssginee{}
@@ -432,6 +486,14 @@ func registerAPIRoutes(a *echo.Group) {
 	a.PUT("/tasks/:task/relations", taskRelationHandler.CreateWeb)
 	a.DELETE("/tasks/:task/relations/:relationKind/:otherTask", taskRelationHandler.DeleteWeb)
 
+	historyHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.HistoryEntry{}
+		},
+	}
+	a.GET("/tasks/:task/history", historyHandler.ReadAllWeb)
+	a.GET("/projects/:project/history", historyHandler.ReadAllWeb)
+
 	if config.ServiceEnableTaskAttachments.GetBool() {
 		taskAttachmentHandler := &handler.WebHandler{
 			EmptyStruct: func() handler.CObject {
@@ -442,6 +504,17 @@ func registerAPIRoutes(a *echo.Group) {
 		a.DELETE("/tasks/:task/attachments/:attachment", taskAttachmentHandler.DeleteWeb)
 		a.PUT("/tasks/:task/attachments", apiv1.UploadTaskAttachment)
 		a.GET("/tasks/:task/attachments/:attachment", apiv1.GetTaskAttachment)
+
+		taskAttachmentUploadHandler := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.TaskAttachmentUpload{}
+			},
+		}
+		a.PUT("/tasks/:task/attachments/uploads", taskAttachmentUploadHandler.CreateWeb)
+		a.GET("/tasks/:task/attachments/uploads/:upload", taskAttachmentUploadHandler.ReadOneWeb)
+		a.PATCH("/tasks/:task/attachments/uploads/:upload", apiv1.UploadTaskAttachmentChunk)
+		a.POST("/tasks/:task/attachments/uploads/:upload", taskAttachmentUploadHandler.UpdateWeb)
+		a.DELETE("/tasks/:task/attachments/uploads/:upload", taskAttachmentUploadHandler.DeleteWeb)
 	}
 
 	if config.ServiceEnableTaskComments.GetBool() {
@@ -457,6 +530,59 @@ func registerAPIRoutes(a *echo.Group) {
 		a.GET("/tasks/:task/comments/:commentid", taskCommentHandler.ReadOneWeb)
 	}
 
+	if config.ServiceEnableTimeTracking.GetBool() {
+		taskTimeEntryHandler := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.TaskTimeEntry{}
+			},
+		}
+		a.GET("/tasks/:task/time", taskTimeEntryHandler.ReadAllWeb)
+		a.PUT("/tasks/:task/time", taskTimeEntryHandler.CreateWeb)
+		a.DELETE("/tasks/:task/time/:timeentry", taskTimeEntryHandler.DeleteWeb)
+		a.POST("/tasks/:task/time/:timeentry", taskTimeEntryHandler.UpdateWeb)
+		a.GET("/tasks/:task/time/:timeentry", taskTimeEntryHandler.ReadOneWeb)
+
+		taskTimerHandler := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.TaskTimer{}
+			},
+		}
+		a.PUT("/tasks/:task/time/timer", taskTimerHandler.CreateWeb)
+		a.POST("/tasks/:task/time/timer", taskTimerHandler.UpdateWeb)
+
+		projectTimeSummaryHandler := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.ProjectTimeSummary{}
+			},
+		}
+		a.GET("/projects/:project/time", projectTimeSummaryHandler.ReadOneWeb)
+	}
+
+	projectDependencyGraphHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ProjectDependencyGraph{}
+		},
+	}
+	a.GET("/projects/:project/dependencies", projectDependencyGraphHandler.ReadOneWeb)
+
+	projectGanttHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.ProjectGantt{}
+		},
+	}
+	a.GET("/projects/:project/gantt", projectGanttHandler.ReadOneWeb)
+
+	customFieldHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.CustomField{}
+		},
+	}
+	a.GET("/projects/:project/customfields", customFieldHandler.ReadAllWeb)
+	a.PUT("/projects/:project/customfields", customFieldHandler.CreateWeb)
+	a.GET("/projects/:project/customfields/:customfield", customFieldHandler.ReadOneWeb)
+	a.POST("/projects/:project/customfields/:customfield", customFieldHandler.UpdateWeb)
+	a.DELETE("/projects/:project/customfields/:customfield", customFieldHandler.DeleteWeb)
+
 	labelHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.Label{}
@@ -498,6 +624,24 @@ func registerAPIRoutes(a *echo.Group) {
 	a.DELETE("/filters/:filter", savedFiltersHandler.DeleteWeb)
 	a.POST("/filters/:filter", savedFiltersHandler.UpdateWeb)
 
+	taskTemplateHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskTemplate{}
+		},
+	}
+	a.GET("/tasktemplates", taskTemplateHandler.ReadAllWeb)
+	a.GET("/tasktemplates/:tasktemplate", taskTemplateHandler.ReadOneWeb)
+	a.PUT("/tasktemplates", taskTemplateHandler.CreateWeb)
+	a.DELETE("/tasktemplates/:tasktemplate", taskTemplateHandler.DeleteWeb)
+	a.POST("/tasktemplates/:tasktemplate", taskTemplateHandler.UpdateWeb)
+
+	taskFromTemplateHandler := &handler.WebHandler{
+		EmptyStruct: func() handler.CObject {
+			return &models.TaskFromTemplate{}
+		},
+	}
+	a.PUT("/tasktemplates/:tasktemplate/tasks", taskFromTemplateHandler.CreateWeb)
+
 	teamHandler := &handler.WebHandler{
 		EmptyStruct: func() handler.CObject {
 			return &models.Team{}
@@ -574,6 +718,41 @@ func registerAPIRoutes(a *echo.Group) {
 	a.GET("/tokens", apiTokenProvider.ReadAllWeb)
 	a.PUT("/tokens", apiTokenProvider.CreateWeb)
 	a.DELETE("/tokens/:token", apiTokenProvider.DeleteWeb)
+
+	// Webhooks
+	if config.WebhooksEnabled.GetBool() {
+		webhookProvider := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.Webhook{}
+			},
+		}
+		a.GET("/projects/:project/webhooks", webhookProvider.ReadAllWeb)
+		a.PUT("/projects/:project/webhooks", webhookProvider.CreateWeb)
+		a.DELETE("/projects/:project/webhooks/:webhook", webhookProvider.DeleteWeb)
+		a.POST("/projects/:project/webhooks/:webhook", webhookProvider.UpdateWeb)
+		a.GET("/webhooks/events", models.GetAvailableWebhookEvents)
+	}
+
+	// Automations
+	if config.AutomationsEnabled.GetBool() {
+		automationRuleProvider := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.AutomationRule{}
+			},
+		}
+		a.GET("/projects/:project/automations", automationRuleProvider.ReadAllWeb)
+		a.PUT("/projects/:project/automations", automationRuleProvider.CreateWeb)
+		a.DELETE("/projects/:project/automations/:automation", automationRuleProvider.DeleteWeb)
+		a.POST("/projects/:project/automations/:automation", automationRuleProvider.UpdateWeb)
+
+		automationRuleExecutionProvider := &handler.WebHandler{
+			EmptyStruct: func() handler.CObject {
+				return &models.AutomationRuleExecution{}
+			},
+		}
+		a.GET("/projects/:project/automations/:automation/executions", automationRuleExecutionProvider.ReadAllWeb)
+		a.GET("/automations/events", models.GetAvailableAutomationEvents)
+	}
 }
 
 func registerMigrations(m *echo.Group) {
@@ -638,4 +817,10 @@ func registerCalDavRoutes(c *echo.Group) {
 	c.Any("/projects/:project", caldav.ProjectHandler)
 	c.Any("/projects/:project/", caldav.ProjectHandler)
 	c.Any("/projects/:project/:task", caldav.TaskHandler) // Mostly used for editing
+	c.GET("/attachments/:task/:attachment", caldav.AttachmentHandler)
+	c.Any("/addressbooks", caldav.AddressbookHandler)
+	c.Any("/addressbooks/", caldav.AddressbookHandler)
+	c.Any("/addressbooks/:addressbook", caldav.AddressbookHandler)
+	c.Any("/addressbooks/:addressbook/", caldav.AddressbookHandler)
+	c.Any("/addressbooks/:addressbook/:contact", caldav.ContactHandler)
 }
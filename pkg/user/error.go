@@ -506,3 +506,28 @@ func (err *ErrUsernameMustNotContainSpaces) HTTPError() web.HTTPError {
 		Message:  "The username must not contain spaces.",
 	}
 }
+
+// ErrInvalidICSFeedToken represents an error where an ics feed is requested with a token which does not exist
+type ErrInvalidICSFeedToken struct{}
+
+// IsErrInvalidICSFeedToken checks if an error is ErrInvalidICSFeedToken.
+func IsErrInvalidICSFeedToken(err error) bool {
+	_, ok := err.(ErrInvalidICSFeedToken)
+	return ok
+}
+
+func (err ErrInvalidICSFeedToken) Error() string {
+	return "invalid ics feed token"
+}
+
+// ErrCodeInvalidICSFeedToken holds the unique world-error code of this error
+const ErrCodeInvalidICSFeedToken = 1023
+
+// HTTPError holds the http error description
+func (err ErrInvalidICSFeedToken) HTTPError() web.HTTPError {
+	return web.HTTPError{
+		HTTPCode: http.StatusNotFound,
+		Code:     ErrCodeInvalidICSFeedToken,
+		Message:  "This calendar feed does not exist or was revoked.",
+	}
+}
@@ -0,0 +1,71 @@
+// Vikunja is a to-do list application to facilitate your life.
+// Copyright 2018-present Vikunja and contributors. All rights reserved.
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public Licensee as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public Licensee for more details.
+//
+// You should have received a copy of the GNU Affero General Public Licensee
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package user
+
+import (
+	"code.vikunja.io/api/pkg/db"
+	"code.vikunja.io/api/pkg/utils"
+
+	"xorm.io/xorm"
+)
+
+// GenerateNewICSFeedToken creates a new token a user can use to subscribe to their tasks as an iCalendar feed.
+// Only a hash of the token is stored, the clear text token is only available right after creating it.
+func GenerateNewICSFeedToken(u *User) (token *Token, err error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	token = genToken(u, TokenICSFeed)
+	token.ClearTextToken = token.Token
+	// The token is the only thing identifying the user in a feed url, so it needs to be looked up directly.
+	// That's why a sha256 hash is used instead of bcrypt like for caldav tokens.
+	token.Token = utils.Sha256(token.ClearTextToken)
+
+	_, err = s.Insert(token)
+	return
+}
+
+func GetICSFeedTokens(u *User) (tokens []*Token, err error) {
+	s := db.NewSession()
+	defer s.Close()
+
+	return getTokensForKind(s, u, TokenICSFeed)
+}
+
+func DeleteICSFeedTokenByID(u *User, id int64) error {
+	s := db.NewSession()
+	defer s.Close()
+
+	return removeTokenByID(s, u, TokenICSFeed, id)
+}
+
+// GetUserByICSFeedToken returns the user a feed token belongs to
+func GetUserByICSFeedToken(s *xorm.Session, token string) (*User, error) {
+	if token == "" {
+		return nil, ErrInvalidICSFeedToken{}
+	}
+
+	t, err := getToken(s, utils.Sha256(token), TokenICSFeed)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrInvalidICSFeedToken{}
+	}
+
+	return GetUserByID(s, t.UserID)
+}
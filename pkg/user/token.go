@@ -35,6 +35,7 @@ const (
 	TokenEmailConfirm
 	TokenAccountDeletion
 	TokenCaldavAuth
+	TokenICSFeed
 
 	tokenSize = 64
 )
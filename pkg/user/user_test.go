@@ -568,3 +568,42 @@ func TestUserPasswordReset(t *testing.T) {
 		assert.True(t, IsErrInvalidPasswordResetToken(err))
 	})
 }
+
+func TestGetUserByICSFeedToken(t *testing.T) {
+	t.Run("valid token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		u, err := GetUserByICSFeedToken(s, "icsfeedtesttoken")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), u.ID)
+	})
+	t.Run("empty token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetUserByICSFeedToken(s, "")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidICSFeedToken(err))
+	})
+	t.Run("wrong token", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetUserByICSFeedToken(s, "somethingsomething")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidICSFeedToken(err))
+	})
+	t.Run("token of another kind", func(t *testing.T) {
+		db.LoadAndAssertFixtures(t)
+		s := db.NewSession()
+		defer s.Close()
+
+		_, err := GetUserByICSFeedToken(s, "passwordresettesttoken")
+		assert.Error(t, err)
+		assert.True(t, IsErrInvalidICSFeedToken(err))
+	})
+}